@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// DefaultEntity is the table or collection the audit entries are written to when the connection doesn't set one.
+const DefaultEntity = "dbfusionAudit"
+
+// Operation is the kind of write recorded by an entry.
+type Operation string
+
+// Operations recorded in the audit trail.
+const (
+	Insert = Operation("insert")
+	Update = Operation("update")
+	Delete = Operation("delete")
+)
+
+// Change holds the values of a field before and after a write.
+type Change struct {
+	Before interface{} `json:"before" bson:"before"` // The value before the write, nil for insertions.
+	After  interface{} `json:"after" bson:"after"`   // The value after the write, nil for deletions.
+}
+
+// Entry is a record of the audit trail. The columns of the audit table and the fields of the audit collection use
+// the names of the dbfusion tags, the changes are stored as JSON in SQL tables.
+type Entry struct {
+	Entity    string            `dbfusion:"entity" bson:"entity"`       // The table or collection written.
+	EntityID  interface{}       `dbfusion:"entityId" bson:"entityId"`   // The primary key of the record, nil without one.
+	Operation Operation         `dbfusion:"operation" bson:"operation"` // The kind of write.
+	Changes   map[string]Change `dbfusion:"changes" bson:"changes"`     // The fields changed by the write.
+	Actor     string            `dbfusion:"actor" bson:"actor"`         // The actor set with WithActor, empty without one.
+	Timestamp time.Time         `dbfusion:"timestamp" bson:"timestamp"` // The time of the write.
+}
+
+// actorKey is the key of the actor in the context of an operation.
+type actorKey struct{}
+
+// WithActor returns a copy of the context carrying the actor recorded by the audit entries of the writes using it.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - actor: The user or service performing the writes, e.g. an email address.
+//
+// Returns:
+//   - context.Context: The context carrying the actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by a context.
+//
+// Parameters:
+//   - ctx: The context of the operation.
+//
+// Returns:
+//   - string: The actor, empty if the context doesn't carry one.
+func ActorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// auditor is a singleton building the entries of the audit trail.
+type auditor struct{}
+
+var (
+	instance *auditor  // Singleton instance of the auditor.
+	once     sync.Once // Once ensures the singleton instance is created only once.
+)
+
+// GetInstance returns the singleton instance of the auditor.
+//
+// Returns:
+//   - *auditor: A pointer to the singleton instance of the auditor.
+func GetInstance() *auditor {
+	// Use sync.Once to ensure that the instance is created only once.
+	once.Do(func() {
+		instance = &auditor{}
+	})
+
+	// Return the singleton instance.
+	return instance
+}
+
+// NewEntry builds the entry of a write from the values of the record before and after it.
+//
+// Parameters:
+//   - ctx: The context of the operation, carrying the actor.
+//   - entity: The table or collection written.
+//   - entityID: The primary key of the record.
+//   - operation: The kind of write.
+//   - before: The values of the record before the write, nil for insertions.
+//   - after: The values of the record after the write, nil for deletions.
+//
+// Returns:
+//   - Entry: The entry of the write, timestamped now.
+func (a *auditor) NewEntry(ctx context.Context, entity string, entityID interface{}, operation Operation, before map[string]interface{}, after map[string]interface{}) Entry {
+	return Entry{
+		Entity:    entity,
+		EntityID:  entityID,
+		Operation: operation,
+		Changes:   a.Diff(before, after),
+		Actor:     ActorFromContext(ctx),
+		Timestamp: time.Now(),
+	}
+}
+
+// Diff compares the values of a record before and after a write. The fields of the record after the write are
+// compared, or the fields before it for deletions.
+//
+// Parameters:
+//   - before: The values before the write, nil for insertions.
+//   - after: The values after the write, nil for deletions.
+//
+// Returns:
+//   - map[string]Change: The changed fields.
+//
+// Example:
+//   changes := audit.GetInstance().Diff(map[string]interface{}{"name": "Bob", "age": 30}, map[string]interface{}{"name": "Bob", "age": 31})
+//   // changes is map[age:{Before:30 After:31}]
+func (a *auditor) Diff(before map[string]interface{}, after map[string]interface{}) map[string]Change {
+	changes := make(map[string]Change)
+
+	// Deleted records lose every field.
+	if after == nil {
+		for key, value := range before {
+			changes[key] = Change{Before: value}
+		}
+		return changes
+	}
+
+	for key, value := range after {
+		previous, existed := before[key]
+		if existed && a.equal(previous, value) {
+			continue
+		}
+		changes[key] = Change{Before: previous, After: value}
+	}
+	return changes
+}
+
+// equal compares two values of a field, which may be read with different types, e.g. an int32 from MongoDB and the
+// int64 of a struct.
+func (a *auditor) equal(x interface{}, y interface{}) bool {
+	if reflect.DeepEqual(x, y) {
+		return true
+	}
+	if xTime, ok := x.(time.Time); ok {
+		if yTime, ok := y.(time.Time); ok {
+			return xTime.Equal(yTime)
+		}
+	}
+	if x == nil || y == nil {
+		return false
+	}
+	return fmt.Sprintf("%v", x) == fmt.Sprintf("%v", y)
+}
@@ -0,0 +1,13 @@
+// Package audit describes the audit trail written by the connections for the entities implementing hooks.Auditable.
+// Every insertion, update and deletion of such an entity writes an Entry to the audit entity of the connection, a
+// table or a collection of the same database, in the transaction of the write when one is active.
+//
+// The actor of a write is read from the context of the operation, set with WithActor:
+//
+// Example:
+//   ctx := audit.WithActor(context.Background(), "alice@example.com")
+//   err := con.WithContext(ctx).Where(ftypes.QMap{"email": "bob@example.com"}).UpdateAndFindOne(update, &user, false)
+//
+// The entry of the update holds the primary key of the record and the fields the update changed, each with its value
+// before and after the update. Insertions only have values after and deletions only have values before.
+package audit
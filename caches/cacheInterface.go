@@ -40,7 +40,9 @@ Methods:
      - `error`: An error if the value cannot be stored in the cache.
 
 6. FlushAll()
-   - Description: Clears all data stored in the cache. Use with caution as it removes all cached items.
+   - Description: Clears all data stored in the cache. Use with caution as it removes all cached items, including the
+     ones that don't belong to dbFusion. Prefer the cache processor's InvalidateEntity and InvalidateDatabase in a
+     shared cache.
 
 7. DelKey(key string) error
    - Description: Deletes a key from the cache.
@@ -49,6 +51,57 @@ Methods:
    - Returns:
      - `error`: An error if the key cannot be deleted from the cache.
 
+8. MGet(keys []string) ([]interface{}, error)
+   - Description: Retrieves the values of several keys in a single round trip.
+   - Parameters:
+     - `keys` ([]string): The keys to look up.
+   - Returns:
+     - `[]interface{}`: The cached values in the same order as `keys`; a missing key yields `nil` at its position.
+     - `error`: An error if the values cannot be retrieved.
+
+9. MSet(values map[string]interface{}) error
+   - Description: Stores several key-value pairs in a single round trip.
+   - Parameters:
+     - `values` (map[string]interface{}): The keys and the values to associate with them.
+   - Returns:
+     - `error`: An error if the values cannot be stored in the cache.
+
+10. MDel(keys []string) error
+   - Description: Deletes several keys in a single round trip.
+   - Parameters:
+     - `keys` ([]string): The keys to be deleted from the cache.
+   - Returns:
+     - `error`: An error if the keys cannot be deleted from the cache.
+
+11. ScanKeys(pattern string) ([]string, error)
+   - Description: Returns every key matching a glob style pattern without blocking the cache (e.g. using SCAN on Redis).
+   - Parameters:
+     - `pattern` (string): The glob style pattern the keys have to match.
+   - Returns:
+     - `[]string`: The matching keys.
+     - `error`: An error if the keys cannot be listed.
+
+12. UpdatePayload(oldKeys []string, newKeys []string, payloadKey string, payload []byte) (string, error)
+   - Description: Points the new index keys of a record to the payload key referenced by the first old index key
+     present in the cache, or to `payloadKey` if none is, stores the payload under it and deletes the old index keys
+     that are not rewritten, atomically and in a single round trip (e.g. with a Lua script on Redis).
+   - Parameters:
+     - `oldKeys` ([]string): The index keys of the record before the update.
+     - `newKeys` ([]string): The index keys of the record after the update.
+     - `payloadKey` (string): The payload key used if none of the old index keys is present in the cache.
+     - `payload` ([]byte): The encoded record.
+   - Returns:
+     - `string`: The payload key the new index keys point to.
+     - `error`: An error if the cache cannot be updated.
+
+13. DeletePayload(keys []string) error
+   - Description: Deletes the index keys of a record along with the payload key referenced by the first of them
+     present in the cache, atomically and in a single round trip.
+   - Parameters:
+     - `keys` ([]string): The index keys of the record.
+   - Returns:
+     - `error`: An error if the keys cannot be deleted from the cache.
+
 This interface provides a common set of methods that cache implementations must adhere to, allowing dbFusion to work seamlessly with various caching systems. Implement this interface to create a cache that can be used with dbFusion's caching capabilities.
 */
 type Cache interface {
@@ -59,4 +112,10 @@ type Cache interface {
 	SetKey(key string, value interface{}) error
 	FlushAll()
 	DelKey(key string) error
+	MGet(keys []string) ([]interface{}, error)
+	MSet(values map[string]interface{}) error
+	MDel(keys []string) error
+	ScanKeys(pattern string) ([]string, error)
+	UpdatePayload(oldKeys []string, newKeys []string, payloadKey string, payload []byte) (string, error)
+	DeletePayload(keys []string) error
 }
@@ -0,0 +1,127 @@
+package caches
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kinds of keys written by dbFusion. Every key is namespaced by CACHE_KEY_PREFIX, the database name and the entity
+// name, with ':' escaped in both names, followed by one of these kinds, which keeps index keys, payloads and query
+// results apart from each other.
+const (
+	indexKeyKind   = "idx" // Composite index keys pointing to a payload.
+	payloadKeyKind = "obj" // Encoded payloads stored under a ULID.
+	queryKeyKind   = "qry" // Cached query results.
+)
+
+// escapeSegment escapes the separator of a key segment so that a name containing ':' can't be mistaken for a
+// namespace of another name, e.g. the database "a:b" for the entity "b" of the database "a".
+//
+// Parameters:
+//   - segment: The database or entity name.
+//
+// Returns:
+//   - string: The segment with '%' and ':' percent-encoded.
+func (cp *cacheProcessor) escapeSegment(segment string) string {
+	replacer := strings.NewReplacer(`%`, `%25`, `:`, `%3A`)
+	return replacer.Replace(segment)
+}
+
+// databaseNamespace returns the prefix shared by every key belonging to the database.
+//
+// Parameters:
+//   - dbName: The name of the database.
+//
+// Returns:
+//   - string: The namespace in the form "<prefix>:<dbName>:", with ':' escaped in dbName.
+func (cp *cacheProcessor) databaseNamespace(dbName string) string {
+	return CACHE_KEY_PREFIX + ":" + cp.escapeSegment(dbName) + ":"
+}
+
+// entityNamespace returns the prefix shared by every key belonging to the entity.
+//
+// Parameters:
+//   - dbName: The name of the database.
+//   - entityName: The name of the entity or collection in the database.
+//
+// Returns:
+//   - string: The namespace in the form "<prefix>:<dbName>:<entityName>:", with ':' escaped in both names.
+func (cp *cacheProcessor) entityNamespace(dbName string, entityName string) string {
+	return cp.databaseNamespace(dbName) + cp.escapeSegment(entityName) + ":"
+}
+
+// IndexValues joins the indexed fields with their values, e.g. "email=a@b.c_id=5", into the value of an index key.
+// The names keep an index from matching a query on other fields holding the same values, and the fields are sorted
+// so that the conditions of a query build the key of the index whatever their order.
+//
+// Parameters:
+//   - values: The values of the indexed fields keyed by field name.
+//
+// Returns:
+//   - string: The joined fields and values, empty if there are none.
+func (cp *cacheProcessor) IndexValues(values map[string]interface{}) string {
+	fields := make([]string, 0, len(values))
+	for field := range values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s=%v", field, values[field]))
+	}
+	return strings.Join(parts, "_")
+}
+
+// IndexKey builds the key of a composite index entry from the joined values of the indexed fields.
+//
+// Parameters:
+//   - dbName: The name of the database.
+//   - entityName: The name of the entity or collection in the database.
+//   - values: The indexed fields and their values joined by IndexValues.
+//
+// Returns:
+//   - string: The namespaced index key.
+func (cp *cacheProcessor) IndexKey(dbName string, entityName string, values string) string {
+	return cp.entityNamespace(dbName, entityName) + indexKeyKind + ":" + values
+}
+
+// PayloadKey builds the key under which the encoded payload identified by the ULID is stored.
+//
+// Parameters:
+//   - dbName: The name of the database.
+//   - entityName: The name of the entity or collection in the database.
+//   - id: The ULID of the payload.
+//
+// Returns:
+//   - string: The namespaced payload key.
+func (cp *cacheProcessor) PayloadKey(dbName string, entityName string, id string) string {
+	return cp.entityNamespace(dbName, entityName) + payloadKeyKind + ":" + id
+}
+
+// QueryKey builds the key under which the result of a query is cached.
+//
+// Parameters:
+//   - dbName: The name of the database.
+//   - entityName: The name of the entity or collection in the database.
+//   - cacheKey: The cache key generated for the query conditions.
+//
+// Returns:
+//   - string: The namespaced query key.
+func (cp *cacheProcessor) QueryKey(dbName string, entityName string, cacheKey string) string {
+	return cp.entityNamespace(dbName, entityName) + queryKeyKind + ":" + cacheKey
+}
+
+// escapePattern escapes the glob special characters of a namespace so that it is matched literally
+// when it is used as the prefix of a scan pattern.
+//
+// Parameters:
+//   - value: The literal value to be escaped.
+//
+// Returns:
+//   - string: The value with '*', '?', '[', ']' and '\' escaped.
+func (cp *cacheProcessor) escapePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return replacer.Replace(value)
+}
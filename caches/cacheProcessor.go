@@ -3,7 +3,6 @@ package caches
 import (
 	"context"
 	"crypto/rand"
-	"fmt"
 	"strings"
 	"sync"
 	"time"
@@ -73,37 +72,15 @@ func (cp *cacheProcessor) ProcessInsertCache(cache Cache, indexes []string, data
 	go func() {
 		defer wg.Done()
 
-		// Create a slice to store cache indexes.
-		cacheIndexes := make([]string, 0)
-
-		// Iterate through the provided indexes.
-		for _, val := range indexes {
-			// Split unique keys within each index.
-			uniqueKeys := strings.Split(val, ",")
-
-			// Check if the number of unique keys exceeds a limit.
-			if len(uniqueKeys) > 5 {
-				err = dbfusionErrors.ErrCacheUniqueKeysIncreased
-			}
-
-			// Construct a cache index based on dbName, entityName, and unique key values.
-			index := dbName + "_" + entityName + "_"
-			for _, key := range uniqueKeys {
-				if value, ok := data[key]; ok {
-					index += fmt.Sprintf("%v", value)
-					index += "_"
-				}
-			}
-
-			// Remove the trailing underscore and add the index to the slice.
-			if len(index) > 1 {
-				index = index[:len(index)-1]
-				cacheIndexes = append(cacheIndexes, index)
-			}
+		// Construct the cache indexes from the unique key values of the data.
+		var cacheIndexes []string
+		cacheIndexes, err = cp.buildCacheIndexes(indexes, data, dbName, entityName)
+		if err != nil {
+			return
 		}
 
 		// Process the constructed cache indexes.
-		err = cp.processIndexes(cache, cacheIndexes, data)
+		err = cp.processIndexes(cache, cacheIndexes, data, dbName, entityName)
 	}()
 
 	// Wait for parallel processing to complete.
@@ -111,47 +88,235 @@ func (cp *cacheProcessor) ProcessInsertCache(cache Cache, indexes []string, data
 	return err
 }
 
-// processIndexes is a method of the cacheProcessor type used to process and set cache indexes and associated data.
-// It generates a unique ULID (Universally Unique Lexicographically Sortable Identifier), associates it with each index,
-// and stores the ULID in the cache. It also encodes and stores the provided data in the cache using the generated ULID.
+// ProcessInsertCacheBatch is a method of the cacheProcessor type used to cache many records of an entity at once,
+// e.g. while warming the cache from the database. It builds the composite indexes and payload of every record just
+// like ProcessInsertCache. The index keys of the whole batch are read with a single MGet call so that records which
+// are cached already keep their composite key, the other payloads their indexes pointed to are removed with a single
+// MDel call and the batch is written with a single MSet call.
 //
 // Receiver:
 //   - cp: A cacheProcessor instance responsible for processing cache operations.
 //
 // Parameters:
 //   - cache: The Cache interface to interact with the cache system.
-//   - cacheIndexes: A slice of strings representing cache indexes.
-//   - data: A map containing data to be cached.
+//   - indexes: A slice of strings representing the indexes to be created.
+//   - batch: The records to be cached, each one as a map of field names to values.
+//   - dbName: The name of the database.
+//   - entityName: The name of the entity or collection in the database.
 //
 // Returns:
-//   - err: An error indicating the success or failure of the cache processing operation.
-func (cp *cacheProcessor) processIndexes(cache Cache, cacheIndexes []string, data map[string]interface{}) error {
-	// Generate a unique ULID based on the current timestamp and entropy source.
-	ulid := ulid.MustNew(ulid.Timestamp(time.Now()), cp.entropy).String()
+//   - int: The number of records of the batch for which at least one index was created.
+//   - err: An error indicating the success or failure of the cache insertion operation.
+func (cp *cacheProcessor) ProcessInsertCacheBatch(cache Cache, indexes []string, batch []map[string]interface{}, dbName string, entityName string) (int, error) {
+	// Check if the number of indexes exceeds a limit.
+	if len(indexes) > 10 {
+		return 0, dbfusionErrors.ErrCacheIndexesIncreased
+	}
+	// If there are no indexes or records, return without processing.
+	if len(indexes) == 0 || len(batch) == 0 {
+		return 0, nil
+	}
 
-	// Iterate through the provided cache indexes.
-	for _, index := range cacheIndexes {
-		// Set each cache index with the generated ULID.
-		err := cache.SetKey(index, ulid)
+	// Acquire a semaphore to control concurrent cache processing.
+	cp.semaphore.Acquire(context.TODO(), 1)
+	defer cp.semaphore.Release(1)
+
+	// Collect the index keys of every record of the batch.
+	batchIndexes := make([][]string, len(batch))
+	keys := make([]string, 0, len(batch)*len(indexes))
+	for idx, data := range batch {
+		cacheIndexes, err := cp.buildCacheIndexes(indexes, data, dbName, entityName)
 		if err != nil {
-			return err
+			return 0, err
 		}
+		batchIndexes[idx] = cacheIndexes
+		keys = append(keys, cacheIndexes...)
 	}
 
-	// Encode the data and store it in the cache using the generated ULID as the key.
-	encodedData, err := codec.GetInstance().Encode(data)
+	// Look up the composite keys the index keys of the whole batch point to at once.
+	compKeys, err := cache.MGet(keys)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	cached := 0
+	offset := 0
+	values := make(map[string]interface{})
+	replaced := make([]string, 0)
+	for idx, data := range batch {
+		cacheIndexes := batchIndexes[idx]
+
+		// Records without any indexed value can never be found through the cache, so they are skipped.
+		if len(cacheIndexes) == 0 {
+			continue
+		}
+
+		// Reuse the first composite key of the record, the others would be left without any index pointing to them.
+		recordKeys := cp.compositeKeys(compKeys[offset : offset+len(cacheIndexes)])
+		offset += len(cacheIndexes)
+		compKey := ""
+		if len(recordKeys) > 0 {
+			compKey = recordKeys[0]
+			replaced = append(replaced, recordKeys[1:]...)
+		}
+
+		err = cp.addPayload(values, compKey, cacheIndexes, data, dbName, entityName)
+		if err != nil {
+			return 0, err
+		}
+		cached++
+	}
+
+	// Remove the replaced payloads unless another record of the batch reuses them.
+	stale := make([]string, 0, len(replaced))
+	for _, key := range replaced {
+		if _, ok := values[key]; !ok {
+			stale = append(stale, key)
+		}
 	}
-	err = cache.SetKey(ulid, encodedData)
+	if err := cache.MDel(stale); err != nil {
+		return 0, err
+	}
+
+	// Write the whole batch in a single round trip.
+	if len(values) == 0 {
+		return 0, nil
+	}
+	return cached, cache.MSet(values)
+}
+
+// buildCacheIndexes constructs the namespaced cache index keys of a record from the values of its unique keys.
+//
+// Parameters:
+//   - indexes: A slice of strings representing the indexes, each one a comma separated list of unique keys.
+//   - data: A map containing the data of the record.
+//   - dbName: The name of the database.
+//   - entityName: The name of the entity or collection in the database.
+//
+// Returns:
+//   - []string: The index keys for which the record has at least one value.
+//   - error: ErrCacheUniqueKeysIncreased if an index has more than 5 unique keys.
+func (cp *cacheProcessor) buildCacheIndexes(indexes []string, data map[string]interface{}, dbName string, entityName string) ([]string, error) {
+	// Create a slice to store cache indexes.
+	cacheIndexes := make([]string, 0)
+
+	// Iterate through the provided indexes.
+	for _, val := range indexes {
+		// Split unique keys within each index.
+		uniqueKeys := strings.Split(val, ",")
+
+		// Check if the number of unique keys exceeds a limit.
+		if len(uniqueKeys) > 5 {
+			return nil, dbfusionErrors.ErrCacheUniqueKeysIncreased
+		}
+
+		// Join the unique keys that make up the index with their values.
+		values := make(map[string]interface{}, len(uniqueKeys))
+		for _, key := range uniqueKeys {
+			if value, ok := data[key]; ok {
+				values[key] = value
+			}
+		}
+
+		// Add the namespaced index to the slice, indexes without any value have no key.
+		if len(values) > 0 {
+			cacheIndexes = append(cacheIndexes, cp.IndexKey(dbName, entityName, cp.IndexValues(values)))
+		}
+	}
+	return cacheIndexes, nil
+}
+
+// addPayload points each of the cache indexes of a record to its composite key, a new ULID unless the record is
+// cached already, and adds the encoded record under the composite key, all into the values that are written to the
+// cache later on.
+//
+// Parameters:
+//   - values: The key-value pairs to be written to the cache.
+//   - compKey: The composite key of the record, or an empty string to generate a new one.
+//   - cacheIndexes: A slice of strings representing cache indexes.
+//   - data: A map containing data to be cached.
+//   - dbName: The name of the database.
+//   - entityName: The name of the entity or collection in the database.
+//
+// Returns:
+//   - error: An error if the data cannot be encoded.
+func (cp *cacheProcessor) addPayload(values map[string]interface{}, compKey string, cacheIndexes []string, data map[string]interface{}, dbName string, entityName string) error {
+	// Generate a unique ULID based on the current timestamp and entropy source and namespace it with the entity.
+	if compKey == "" {
+		compKey = cp.PayloadKey(dbName, entityName, ulid.MustNew(ulid.Timestamp(time.Now()), cp.entropy).String())
+	}
+
+	// Encode the data that will be stored under the composite key.
+	encodedData, err := codec.GetInstance().Encode(data)
 	if err != nil {
 		return err
 	}
 
-	// Return nil to indicate successful cache processing.
+	// Point every cache index to the composite key and store the payload itself in the same batch.
+	for _, index := range cacheIndexes {
+		values[index] = compKey
+	}
+	values[compKey] = encodedData
 	return nil
 }
 
+// processIndexes is a method of the cacheProcessor type used to process and set cache indexes and associated data.
+// It generates a unique ULID (Universally Unique Lexicographically Sortable Identifier), associates it with each index,
+// and stores the encoded data under the generated ULID. All of the keys are written with a single MSet call so the
+// whole composite index is stored in one round trip.
+//
+// Receiver:
+//   - cp: A cacheProcessor instance responsible for processing cache operations.
+//
+// Parameters:
+//   - cache: The Cache interface to interact with the cache system.
+//   - cacheIndexes: A slice of strings representing cache indexes.
+//   - data: A map containing data to be cached.
+//   - dbName: The name of the database.
+//   - entityName: The name of the entity or collection in the database.
+//
+// Returns:
+//   - err: An error indicating the success or failure of the cache processing operation.
+func (cp *cacheProcessor) processIndexes(cache Cache, cacheIndexes []string, data map[string]interface{}, dbName string, entityName string) error {
+	// Build the index and payload keys of the record.
+	values := make(map[string]interface{}, len(cacheIndexes)+1)
+	if err := cp.addPayload(values, "", cacheIndexes, data, dbName, entityName); err != nil {
+		return err
+	}
+
+	// Write the whole batch in a single round trip.
+	return cache.MSet(values)
+}
+
+// compositeKeys returns the distinct composite keys (the ULIDs holding the payloads) referenced by the values of
+// index keys read with MGet, in the order of the index keys.
+//
+// Parameters:
+//   - values: The values of the index keys, nil for the missing ones.
+//
+// Returns:
+//   - []string: The composite keys, empty if none of the index keys exist.
+func (cp *cacheProcessor) compositeKeys(values []interface{}) []string {
+	compKeys := make([]string, 0, 1)
+	for _, value := range values {
+		compKey, ok := value.([]byte)
+		if !ok || len(compKey) == 0 {
+			continue
+		}
+		found := false
+		for _, key := range compKeys {
+			if key == string(compKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			compKeys = append(compKeys, string(compKey))
+		}
+	}
+	return compKeys
+}
+
 // ProcessGetCache is a method of the cacheProcessor type used to retrieve cached data from the cache system.
 // It looks up the cache using the specified key, retrieves the associated data, decodes it, and populates the
 // provided 'data' interface with the decoded data. If the key or data is not found in the cache, it returns false.
@@ -276,55 +441,36 @@ func (cp *cacheProcessor) ProceessSetQueryCache(cache Cache, key string, data in
 }
 
 // ProceessUpdateCache updates the Redis cache by deleting old keys and creating new keys.
+// The composite key of the old keys is reused, the new keys and the payload are written and the stale keys are
+// removed with a single UpdatePayload call, i.e. in one round trip.
 // Parameters:
 //   - cache (Cache): The cache instance used for data storage and retrieval.
 //   - oldKeys ([]string): A slice of old keys to be deleted from the cache.
 //   - newKeys ([]string): A slice of new keys to be created in the cache.
 //   - data (interface{}): The data to be stored in the cache.
+//   - dbName (string): The name of the database, used to namespace a newly created composite key.
+//   - entityName (string): The name of the entity, used to namespace a newly created composite key.
 //
 // Returns:
 //   - bool: A boolean indicating success (true) or failure (false).
 //   - error: An error, if any, that occurred during the cache update.
-func (cp *cacheProcessor) ProceessUpdateCache(cache Cache, oldKeys []string, newKeys []string, data interface{}) (bool, error) {
+func (cp *cacheProcessor) ProceessUpdateCache(cache Cache, oldKeys []string, newKeys []string, data interface{}, dbName string, entityName string) (bool, error) {
 	// Check if there are no new keys to create, and return success.
 	if len(newKeys) == 0 {
 		return true, nil
 	}
 
-	compKey := ""
-
-	// This loop deletes the old keys but tries to save the composite key.
-	for _, key := range oldKeys {
-		if compKey == "" {
-			// Attempt to retrieve the firstKey associated with the old key.
-			firstKey, err := cache.GetKey(key)
-			if err != nil {
-				return false, err
-			}
-			if firstKey != nil {
-				compKey = string(firstKey.([]byte))
-			}
-		}
-		// Delete the old key from the cache.
-		cache.DelKey(key)
-	}
-
-	// If composite key not found, create one.
-	if compKey == "" {
-		compKey = ulid.MustNew(ulid.Timestamp(time.Now()), cp.entropy).String()
-	}
-
-	// Set the composite keys on the new keys in the cache.
-	for _, key := range newKeys {
-		cache.SetKey(key, compKey)
-	}
-
-	// Encode the data and store it in the cache with the composite key.
+	// Encode the data that will be stored with the composite key.
 	encodedData, err := codec.GetInstance().Encode(data)
 	if err != nil {
 		return false, err
 	}
-	cache.SetKey(compKey, encodedData)
+
+	// The composite key is created only if none of the old keys points to one.
+	compKey := cp.PayloadKey(dbName, entityName, ulid.MustNew(ulid.Timestamp(time.Now()), cp.entropy).String())
+	if _, err := cache.UpdatePayload(oldKeys, newKeys, compKey, encodedData); err != nil {
+		return false, err
+	}
 
 	// Return success and no error.
 	return false, nil
@@ -346,52 +492,87 @@ func (cp *cacheProcessor) ProceessUpdateCache(cache Cache, oldKeys []string, new
 //   - error: An error if any error occurs during cache entry deletion, or nil if the operation is successful.
 //
 // Description:
-// This method starts by attempting to retrieve the firstKey associated with the first old key found in the list.
-// If the firstKey exists, it is treated as a composite key, and its value is stored in the compKey variable.
-// Then, it iterates through each old key and deletes the corresponding cache entry from the cache.
-// If any error occurs during cache entry deletion, the function returns the error immediately.
-//
-// After deleting the individual cache entries, it proceeds to delete the composite key (if it was found earlier).
-// If any error occurs during the composite key deletion, the function returns the error.
+// This method removes the old keys along with the composite key referenced by the first old key found in the cache
+// with a single DeletePayload call, so the lookup and the deletion take one round trip and no other client sees the
+// payload without its keys.
 //
 // This method is designed for batch cache management and cleanup, including the removal of associated composite keys.
 func (cp *cacheProcessor) ProceessDeleteCache(cache Cache, oldKeys []string) error {
-	// Initialize a variable to store the composite key associated with the first old key found.
-	compKey := ""
-
-	// Loop through each old key in the provided list.
-	for _, key := range oldKeys {
-		// If compKey is empty, attempt to retrieve the firstKey associated with the old key.
-		if compKey == "" {
-			// Attempt to retrieve the firstKey associated with the old key.
-			firstKey, err := cache.GetKey(key)
-			if err != nil {
-				// If an error occurs during retrieval, return the error.
-				return err
-			}
-			// If a firstKey exists, set compKey to its value.
-			if firstKey != nil {
-				compKey = string(firstKey.([]byte))
-			}
-		}
+	return cache.DeletePayload(oldKeys)
+}
 
-		// Delete the cache entry associated with the current old key.
-		err := cache.DelKey(key)
+// InvalidateEntity is a method of the cacheProcessor struct used to remove every key that belongs to a single entity.
+// Composite index keys, ULID payloads and query cache keys of the entity share the same namespace, so they are found
+// with a single scan of that namespace and deleted in batches, leaving the rest of a shared cache untouched.
+//
+// Parameters:
+//   - cache: A Cache interface representing the cache storage where entries will be deleted.
+//   - dbName: The name of the database the entity belongs to.
+//   - entityName: The name of the entity or collection whose keys have to be removed.
+//
+// Returns:
+//   - error: An error if any error occurs while scanning or deleting the keys, or nil if the operation is successful.
+func (cp *cacheProcessor) InvalidateEntity(cache Cache, dbName string, entityName string) error {
+	return cp.invalidateNamespace(cache, cp.entityNamespace(dbName, entityName))
+}
 
-		// If an error occurs during deletion, return the error.
-		if err != nil {
-			return err
-		}
-	}
+// InvalidateDatabase is a method of the cacheProcessor struct used to remove every key that belongs to any entity
+// of a database. It works like InvalidateEntity on the namespace shared by all the entities of the database.
+//
+// Parameters:
+//   - cache: A Cache interface representing the cache storage where entries will be deleted.
+//   - dbName: The name of the database whose keys have to be removed.
+//
+// Returns:
+//   - error: An error if any error occurs while scanning or deleting the keys, or nil if the operation is successful.
+func (cp *cacheProcessor) InvalidateDatabase(cache Cache, dbName string) error {
+	return cp.invalidateNamespace(cache, cp.databaseNamespace(dbName))
+}
 
-	// Delete the composite key associated with the first old key found.
-	err := cache.DelKey(compKey)
+// InvalidateQueries is a method of the cacheProcessor struct used to remove the cached query results of an entity,
+// e.g. after a change that may remove records from them. The index keys and payloads of the entity are kept.
+//
+// Parameters:
+//   - cache: A Cache interface representing the cache storage where entries will be deleted.
+//   - dbName: The name of the database the entity belongs to.
+//   - entityName: The name of the entity or collection whose query results have to be removed.
+//
+// Returns:
+//   - error: An error if any error occurs while scanning or deleting the keys, or nil if the operation is successful.
+func (cp *cacheProcessor) InvalidateQueries(cache Cache, dbName string, entityName string) error {
+	return cp.invalidateNamespace(cache, cp.entityNamespace(dbName, entityName)+queryKeyKind+":")
+}
+
+// invalidateNamespace scans the cache for every key starting with the namespace and deletes the keys found,
+// CACHE_SCAN_COUNT keys at a time.
+//
+// Parameters:
+//   - cache: A Cache interface representing the cache storage where entries will be deleted.
+//   - namespace: The literal prefix of the keys to be deleted.
+//
+// Returns:
+//   - error: An error if any error occurs while scanning or deleting the keys, or nil if the operation is successful.
+func (cp *cacheProcessor) invalidateNamespace(cache Cache, namespace string) error {
+	// Acquire a semaphore to control concurrent cache processing.
+	cp.semaphore.Acquire(context.TODO(), 1)
+	defer cp.semaphore.Release(1)
 
-	// If an error occurs during deletion, return the error.
+	// Find every key of the namespace, the namespace itself is matched literally.
+	keys, err := cache.ScanKeys(cp.escapePattern(namespace) + "*")
 	if err != nil {
 		return err
 	}
 
-	// Return nil to indicate successful deletion of cache entries.
+	// Delete the keys in batches so that a single command never grows unbounded.
+	for start := 0; start < len(keys); start += CACHE_SCAN_COUNT {
+		end := start + CACHE_SCAN_COUNT
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := cache.MDel(keys[start:end]); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,188 @@
+package caches
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glodb/dbfusion/codec"
+)
+
+// VerifyCacheBatch is a method of the cacheProcessor struct used to compare a batch of database records with the
+// entries cached for them. For every record the expected index keys are looked up, the payloads they point to are
+// fetched and every field of a payload is compared with the same field of the record.
+//
+// Parameters:
+//   - cache: A Cache interface representing the cache storage to be verified.
+//   - keys: The expected index keys of every record, in the same order as batch.
+//   - batch: The records read from the database as maps of field names to values.
+//
+// Returns:
+//   - missing: The index keys that are absent from the cache or point to a payload that doesn't exist.
+//   - stale: The index keys whose payload differs from the database record.
+//   - inconsistent: The positions in batch of the records with at least one missing or stale key.
+//   - err: An error if the cache cannot be read.
+func (cp *cacheProcessor) VerifyCacheBatch(cache Cache, keys [][]string, batch []map[string]interface{}) (missing []string, stale []string, inconsistent []int, err error) {
+	// Acquire a semaphore to control concurrent cache processing.
+	cp.semaphore.Acquire(context.TODO(), 1)
+	defer cp.semaphore.Release(1)
+
+	// Look up every index key of the batch in a single round trip.
+	allKeys := make([]string, 0)
+	for _, recordKeys := range keys {
+		allKeys = append(allKeys, recordKeys...)
+	}
+	if len(allKeys) == 0 {
+		return
+	}
+	payloadKeys, err := cache.MGet(allKeys)
+	if err != nil {
+		return
+	}
+
+	// Fetch every payload referenced by the index keys in a second round trip.
+	referenced := make([]string, 0)
+	for _, payloadKey := range payloadKeys {
+		if value, ok := payloadKey.([]byte); ok && len(value) != 0 {
+			referenced = append(referenced, string(value))
+		}
+	}
+	payloads := make(map[string][]byte)
+	if len(referenced) > 0 {
+		values, mgetErr := cache.MGet(referenced)
+		if mgetErr != nil {
+			err = mgetErr
+			return
+		}
+		for idx, value := range values {
+			if payload, ok := value.([]byte); ok {
+				payloads[referenced[idx]] = payload
+			}
+		}
+	}
+
+	// Compare the payload of every index key with its record.
+	position := 0
+	for recordIdx, recordKeys := range keys {
+		consistent := true
+		for _, key := range recordKeys {
+			payloadKey, _ := payloadKeys[position].([]byte)
+			position++
+
+			payload, ok := payloads[string(payloadKey)]
+			if !ok {
+				missing = append(missing, key)
+				consistent = false
+				continue
+			}
+
+			equal, compareErr := cp.payloadMatches(payload, batch[recordIdx])
+			if compareErr != nil {
+				err = compareErr
+				return
+			}
+			if !equal {
+				stale = append(stale, key)
+				consistent = false
+			}
+		}
+		if !consistent {
+			inconsistent = append(inconsistent, recordIdx)
+		}
+	}
+	return
+}
+
+// payloadMatches compares a cached payload with a database record. Both are normalised through the codec so that
+// the values have the same representation, and only the fields present in the payload are compared because fields
+// omitted on insertion are never cached.
+//
+// Parameters:
+//   - payload: The encoded payload read from the cache.
+//   - data: The record read from the database.
+//
+// Returns:
+//   - bool: true if every field of the payload has the same value in the record.
+//   - error: An error if the payload or the record cannot be decoded or encoded.
+func (cp *cacheProcessor) payloadMatches(payload []byte, data map[string]interface{}) (bool, error) {
+	cached := make(map[string]interface{})
+	if err := codec.GetInstance().Decode(payload, &cached); err != nil {
+		return false, err
+	}
+
+	// Round trip the record through the codec to get the representation it would have in the cache.
+	encodedData, err := codec.GetInstance().Encode(data)
+	if err != nil {
+		return false, err
+	}
+	record := make(map[string]interface{})
+	if err := codec.GetInstance().Decode(encodedData, &record); err != nil {
+		return false, err
+	}
+
+	for key, value := range cached {
+		recordValue, ok := record[key]
+		if !ok {
+			continue
+		}
+		// Values are compared in their printed form as drivers may return numbers as strings.
+		if fmt.Sprintf("%v", value) != fmt.Sprintf("%v", recordValue) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// FindOrphanedPayloads is a method of the cacheProcessor struct used to find the payloads of an entity that no
+// index key points to anymore, e.g. because the indexes were overwritten or evicted. Such payloads can never be
+// read and only take up memory.
+//
+// Parameters:
+//   - cache: A Cache interface representing the cache storage to be verified.
+//   - dbName: The name of the database the entity belongs to.
+//   - entityName: The name of the entity or collection whose payloads have to be checked.
+//
+// Returns:
+//   - []string: The keys of the orphaned payloads.
+//   - error: An error if the cache cannot be scanned or read.
+func (cp *cacheProcessor) FindOrphanedPayloads(cache Cache, dbName string, entityName string) ([]string, error) {
+	// Acquire a semaphore to control concurrent cache processing.
+	cp.semaphore.Acquire(context.TODO(), 1)
+	defer cp.semaphore.Release(1)
+
+	namespace := cp.escapePattern(cp.entityNamespace(dbName, entityName))
+
+	// Collect the payload keys referenced by any index key of the entity.
+	indexKeys, err := cache.ScanKeys(namespace + indexKeyKind + ":*")
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]bool)
+	for start := 0; start < len(indexKeys); start += CACHE_SCAN_COUNT {
+		end := start + CACHE_SCAN_COUNT
+		if end > len(indexKeys) {
+			end = len(indexKeys)
+		}
+		values, err := cache.MGet(indexKeys[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			if payloadKey, ok := value.([]byte); ok {
+				referenced[string(payloadKey)] = true
+			}
+		}
+	}
+
+	// Every payload of the entity that isn't referenced is an orphan.
+	payloadKeys, err := cache.ScanKeys(namespace + payloadKeyKind + ":*")
+	if err != nil {
+		return nil, err
+	}
+	orphans := make([]string, 0)
+	for _, payloadKey := range payloadKeys {
+		if !referenced[payloadKey] {
+			orphans = append(orphans, payloadKey)
+		}
+	}
+	return orphans, nil
+}
@@ -1,5 +1,7 @@
 package caches
 
+import "time"
+
 /*
 Cache Configuration Variables
 
@@ -35,6 +37,37 @@ This section documents the configuration variables related to caching in the dbF
    - Default Value: 1000
    - Usage: Set this variable to manage the number of simultaneous connections that can be established to the cache system. It influences the concurrency of cache-related operations.
 
+7. CACHE_KEY_PREFIX (string)
+   - Description: The namespace prepended to every key written by dbFusion.
+   - Default Value: "dbfusion"
+   - Usage: Keys are laid out as `<prefix>:<dbName>:<entityName>:<kind>:<value>` so that the keys belonging to an
+     entity or a database can be found and removed without touching unrelated data in a shared cache. Change it
+     when several applications share a cache and must not see each other's keys.
+
+8. CACHE_SCAN_COUNT (int)
+   - Description: The number of keys requested per step while scanning the cache.
+   - Default Value: 1000
+   - Usage: Used by ScanKeys and by the entity and database invalidation. It is also the size of the batches in which
+     the found keys are deleted.
+
+9. CACHE_QUEUE_PREFIX (string)
+   - Description: The prefix of the queues kept in the cache, e.g. the write-behind queue.
+   - Default Value: "dbfusion-queue"
+   - Usage: Queues are kept apart from CACHE_KEY_PREFIX so that invalidating the cache of a database or an entity
+     never drops records that are still waiting to be written to the database. Queues are laid out as
+     `<prefix>:<server>:<dbName>:<name>`, so each database of each server has its own write-behind queue.
+
+10. CACHE_WRITE_BEHIND_RETRIES (int)
+   - Description: The number of times a write-behind record is written to the database before it is given up.
+   - Default Value: 5
+   - Usage: Records that still fail after the last attempt are moved to the dead letter queue for inspection.
+
+11. CACHE_WRITE_BEHIND_BACKOFF (time.Duration)
+   - Description: The delay before the first retry of a failed write-behind record.
+   - Default Value: 100 milliseconds
+   - Usage: The delay doubles with every attempt, up to a maximum of 30 seconds. A failed record is queued again
+     with the time it is due at, so the records queued behind it are written in the meantime.
+
 These configuration variables allow you to fine-tune the behavior of the caching system within dbFusion, ensuring that it aligns with your application's requirements and resource constraints.
 */
 var MAX_CACHE_SIZE = 1024
@@ -43,3 +76,8 @@ var CACHE_DEFAULT_CONNECTIONS = 1000
 var CACHE_MAX_IDLE_CONNECTIONS = 80
 var CACHE_MAX_CONNECTIONS = 1000
 var CACHE_PARALLEL_PROCESS = 1000
+var CACHE_KEY_PREFIX = "dbfusion"
+var CACHE_SCAN_COUNT = 1000
+var CACHE_QUEUE_PREFIX = "dbfusion-queue"
+var CACHE_WRITE_BEHIND_RETRIES = 5
+var CACHE_WRITE_BEHIND_BACKOFF = 100 * time.Millisecond
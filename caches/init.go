@@ -15,6 +15,8 @@ The `caches` package defines the use of caching within the dbFusion system. It h
   - It processes keys and constructs composite indexes.
   - It searches for composite indexes and provides updates.
   - Deletion is straightforward, currently deleting the first index, while composite indexes invalidate on expiration or are moved away for LRU (Least Recently Used) eviction.
+  - Every key is namespaced as "<CACHE_KEY_PREFIX>:<dbName>:<entityName>:<kind>:<value>", where kind is "idx" for composite indexes, "obj" for ULID payloads and "qry" for query results. The value of an index key names the indexed fields with their values, e.g. "email=a@b.c", so that a query on one field never reads the index of another field holding the same value. A ':' in a database or entity name is percent-encoded so that one name never matches the namespace of another.
+  - InvalidateEntity and InvalidateDatabase remove the keys of a single entity or database with SCAN based deletion, which makes them safe to use in a shared cache unlike FlushAll.
 
 
 Developers can use this package as a foundation for creating their own custom cache solutions within the dbFusion framework. It provides the necessary architecture and an example Redis implementation for reference.
@@ -0,0 +1,72 @@
+package caches
+
+import "time"
+
+/*
+Queue Interface
+
+The Queue interface defines a durable, reliable queue that a cache can optionally provide. dbFusion uses it for the
+write-behind cache policy, where records are acknowledged once they are cached and written to the database later on.
+A cache that doesn't implement this interface can't be used with write-behind entities.
+
+Every value taken from a queue is moved atomically to a processing list until it is acknowledged, so values being
+processed when a process stops are not lost and can be moved back with Requeue. Delivery is therefore at-least-once.
+
+Methods:
+
+1. Enqueue(queue string, value []byte) error
+   - Description: Appends a value to the tail of the queue.
+   - Parameters:
+     - `queue` (string): The key of the queue.
+     - `value` ([]byte): The value to be queued.
+   - Returns:
+     - `error`: An error if the value cannot be queued.
+
+2. Dequeue(queue string, processing string, timeout time.Duration) ([]byte, error)
+   - Description: Moves the value at the head of the queue to the processing list and returns it, waiting up to
+     timeout for a value to arrive.
+   - Parameters:
+     - `queue` (string): The key of the queue.
+     - `processing` (string): The key of the processing list.
+     - `timeout` (time.Duration): How long to wait for a value, it is rounded up to whole seconds.
+   - Returns:
+     - `[]byte`: The value, or nil if the queue stayed empty.
+     - `error`: An error if the queue cannot be read.
+
+3. Ack(processing string, value []byte) error
+   - Description: Removes a processed value from the processing list.
+   - Parameters:
+     - `processing` (string): The key of the processing list.
+     - `value` ([]byte): The value returned by Dequeue.
+   - Returns:
+     - `error`: An error if the value cannot be removed.
+
+4. Requeue(processing string, queue string) error
+   - Description: Moves every value of the processing list back to the head of the queue.
+   - Parameters:
+     - `processing` (string): The key of the processing list.
+     - `queue` (string): The key of the queue.
+   - Returns:
+     - `error`: An error if the values cannot be moved.
+*/
+type Queue interface {
+	Enqueue(queue string, value []byte) error
+	Dequeue(queue string, processing string, timeout time.Duration) ([]byte, error)
+	Ack(processing string, value []byte) error
+	Requeue(processing string, queue string) error
+}
+
+// QueueKey builds the key of a queue. Queues live outside of the database namespaces so that invalidating the cache of
+// a database or an entity never drops records waiting to be written. They are namespaced by the database server and
+// the database instead, so that services sharing a cache never consume each other's records.
+//
+// Parameters:
+//   - server: The address of the database server.
+//   - dbName: The name of the database.
+//   - name: The name of the queue.
+//
+// Returns:
+//   - string: The key in the form "<CACHE_QUEUE_PREFIX>:<server>:<dbName>:<name>", with ':' escaped in server and dbName.
+func (cp *cacheProcessor) QueueKey(server string, dbName string, name string) string {
+	return CACHE_QUEUE_PREFIX + ":" + cp.escapeSegment(server) + ":" + cp.escapeSegment(dbName) + ":" + name
+}
@@ -2,11 +2,56 @@ package caches
 
 import (
 	"context"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
 	"golang.org/x/sync/semaphore"
 )
 
+// updatePayloadScript points new index keys to the payload referenced by the first old index key present in the
+// cache, or to the payload key of ARGV[2] if none is, stores the payload of ARGV[3] under it and deletes the old index
+// keys that are not rewritten. KEYS holds the ARGV[1] old index keys followed by the new ones. It returns the payload
+// key.
+var updatePayloadScript = redis.NewScript(-1, `
+local oldCount = tonumber(ARGV[1])
+local payloadKey = ARGV[2]
+for i = 1, oldCount do
+	local value = redis.call("GET", KEYS[i])
+	if value and value ~= "" then
+		payloadKey = value
+		break
+	end
+end
+local rewritten = {}
+for i = oldCount + 1, #KEYS do
+	redis.call("SET", KEYS[i], payloadKey)
+	rewritten[KEYS[i]] = true
+end
+for i = 1, oldCount do
+	if not rewritten[KEYS[i]] then
+		redis.call("DEL", KEYS[i])
+	end
+end
+redis.call("SET", payloadKey, ARGV[3])
+return payloadKey
+`)
+
+// deletePayloadScript deletes the index keys of KEYS along with the payload referenced by the first of them present in
+// the cache.
+var deletePayloadScript = redis.NewScript(-1, `
+for i = 1, #KEYS do
+	local value = redis.call("GET", KEYS[i])
+	if value and value ~= "" then
+		redis.call("DEL", value)
+		break
+	end
+end
+if #KEYS > 0 then
+	redis.call("DEL", unpack(KEYS))
+end
+return 0
+`)
+
 // RedisCache struct implements the Cache interface for Redis.
 type RedisCache struct {
 	pool      *redis.Pool         // The Redis connection pool.
@@ -44,7 +89,11 @@ func (rc *RedisCache) ConnectCache(connectionUri string, password ...string) err
 	}
 
 	// If there is no error but the pool's connection has an error, attempt to recreate the pool.
-	if rc.pool.Get().Err() != nil {
+	// The borrowed connection is returned to the pool straight after the check.
+	conn := rc.pool.Get()
+	connErr := conn.Err()
+	conn.Close()
+	if connErr != nil {
 		rc.pool, err = rc.newPool(connectionUri, password...)
 	}
 
@@ -100,6 +149,7 @@ func (rc *RedisCache) newPool(uri string, password ...string) (*redis.Pool, erro
 
 	// Test the connection by attempting to get a connection from the pool.
 	conn := pool.Get()
+	defer conn.Close()
 
 	// If there is an error with the connection, return an error indicating connection failure.
 	if err := conn.Err(); err != nil {
@@ -155,8 +205,9 @@ func (rc *RedisCache) DisconnectCache() {
 }
 
 // GetKey is a method of the RedisCache type used to retrieve a value associated with a specific key from the Redis cache.
-// It acquires a semaphore to ensure exclusive access to this operation, retrieves the value using the "GET" command on
-// the Redis connection, and returns the retrieved data along with any potential errors.
+// It acquires a semaphore to ensure exclusive access to this operation, borrows a connection from the pool, retrieves
+// the value using the "GET" command and returns the connection to the pool before returning the retrieved data along
+// with any potential errors.
 //
 // Receiver:
 //   - rc: A RedisCache instance responsible for managing Redis cache connections.
@@ -172,8 +223,12 @@ func (rc *RedisCache) GetKey(key string) (interface{}, error) {
 	rc.semaphore.Acquire(context.TODO(), 1)
 	defer rc.semaphore.Release(1)
 
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
 	// Retrieve the value associated with the specified 'key' using the "GET" command on the Redis connection.
-	data, err := rc.pool.Get().Do("GET", key)
+	data, err := conn.Do("GET", key)
 
 	// Return the retrieved data and any potential errors.
 	return data, err
@@ -198,8 +253,12 @@ func (rc *RedisCache) SetKey(key string, value interface{}) error {
 	rc.semaphore.Acquire(context.TODO(), 1)
 	defer rc.semaphore.Release(1)
 
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
 	// Use the "SET" command on the Redis connection to set the 'key' with the provided 'value'.
-	_, err := rc.pool.Get().Do("SET", key, value)
+	_, err := conn.Do("SET", key, value)
 
 	// Return any potential errors that may occur during the cache update.
 	return err
@@ -216,8 +275,12 @@ func (rc *RedisCache) FlushAll() {
 	rc.semaphore.Acquire(context.TODO(), 1)
 	defer rc.semaphore.Release(1)
 
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
 	// Use the "FLUSHALL" command on the Redis connection to remove all data from the cache.
-	rc.pool.Get().Do("FLUSHALL")
+	conn.Do("FLUSHALL")
 }
 
 // DelKey is a method of the RedisCache type used to delete a specific key from the Redis cache.
@@ -238,9 +301,345 @@ func (rc *RedisCache) DelKey(key string) error {
 	rc.semaphore.Acquire(context.TODO(), 1)
 	defer rc.semaphore.Release(1)
 
-	// Use the Redis connection pool to send the "DEL" command and delete the specified 'key'.
-	_, err := rc.pool.Get().Do("DEL", key)
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	// Use the borrowed connection to send the "DEL" command and delete the specified 'key'.
+	_, err := conn.Do("DEL", key)
+
+	// Return any potential errors that may occur during the key deletion.
+	return err
+}
+
+// MGet is a method of the RedisCache type used to retrieve the values of several keys in a single round trip.
+// It acquires a semaphore to ensure exclusive access to this operation, borrows a connection from the pool and
+// uses the "MGET" command to read all the keys at once.
+//
+// Receiver:
+//   - rc: A RedisCache instance responsible for managing Redis cache connections.
+//
+// Parameters:
+//   - keys: The keys whose values are to be retrieved from the Redis cache.
+//
+// Returns:
+//   - values: The values in the same order as 'keys'; a missing key yields nil at its position.
+//   - err: An error indicating the success or failure of the cache retrieval operation.
+func (rc *RedisCache) MGet(keys []string) ([]interface{}, error) {
+	// Nothing to read, avoid a needless round trip.
+	if len(keys) == 0 {
+		return []interface{}{}, nil
+	}
+
+	// Acquire a semaphore to ensure exclusive access to this operation.
+	rc.semaphore.Acquire(context.TODO(), 1)
+	defer rc.semaphore.Release(1)
+
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	// Use the "MGET" command to read every key in one round trip.
+	return redis.Values(conn.Do("MGET", redis.Args{}.AddFlat(keys)...))
+}
+
+// MSet is a method of the RedisCache type used to store several key-value pairs in a single round trip.
+// It acquires a semaphore to ensure exclusive access to this operation, borrows a connection from the pool and
+// uses the atomic "MSET" command so that either all of the pairs are written or none of them are.
+//
+// Receiver:
+//   - rc: A RedisCache instance responsible for managing Redis cache connections.
+//
+// Parameters:
+//   - values: The keys and the values to be associated with them in the Redis cache.
+//
+// Returns:
+//   - err: An error indicating the success or failure of the cache update operation.
+func (rc *RedisCache) MSet(values map[string]interface{}) error {
+	// Nothing to write, avoid a needless round trip.
+	if len(values) == 0 {
+		return nil
+	}
+
+	// Acquire a semaphore to ensure exclusive access to this operation.
+	rc.semaphore.Acquire(context.TODO(), 1)
+	defer rc.semaphore.Release(1)
+
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	// Flatten the map into key value arguments and write them with a single "MSET" command.
+	args := redis.Args{}
+	for key, value := range values {
+		args = args.Add(key, value)
+	}
+	_, err := conn.Do("MSET", args...)
+
+	// Return any potential errors that may occur during the cache update.
+	return err
+}
+
+// MDel is a method of the RedisCache type used to delete several keys in a single round trip.
+// It acquires a semaphore to ensure exclusive access to this operation, borrows a connection from the pool and
+// sends a single "DEL" command carrying all of the keys.
+//
+// Receiver:
+//   - rc: A RedisCache instance responsible for managing Redis cache connections.
+//
+// Parameters:
+//   - keys: The keys to be deleted from the Redis cache.
+//
+// Returns:
+//   - err: An error indicating the success or failure of the key deletion operation.
+func (rc *RedisCache) MDel(keys []string) error {
+	// Nothing to delete, avoid a needless round trip.
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Acquire a semaphore to ensure exclusive access to this operation.
+	rc.semaphore.Acquire(context.TODO(), 1)
+	defer rc.semaphore.Release(1)
+
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	// Use the "DEL" command with all the keys to delete them at once.
+	_, err := conn.Do("DEL", redis.Args{}.AddFlat(keys)...)
 
 	// Return any potential errors that may occur during the key deletion.
 	return err
 }
+
+// UpdatePayload is a method of the RedisCache type used to move the index keys of a record to new ones and store
+// its payload in a single round trip. A Lua script reads the old index keys, reuses the payload key the first of
+// them points to, writes the new index keys and the payload and deletes the old index keys that are not rewritten,
+// so that no other client sees the update half done.
+//
+// Receiver:
+//   - rc: A RedisCache instance responsible for managing Redis cache connections.
+//
+// Parameters:
+//   - oldKeys: The index keys of the record before the update.
+//   - newKeys: The index keys of the record after the update.
+//   - payloadKey: The payload key used if none of the old index keys is present in the cache.
+//   - payload: The encoded record.
+//
+// Returns:
+//   - string: The payload key the new index keys point to.
+//   - err: An error indicating the success or failure of the cache update operation.
+func (rc *RedisCache) UpdatePayload(oldKeys []string, newKeys []string, payloadKey string, payload []byte) (string, error) {
+	// Acquire a semaphore to ensure exclusive access to this operation.
+	rc.semaphore.Acquire(context.TODO(), 1)
+	defer rc.semaphore.Release(1)
+
+	// Borrow a connection from the pool and make sure it is returned once the script completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	// The script is sent by its digest and only loaded again if the server doesn't know it yet.
+	args := redis.Args{}.Add(len(oldKeys) + len(newKeys)).AddFlat(oldKeys).AddFlat(newKeys)
+	args = args.Add(len(oldKeys), payloadKey, payload)
+	return redis.String(updatePayloadScript.Do(conn, args...))
+}
+
+// DeletePayload is a method of the RedisCache type used to delete the index keys of a record along with its payload
+// in a single round trip. A Lua script reads the index keys and deletes them together with the payload key the first
+// of them points to.
+//
+// Receiver:
+//   - rc: A RedisCache instance responsible for managing Redis cache connections.
+//
+// Parameters:
+//   - keys: The index keys of the record.
+//
+// Returns:
+//   - err: An error indicating the success or failure of the deletion.
+func (rc *RedisCache) DeletePayload(keys []string) error {
+	// Nothing to delete, avoid a needless round trip.
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Acquire a semaphore to ensure exclusive access to this operation.
+	rc.semaphore.Acquire(context.TODO(), 1)
+	defer rc.semaphore.Release(1)
+
+	// Borrow a connection from the pool and make sure it is returned once the script completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	_, err := deletePayloadScript.Do(conn, redis.Args{}.Add(len(keys)).AddFlat(keys)...)
+	return err
+}
+
+// ScanKeys is a method of the RedisCache type used to list every key matching a glob style pattern.
+// It walks the keyspace with the "SCAN" command instead of "KEYS" so that the Redis server is never blocked,
+// even when the cache holds a large number of keys.
+//
+// Receiver:
+//   - rc: A RedisCache instance responsible for managing Redis cache connections.
+//
+// Parameters:
+//   - pattern: The glob style pattern the keys have to match.
+//
+// Returns:
+//   - keys: The keys matching the pattern.
+//   - err: An error indicating the success or failure of the scan.
+func (rc *RedisCache) ScanKeys(pattern string) ([]string, error) {
+	// Acquire a semaphore to ensure exclusive access to this operation.
+	rc.semaphore.Acquire(context.TODO(), 1)
+	defer rc.semaphore.Release(1)
+
+	// Borrow a connection from the pool and make sure it is returned once the scan completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	keys := make([]string, 0)
+	cursor := 0
+	for {
+		// Each SCAN call returns the next cursor and a page of matching keys.
+		reply, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", pattern, "COUNT", CACHE_SCAN_COUNT))
+		if err != nil {
+			return nil, err
+		}
+
+		cursor, err = redis.Int(reply[0], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := redis.Strings(reply[1], nil)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, page...)
+
+		// A zero cursor means the whole keyspace has been walked.
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return keys, nil
+}
+
+// Enqueue is a method of the RedisCache type used to append a value to the tail of a queue.
+// The queue is a Redis list, so it is as durable as the persistence configured for the Redis server.
+//
+// Receiver:
+//   - rc: A RedisCache instance responsible for the queue.
+//
+// Parameters:
+//   - queue: The key of the queue.
+//   - value: The value to be queued.
+//
+// Returns:
+//   - error: An error if the value cannot be queued.
+func (rc *RedisCache) Enqueue(queue string, value []byte) error {
+	// Acquire a semaphore to ensure exclusive access to this operation.
+	rc.semaphore.Acquire(context.TODO(), 1)
+	defer rc.semaphore.Release(1)
+
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	// New values are pushed on the left and taken from the right, which makes the list a FIFO queue.
+	_, err := conn.Do("LPUSH", queue, value)
+	return err
+}
+
+// Dequeue is a method of the RedisCache type used to take the value at the head of a queue. The value is moved
+// atomically to the processing list, where it stays until it is acknowledged.
+//
+// Receiver:
+//   - rc: A RedisCache instance responsible for the queue.
+//
+// Parameters:
+//   - queue: The key of the queue.
+//   - processing: The key of the processing list.
+//   - timeout: How long to wait for a value, rounded up to whole seconds.
+//
+// Returns:
+//   - []byte: The value, or nil if the queue stayed empty.
+//   - error: An error if the queue cannot be read.
+func (rc *RedisCache) Dequeue(queue string, processing string, timeout time.Duration) ([]byte, error) {
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	// The semaphore is not acquired as the command blocks for up to the timeout.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	// BRPOPLPUSH blocks for whole seconds, a zero timeout would block forever.
+	seconds := int((timeout + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	value, err := redis.Bytes(conn.Do("BRPOPLPUSH", queue, processing, seconds))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	return value, err
+}
+
+// Ack is a method of the RedisCache type used to remove a processed value from the processing list.
+//
+// Receiver:
+//   - rc: A RedisCache instance responsible for the queue.
+//
+// Parameters:
+//   - processing: The key of the processing list.
+//   - value: The value returned by Dequeue.
+//
+// Returns:
+//   - error: An error if the value cannot be removed.
+func (rc *RedisCache) Ack(processing string, value []byte) error {
+	// Acquire a semaphore to ensure exclusive access to this operation.
+	rc.semaphore.Acquire(context.TODO(), 1)
+	defer rc.semaphore.Release(1)
+
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	// Remove a single occurrence of the value, identical values queued twice are acknowledged one by one.
+	_, err := conn.Do("LREM", processing, 1, value)
+	return err
+}
+
+// Requeue is a method of the RedisCache type used to move every value of a processing list back to its queue,
+// e.g. values that were being processed when the previous process stopped.
+//
+// Receiver:
+//   - rc: A RedisCache instance responsible for the queue.
+//
+// Parameters:
+//   - processing: The key of the processing list.
+//   - queue: The key of the queue.
+//
+// Returns:
+//   - error: An error if the values cannot be moved.
+func (rc *RedisCache) Requeue(processing string, queue string) error {
+	// Acquire a semaphore to ensure exclusive access to this operation.
+	rc.semaphore.Acquire(context.TODO(), 1)
+	defer rc.semaphore.Release(1)
+
+	// Borrow a connection from the pool and make sure it is returned once the command completes.
+	conn := rc.pool.Get()
+	defer conn.Close()
+
+	// Move the values one by one, newest first, to the right end of the queue where values are taken from,
+	// until the processing list is empty. The oldest value ends up at the head (requires Redis 6.2 or later).
+	for {
+		value, err := conn.Do("LMOVE", processing, queue, "LEFT", "RIGHT")
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,391 @@
+// Command dbfusion provides maintenance tasks for applications built on dbFusion.
+//
+// Usage:
+//
+//	dbfusion warm -driver mysql -uri "user:pass@tcp(localhost:3306)/db" -db db -cache localhost:6379 \
+//	    -table users -indexes "email;firstname,lastname" -batch 1000 -rate 5000
+//
+// The warm command streams every record of a table or collection and rebuilds its cache indexes and payloads,
+// e.g. after the cache was restarted. Indexes are separated by semicolons and the keys of a composite index by
+// commas, just like the values returned by GetCacheIndexes.
+//
+//	dbfusion verify -driver mongo -uri mongodb://localhost:27017 -db db -table users -indexes "email" -sample 1000
+//
+// The verify command compares the cache of a table or collection with the database and reports missing keys, stale
+// values and orphaned payloads. With -repair everything found is fixed.
+//
+//	dbfusion migrate -driver mysql -uri "user:pass@tcp(localhost:3306)/db" -db db -dir migrations up
+//
+// The migrate command applies the pending migration files of a directory with up, reverts the last -steps applied
+// migrations with down and lists the applied and pending migrations with status. It connects without a cache unless
+// -cache is given.
+//
+//	dbfusion gen -driver mysql -uri "user:pass@tcp(localhost:3306)/db" -db db -table users,orders -package models -out models/gen.go
+//
+// The gen command reads the columns and indexes of existing tables, or samples -sample documents of existing
+// collections, and writes their models: structs with dbfusion tags, GetEntityName methods and the index hooks
+// declaring the existing indexes. Without -out the source is printed.
+//
+//	//go:generate go run github.com/glodb/dbfusion/cmd/dbfusion mapper -type User,Order
+//
+// The mapper command writes the mappers of the models of the package in -dir, the current directory by default, to
+// -out: the methods reading and writing their tagged fields without reflection. Without -type the mappers of every
+// struct with a tagged field are written.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/mapper"
+	"github.com/glodb/dbfusion/migrations"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/schema"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "warm":
+		err = warm(os.Args[2:])
+	case "verify":
+		err = verify(os.Args[2:])
+	case "migrate":
+		err = migrate(os.Args[2:])
+	case "gen":
+		err = gen(os.Args[2:])
+	case "mapper":
+		err = generateMappers(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dbfusion:", err)
+		os.Exit(1)
+	}
+}
+
+// usage prints the available commands.
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dbfusion <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  warm    rebuild the cache indexes and payloads of a table or collection")
+	fmt.Fprintln(os.Stderr, "  verify  compare the cache of a table or collection with the database")
+	fmt.Fprintln(os.Stderr, "  migrate apply, revert or list the schema migrations of a directory")
+	fmt.Fprintln(os.Stderr, "  gen     generate the models of existing tables or collections")
+	fmt.Fprintln(os.Stderr, "  mapper  generate the mappers reading the fields of models without reflection")
+}
+
+// connectionFlags holds the flags shared by the commands that connect to a database and a cache.
+type connectionFlags struct {
+	driver   *string
+	uri      *string
+	dbName   *string
+	cacheUri *string
+}
+
+// addConnectionFlags registers the connection flags on the flag set, an empty cache address connecting without a
+// cache.
+func addConnectionFlags(flags *flag.FlagSet, cacheUri string) connectionFlags {
+	return connectionFlags{
+		driver:   flags.String("driver", "mysql", "database driver, mysql or mongo"),
+		uri:      flags.String("uri", "", "connection uri of the database"),
+		dbName:   flags.String("db", "", "name of the database"),
+		cacheUri: flags.String("cache", cacheUri, "address of the redis cache"),
+	}
+}
+
+// connect opens the cache and the database described by the flags.
+func (cf connectionFlags) connect() (connections.Connection, error) {
+	options := dbfusion.Options{
+		DbName: cf.dbName,
+		Uri:    cf.uri,
+	}
+	if *cf.cacheUri != "" {
+		cache := caches.RedisCache{}
+		if err := cache.ConnectCache(*cf.cacheUri); err != nil {
+			return nil, err
+		}
+		options.Cache = &cache
+	}
+
+	switch *cf.driver {
+	case "mysql":
+		return dbfusion.GetInstance().GetMySqlConnection(options)
+	case "mongo":
+		return dbfusion.GetInstance().GetMongoConnection(options)
+	}
+	return nil, fmt.Errorf("unsupported driver %q", *cf.driver)
+}
+
+// warm implements the warm command.
+func warm(args []string) error {
+	flags := flag.NewFlagSet("warm", flag.ExitOnError)
+	connection := addConnectionFlags(flags, "localhost:6379")
+	table := flags.String("table", "", "table or collection to warm")
+	indexes := flags.String("indexes", "", "cache indexes separated by ';', keys of an index separated by ','")
+	batchSize := flags.Int("batch", 500, "number of records written to the cache at once")
+	rate := flags.Int("rate", 0, "maximum number of records cached per second, 0 for no limit")
+	invalidate := flags.Bool("invalidate", false, "remove the cached keys of the table before warming")
+	flags.Parse(args)
+
+	if *table == "" || *indexes == "" {
+		return fmt.Errorf("-table and -indexes are required")
+	}
+
+	con, err := connection.connect()
+	if err != nil {
+		return err
+	}
+	defer con.DisConnect()
+
+	options := queryoptions.WarmCacheOptions{
+		BatchSize:     *batchSize,
+		RatePerSecond: *rate,
+		Indexes:       strings.Split(*indexes, ";"),
+		Invalidate:    *invalidate,
+		Progress: func(processed int64, cached int64) {
+			fmt.Printf("\rprocessed %d, cached %d", processed, cached)
+		},
+	}
+
+	// Records are read as maps, the table is taken from the Table call of the connection.
+	var results connections.WarmCacheResults
+	switch typedCon := con.(type) {
+	case connections.SQLConnection:
+		results, err = typedCon.Table(*table).WarmCache(map[string]interface{}{}, options)
+	case connections.MongoConnection:
+		results, err = typedCon.Table(*table).WarmCache(map[string]interface{}{}, options)
+	}
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("warmed %s: %d processed, %d cached, %d skipped in %v\n", *table, results.Processed, results.Cached, results.Skipped, results.Duration)
+	return nil
+}
+
+// verify implements the verify command.
+func verify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	connection := addConnectionFlags(flags, "localhost:6379")
+	table := flags.String("table", "", "table or collection to verify")
+	indexes := flags.String("indexes", "", "cache indexes separated by ';', keys of an index separated by ','")
+	sample := flags.Int("sample", 0, "number of random records to verify, 0 to verify every record")
+	orphans := flags.Bool("orphans", true, "look for payloads no index points to")
+	repair := flags.Bool("repair", false, "cache inconsistent records again and remove orphaned payloads")
+	verbose := flags.Bool("v", false, "print every inconsistent key")
+	flags.Parse(args)
+
+	if *table == "" || *indexes == "" {
+		return fmt.Errorf("-table and -indexes are required")
+	}
+
+	con, err := connection.connect()
+	if err != nil {
+		return err
+	}
+	defer con.DisConnect()
+
+	options := queryoptions.VerifyCacheOptions{
+		SampleSize:   *sample,
+		Indexes:      strings.Split(*indexes, ";"),
+		CheckOrphans: *orphans,
+		Repair:       *repair,
+	}
+
+	// Records are read as maps, the table is taken from the Table call of the connection.
+	var report connections.CacheVerificationReport
+	switch typedCon := con.(type) {
+	case connections.SQLConnection:
+		report, err = typedCon.Table(*table).VerifyCache(map[string]interface{}{}, options)
+	case connections.MongoConnection:
+		report, err = typedCon.Table(*table).VerifyCache(map[string]interface{}{}, options)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("verified %s: %d checked, %d inconsistent, %d missing keys, %d stale keys, %d orphaned payloads, %d repaired in %v\n",
+		*table, report.Checked, report.Inconsistent, len(report.MissingKeys), len(report.StaleKeys), len(report.OrphanedPayloads), report.Repaired, report.Duration)
+	if *verbose {
+		for _, key := range report.MissingKeys {
+			fmt.Println("missing ", key)
+		}
+		for _, key := range report.StaleKeys {
+			fmt.Println("stale   ", key)
+		}
+		for _, key := range report.OrphanedPayloads {
+			fmt.Println("orphaned", key)
+		}
+	}
+	return nil
+}
+
+// migrate implements the migrate command.
+func migrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	connection := addConnectionFlags(flags, "")
+	dir := flags.String("dir", "migrations", "directory of the migration files")
+	steps := flags.Int("steps", 1, "number of migrations reverted by down")
+	entity := flags.String("entity", migrations.DefaultEntity, "table or collection of the applied migrations")
+	shell := flags.String("shell", migrations.DefaultShell, "mongo shell running the .js migrations")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("migrate expects one of up, down or status")
+	}
+
+	con, err := connection.connect()
+	if err != nil {
+		return err
+	}
+	defer con.DisConnect()
+
+	// The .js migrations run with the mongo shell on the database of the connection.
+	migrator := migrations.NewMigrator(con, migrations.Options{
+		Entity:        *entity,
+		Shell:         *shell,
+		ShellURI:      *connection.uri,
+		ShellDatabase: *connection.dbName,
+	})
+	if err := migrator.LoadDir(*dir); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch flags.Arg(0) {
+	case "up":
+		applied, err := migrator.Migrate(ctx)
+		for _, migration := range applied {
+			fmt.Printf("applied  %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migrations applied\n", len(applied))
+	case "down":
+		reverted, err := migrator.Rollback(ctx, *steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migrations reverted\n", len(reverted))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Missing {
+				state = "missing"
+			} else if status.Applied {
+				state = "applied"
+			}
+			appliedAt := ""
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-8s %d_%s %s\n", state, status.Version, status.Name, appliedAt)
+		}
+	default:
+		return fmt.Errorf("unknown migrate action %q, expected up, down or status", flags.Arg(0))
+	}
+	return nil
+}
+
+// gen implements the gen command.
+func gen(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	connection := addConnectionFlags(flags, "")
+	tables := flags.String("table", "", "tables or collections to generate the models of, separated by ','")
+	packageName := flags.String("package", "models", "package of the generated file")
+	out := flags.String("out", "", "file the models are written to, the standard output when empty")
+	sample := flags.Int("sample", 1000, "number of documents sampled per collection, 0 to read every document")
+	flags.Parse(args)
+
+	if *tables == "" {
+		return fmt.Errorf("-table is required")
+	}
+
+	con, err := connection.connect()
+	if err != nil {
+		return err
+	}
+	defer con.DisConnect()
+
+	// Describe the tables with the connection, collections being sampled.
+	described := make([]schema.Table, 0)
+	for _, name := range strings.Split(*tables, ",") {
+		var table schema.Table
+		switch typedCon := con.(type) {
+		case connections.SQLConnection:
+			table, err = typedCon.DescribeTable(strings.TrimSpace(name))
+		case connections.MongoConnection:
+			table, err = typedCon.DescribeCollection(strings.TrimSpace(name), *sample)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		described = append(described, table)
+	}
+
+	source, err := schema.Generate(*packageName, described...)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	if err := os.WriteFile(*out, source, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("generated %d models in %s\n", len(described), *out)
+	return nil
+}
+
+// generateMappers implements the mapper command.
+func generateMappers(args []string) error {
+	flags := flag.NewFlagSet("mapper", flag.ExitOnError)
+	types := flags.String("type", "", "structs to generate the mappers of, separated by ',', every tagged struct when empty")
+	dir := flags.String("dir", ".", "directory of the package declaring the structs")
+	out := flags.String("out", "dbfusion_mappers.go", "file the mappers are written to, relative to -dir")
+	flags.Parse(args)
+
+	typeNames := make([]string, 0)
+	if *types != "" {
+		for _, typeName := range strings.Split(*types, ",") {
+			typeNames = append(typeNames, strings.TrimSpace(typeName))
+		}
+	}
+
+	packageName, models, imports, err := mapper.Parse(*dir, typeNames...)
+	if err != nil {
+		return err
+	}
+	source, err := mapper.Generate(packageName, models, imports)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(*dir, *out), source, 0644)
+}
@@ -0,0 +1,64 @@
+package codec
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// bsonCodecProcessor is a singleton processor encoding data to and from BSON. Unlike JSON, BSON keeps the type of
+// the values held by interface{} fields, such as time.Time, primitive.ObjectID and int64, which makes it the codec of
+// values that are decoded without a typed target, e.g. the records queued by write-behind entities.
+type bsonCodecProcessor struct {
+	registry *bsoncodec.Registry // The registry decoding dates as time.Time and binary values as []byte.
+}
+
+var (
+	bsonInstance *bsonCodecProcessor // Singleton instance of the bsonCodecProcessor.
+	bsonOnce     sync.Once           // Ensures the singleton instance is created only once.
+)
+
+// GetBSONInstance returns the singleton instance of the BSON codec, creating it on the first call.
+//
+// Returns:
+//   - *bsonCodecProcessor: A pointer to the singleton instance of the bsonCodecProcessor.
+func GetBSONInstance() *bsonCodecProcessor {
+	bsonOnce.Do(func() {
+		// Dates and binary values decoded into interface{} come back with the types they were encoded from
+		// instead of primitive.DateTime and primitive.Binary.
+		builder := bson.NewRegistryBuilder()
+		builder.RegisterTypeMapEntry(bsontype.DateTime, reflect.TypeOf(time.Time{}))
+		builder.RegisterTypeMapEntry(bsontype.Binary, reflect.TypeOf([]byte{}))
+		bsonInstance = &bsonCodecProcessor{registry: builder.Build()}
+	})
+
+	return bsonInstance
+}
+
+// Encode encodes a struct or a map into a BSON document.
+//
+// Parameters:
+//   - data: The struct or map to be encoded.
+//
+// Returns:
+//   - []byte: The encoded document.
+//   - error: An error if encoding fails, e.g. when data is not a struct or a map.
+func (bp *bsonCodecProcessor) Encode(data interface{}) ([]byte, error) {
+	return bson.MarshalWithRegistry(bp.registry, data)
+}
+
+// Decode decodes a BSON document. Dates are decoded as time.Time in UTC with millisecond precision.
+//
+// Parameters:
+//   - encodedData: The encoded document.
+//   - v: A pointer to the target data structure into which the decoded data will be stored.
+//
+// Returns:
+//   - error: An error if decoding fails.
+func (bp *bsonCodecProcessor) Decode(encodedData []byte, v any) error {
+	return bson.UnmarshalWithRegistry(bp.registry, encodedData, v)
+}
@@ -1,11 +1,13 @@
 // Package codec provides encoding and decoding functionality for setting values in a cache.
-// Currently, it supports JSON encoding. Additional encoding methods may be added in the future.
+// It supports JSON encoding and BSON encoding. Additional encoding methods may be added in the future.
 //
 // The primary purpose of this package is to encode Go data structures into byte slices for storage
 // in a cache and decode them back into their original form when retrieved from the cache.
 //
 // Supported Encoding Formats:
-//   - JSON: This package currently supports encoding and decoding data in JSON format.
+//   - JSON: GetInstance encodes and decodes data in JSON format.
+//   - BSON: GetBSONInstance encodes and decodes structs and maps in BSON format, keeping the types of the values
+//     held by interface{} fields such as time.Time and primitive.ObjectID.
 //
 // Usage:
 // To use this package, import it into your Go code and utilize the provided functions for encoding
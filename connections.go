@@ -91,6 +91,16 @@ func (c *connectionsFactory) getConnection(option Options, dbType ftypes.DBTypes
 	// Set the default page size for pagination on the connection.
 	connection.SetPageSize(DEFAULT_PAGE_SIZE)
 
+	// If an audit entity is provided in the options, write the audit trail to it.
+	if option.AuditEntity != nil {
+		connection.SetAuditEntity(*option.AuditEntity)
+	}
+
+	// If a key provider is provided in the options, encrypt the fields with the encrypt option with its keys.
+	if option.KeyProvider != nil {
+		connection.SetKeyProvider(option.KeyProvider)
+	}
+
 	// If the 'connection' variable is still nil, it means the specified DB type is not supported.
 	if connection == nil {
 		err = dbfusionErrors.ErrDBTypeNotSupported
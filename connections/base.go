@@ -2,6 +2,7 @@ package connections
 
 import (
 	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/encryption"
 )
 
 // base is the foundational interface in the connections package, providing essential methods
@@ -18,4 +19,16 @@ type base interface {
 	// SetPageSize sets the page size to be used by pagination queries.
 	// It takes a int as a parameter and associates it with the pagination results.
 	SetPageSize(int)
+
+	// SetAuditEntity sets the table or collection the audit entries of auditable entities are written to.
+	// It takes the name of the entity, audit.DefaultEntity is used when it is empty.
+	SetAuditEntity(string)
+
+	// SetKeyProvider sets the provider of the keys encrypting the fields with the encrypt option.
+	// It takes the key provider, fields with the encrypt option can't be written or read without one.
+	SetKeyProvider(encryption.KeyProvider)
+
+	// EncryptLookup encrypts a value of a deterministic field, so that it can be looked up with Where.
+	// It takes the plaintext value and returns its ciphertext, or an error if it can't be encrypted.
+	EncryptLookup(string) (string, error)
 }
@@ -1,6 +1,10 @@
 package connections
 
-import "github.com/glodb/dbfusion/ftypes"
+import (
+	"time"
+
+	"github.com/glodb/dbfusion/ftypes"
+)
 
 //Supported Dbtypes
 const (
@@ -17,6 +21,65 @@ type PaginationResults struct {
 	Limit          int64 // The limit of documents displayed per page.
 }
 
+// WarmCacheResults represents the outcome of warming the cache of an entity.
+type WarmCacheResults struct {
+	Processed int64         // Number of records read from the database.
+	Cached    int64         // Number of records written to the cache.
+	Skipped   int64         // Number of records without any indexed value, which can't be cached.
+	Duration  time.Duration // Time taken to warm the cache.
+}
+
+// CacheVerificationReport represents the outcome of comparing the cache of an entity with the database.
+type CacheVerificationReport struct {
+	Checked          int64         // Number of records compared with the cache.
+	Inconsistent     int64         // Number of records with at least one missing or stale index key.
+	MissingKeys      []string      // Index keys expected for a record but absent from the cache.
+	StaleKeys        []string      // Index keys pointing to a payload that differs from the database record.
+	OrphanedPayloads []string      // Payload keys that no index key points to.
+	Repaired         int64         // Number of records cached again and orphaned payloads removed by the repair.
+	Duration         time.Duration // Time taken to verify the cache.
+}
+
+// IndexSyncReport represents the outcome of comparing the indexes of an entity with the index hooks of its model.
+type IndexSyncReport struct {
+	Missing    []string // Names of the declared indexes which didn't exist.
+	Changed    []string // Names of the existing indexes whose TTL expiry or hidden flag differ from the model.
+	Undeclared []string // Names of the existing indexes which the model doesn't declare.
+	Created    []string // Names of the missing indexes created, empty on a dry run.
+	Dropped    []string // Names of the undeclared indexes dropped, empty on a dry run or without DropUndeclared.
+}
+
+// InSync reports whether the indexes matched the model, without missing, changed or undeclared indexes.
+func (r IndexSyncReport) InSync() bool {
+	return len(r.Missing) == 0 && len(r.Changed) == 0 && len(r.Undeclared) == 0
+}
+
+// WriteBehindError describes a failure of the worker writing the records queued by write-behind entities.
+// It is passed to the handler set with SetWriteBehindErrorHandler.
+type WriteBehindError struct {
+	DBName     string // The database the record was queued for.
+	EntityName string // The entity of the record, empty when the queue itself failed.
+	Attempts   int    // Number of failed attempts of the record.
+	Dead       bool   // Whether the record was given up and moved to the dead letter queue.
+	Err        error  // The error of the database or the queue.
+}
+
+// Error returns the message of the underlying error, prefixed with the entity of the record.
+func (e *WriteBehindError) Error() string {
+	if e.EntityName == "" {
+		return "write behind queue of " + e.DBName + ": " + e.Err.Error()
+	}
+	return "write behind record of " + e.DBName + "." + e.EntityName + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *WriteBehindError) Unwrap() error {
+	return e.Err
+}
+
+// WriteBehindErrorHandler is called by the write-behind worker for every failure, from the goroutine of the worker.
+type WriteBehindErrorHandler func(*WriteBehindError)
+
 // baseConnections is an interface used by various database connection classes to define common methods
 // for managing database connections. It extends the base interface, allowing for changing the active database,
 // setting the cache, connecting to a database, disconnecting, and connecting with certificate-based authentication.
@@ -34,4 +97,8 @@ type baseConnections interface {
 	// DisConnect closes the active connection to the database.
 	// It returns an error if the disconnection process encounters any issues.
 	DisConnect() error
+
+	// Use appends middlewares to the chain wrapping every terminal call of the connection.
+	// The first middleware registered is the outermost one.
+	Use(middlewares ...Middleware)
 }
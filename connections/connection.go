@@ -15,8 +15,9 @@ type Connection interface {
 	// total pages, current page number, and the limit of documents per page.
 	Paginate(interface{}, int) (PaginationResults, error)
 
-	// FindMany retrieves multiple records from the database based on the provided query criteria.
-	// It takes an interface representing the query criteria and optional FindOptions.
+	// FindMany retrieves multiple records from the database based on the query criteria set with Where.
+	// It takes a pointer to a slice the records are decoded into and optional FindOptions.
+	// Soft-deleted records are left out unless WithDeleted or OnlyDeleted is called.
 	// An error is returned if the operation encounters any issues.
 	FindMany(interface{}, ...queryoptions.FindOptions) error
 
@@ -25,14 +26,62 @@ type Connection interface {
 	// An error is returned if the insertion fails.
 	InsertMany(interface{}) error
 
-	// UpdateMany updates multiple records in the database based on the provided query criteria.
-	// It takes two interfaces, one representing the query criteria and the other the update data.
-	// The third boolean parameter specifies whether to return the updated records.
+	// UpdateMany updates every record matching the query criteria set with Where.
+	// It takes two interfaces, one representing the update data and the other the model of the entity.
+	// The third boolean parameter specifies whether to insert the data when no record matches.
 	// An error is returned if the operation encounters any issues.
 	UpdateMany(interface{}, interface{}, bool) error
 
-	// DeleteMany removes multiple records from the database based on the provided query criteria.
-	// It takes one or more interfaces representing the query criteria.
+	// DeleteMany removes every record matching the query criteria set with Where.
+	// It takes the model of the entity as optional first argument, the table set with Table is used without it.
+	// Records of models with a softdelete field are soft deleted unless HardDelete is called.
 	// An error is returned if the deletion operation fails.
 	DeleteMany(...interface{}) error
+
+	// Restore clears the soft delete field of the deleted records matching the query criteria set with Where.
+	// It takes the model of the entity, which must have a field with the softdelete option.
+	// An error is returned if the model isn't soft deleted or the update fails.
+	Restore(interface{}) error
+
+	// FindByID retrieves the record of a primary key, read from the cache when the entity is cached.
+	// It takes the key, a pointer to the struct the record is decoded into and optional FindOptions.
+	// An error is returned if the model has no primary key or the operation encounters any issues.
+	FindByID(interface{}, interface{}, ...queryoptions.FindOptions) error
+
+	// UpdateByID updates the record of a primary key and decodes it into the result.
+	// It takes the key, the update data and a pointer to the struct of the result.
+	// An error is returned if the model has no primary key or the update fails.
+	UpdateByID(interface{}, interface{}, interface{}) error
+
+	// DeleteByID removes the record of a primary key, or soft deletes it, and evicts it from the cache.
+	// It takes the key and the model of the entity.
+	// An error is returned if the model has no primary key or the deletion fails.
+	DeleteByID(interface{}, interface{}) error
+
+	// WarmCache streams every record of an entity from the database and rebuilds its cache indexes and payloads.
+	// It takes the model of the entity, a struct implementing hooks.CacheHook or a map used together with Table
+	// and WarmCacheOptions.Indexes, and optional WarmCacheOptions to control batching, rate limiting and progress.
+	// It returns WarmCacheResults with the counts of processed and cached records.
+	WarmCache(interface{}, ...queryoptions.WarmCacheOptions) (WarmCacheResults, error)
+
+	// VerifyCache compares the cached entries of an entity with its records in the database.
+	// It takes the model of the entity, like WarmCache, and optional VerifyCacheOptions to sample the records,
+	// look for orphaned payloads and repair what is found.
+	// It returns a CacheVerificationReport listing the missing keys, stale keys and orphaned payloads.
+	VerifyCache(interface{}, ...queryoptions.VerifyCacheOptions) (CacheVerificationReport, error)
+
+	// SyncIndexes compares the indexes of an entity with the ones declared by the index hooks of its model.
+	// It takes the model and optional SyncIndexesOptions to only report the differences or to drop the undeclared
+	// indexes, and creates the missing indexes.
+	// It returns an IndexSyncReport listing the missing, undeclared, created and dropped indexes.
+	SyncIndexes(interface{}, ...queryoptions.SyncIndexesOptions) (IndexSyncReport, error)
+
+	// StartWriteBehind starts writing the records queued by write-behind entities to the database.
+	// The worker also starts with the first write-behind insertion and stops on DisConnect.
+	// An error is returned if the cache of the connection doesn't implement caches.Queue.
+	StartWriteBehind() error
+
+	// SetWriteBehindErrorHandler sets the function called when a queued record can't be written or the queue fails.
+	// Failed records are retried, WriteBehindError.Dead reports the ones given up after the last attempt.
+	SetWriteBehindErrorHandler(WriteBehindErrorHandler)
 }
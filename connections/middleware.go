@@ -0,0 +1,65 @@
+package connections
+
+import (
+	"context"
+	"time"
+)
+
+// OperationKind identifies the terminal call of a connection wrapped by the middleware chain.
+type OperationKind string
+
+// Operation kinds passed to the middleware chain.
+const (
+	OpInsertOne         = OperationKind("InsertOne")
+	OpFindOne           = OperationKind("FindOne")
+	OpFindMany          = OperationKind("FindMany")
+	OpUpdateAndFindOne  = OperationKind("UpdateAndFindOne")
+	OpUpdateMany        = OperationKind("UpdateMany")
+	OpDeleteOne         = OperationKind("DeleteOne")
+	OpDeleteMany        = OperationKind("DeleteMany")
+	OpRestore           = OperationKind("Restore")
+	OpFindByID          = OperationKind("FindByID")
+	OpUpdateByID        = OperationKind("UpdateByID")
+	OpDeleteByID        = OperationKind("DeleteByID")
+	OpPaginate          = OperationKind("Paginate")
+	OpAggregate         = OperationKind("Aggregate")
+	OpAggregatePaginate = OperationKind("AggregatePaginate")
+	OpCreateTable       = OperationKind("CreateTable")
+	OpCreateIndexes     = OperationKind("CreateIndexes")
+	OpCreateCollection  = OperationKind("CreateCollection")
+	OpSyncIndexes       = OperationKind("SyncIndexes")
+	OpExecuteSQL        = OperationKind("ExecuteSQL")
+	OpRunCommand        = OperationKind("RunCommand")
+	OpAutoMigrate       = OperationKind("AutoMigrate")
+	OpDescribe          = OperationKind("Describe")
+)
+
+// Operation describes a call going through the middleware chain. The kind, database, entity and context are set
+// before the chain runs. The query, arguments, duration and error are filled in by the connection while the
+// operation runs, so a middleware reads them once next returned.
+type Operation struct {
+	Kind     OperationKind   // The terminal call being run.
+	Database string          // The name of the current database.
+	Entity   string          // The table or collection, known once the operation resolved it from the data.
+	Context  context.Context // The context of the operation, a middleware can replace it before calling next.
+	Query    interface{}     // The compiled query, a SQL statement or a MongoDB filter, document or pipeline.
+	Args     []interface{}   // The arguments bound to a SQL statement, or the update document of a MongoDB update.
+	Duration time.Duration   // Time taken by the operation, excluding the middlewares.
+	Err      error           // The error returned by the operation.
+}
+
+// Handler runs an operation. The last handler of the chain runs the operation on the database.
+type Handler func(op *Operation) error
+
+// Middleware wraps a handler to add behaviour around every operation, such as logging, metrics or tenant checks.
+// A middleware can abort an operation by returning an error without calling next.
+//
+// Example:
+//   con.Use(func(next connections.Handler) connections.Handler {
+//       return func(op *connections.Operation) error {
+//           err := next(op)
+//           log.Println(op.Kind, op.Entity, op.Query, op.Duration, err)
+//           return err
+//       }
+//   })
+type Middleware func(next Handler) Handler
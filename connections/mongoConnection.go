@@ -1,5 +1,13 @@
 package connections
 
+import (
+	"context"
+	"time"
+
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/schema"
+)
+
 // MongoConnection is an interface that extends the base Connection interface and provides
 // methods specific to MongoDB database interactions. It allows building and executing MongoDB
 // aggregation pipelines, specifying query criteria, sorting, limiting, and more.
@@ -157,4 +165,38 @@ type MongoConnection interface {
 	// CreateIndexes creates one or more indexes in the MongoDB collection based on the provided data.
 	// It takes an interface representing index creation data and returns an error if the operation fails.
 	CreateIndexes(data interface{}) error
+
+	// CreateCollection creates the collection of a model with a $jsonSchema validator derived from its fields and
+	// validate tags, or replaces the validator of the collection with collMod when it exists.
+	// It takes the model and optional CreateCollectionOptions setting the validation level and action.
+	CreateCollection(data interface{}, opts ...queryoptions.CreateCollectionOptions) error
+
+	// DescribeCollection samples the documents of a collection and lists its indexes to generate its model.
+	// It takes the name of the collection and the number of documents to sample, 0 to read every document, and
+	// returns an error if the collection holds no document or can't be read.
+	DescribeCollection(collection string, sampleSize int) (schema.Table, error)
+
+	// RunCommand runs a database command on the current database and decodes its reply into result, nil discards it.
+	// It returns an error if the command fails.
+	RunCommand(command interface{}, result interface{}) error
+
+	// WithContext sets the context of the next operation, passed to the MongoDB driver and to the context-aware hooks.
+	// It takes the context and returns the modified MongoConnection.
+	WithContext(ctx context.Context) MongoConnection
+
+	// WithDeleted includes the soft-deleted documents in the results of the next find or aggregation.
+	// It returns the modified MongoConnection.
+	WithDeleted() MongoConnection
+
+	// OnlyDeleted restricts the results of the next find or aggregation to the soft-deleted documents.
+	// It returns the modified MongoConnection.
+	OnlyDeleted() MongoConnection
+
+	// HardDelete removes the documents of the next delete even if their model has a softdelete field.
+	// It returns the modified MongoConnection.
+	HardDelete() MongoConnection
+
+	// AsOf reads the documents of historical entities as they were at a moment on the next find.
+	// It takes the moment and returns the modified MongoConnection.
+	AsOf(t time.Time) MongoConnection
 }
@@ -1,7 +1,12 @@
 package connections
 
 import (
+	"context"
+	"time"
+
 	"github.com/glodb/dbfusion/joins"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/schema"
 )
 
 // SQLConnection is an interface that extends the base Connection interface and provides
@@ -18,6 +23,28 @@ type SQLConnection interface {
 	// It returns an error if the table creation process encounters any issues.
 	CreateTable(tableType interface{}, ifNotExist bool) error
 
+	// CreateAuditTable creates the table the audit entries of auditable entities are written to.
+	// It takes a boolean indicating whether to create the table if it doesn't exist.
+	// It returns an error if the table creation process encounters any issues.
+	CreateAuditTable(ifNotExist bool) error
+
+	// CreateHistoryTable creates the table the previous versions of a historical entity are copied to.
+	// It takes the model of the entity and a boolean indicating whether to create the table if it doesn't exist.
+	// It returns an error if the table creation process encounters any issues.
+	CreateHistoryTable(tableType interface{}, ifNotExist bool) error
+
+	// AutoMigrate creates the missing tables of the models and alters the existing ones to match their dbfusion tags.
+	// It takes the migration options and the models, and returns the statements run, or planned on a dry run.
+	AutoMigrate(options queryoptions.AutoMigrateOptions, models ...interface{}) ([]string, error)
+
+	// CreateIndexes creates the indexes declared by the index hooks of a model which don't exist on its table.
+	// It takes the model and returns an error if an index can't be created.
+	CreateIndexes(data interface{}) error
+
+	// DescribeTable reads the columns, foreign keys and indexes of an existing table to generate its model.
+	// It takes the name of the table and returns an error if the table doesn't exist or its schema can't be read.
+	DescribeTable(table string) (schema.Table, error)
+
 	// Where specifies the criteria for filtering records in the SQL database.
 	// It takes an interface representing the filter criteria and returns the modified SQLConnection.
 	Where(interface{}) SQLConnection
@@ -55,4 +82,24 @@ type SQLConnection interface {
 	// Join specifies a join operation to combine records from multiple tables in the SQL database.
 	// It takes a joins.Join object representing the join operation and returns the modified SQLConnection.
 	Join(join joins.Join) SQLConnection
+
+	// WithContext sets the context of the next operation, passed to the database driver and to the context-aware hooks.
+	// It takes the context and returns the modified SQLConnection.
+	WithContext(ctx context.Context) SQLConnection
+
+	// WithDeleted includes the soft-deleted records in the results of the next find.
+	// It returns the modified SQLConnection.
+	WithDeleted() SQLConnection
+
+	// OnlyDeleted restricts the results of the next find to the soft-deleted records.
+	// It returns the modified SQLConnection.
+	OnlyDeleted() SQLConnection
+
+	// HardDelete removes the records of the next delete even if their model has a softdelete field.
+	// It returns the modified SQLConnection.
+	HardDelete() SQLConnection
+
+	// AsOf reads the records of historical entities as they were at a moment on the next find.
+	// It takes the moment and returns the modified SQLConnection.
+	AsOf(t time.Time) SQLConnection
 }
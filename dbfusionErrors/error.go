@@ -45,3 +45,61 @@ var ErrInvalidType = errors.New("This type is not supported by the library yet")
 
 // ErrNoRecordFound is returned when no records are found for a query.
 var ErrNoRecordFound = errors.New("No record found for the query")
+
+// ErrCacheIndexesRequired is returned when the cache of an entity is warmed without any cache indexes.
+var ErrCacheIndexesRequired = errors.New("Cache indexes are required to warm the cache, implement CacheHook or pass the indexes")
+
+// ErrCacheQueueNotSupported is returned when a write-behind entity is used with a cache that doesn't implement caches.Queue.
+var ErrCacheQueueNotSupported = errors.New("Write behind requires a cache implementing the Queue interface")
+
+// ErrWriteBehindNotSupported is returned when a write-behind entity is updated or deleted, which could overtake its
+// queued insertions.
+var ErrWriteBehindNotSupported = errors.New("Write behind entities only support insertions")
+
+// ErrValidationFailed is wrapped by the validation errors of structs failing the rules of their validate tags.
+var ErrValidationFailed = errors.New("Validation failed")
+
+// ErrValidationRuleNotSupported is returned when a validate tag uses an unknown rule or an invalid parameter.
+var ErrValidationRuleNotSupported = errors.New("Validation rule is not supported")
+
+// ErrSoftDeleteNotSupported is returned when soft-deleted records are restored for a model without a softdelete field.
+var ErrSoftDeleteNotSupported = errors.New("The model has no field with the softdelete option")
+
+// ErrStaleVersion is returned when a versioned record was changed by another update since its version was read.
+var ErrStaleVersion = errors.New("The record was updated since its version was read")
+
+// ErrPrimaryKeyNotFound is returned when the ByID methods are called with a model that has no primary key.
+var ErrPrimaryKeyNotFound = errors.New("The model has no field with the pk option, PRIMARY KEY, _id or id")
+
+// ErrKeyProviderRequired is returned when fields with the encrypt option are written or read without a key provider.
+var ErrKeyProviderRequired = errors.New("Encrypted fields require a key provider, set one with SetKeyProvider")
+
+// ErrEncryptionKeyNotFound is returned when the key provider doesn't hold the key of an ID.
+var ErrEncryptionKeyNotFound = errors.New("The key provider has no key with this ID")
+
+// ErrInvalidCiphertext is returned when an encrypted value is malformed or fails authentication.
+var ErrInvalidCiphertext = errors.New("The encrypted value can't be decrypted")
+
+// ErrMigrationLocked is returned when migrations are run while another instance holds the migration lock.
+var ErrMigrationLocked = errors.New("Migrations are locked by another instance")
+
+// ErrMigrationVersionConflict is returned when two different migrations are added with the same version.
+var ErrMigrationVersionConflict = errors.New("Another migration has the same version")
+
+// ErrMigrationNotFound is returned when an applied migration is rolled back without being known to the migrator.
+var ErrMigrationNotFound = errors.New("The applied migration is not known to the migrator")
+
+// ErrMigrationIrreversible is returned when a migration without a down step is rolled back.
+var ErrMigrationIrreversible = errors.New("The migration has no down step")
+
+// ErrMigrationFileNotSupported is returned when a migration file has a name or an extension the connection can't run.
+var ErrMigrationFileNotSupported = errors.New("The migration file is not supported by the connection")
+
+// ErrMigrationShellRequired is returned when JavaScript migrations are loaded without the URI of the mongo shell.
+var ErrMigrationShellRequired = errors.New("JavaScript migrations require the mongo shell URI")
+
+// ErrMigrationInvalid is returned when a migration is added without a positive version or an up step.
+var ErrMigrationInvalid = errors.New("A migration requires a positive version and an up step")
+
+// ErrEntityNotFound is returned when a table or a collection is described without existing or holding documents.
+var ErrEntityNotFound = errors.New("The table or collection doesn't exist or is empty")
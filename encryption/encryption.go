@@ -0,0 +1,201 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"sync"
+
+	"github.com/glodb/dbfusion/dbfusionErrors"
+)
+
+// Prefix starts every ciphertext, values without it are read as they are so that existing plaintexts stay readable
+// until they are written again.
+const Prefix = "dbfenc:"
+
+// nonceKeyLabel is the label deriving the key of the deterministic nonces from a key, so that the nonces are never
+// computed with the key AES-GCM encrypts with.
+const nonceKeyLabel = "dbfusion deterministic nonce"
+
+// KeyProvider supplies the keys encrypting the fields. The keys are 16, 24 or 32 bytes long, selecting AES-128,
+// AES-192 or AES-256, and their IDs can't contain colons.
+type KeyProvider interface {
+	// CurrentKeyID returns the ID of the key encrypting new values.
+	CurrentKeyID() string
+
+	// Key returns the key of an ID, the current one or one of the previous keys still decrypting stored values.
+	Key(keyID string) ([]byte, error)
+}
+
+// StaticKeyProvider is a KeyProvider holding its keys in memory.
+type StaticKeyProvider struct {
+	currentKeyID string            // The ID of the key encrypting new values.
+	keys         map[string][]byte // The keys by ID.
+}
+
+// NewStaticKeyProvider creates a KeyProvider holding its keys in memory.
+//
+// Parameters:
+//   - currentKeyID: The ID of the key encrypting new values.
+//   - keys: The keys by ID, including the previous keys still decrypting stored values.
+//
+// Returns:
+//   - *StaticKeyProvider: The key provider.
+//
+// Example:
+//   provider := encryption.NewStaticKeyProvider("v2", map[string][]byte{"v1": oldKey, "v2": newKey})
+func NewStaticKeyProvider(currentKeyID string, keys map[string][]byte) *StaticKeyProvider {
+	return &StaticKeyProvider{currentKeyID: currentKeyID, keys: keys}
+}
+
+// CurrentKeyID returns the ID of the key encrypting new values.
+func (p *StaticKeyProvider) CurrentKeyID() string {
+	return p.currentKeyID
+}
+
+// Key returns the key of an ID, or ErrEncryptionKeyNotFound if the provider doesn't hold it.
+func (p *StaticKeyProvider) Key(keyID string) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, dbfusionErrors.ErrEncryptionKeyNotFound
+	}
+	return key, nil
+}
+
+// encryptor is a singleton encrypting and decrypting the values of the fields.
+type encryptor struct{}
+
+var (
+	instance *encryptor // Singleton instance of the encryptor.
+	once     sync.Once  // Once ensures the singleton instance is created only once.
+)
+
+// GetInstance returns the singleton instance of the encryptor.
+//
+// Returns:
+//   - *encryptor: A pointer to the singleton instance of the encryptor.
+func GetInstance() *encryptor {
+	// Use sync.Once to ensure that the instance is created only once.
+	once.Do(func() {
+		instance = &encryptor{}
+	})
+
+	// Return the singleton instance.
+	return instance
+}
+
+// Encrypt encrypts a value with the current key of the provider. Deterministic encryption derives the nonce from the
+// value, so equal values get equal ciphertexts.
+//
+// Parameters:
+//   - provider: The provider of the keys.
+//   - plaintext: The value to encrypt.
+//   - deterministic: true to get the same ciphertext for the same value and key.
+//
+// Returns:
+//   - string: The ciphertext, the prefix followed by the ID of the key and the base64 encoded nonce and sealed value.
+//   - error: ErrKeyProviderRequired without a provider, or an error if the key can't be read or used.
+//
+// Example:
+//   ciphertext, err := encryption.GetInstance().Encrypt(provider, "+1 555 0100", false)
+func (e *encryptor) Encrypt(provider KeyProvider, plaintext string, deterministic bool) (string, error) {
+	if provider == nil {
+		return "", dbfusionErrors.ErrKeyProviderRequired
+	}
+	keyID := provider.CurrentKeyID()
+	key, err := provider.Key(keyID)
+	if err != nil {
+		return "", err
+	}
+	aead, err := e.newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	// Derive the nonce of deterministic encryption from the value with a key of its own, or else draw it at random.
+	nonce := make([]byte, aead.NonceSize())
+	if deterministic {
+		mac := hmac.New(sha256.New, e.nonceKey(key))
+		mac.Write([]byte(plaintext))
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	// The ID of the key is authenticated with the value.
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(keyID))
+	return Prefix + keyID + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value with the key named by its ciphertext. Values without the prefix are returned as they are.
+//
+// Parameters:
+//   - provider: The provider of the keys.
+//   - value: The ciphertext.
+//
+// Returns:
+//   - string: The plaintext.
+//   - error: ErrKeyProviderRequired without a provider, ErrInvalidCiphertext if the value can't be decrypted, or an
+//     error if the key can't be read.
+func (e *encryptor) Decrypt(provider KeyProvider, value string) (string, error) {
+	if !e.IsEncrypted(value) {
+		return value, nil
+	}
+	if provider == nil {
+		return "", dbfusionErrors.ErrKeyProviderRequired
+	}
+
+	// Split the ID of the key from the sealed value.
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(value, Prefix), ":")
+	if !ok {
+		return "", dbfusionErrors.ErrInvalidCiphertext
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", dbfusionErrors.ErrInvalidCiphertext
+	}
+	key, err := provider.Key(keyID)
+	if err != nil {
+		return "", err
+	}
+	aead, err := e.newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", dbfusionErrors.ErrInvalidCiphertext
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(keyID))
+	if err != nil {
+		return "", dbfusionErrors.ErrInvalidCiphertext
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether a value is a ciphertext.
+func (e *encryptor) IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// nonceKey derives the key of the deterministic nonces from a key with HKDF-Expand (RFC 5869) under nonceKeyLabel.
+// The keys of the provider are uniformly random, so they are used as the pseudorandom key without the extract step.
+func (e *encryptor) nonceKey(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(nonceKeyLabel))
+	mac.Write([]byte{1})
+	return mac.Sum(nil)
+}
+
+// newAEAD creates the AES-GCM cipher of a key.
+func (e *encryptor) newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,17 @@
+// Package encryption encrypts the fields of the models carrying the encrypt option in their dbfusion tag. The values
+// are encrypted with AES-GCM before they are written and decrypted once they are read, so the database only holds
+// ciphertexts. The keys are read from the KeyProvider of the connection, each ciphertext naming the key it was
+// encrypted with so that the keys can be rotated.
+//
+// Example:
+//   type Customer struct {
+//       NationalID string `dbfusion:"nationalId,encrypt:deterministic"`
+//       Phone      string `dbfusion:"phone,encrypt"`
+//   }
+//
+//   provider := encryption.NewStaticKeyProvider("2024-01", map[string][]byte{"2024-01": key})
+//   con.SetKeyProvider(provider)
+//
+// Fields encrypted randomly get a new ciphertext on every write. Deterministic fields get the same ciphertext for
+// the same value and key, which allows equality lookups and cache indexes at the cost of revealing equal values.
+package encryption
@@ -0,0 +1,15 @@
+package hooks
+
+// Auditable is an interface that user-defined models can implement to record their insertions, updates and deletions
+// in the audit trail of the connection. Each write adds an audit.Entry holding the primary key of the record, the
+// changed fields, the actor of the context and the time of the write. The entry is written in the transaction of the
+// write when the model also implements Transactional.
+//
+// Example Usage:
+//   func (model MyModel) UseAudit() bool {
+//       return true
+//   }
+type Auditable interface {
+	// UseAudit reports whether the writes of the model are recorded in the audit trail.
+	UseAudit() bool
+}
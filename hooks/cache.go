@@ -20,3 +20,40 @@ type CacheHook interface {
 	// store and retrieve data in the cache, allowing for efficient caching of associated data.
 	GetCacheIndexes() []string
 }
+
+// CachePolicy selects how the cache of an entity is kept in sync with the database. Policies are flags and can be
+// combined, e.g. ReadThrough | WriteBehind.
+type CachePolicy uint8
+
+const (
+	// WriteThrough writes records to the database first and caches them once the write succeeded.
+	// This is the policy of entities that don't implement CachePolicyHook.
+	WriteThrough CachePolicy = 1 << iota
+
+	// ReadThrough populates the cache indexes of a record whenever FindOne has to read it from the database,
+	// so that subsequent lookups by any of its indexes are served from the cache.
+	ReadThrough
+
+	// WriteBehind caches inserted records and acknowledges the insertion right away. The records are queued in
+	// the cache and written to the database asynchronously, with retries. It takes precedence over WriteThrough
+	// and requires a cache implementing caches.Queue. Updates and deletions of write-behind entities are rejected
+	// with ErrWriteBehindNotSupported, as they could overtake the queued insertions.
+	WriteBehind
+)
+
+// Has reports whether all the flags of policy are set.
+func (cp CachePolicy) Has(policy CachePolicy) bool {
+	return cp&policy == policy
+}
+
+// CachePolicyHook is an interface that user-defined models can implement together with CacheHook to select the
+// cache policy of the entity.
+//
+// Example Usage:
+//   func (u User) GetCachePolicy() hooks.CachePolicy {
+//       return hooks.ReadThrough | hooks.WriteBehind
+//   }
+type CachePolicyHook interface {
+	// GetCachePolicy returns the cache policy of the entity.
+	GetCachePolicy() CachePolicy
+}
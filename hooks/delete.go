@@ -1,5 +1,7 @@
 package hooks
 
+import "context"
+
 // PreDelete is an interface that user-defined models can implement to define pre-delete hooks.
 // Pre-delete hooks are executed before a data deletion operation, allowing developers to perform
 // custom actions or validations before the deletion occurs.
@@ -37,3 +39,27 @@ type PostDelete interface {
 	//   // will be invoked to execute the defined post-delete logic.
 	PostDelete() PostDelete
 }
+
+// BeforeDelete is an interface that user-defined models can implement to run checks before deleting data from the database.
+// Unlike PreDelete it receives the context of the operation and can abort the operation by returning an error,
+// which is returned unchanged by the operation.
+type BeforeDelete interface {
+	// BeforeDelete is called before the deletion with the context set by WithContext.
+	//
+	// Example Usage:
+	//   func (model MyModel) BeforeDelete(ctx context.Context) error {
+	//       if model.Email == "" {
+	//           return errors.New("email is required")
+	//       }
+	//       return nil
+	//   }
+	BeforeDelete(ctx context.Context) error
+}
+
+// AfterDelete is an interface that user-defined models can implement to run actions after the data has been deleted from the database.
+// Unlike PostDelete its error is returned by the operation. When the model implements Transactional, the deletion
+// runs in a transaction that is rolled back if AfterDelete returns an error.
+type AfterDelete interface {
+	// AfterDelete is called after the deletion with the context set by WithContext.
+	AfterDelete(ctx context.Context) error
+}
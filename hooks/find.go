@@ -1,5 +1,7 @@
 package hooks
 
+import "context"
+
 // PreFind is an interface that user-defined models can implement to define pre-find hooks.
 // Pre-find hooks are executed before a database query is performed to customize or modify
 // the query parameters. This can be useful for applying filters or additional conditions
@@ -42,3 +44,26 @@ type PostFind interface {
 	//   // Any processing done within PostFind will affect the retrieved data.
 	PostFind() PostFind
 }
+
+// BeforeFind is an interface that user-defined models can implement to run checks before querying the database.
+// Unlike PreFind it receives the context of the operation and can abort the operation by returning an error,
+// which is returned unchanged by the operation.
+type BeforeFind interface {
+	// BeforeFind is called before the query with the context set by WithContext.
+	//
+	// Example Usage:
+	//   func (model MyModel) BeforeFind(ctx context.Context) error {
+	//       if model.Email == "" {
+	//           return errors.New("email is required")
+	//       }
+	//       return nil
+	//   }
+	BeforeFind(ctx context.Context) error
+}
+
+// AfterFind is an interface that user-defined models can implement to run actions after the data has been read from the database or the cache.
+// Unlike PostFind its error is returned by the operation.
+type AfterFind interface {
+	// AfterFind is called after the query with the context set by WithContext.
+	AfterFind(ctx context.Context) error
+}
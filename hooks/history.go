@@ -0,0 +1,16 @@
+package hooks
+
+// Historical is an interface that user-defined models can implement to keep the previous versions of their records.
+// Each UpdateAndFindOne and DeleteOne copies the record as it was before the write to the history table or collection
+// of the entity, named after it with the "_history" suffix, stamped with the times it was valid from and to. The
+// versions are read back with the AsOf modifier of the connections. The copy is written in the transaction of the
+// write when the model also implements Transactional.
+//
+// Example Usage:
+//   func (model MyModel) UseHistory() bool {
+//       return true
+//   }
+type Historical interface {
+	// UseHistory reports whether the previous versions of the records of the model are kept.
+	UseHistory() bool
+}
@@ -106,3 +106,66 @@ type SparseIndexes interface {
 	// In this example, the model specifies that sparse indexes should be created on "sparseField1" and "sparseField2".
 	GetSparseIndexes() []string
 }
+
+// Collation sets the language rules used to compare strings in an index, e.g. to ignore their case.
+type Collation struct {
+	Locale          string // The ICU locale, e.g. "en" or "simple".
+	CaseLevel       bool   // Compares the case at strengths 1 and 2.
+	CaseFirst       string // Sorts "upper" or "lower" case first, "off" by default.
+	Strength        int    // The level of comparison, from 1 to 5, 2 ignoring the case.
+	NumericOrdering bool   // Compares numeric strings as numbers.
+	Alternate       string // Considers the whitespaces and punctuations as base characters with "non-ignorable".
+	MaxVariable     string // The characters ignored with the "shifted" alternate, "punct" or "space".
+	Normalization   bool   // Checks whether the text requires normalization.
+	Backwards       bool   // Sorts the strings with diacritics from the back of the string.
+}
+
+// IndexSpec describes an index with the options the other index hooks can't express.
+type IndexSpec struct {
+	// Name is the name of the index, MongoDB names it after its keys when it is empty.
+	Name string
+
+	// Keys are the keys of the index written like the other hooks, e.g. "userId:1,createdAt:-1". A key may be
+	// typed instead of sorted, e.g. "bio:text" or "location:2dsphere", and "$**" or "attributes.$**" declare a
+	// wildcard index.
+	Keys string
+
+	// Unique and Sparse create a unique or a sparse index.
+	Unique bool
+	Sparse bool
+
+	// Hidden hides the index from the query planner, e.g. to evaluate the impact of dropping it.
+	Hidden bool
+
+	// ExpireAfterSeconds makes a TTL index removing the documents the given number of seconds after the date held
+	// by the key. Nil for an index without expiry.
+	ExpireAfterSeconds *int32
+
+	// PartialFilterExpression only indexes the documents matching the filter, e.g. bson.M{"active": true}.
+	PartialFilterExpression interface{}
+
+	// WildcardProjection includes or excludes fields from a wildcard index on "$**".
+	WildcardProjection interface{}
+
+	// Collation sets the language rules of the index, nil for the collation of the collection.
+	Collation *Collation
+}
+
+// IndexSpecs is an interface that user-defined models can implement to specify indexes with options such as TTL
+// expiry, partial filters, wildcard projections, collations or hidden indexes for a MongoDB collection.
+type IndexSpecs interface {
+	// GetIndexSpecs should be implemented to return the specifications of the indexes to create.
+	//
+	// Example Usage:
+	//   func (model *Session) GetIndexSpecs() []hooks.IndexSpec {
+	//       expiry := int32(3600)
+	//       return []hooks.IndexSpec{
+	//           {Keys: "createdAt:1", ExpireAfterSeconds: &expiry},
+	//           {Keys: "email:1", Unique: true, PartialFilterExpression: bson.M{"active": true}},
+	//       }
+	//   }
+	//
+	// In this example, the sessions expire an hour after their creation and the emails of the active sessions are
+	// unique.
+	GetIndexSpecs() []IndexSpec
+}
@@ -1,5 +1,7 @@
 package hooks
 
+import "context"
+
 // PreInsert is an interface that user-defined models can implement to define pre-insert hooks.
 // Pre-insert hooks are executed before inserting data into the database, allowing custom
 // actions or modifications to be applied to the data being inserted.
@@ -39,3 +41,27 @@ type PostInsert interface {
 	//   // Any processing done within PostInsert will affect the inserted data.
 	PostInsert() PostInsert
 }
+
+// BeforeInsert is an interface that user-defined models can implement to run checks before inserting data into the database.
+// Unlike PreInsert it receives the context of the operation and can abort the operation by returning an error,
+// which is returned unchanged by the operation.
+type BeforeInsert interface {
+	// BeforeInsert is called before the insertion with the context set by WithContext.
+	//
+	// Example Usage:
+	//   func (model MyModel) BeforeInsert(ctx context.Context) error {
+	//       if model.Email == "" {
+	//           return errors.New("email is required")
+	//       }
+	//       return nil
+	//   }
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInsert is an interface that user-defined models can implement to run actions after the data has been inserted into the database.
+// Unlike PostInsert its error is returned by the operation. When the model implements Transactional, the insertion
+// runs in a transaction that is rolled back if AfterInsert returns an error.
+type AfterInsert interface {
+	// AfterInsert is called after the insertion with the context set by WithContext.
+	AfterInsert(ctx context.Context) error
+}
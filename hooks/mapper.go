@@ -0,0 +1,36 @@
+package hooks
+
+// FieldMapper is an interface implemented by the mappers the `dbfusion mapper` command generates for the models,
+// to read the tagged fields of a model without reflection. The fields are the ones whose dbfusion tag has a name,
+// in the order they are declared, and the three methods return one entry per field in that order.
+//
+// dbFusion uses the mapper to build the column lists, the values written by the insertions, the BSON documents of
+// the filters and the values the cache keys are computed from. Models without a mapper are read with reflection,
+// so a mapper only changes how fast the fields are read, never what is read.
+//
+// Example Usage:
+//   //go:generate go run github.com/glodb/dbfusion/cmd/dbfusion mapper -type User
+//   type User struct {
+//       ID    int64  `dbfusion:"id,pk:autoincr"`
+//       Email string `dbfusion:"email,size:255,unique"`
+//   }
+//
+// The generated methods must be regenerated whenever the tagged fields of the model change.
+type FieldMapper interface {
+	// DBFusionColumns returns the names of the tagged fields, the first part of their dbfusion tag. The slice is
+	// shared by every call and must not be modified.
+	DBFusionColumns() []string
+
+	// DBFusionValues returns the values of the tagged fields.
+	DBFusionValues() []interface{}
+
+	// DBFusionSet reports for each tagged field whether it holds a value other than the zero value of its type.
+	DBFusionSet() []bool
+}
+
+// FieldScanner is an interface implemented with a pointer receiver by the generated mappers, to write the values
+// read from the rows of a SQL result into the tagged fields of a model without reflection.
+type FieldScanner interface {
+	// DBFusionPointers returns pointers to the tagged fields, in the order of DBFusionColumns.
+	DBFusionPointers() []interface{}
+}
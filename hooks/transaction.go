@@ -0,0 +1,15 @@
+package hooks
+
+// Transactional is an interface that user-defined models can implement to run their insertions, updates and deletions
+// in a database transaction together with their After hooks. An error returned by AfterInsert, AfterUpdate or
+// AfterDelete then rolls the write back and the cache is left untouched. MongoDB transactions require a replica set
+// or a sharded cluster.
+//
+// Example Usage:
+//   func (model MyModel) UseTransaction() bool {
+//       return true
+//   }
+type Transactional interface {
+	// UseTransaction reports whether the writes of the model have to run in a transaction.
+	UseTransaction() bool
+}
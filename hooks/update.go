@@ -1,5 +1,7 @@
 package hooks
 
+import "context"
+
 // PreUpdate is an interface that user-defined models can implement to define pre-update hooks.
 // Pre-update hooks are executed before updating data in the database, allowing custom
 // actions or modifications to be applied to the data before the update operation.
@@ -39,3 +41,27 @@ type PostUpdate interface {
 	//   // Any processing done within PostUpdate will affect the updated data.
 	PostUpdate() PostUpdate
 }
+
+// BeforeUpdate is an interface that user-defined models can implement to run checks before updating data in the database.
+// Unlike PreUpdate it receives the context of the operation and can abort the operation by returning an error,
+// which is returned unchanged by the operation.
+type BeforeUpdate interface {
+	// BeforeUpdate is called before the update with the context set by WithContext.
+	//
+	// Example Usage:
+	//   func (model MyModel) BeforeUpdate(ctx context.Context) error {
+	//       if model.Email == "" {
+	//           return errors.New("email is required")
+	//       }
+	//       return nil
+	//   }
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdate is an interface that user-defined models can implement to run actions after the data has been updated in the database.
+// Unlike PostUpdate its error is returned by the operation. When the model implements Transactional, the update
+// runs in a transaction that is rolled back if AfterUpdate returns an error.
+type AfterUpdate interface {
+	// AfterUpdate is called after the update with the context set by WithContext.
+	AfterUpdate(ctx context.Context) error
+}
@@ -0,0 +1,348 @@
+package implementations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/glodb/dbfusion/audit"
+	"github.com/glodb/dbfusion/conditions"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/hooks"
+	"github.com/glodb/dbfusion/metadata"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SetAuditEntity sets the table or collection the audit entries of the entities implementing hooks.Auditable are
+// written to, audit.DefaultEntity when it is empty.
+//
+// Parameters:
+// - entityName: The name of the audit table or collection.
+//
+// Example:
+//   con.SetAuditEntity("auditTrail")
+func (dbc *DBCommon) SetAuditEntity(entityName string) {
+	dbc.auditEntity = entityName
+}
+
+// auditEntityName returns the table or collection the audit entries are written to.
+func (dbc *DBCommon) auditEntityName() string {
+	if dbc.auditEntity == "" {
+		return audit.DefaultEntity
+	}
+	return dbc.auditEntity
+}
+
+// useAudit reports whether the writes of the data are recorded in the audit trail.
+//
+// Parameters:
+// - data: The data being written, or the model of a bulk write.
+//
+// Returns:
+// - bool: true if the data implements hooks.Auditable and asks for an audit trail.
+func (dbc *DBCommon) useAudit(data interface{}) bool {
+	value, ok := interface{}(data).(hooks.Auditable)
+	return ok && value.UseAudit()
+}
+
+// auditEntry builds the audit entry of a write to a record. The entry is identified by the primary key of the model,
+// or by the _id of MongoDB documents when the model has none.
+//
+// Parameters:
+// - ctx: The context of the operation, carrying the actor.
+// - entityName: The name of the entity written.
+// - operation: The kind of write.
+// - model: The model of the entity.
+// - before: The values of the record before the write, nil for insertions.
+// - after: The values of the record after the write, nil for deletions.
+//
+// Returns:
+// - audit.Entry: The entry of the write.
+func (dbc *DBCommon) auditEntry(ctx context.Context, entityName string, operation audit.Operation, model interface{}, before map[string]interface{}, after map[string]interface{}) audit.Entry {
+	keyName := "_id"
+	if key, ok := dbc.primaryKey(reflect.TypeOf(model)); ok {
+		keyName = key.name
+	}
+
+	// The key is read after the write for insertions, which may generate it.
+	entityID, ok := after[keyName]
+	if !ok {
+		entityID = before[keyName]
+	}
+
+	// Compare the plaintexts of the encrypted fields and keep their ciphertexts in the entry.
+	entry := audit.GetInstance().NewEntry(ctx, entityName, entityID, operation, dbc.decryptValues(model, before), dbc.decryptValues(model, after))
+	dbc.encryptChanges(model, entry.Changes)
+	return entry
+}
+
+// auditEntries builds the audit entries of a bulk write, one for each record it matched.
+//
+// Parameters:
+// - ctx: The context of the operation, carrying the actor.
+// - entityName: The name of the entity written.
+// - operation: The kind of write, audit.Update or audit.Delete.
+// - model: The model of the entity.
+// - records: The values of the matched records before the write.
+// - updates: The values set by an update, ignored for deletions.
+//
+// Returns:
+// - []audit.Entry: The entries of the write.
+func (dbc *DBCommon) auditEntries(ctx context.Context, entityName string, operation audit.Operation, model interface{}, records []map[string]interface{}, updates map[string]interface{}) []audit.Entry {
+	entries := make([]audit.Entry, len(records))
+	for i, record := range records {
+		var after map[string]interface{}
+		if operation != audit.Delete {
+			after = dbc.overlay(record, updates)
+		}
+		entries[i] = dbc.auditEntry(ctx, entityName, operation, model, record, after)
+	}
+	return entries
+}
+
+// updateValues returns the values set by update data, keyed by field name. Struct fields are only set when they
+// don't hold their zero value and MongoDB operators are left out.
+//
+// Parameters:
+// - data: The update data, a struct, a pointer to a struct or a map.
+//
+// Returns:
+// - map[string]interface{}: The values set by the update.
+func (dbc *DBCommon) updateValues(data interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	switch value := data.(type) {
+	case ftypes.QMap:
+		for key, element := range value {
+			values[key] = element
+		}
+	case map[string]interface{}:
+		for key, element := range value {
+			values[key] = element
+		}
+	case ftypes.DMap:
+		for _, element := range value {
+			values[element.Key] = element.Value
+		}
+	default:
+		// Read the fields of structs that are set.
+		dataValue, dataType := dbc.checkPtr(data)
+		if dataType.Kind() != reflect.Struct {
+			return values
+		}
+		model, _ := metadata.GetInstance().Model(dataType)
+		for _, field := range model.Fields {
+			if dbc.isFieldSet(dataValue.Field(field.Index)) {
+				values[field.Name] = dataValue.Field(field.Index).Interface()
+			}
+		}
+	}
+
+	for key := range values {
+		if strings.HasPrefix(key, "$") {
+			delete(values, key)
+		}
+	}
+	return values
+}
+
+// overlay returns the values of a record after an update, the values set by the update replacing the ones before.
+//
+// Parameters:
+// - before: The values of the record before the update.
+// - updates: The values set by the update.
+//
+// Returns:
+// - map[string]interface{}: The values of the record after the update.
+func (dbc *DBCommon) overlay(before map[string]interface{}, updates map[string]interface{}) map[string]interface{} {
+	after := make(map[string]interface{}, len(before)+len(updates))
+	for key, value := range before {
+		after[key] = value
+	}
+	for key, value := range updates {
+		after[key] = value
+	}
+	return after
+}
+
+// createAuditTableQuery generates the CREATE TABLE query of the audit table.
+//
+// Parameters:
+// - entityName: The name of the audit table.
+// - ifNotExist: Adds IF NOT EXISTS to the query.
+//
+// Returns:
+// - string: The query.
+func (sb *SqlBase) createAuditTableQuery(entityName string, ifNotExist bool) string {
+	query := "CREATE TABLE "
+	if ifNotExist {
+		query += "IF NOT EXISTS "
+	}
+	return query + entityName + ` (id BIGINT AUTO_INCREMENT PRIMARY KEY,entity VARCHAR(255) NOT NULL,entityId VARCHAR(255),` +
+		`operation VARCHAR(16) NOT NULL,changes JSON,actor VARCHAR(255),timestamp DATETIME(6) NOT NULL,INDEX (entity, entityId));`
+}
+
+// writeAudit writes audit entries to the audit table, in the transaction of the running write if there is one.
+//
+// Parameters:
+// - ctx: The context of the operation.
+// - entries: The entries to write.
+//
+// Returns:
+// - error: An error if the entries can't be encoded or written.
+func (ms *MySql) writeAudit(ctx context.Context, entries ...audit.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Store the changes as JSON and the key as text, the key of every table fits in the same column.
+	placeholders := make([]string, 0, len(entries))
+	values := make([]interface{}, 0, len(entries)*6)
+	for _, entry := range entries {
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return err
+		}
+		var entityID interface{}
+		if entry.EntityID != nil {
+			entityID = fmt.Sprintf("%v", entry.EntityID)
+		}
+		placeholders = append(placeholders, "(?,?,?,?,?,?)")
+		values = append(values, entry.Entity, entityID, string(entry.Operation), string(changes), entry.Actor, entry.Timestamp)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (entity,entityId,operation,changes,actor,timestamp) VALUES %s", ms.auditEntityName(), strings.Join(placeholders, ","))
+	_, err := ms.executor().ExecContext(ctx, query, values...)
+	return err
+}
+
+// readAuditRecords reads the records matching the WHERE condition of the connection before a bulk write, so that
+// an audit entry is written for each of them.
+//
+// Parameters:
+// - ctx: The context of the operation.
+// - entityName: The name of the table.
+// - dataType: The struct type of the model, used to read the records with the types of its fields.
+// - values: The values of the WHERE condition.
+//
+// Returns:
+// - []map[string]interface{}: The values of the records.
+// - error: An error if the records can't be read.
+func (ms *MySql) readAuditRecords(ctx context.Context, entityName string, dataType reflect.Type, values []interface{}) ([]map[string]interface{}, error) {
+	query := fmt.Sprintf("SELECT * FROM %s", entityName)
+	if whereData, ok := ms.whereQuery.(*conditions.SqlData); ok && whereData.Query != "" {
+		query = fmt.Sprintf("%s WHERE %s", query, whereData.Query)
+	}
+	if dataType.Kind() != reflect.Struct {
+		dataType = nil
+	}
+
+	rows, err := ms.executor().QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		record, err := ms.readSqlRowToMap(rows, columnNames, dataType)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// writeAudit writes audit entries to the audit collection, in the transaction of the running write if there is one.
+//
+// Parameters:
+// - ctx: The context of the operation, the session context while running in a transaction.
+// - entries: The entries to write.
+//
+// Returns:
+// - error: An error if the entries can't be written.
+func (mc *MongoConnection) writeAudit(ctx context.Context, entries ...audit.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		documents[i] = entry
+	}
+	_, err := mc.client.Database(mc.currentDB).Collection(mc.auditEntityName()).InsertMany(ctx, documents)
+	return err
+}
+
+// readAuditDocuments reads the documents matching a filter before a bulk write, so that an audit entry is written
+// for each of them.
+//
+// Parameters:
+// - ctx: The context of the operation.
+// - entityName: The name of the collection.
+// - filter: The filter of the write.
+//
+// Returns:
+// - []map[string]interface{}: The values of the documents.
+// - error: An error if the documents can't be read.
+func (mc *MongoConnection) readAuditDocuments(ctx context.Context, entityName string, filter interface{}) ([]map[string]interface{}, error) {
+	cursor, err := mc.client.Database(mc.currentDB).Collection(entityName).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	documents := make([]primitive.M, 0)
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	records := make([]map[string]interface{}, len(documents))
+	for i, document := range documents {
+		records[i] = mc.documentValues(document)
+	}
+	return records, nil
+}
+
+// auditDeletion records the deletion of a document in the audit trail once it succeeded.
+//
+// Parameters:
+// - ctx: The context of the operation.
+// - err: The error of the deletion.
+// - entityName: The name of the collection.
+// - data: The deleted data.
+// - deleted: The document before the deletion.
+//
+// Returns:
+// - error: The error of the deletion, or of the audit entry.
+func (mc *MongoConnection) auditDeletion(ctx context.Context, err error, entityName string, data interface{}, deleted primitive.M) error {
+	if err != nil || !mc.useAudit(data) {
+		return err
+	}
+	return mc.writeAudit(ctx, mc.auditEntry(ctx, entityName, audit.Delete, data, mc.documentValues(deleted), nil))
+}
+
+// documentValues returns the values of a document read from MongoDB, with dates converted to time.Time so that
+// they compare with the values of structs.
+//
+// Parameters:
+// - document: The document, nil if none was read.
+//
+// Returns:
+// - map[string]interface{}: The values of the document, nil if document is nil.
+func (mc *MongoConnection) documentValues(document primitive.M) map[string]interface{} {
+	if document == nil {
+		return nil
+	}
+	values := make(map[string]interface{}, len(document))
+	for key, value := range document {
+		if date, ok := value.(primitive.DateTime); ok {
+			value = date.Time()
+		}
+		values[key] = value
+	}
+	return values
+}
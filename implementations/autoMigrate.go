@@ -0,0 +1,331 @@
+package implementations
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/schema"
+)
+
+// integerWidth matches the display width of the integer types, which MySQL 8 no longer reports.
+var integerWidth = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+
+// liveColumn is a column of an existing table read from INFORMATION_SCHEMA.COLUMNS.
+type liveColumn struct {
+	name          string
+	columnType    string
+	nullable      bool
+	defaultValue  sql.NullString
+	autoIncrement bool
+	generated     bool // true if the default is an expression, MySQL 8 reporting it without its parentheses.
+	position      int  // The position of the column in the table, from 1.
+}
+
+// liveIndex is an index of an existing table read from INFORMATION_SCHEMA.STATISTICS.
+type liveIndex struct {
+	name       string
+	unique     bool
+	indexType  string // BTREE, FULLTEXT or SPATIAL.
+	columns    []string
+	descending []bool // The sort direction of each column.
+}
+
+// AutoMigrate creates the tables of the models which don't exist and alters the existing ones to match the dbfusion
+// tags of the models. Columns are added and modified to match the type, nullability, default and AUTO_INCREMENT of
+// their field, and unique indexes and a primary key are added for the columns declared UNIQUE or PRIMARY KEY.
+// Columns and unique indexes the models no longer declare are only dropped when the options ask for it.
+//
+// Parameters:
+// - options: The options of the migration, e.g. DryRun to print the statements without running them.
+// - models: The models, structs or pointers to structs whose tags declare the columns.
+//
+// Returns:
+// - []string: The statements run, or planned on a dry run.
+// - error: ErrInvalidType if a model is not a struct, or an error if the schema can't be read or altered.
+//
+// The columns are read like CreateTable reads them, the types the tags don't set being inferred from the fields.
+//
+// Example:
+//   statements, err := ms.AutoMigrate(queryoptions.AutoMigrateOptions{DryRun: true}, &User{}, &Order{})
+func (ms *MySql) AutoMigrate(options queryoptions.AutoMigrateOptions, models ...interface{}) ([]string, error) {
+	statements := make([]string, 0)
+	err := ms.intercept(connections.OpAutoMigrate, func() error {
+		var err error
+		statements, err = ms.autoMigrate(options, models...)
+		return err
+	})
+	return statements, err
+}
+
+// autoMigrate runs AutoMigrate as the last handler of the middleware chain.
+func (ms *MySql) autoMigrate(options queryoptions.AutoMigrateOptions, models ...interface{}) ([]string, error) {
+	statements := make([]string, 0)
+	for _, model := range models {
+		// Plan the statements of the table of the model.
+		planned, err := ms.planMigration(model, options)
+		if err != nil {
+			return statements, err
+		}
+
+		// Print them on a dry run, and run them one after another otherwise.
+		for _, statement := range planned {
+			if options.DryRun {
+				output := options.Output
+				if output == nil {
+					output = os.Stdout
+				}
+				fmt.Fprintln(output, statement+";")
+			} else if _, err := ms.executor().ExecContext(ms.getContext(), statement); err != nil {
+				return statements, err
+			}
+			statements = append(statements, statement)
+		}
+	}
+	return statements, nil
+}
+
+// planMigration compares a model with its table and returns the statements making the table match the model.
+//
+// Parameters:
+// - model: The model.
+// - options: The options of the migration.
+//
+// Returns:
+// - []string: The statements, a CREATE TABLE if the table doesn't exist.
+// - error: ErrInvalidType if the model is not a struct, or an error if the schema can't be read.
+func (ms *MySql) planMigration(model interface{}, options queryoptions.AutoMigrateOptions) ([]string, error) {
+	name, err := ms.getEntityName(model)
+	if err != nil {
+		return nil, err
+	}
+	if name.dataType.Kind() != reflect.Struct {
+		return nil, dbfusionErrors.ErrInvalidType
+	}
+	table := name.entityName
+
+	// Create the table when it doesn't exist.
+	columns, err := ms.liveColumns(table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		query, err := ms.createTableQuery(model, true)
+		if err != nil {
+			return nil, err
+		}
+		return []string{strings.TrimSuffix(query, ";")}, nil
+	}
+	indexes, err := ms.liveIndexes(table)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the missing columns after the column preceding them in the model, and modify the ones which differ.
+	statements := make([]string, 0)
+	specs := ms.sqlColumns(name.dataType)
+	declared := make(map[string]bool)
+	previous := ""
+	for _, spec := range specs {
+		declared[spec.Name] = true
+		if column, exists := columns[spec.Name]; !exists {
+			statement := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, ms.sqlDialect().ColumnDefinition(spec))
+			if previous != "" {
+				statement += " AFTER " + previous
+			}
+			statements = append(statements, statement)
+			if spec.References != nil {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD %s", table, spec.References.ForeignKey(spec.Name)))
+			}
+		} else if !columnMatches(spec, column) {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", table, ms.modifiedColumn(spec)))
+		}
+		previous = spec.Name
+	}
+
+	// Add the primary key and the unique indexes of the existing columns declaring them.
+	_, hasPrimaryKey := indexes["PRIMARY"]
+	for _, spec := range specs {
+		if _, exists := columns[spec.Name]; !exists {
+			continue
+		}
+		if spec.PrimaryKey && !hasPrimaryKey {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s)", table, spec.Name))
+		}
+		if spec.Unique && !spec.PrimaryKey && uniqueIndexOn(indexes, spec.Name) == "" {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD UNIQUE INDEX %s (%s)", table, spec.Name, spec.Name))
+		}
+	}
+
+	// Drop the unique indexes and the columns the model no longer declares when asked to.
+	if options.DropIndexes {
+		for _, spec := range specs {
+			if index := uniqueIndexOn(indexes, spec.Name); index != "" && !spec.Unique && !spec.PrimaryKey {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", table, index))
+			}
+		}
+	}
+	if options.DropColumns {
+		for _, column := range liveColumnOrder(columns) {
+			if !declared[column] {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column))
+			}
+		}
+	}
+	return statements, nil
+}
+
+// modifiedColumn returns the definition of an existing column in a MODIFY COLUMN clause, without the primary key,
+// unique index and foreign key, since modifying a column with them would add them again.
+func (ms *MySql) modifiedColumn(column schema.Column) string {
+	column.PrimaryKey, column.Unique = false, false
+	return ms.sqlDialect().ColumnDefinition(column)
+}
+
+// columnMatches reports whether an existing column has the type, nullability, default and AUTO_INCREMENT of the
+// column of a field.
+func columnMatches(column schema.Column, live liveColumn) bool {
+	if normalizeColumnType(column.Type) != normalizeColumnType(live.columnType) {
+		return false
+	}
+	if column.Nullable != live.nullable || column.AutoIncrement != live.autoIncrement {
+		return false
+	}
+
+	// Compare the defaults without their quotes, DEFAULT NULL being the same as no default.
+	declared := ""
+	if column.Default != nil {
+		declared = normalizeDefault(*column.Default)
+	}
+	current := ""
+	if live.defaultValue.Valid {
+		current = normalizeDefault(live.defaultValue.String)
+	}
+	return declared == current
+}
+
+// normalizeColumnType returns a column type as MySQL reports it in INFORMATION_SCHEMA.COLUMNS.COLUMN_TYPE, so that
+// a type declared as "INTEGER(11)" matches the "int" of an existing column.
+func normalizeColumnType(columnType string) string {
+	columnType = strings.Join(strings.Fields(strings.ToLower(columnType)), " ")
+
+	// Replace the aliases by the type they stand for.
+	for alias, name := range map[string]string{"integer": "int", "boolean": "tinyint(1)", "bool": "tinyint(1)", "numeric": "decimal", "dec": "decimal", "double precision": "double", "real": "double"} {
+		if columnType == alias || strings.HasPrefix(columnType, alias+"(") || strings.HasPrefix(columnType, alias+" ") {
+			columnType = name + columnType[len(alias):]
+			break
+		}
+	}
+	if columnType == "decimal" || strings.HasPrefix(columnType, "decimal ") {
+		columnType = "decimal(10,0)" + columnType[len("decimal"):]
+	}
+	if columnType == "char" {
+		columnType = "char(1)"
+	}
+
+	// Leave out the display width of the integers, except for the tinyint(1) of the booleans.
+	if !strings.HasPrefix(columnType, "tinyint(1)") {
+		columnType = integerWidth.ReplaceAllString(columnType, "$1")
+	}
+	return columnType
+}
+
+// normalizeDefault returns a default value without its quotes and case, NULL being no default.
+func normalizeDefault(value string) string {
+	value = strings.ToLower(strings.Trim(strings.TrimSpace(value), `'"`))
+	if value == "null" {
+		return ""
+	}
+	return value
+}
+
+// uniqueIndexOn returns the name of the unique index on a single column, empty if the column has none.
+func uniqueIndexOn(indexes map[string]liveIndex, column string) string {
+	for _, index := range indexes {
+		if index.unique && index.name != "PRIMARY" && len(index.columns) == 1 && index.columns[0] == column {
+			return index.name
+		}
+	}
+	return ""
+}
+
+// liveColumns reads the columns of a table.
+//
+// Parameters:
+// - table: The name of the table.
+//
+// Returns:
+// - map[string]liveColumn: The columns keyed by name, empty if the table doesn't exist.
+// - error: An error if the columns can't be read.
+func (ms *MySql) liveColumns(table string) (map[string]liveColumn, error) {
+	rows, err := ms.executor().QueryContext(ms.getContext(),
+		"SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA, ORDINAL_POSITION FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+		ms.currentDB, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]liveColumn)
+	for rows.Next() {
+		column := liveColumn{}
+		nullable, extra := "", ""
+		if err := rows.Scan(&column.name, &column.columnType, &nullable, &column.defaultValue, &extra, &column.position); err != nil {
+			return nil, err
+		}
+		column.nullable = nullable == "YES"
+		column.autoIncrement = strings.Contains(strings.ToLower(extra), "auto_increment")
+		column.generated = strings.Contains(strings.ToLower(extra), "default_generated")
+		columns[column.name] = column
+	}
+	return columns, rows.Err()
+}
+
+// liveColumnOrder returns the names of the columns read by liveColumns in the order of the table.
+func liveColumnOrder(columns map[string]liveColumn) []string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return columns[names[i]].position < columns[names[j]].position })
+	return names
+}
+
+// liveIndexes reads the indexes of a table.
+//
+// Parameters:
+// - table: The name of the table.
+//
+// Returns:
+// - map[string]liveIndex: The indexes keyed by name, the primary key being named PRIMARY.
+// - error: An error if the indexes can't be read.
+func (ms *MySql) liveIndexes(table string) (map[string]liveIndex, error) {
+	rows, err := ms.executor().QueryContext(ms.getContext(),
+		"SELECT INDEX_NAME, NON_UNIQUE, INDEX_TYPE, COLUMN_NAME, COLLATION FROM INFORMATION_SCHEMA.STATISTICS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY INDEX_NAME, SEQ_IN_INDEX",
+		ms.currentDB, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make(map[string]liveIndex)
+	for rows.Next() {
+		name, nonUnique, indexType, column, collation := "", 0, "", sql.NullString{}, sql.NullString{}
+		if err := rows.Scan(&name, &nonUnique, &indexType, &column, &collation); err != nil {
+			return nil, err
+		}
+		index := indexes[name]
+		index.name, index.unique, index.indexType = name, nonUnique == 0, indexType
+		index.columns = append(index.columns, column.String)
+		index.descending = append(index.descending, collation.String == "D")
+		indexes[name] = index
+	}
+	return indexes, rows.Err()
+}
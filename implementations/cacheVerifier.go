@@ -0,0 +1,161 @@
+package implementations
+
+import (
+	"time"
+
+	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/hooks"
+	"github.com/glodb/dbfusion/queryoptions"
+)
+
+// cacheIndexes adapts the indexes passed in the options to the CacheHook interface expected by getAllCacheValues.
+type cacheIndexes []string
+
+// GetCacheIndexes returns the indexes.
+func (ci cacheIndexes) GetCacheIndexes() []string {
+	return ci
+}
+
+// cacheVerifier collects the records streamed from the database by VerifyCache and compares them with the cache in
+// batches, building the report and repairing the inconsistencies when requested.
+type cacheVerifier struct {
+	dbc        *DBCommon                           // The connection, used to compute the expected index keys.
+	cache      caches.Cache                        // The cache being verified.
+	hook       hooks.CacheHook                     // The cache indexes of the entity.
+	dbName     string                              // The name of the database.
+	entityName string                              // The name of the entity.
+	options    queryoptions.VerifyCacheOptions     // The options controlling sampling, batching and repair.
+	batch      []map[string]interface{}            // The records waiting to be verified.
+	report     connections.CacheVerificationReport // The report returned at the end of the verification.
+	started    time.Time                           // The time the verification started.
+}
+
+// newCacheVerifier validates the cache and the indexes of the model and prepares a cacheVerifier for the entity.
+//
+// Parameters:
+//   - dbc: The connection the records are read from.
+//   - model: The model of the entity, used to read its cache indexes when they are not passed in the options.
+//   - entityName: The name of the entity.
+//   - dbFusionOptions: Optional VerifyCacheOptions.
+//
+// Returns:
+//   - *cacheVerifier: The prepared cacheVerifier.
+//   - error: ErrNoValidCacheFound or ErrCacheIndexesRequired.
+func newCacheVerifier(dbc *DBCommon, model interface{}, entityName string, dbFusionOptions ...queryoptions.VerifyCacheOptions) (*cacheVerifier, error) {
+	// A connected cache is required to verify it.
+	if dbc.cache == nil {
+		return nil, dbfusionErrors.ErrNoValidCacheFound
+	}
+
+	verifier := &cacheVerifier{dbc: dbc, cache: *dbc.cache, dbName: dbc.currentDB, entityName: entityName, started: time.Now()}
+	if len(dbFusionOptions) > 0 {
+		verifier.options = dbFusionOptions[0]
+	}
+	if verifier.options.BatchSize <= 0 {
+		verifier.options.BatchSize = defaultWarmCacheBatchSize
+	}
+
+	// Indexes passed in the options take precedence over the ones of the model.
+	if len(verifier.options.Indexes) > 0 {
+		verifier.hook = cacheIndexes(verifier.options.Indexes)
+	} else if value, ok := model.(hooks.CacheHook); ok && len(value.GetCacheIndexes()) > 0 {
+		verifier.hook = value
+	} else {
+		return nil, dbfusionErrors.ErrCacheIndexesRequired
+	}
+
+	verifier.batch = make([]map[string]interface{}, 0, verifier.options.BatchSize)
+	verifier.report.MissingKeys = make([]string, 0)
+	verifier.report.StaleKeys = make([]string, 0)
+	verifier.report.OrphanedPayloads = make([]string, 0)
+	return verifier, nil
+}
+
+// add queues a record and verifies the batch once it is full.
+//
+// Parameters:
+//   - data: The record read from the database as a map of field names to values.
+//
+// Returns:
+//   - error: An error if the batch cannot be verified or repaired.
+func (cv *cacheVerifier) add(data map[string]interface{}) error {
+	cv.batch = append(cv.batch, data)
+	if len(cv.batch) < cv.options.BatchSize {
+		return nil
+	}
+	return cv.flush()
+}
+
+// flush verifies the queued records against the cache and caches the inconsistent ones again when repairing.
+//
+// Returns:
+//   - error: An error if the batch cannot be verified or repaired.
+func (cv *cacheVerifier) flush() error {
+	if len(cv.batch) == 0 {
+		return nil
+	}
+
+	// Recompute the expected index keys of every record.
+	keys := make([][]string, len(cv.batch))
+	for idx, data := range cv.batch {
+		keys[idx] = cv.dbc.getAllCacheValues(cv.hook, data, cv.entityName)
+	}
+
+	missing, stale, inconsistent, err := caches.GetInstance().VerifyCacheBatch(cv.cache, keys, cv.batch)
+	if err != nil {
+		return err
+	}
+	cv.report.Checked += int64(len(cv.batch))
+	cv.report.Inconsistent += int64(len(inconsistent))
+	cv.report.MissingKeys = append(cv.report.MissingKeys, missing...)
+	cv.report.StaleKeys = append(cv.report.StaleKeys, stale...)
+
+	// Cache the inconsistent records again, reusing the first payload key of each one and removing the other payloads
+	// its index keys pointed to, so the repair leaves no orphans behind.
+	if cv.options.Repair && len(inconsistent) > 0 {
+		repair := make([]map[string]interface{}, 0, len(inconsistent))
+		for _, idx := range inconsistent {
+			repair = append(repair, cv.batch[idx])
+		}
+		repaired, err := caches.GetInstance().ProcessInsertCacheBatch(cv.cache, cv.dbc.cacheIndexes(cv.hook), repair, cv.dbName, cv.entityName)
+		if err != nil {
+			return err
+		}
+		cv.report.Repaired += int64(repaired)
+	}
+
+	cv.batch = cv.batch[:0]
+	return nil
+}
+
+// finish verifies the remaining records, looks for orphaned payloads when requested and returns the report.
+//
+// Returns:
+//   - connections.CacheVerificationReport: The report of the verification.
+//   - error: An error if the remaining records cannot be verified or the orphans cannot be found or removed.
+func (cv *cacheVerifier) finish() (connections.CacheVerificationReport, error) {
+	if err := cv.flush(); err != nil {
+		return cv.report, err
+	}
+
+	// Orphans are looked up after the repair so that its outcome is what gets reported.
+	if cv.options.CheckOrphans {
+		orphans, err := caches.GetInstance().FindOrphanedPayloads(cv.cache, cv.dbName, cv.entityName)
+		if err != nil {
+			return cv.report, err
+		}
+		cv.report.OrphanedPayloads = orphans
+
+		if cv.options.Repair && len(orphans) > 0 {
+			if err := cv.cache.MDel(orphans); err != nil {
+				return cv.report, err
+			}
+			cv.report.Repaired += int64(len(orphans))
+		}
+	}
+
+	cv.report.Duration = time.Since(cv.started)
+	return cv.report, nil
+}
@@ -0,0 +1,137 @@
+package implementations
+
+import (
+	"time"
+
+	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/hooks"
+	"github.com/glodb/dbfusion/queryoptions"
+)
+
+// defaultWarmCacheBatchSize is the number of records cached at once when WarmCacheOptions.BatchSize is not set.
+const defaultWarmCacheBatchSize = 500
+
+// cacheWarmer collects the records streamed from the database by WarmCache and writes them to the cache in
+// batches, applying the rate limit and reporting the progress of the options.
+type cacheWarmer struct {
+	cache      caches.Cache                  // The cache being warmed.
+	indexes    []string                      // The cache indexes of the entity.
+	dbName     string                        // The name of the database.
+	entityName string                        // The name of the entity.
+	options    queryoptions.WarmCacheOptions // The options controlling batching, rate limiting and progress.
+	batch      []map[string]interface{}      // The records waiting to be written.
+	results    connections.WarmCacheResults  // The counts reported at the end of the warm up.
+	started    time.Time                     // The time the warm up started, used for rate limiting.
+}
+
+// newCacheWarmer validates the cache and the indexes of the model and prepares a cacheWarmer for the entity.
+// When requested, every cached key of the entity is removed before the warm up starts.
+//
+// Parameters:
+//   - dbc: The connection the records are read from, its cache is warmed.
+//   - model: The model of the entity, used to read its cache indexes when they are not passed in the options.
+//   - entityName: The name of the entity.
+//   - dbFusionOptions: Optional WarmCacheOptions.
+//
+// Returns:
+//   - *cacheWarmer: The prepared cacheWarmer.
+//   - error: ErrNoValidCacheFound, ErrCacheIndexesRequired or an error from the invalidation.
+func newCacheWarmer(dbc *DBCommon, model interface{}, entityName string, dbFusionOptions ...queryoptions.WarmCacheOptions) (*cacheWarmer, error) {
+	// A connected cache is required to warm it.
+	if dbc.cache == nil {
+		return nil, dbfusionErrors.ErrNoValidCacheFound
+	}
+
+	warmer := &cacheWarmer{cache: *dbc.cache, dbName: dbc.currentDB, entityName: entityName, started: time.Now()}
+	if len(dbFusionOptions) > 0 {
+		warmer.options = dbFusionOptions[0]
+	}
+	if warmer.options.BatchSize <= 0 {
+		warmer.options.BatchSize = defaultWarmCacheBatchSize
+	}
+
+	// Indexes passed in the options take precedence over the ones of the model and its primary key.
+	warmer.indexes = warmer.options.Indexes
+	if len(warmer.indexes) == 0 {
+		if value, ok := model.(hooks.CacheHook); ok {
+			warmer.indexes = dbc.cacheIndexes(value)
+		}
+	}
+	if len(warmer.indexes) == 0 {
+		return nil, dbfusionErrors.ErrCacheIndexesRequired
+	}
+
+	// Remove the existing keys of the entity so that entries of deleted records don't survive the warm up.
+	if warmer.options.Invalidate {
+		err := caches.GetInstance().InvalidateEntity(warmer.cache, warmer.dbName, entityName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	warmer.batch = make([]map[string]interface{}, 0, warmer.options.BatchSize)
+	return warmer, nil
+}
+
+// add queues a record and writes the batch once it is full.
+//
+// Parameters:
+//   - data: The record read from the database as a map of field names to values.
+//
+// Returns:
+//   - error: An error if the batch cannot be written to the cache.
+func (cw *cacheWarmer) add(data map[string]interface{}) error {
+	cw.batch = append(cw.batch, data)
+	if len(cw.batch) < cw.options.BatchSize {
+		return nil
+	}
+	return cw.flush()
+}
+
+// flush writes the queued records to the cache, reports the progress and waits as long as needed to respect the
+// rate limit.
+//
+// Returns:
+//   - error: An error if the batch cannot be written to the cache.
+func (cw *cacheWarmer) flush() error {
+	if len(cw.batch) == 0 {
+		return nil
+	}
+
+	// Write the whole batch with a single round trip.
+	cached, err := caches.GetInstance().ProcessInsertCacheBatch(cw.cache, cw.indexes, cw.batch, cw.dbName, cw.entityName)
+	if err != nil {
+		return err
+	}
+	cw.results.Processed += int64(len(cw.batch))
+	cw.results.Cached += int64(cached)
+	cw.results.Skipped += int64(len(cw.batch) - cached)
+	cw.batch = cw.batch[:0]
+
+	// Report the progress so far.
+	if cw.options.Progress != nil {
+		cw.options.Progress(cw.results.Processed, cw.results.Cached)
+	}
+
+	// Sleep until the processed records fit into the allowed rate.
+	if cw.options.RatePerSecond > 0 {
+		expected := time.Duration(cw.results.Processed) * time.Second / time.Duration(cw.options.RatePerSecond)
+		if elapsed := time.Since(cw.started); elapsed < expected {
+			time.Sleep(expected - elapsed)
+		}
+	}
+	return nil
+}
+
+// finish writes the remaining records and returns the results of the warm up.
+//
+// Returns:
+//   - connections.WarmCacheResults: The counts of processed, cached and skipped records and the duration.
+//   - error: An error if the remaining records cannot be written to the cache.
+func (cw *cacheWarmer) finish() (connections.WarmCacheResults, error) {
+	err := cw.flush()
+	cw.results.Duration = time.Since(cw.started)
+	return cw.results, err
+}
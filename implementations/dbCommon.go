@@ -1,18 +1,23 @@
 package implementations
 
 import (
+	"context"
 	"fmt"
 	"reflect"
-	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/glodb/dbfusion/caches"
 	"github.com/glodb/dbfusion/conditions"
 	"github.com/glodb/dbfusion/connections"
 	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/encryption"
 	"github.com/glodb/dbfusion/ftypes"
 	"github.com/glodb/dbfusion/hooks"
+	"github.com/glodb/dbfusion/metadata"
 	"github.com/glodb/dbfusion/queryoptions"
-	"github.com/glodb/dbfusion/set"
+	"github.com/glodb/dbfusion/validation"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -21,6 +26,7 @@ import (
 // database queries and managing query parameters.
 type DBCommon struct {
 	cache        *caches.Cache // A cache instance for caching query results.
+	server       string        // The address of the database server, set by Connect.
 	currentDB    string        // The name of the current database.
 	tableName    string        // The name of the database table being queried.
 	whereQuery   interface{}   // The query conditions for filtering results.
@@ -34,6 +40,24 @@ type DBCommon struct {
 	havingValues []interface{} // Values for the parameters in the HAVING clause.
 	orderBy      string        // The ORDER BY clause for sorting query results.
 	pageSize     int           // The number of records per page for paginated queries.
+
+	ctx context.Context // The context of the next operation, set by WithContext.
+
+	middlewares []connections.Middleware // The chain wrapping every terminal call, set by Use.
+	operation   *connections.Operation   // The operation being run by the chain, nil outside of it.
+
+	deletedScope softDeleteScope // The soft-deleted records read by the next operation, set by WithDeleted and OnlyDeleted.
+	hardDelete   bool            // Removes the records of soft-deleted entities on the next delete, set by HardDelete.
+
+	writeBehind       map[string]*writeBehindWorker // The workers writing queued write-behind records, by database.
+	writeBehindMutex  sync.Mutex                    // Guards the start and stop of the write-behind workers.
+	writeBehindErrors atomic.Value                  // The connections.WriteBehindErrorHandler set by SetWriteBehindErrorHandler.
+
+	auditEntity string // The table or collection of the audit trail, set by SetAuditEntity.
+
+	asOf *time.Time // The moment the next find reads historical entities at, set by AsOf.
+
+	keyProvider encryption.KeyProvider // The provider of the keys of the encrypted fields, set by SetKeyProvider.
 }
 
 // SetCache associates a cache object with the DBCommon instance, enabling caching
@@ -269,8 +293,15 @@ func (dbc *DBCommon) createTagValueMap(data interface{}) (tagMapValue map[string
 	dataValue := reflect.ValueOf(data)
 	dataType := dataValue.Type()
 
-	// Initialize the tag-value map
-	tagMapValue = make(map[string]interface{})
+	// Read the fields with the generated mapper of the model when it has one
+	if mapper, ok := data.(hooks.FieldMapper); ok {
+		columns, values := mapper.DBFusionColumns(), mapper.DBFusionValues()
+		tagMapValue = make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			tagMapValue[column] = values[i]
+		}
+		return tagMapValue, nil
+	}
 
 	// If data is a pointer, get the value it points to
 	if dataType.Kind() == reflect.Ptr {
@@ -279,24 +310,18 @@ func (dbc *DBCommon) createTagValueMap(data interface{}) (tagMapValue map[string
 		dataType = dataValue.Type()
 	}
 
-	// Iterate over the fields of the data structure
-	for i := 0; i < dataType.NumField(); i++ {
-		field := dataType.Field(i)
-
-		// Split the raw struct tags into individual tags
-		rawTags := strings.Split(field.Tag.Get("dbfusion"), ",")
-		tagName := rawTags[0]
-
-		// Skip fields with empty tags
-		if tagName == "" {
-			continue
-		}
+	// Get the tagged fields of the data structure
+	model, ok := metadata.GetInstance().Model(dataType)
+	if !ok {
+		return make(map[string]interface{}), nil
+	}
 
-		// Get the field's value as an interface
-		value := dataValue.Field(i).Interface()
+	// Initialize the tag-value map
+	tagMapValue = make(map[string]interface{}, len(model.Fields))
 
-		// Add the tag and its corresponding value to the map
-		tagMapValue[tagName] = value
+	// Add the tag of each field and its value to the map
+	for _, field := range model.Fields {
+		tagMapValue[field.Name] = dataValue.Field(field.Index).Interface()
 	}
 
 	// Return the tag-value map and any potential error
@@ -389,11 +414,54 @@ func (dbc *DBCommon) getEntityName(data interface{}) (entityData entityData, err
 		}
 	}
 
-	// Set the structType in entityData and return the result
+	// Set the structType in entityData and record the entity of the running operation
 	entityData.structType = structType
+	dbc.traceEntity(entityData.entityName)
+
+	// Remember the model of the entity for the operations given conditions only
+	if structType == 1 {
+		metadata.GetInstance().RegisterEntity(dbc.currentDB, entityData.entityName, dataType)
+	}
 	return
 }
 
+// getResultsEntityName returns the entity name of the records read into a slice. Like getEntityName, structs
+// implementing the Entity interface name their entity and the table set with Table is used otherwise.
+//
+// Parameters:
+// - results: A pointer to the slice the records are read into.
+//
+// Returns:
+// - string: The name of the entity.
+// - error: dbfusionErrors.ErrInvalidType if results is not a slice, or dbfusionErrors.ErrEntityNameRequired if the
+//   entity has no name.
+func (dbc *DBCommon) getResultsEntityName(results interface{}) (string, error) {
+	// Reach the type of the elements of the slice.
+	if results == nil {
+		return "", dbfusionErrors.ErrInvalidType
+	}
+	_, resultsType := dbc.checkPtr(results)
+	if resultsType.Kind() != reflect.Slice {
+		return "", dbfusionErrors.ErrInvalidType
+	}
+	elementType := resultsType.Elem()
+	for elementType.Kind() == reflect.Ptr {
+		elementType = elementType.Elem()
+	}
+
+	// Ask the elements for their entity name, falling back on the table name.
+	entityName := dbc.tableName
+	if value, ok := reflect.New(elementType).Interface().(hooks.Entity); ok {
+		entityName = value.GetEntityName()
+	}
+	if entityName == "" {
+		return "", dbfusionErrors.ErrEntityNameRequired
+	}
+
+	dbc.traceEntity(entityName)
+	return entityName, nil
+}
+
 // preInsert prepares data for insertion into the database and returns pre-insertion details.
 //
 // This function inspects the provided data, extracts relevant information, and prepares it for insertion
@@ -451,6 +519,18 @@ func (dbc *DBCommon) preInsert(data interface{}) (preCreateData preCreateReturn,
 		dataType = dataValue.Type()
 	}
 
+	// Check the data against the rules of its validate tags
+	if err = validation.GetInstance().Validate(data); err != nil {
+		return
+	}
+
+	// Check if the data implements BeforeInsert interface and abort the insertion if it fails
+	if value, ok := interface{}(data).(hooks.BeforeInsert); ok {
+		if err = value.BeforeInsert(dbc.getContext()); err != nil {
+			return
+		}
+	}
+
 	// Initialize variables for keys, placeholders, and values
 	keys := ""
 	placeholders := ""
@@ -459,26 +539,76 @@ func (dbc *DBCommon) preInsert(data interface{}) (preCreateData preCreateReturn,
 	// Handle struct data type (structType == 1)
 	if structType == 1 {
 		mData := make(map[string]interface{})
-		for i := 0; i < dataType.NumField(); i++ {
-			field := dataType.Field(i)
+		now := time.Now()
+		key, hasKey := dbc.primaryKey(dataType)
+
+		// Read the values with the generated mapper of the model when it has one, indexed by tagged field
+		var mappedValues []interface{}
+		var mappedSet []bool
+		if mapper, ok := data.(hooks.FieldMapper); ok {
+			mappedValues, mappedSet = mapper.DBFusionValues(), mapper.DBFusionSet()
+		}
 
-			rawtags := strings.Split(field.Tag.Get("dbfusion"), ",")
-			tagName := rawtags[0]
+		// Iterate over the tagged fields of the model
+		model, _ := metadata.GetInstance().Model(dataType)
+		for tagged, modelField := range model.Fields {
+			i, field, tagName := modelField.Index, modelField.StructField, modelField.Name
 
-			tags := set.ConvertArray[string](rawtags)
+			var value interface{}
+			var fieldSet bool
+			if tagged < len(mappedValues) {
+				value, fieldSet = mappedValues[tagged], mappedSet[tagged]
+			} else {
+				value = dataValue.Field(i).Interface()
+				fieldSet = dbc.isFieldSet(dataValue.Field(i))
+			}
 
-			if tagName == "" {
-				continue
+			// Fill the automatic timestamps left empty, in the data as well when it is addressable
+			if !fieldSet {
+				if timestamp, ok := dbc.autoTimestamp(field, now, autoCreateTimeOption, autoUpdateTimeOption); ok {
+					if dataValue.Field(i).CanSet() {
+						dataValue.Field(i).Set(timestamp)
+					}
+					value = timestamp.Interface()
+					fieldSet = true
+				}
 			}
 
-			value := dataValue.Field(i).Interface()
+			// Start the version of optimistic locking at 1 when it is left empty
+			if !fieldSet {
+				if version, ok := dbc.initialVersion(field); ok {
+					if dataValue.Field(i).CanSet() {
+						dataValue.Field(i).Set(version)
+					}
+					value = version.Interface()
+					fieldSet = true
+				}
+			}
 
-			if tags.Contains("omitempty") {
-				if !dbc.isFieldSet(dataValue.Field(i)) {
+			// Generate the primary key left empty, the database generates autoincr keys so they are left out
+			if !fieldSet && hasKey && key.field.Name == field.Name {
+				if id, ok := dbc.generateID(key); ok {
+					if dataValue.Field(i).CanSet() {
+						dataValue.Field(i).Set(id)
+					}
+					value = id.Interface()
+					fieldSet = true
+				} else if key.strategy == autoIncrementStrategy {
 					continue
 				}
 			}
 
+			if _, ok := modelField.Option(omitEmptyOption); ok {
+				if !fieldSet {
+					continue
+				}
+			}
+
+			// Encrypt the fields with the encrypt option, the data keeps its plaintext
+			if value, err = dbc.encryptValue(field, value); err != nil {
+				return
+			}
+
 			mData[tagName] = value
 			keys += tagName + ","
 			placeholders += "?,"
@@ -538,7 +668,7 @@ func (dbc *DBCommon) postInsert(cache *caches.Cache, data interface{}, mData map
 		// Ensure a valid cache instance is available
 		if cache != nil {
 			// Process cache update based on the CacheHook's cache indexes
-			err := caches.GetInstance().ProcessInsertCache(*cache, val.GetCacheIndexes(), mData, dbName, entityName)
+			err := caches.GetInstance().ProcessInsertCache(*cache, dbc.cacheIndexes(val), mData, dbName, entityName)
 			if err != nil {
 				return err
 			}
@@ -595,6 +725,13 @@ func (dbc *DBCommon) preFind(cache *caches.Cache, result interface{}, dbFusionOp
 		result = value.PreFind()
 	}
 
+	// Check if the result data implements the BeforeFind interface and abort the find if it fails
+	if value, ok := interface{}(result).(hooks.BeforeFind); ok {
+		if err = value.BeforeFind(dbc.getContext()); err != nil {
+			return
+		}
+	}
+
 	// Get entity name and related information for the result data
 	nameData, err = dbc.getEntityName(result)
 	if err != nil {
@@ -615,7 +752,8 @@ func (dbc *DBCommon) preFind(cache *caches.Cache, result interface{}, dbFusionOp
 		dbFusionData = value
 	}
 
-	if options.ForceDB { // Database query is forced, skip cache checks
+	// Database query is forced, or the cache which only holds current records that are not deleted can't answer it
+	if options.ForceDB || dbc.deletedScope != excludeDeleted || dbc.asOf != nil {
 		prefindReturn.query = dbFusionData.GetQuery()
 		prefindReturn.whereQuery = dbc.whereQuery
 		prefindReturn.queryDatabase = true
@@ -623,7 +761,7 @@ func (dbc *DBCommon) preFind(cache *caches.Cache, result interface{}, dbFusionOp
 		ok := false
 
 		// Construct a cache key for the result data based on database, entity name, and cache values
-		redisKey := dbc.currentDB + "_" + prefindReturn.entityName + "_" + dbFusionData.GetCacheValues()
+		redisKey := caches.GetInstance().IndexKey(dbc.currentDB, prefindReturn.entityName, dbFusionData.GetCacheValues())
 
 		// Check if the data exists in the cache and retrieve it
 		ok, err = caches.GetInstance().ProceessGetCache(*cache, redisKey, result)
@@ -636,7 +774,7 @@ func (dbc *DBCommon) preFind(cache *caches.Cache, result interface{}, dbFusionOp
 
 		if !ok { // Data not found in the Redis composite index, check if it exists in the query cache
 			// Construct a cache key for the query based on database, entity name, and cache key
-			redisQueryKey := dbc.currentDB + "_" + prefindReturn.entityName + "_" + dbFusionData.GetCacheKey()
+			redisQueryKey := caches.GetInstance().QueryKey(dbc.currentDB, prefindReturn.entityName, dbFusionData.GetCacheKey())
 			// Check if the data exists in the query cache and retrieve it
 			skipDB, err = caches.GetInstance().ProceessGetQueryCache(*cache, redisQueryKey, result)
 			if err != nil {
@@ -690,13 +828,19 @@ func (dbc *DBCommon) postFind(cache *caches.Cache, result interface{}, entityNam
 		options = dbFusionOptions[0]
 	}
 
-	// Cache the results if CacheResult option is enabled
-	if options.CacheResult {
+	// Cache the results if CacheResult option is enabled, results including deleted or past records are never cached
+	if options.CacheResult && dbc.deletedScope == excludeDeleted && dbc.asOf == nil {
 		// Check if the whereQuery is of type conditions.DBFusionData, as caching is only possible for this type
 		if value, ok := dbc.whereQuery.(conditions.DBFusionData); ok {
 			// Construct a cache key for the query based on database, entity name, and cache key
-			redisQueryKey := dbc.currentDB + "_" + entityName + "_" + value.GetCacheKey()
-			caches.GetInstance().ProceessSetQueryCache(*cache, redisQueryKey, result)
+			redisQueryKey := caches.GetInstance().QueryKey(dbc.currentDB, entityName, value.GetCacheKey())
+
+			// The cache holds the ciphertexts of the encrypted fields, they are decrypted after the cache is read.
+			cached, err := dbc.encryptedCopy(result)
+			if err != nil {
+				return err
+			}
+			caches.GetInstance().ProceessSetQueryCache(*cache, redisQueryKey, cached)
 		}
 	}
 
@@ -705,6 +849,11 @@ func (dbc *DBCommon) postFind(cache *caches.Cache, result interface{}, entityNam
 		dbc.whereQuery = value.PostFind()
 	}
 
+	// Check if the result data implements the AfterFind interface and return its error
+	if value, ok := interface{}(result).(hooks.AfterFind); ok {
+		return value.AfterFind(dbc.getContext())
+	}
+
 	return nil
 }
 
@@ -732,29 +881,29 @@ func (dbc *DBCommon) postFind(cache *caches.Cache, result interface{}, entityNam
 func (dbc *DBCommon) buildMongoData(dataType reflect.Type, dataValue reflect.Value) primitive.D {
 	queryMap := primitive.D{}
 
-	// Iterate through the fields of the data structure
-	for i := 0; i < dataType.NumField(); i++ {
-		field := dataType.Field(i)
-
-		// Extract tags from the field's "dbfusion" tag
-		rawtags := strings.Split(field.Tag.Get("dbfusion"), ",")
-		tagName := rawtags[0]
-
-		// Skip fields without a valid tag name
-		if tagName == "" {
-			continue
+	// Read the fields with the generated mapper of the model when it has one
+	if dataValue.CanInterface() {
+		if mapper, ok := dataValue.Interface().(hooks.FieldMapper); ok {
+			columns, values, fieldSet := mapper.DBFusionColumns(), mapper.DBFusionValues(), mapper.DBFusionSet()
+			for i, column := range columns {
+				if fieldSet[i] {
+					queryMap = append(queryMap, primitive.E{Key: column, Value: values[i]})
+				}
+			}
+			return queryMap
 		}
+	}
 
-		// Get the field's value
-		value := dataValue.Field(i).Interface()
-
+	// Iterate through the tagged fields of the data structure
+	model, _ := metadata.GetInstance().Model(dataType)
+	for _, field := range model.Fields {
 		// Skip fields with zero values
-		if !dbc.isFieldSet(dataValue.Field(i)) {
+		if !dbc.isFieldSet(dataValue.Field(field.Index)) {
 			continue
 		}
 
 		// Create a MongoDB primitive.E element for the field and add it to the document
-		singlePoint := primitive.E{Key: tagName, Value: value}
+		singlePoint := primitive.E{Key: field.Name, Value: dataValue.Field(field.Index).Interface()}
 		queryMap = append(queryMap, singlePoint)
 	}
 
@@ -783,19 +932,45 @@ func (dbc *DBCommon) buildMongoData(dataType reflect.Type, dataValue reflect.Val
 //   var customUpdate map[string]interface{}
 //   updateDoc, err := buildMongoUpdate(customUpdate, nameData)
 //   // 'updateDoc' now contains a MongoDB update document suitable for updating records based on 'customUpdate'.
-func (dbc *DBCommon) buildMongoUpdate(data interface{}, nameData entityData) (interface{}, error) {
+func (dbc *DBCommon) buildMongoUpdate(data interface{}, nameData entityData, modelType reflect.Type, upsert bool) (interface{}, error) {
 	dataValue := nameData.dataValue
 	dataType := nameData.dataType
 
 	structType := nameData.structType
 
 	var topMap interface{}
+	now := time.Now()
 
 	// Check the data type and structure type to determine how to construct the update document
 	if structType == 1 { // It's a structure
-		queryMap := dbc.buildMongoData(dataType, dataValue)
+		queryMap, err := dbc.encryptDocument(dbc.buildMongoData(dataType, dataValue), dataType)
+		if err != nil {
+			return nil, err
+		}
+
+		// Set the update timestamps of the structure, replacing the values it carries
+		for _, timestamp := range dbc.modelTimestamps(dataType, now, autoUpdateTimeOption) {
+			queryMap = append(dbc.removeKey(queryMap, timestamp.Key), timestamp)
+		}
+
+		// The version is incremented rather than set
+		if version, ok := dbc.versionField(dataType); ok {
+			queryMap = dbc.removeKey(queryMap, version.name)
+		}
 		topMap = primitive.D{{Key: "$set", Value: queryMap}}
+		modelType = dataType
 	} else {
+		// Add the update timestamps of the model missing from the map, the version is incremented rather than set
+		data = dbc.mapWithTimestamps(data, modelType, now, autoUpdateTimeOption)
+		if version, ok := dbc.versionField(modelType); ok {
+			data = dbc.mapWithoutVersion(data, version)
+		}
+
+		// Encrypt the values of the encrypted fields of the model
+		var err error
+		if data, err = dbc.encryptMap(data, modelType); err != nil {
+			return nil, err
+		}
 		switch data.(type) {
 		case ftypes.QMap:
 			// Convert data to a primitive.D document
@@ -821,9 +996,46 @@ func (dbc *DBCommon) buildMongoUpdate(data interface{}, nameData entityData) (in
 		}
 	}
 
+	// Increment the version of optimistic locking, documents inserted by upserts start at 1
+	if version, ok := dbc.versionField(modelType); ok {
+		topMap = append(topMap.(primitive.D), primitive.E{Key: "$inc", Value: primitive.D{{Key: version.name, Value: 1}}})
+	}
+
+	// Set the creation timestamps of the documents inserted by upserts
+	if upsert {
+		setFields := topMap.(primitive.D)[0].Value.(primitive.D)
+		onInsert := primitive.D{}
+		for _, timestamp := range dbc.modelTimestamps(modelType, now, autoCreateTimeOption) {
+			if len(dbc.removeKey(setFields, timestamp.Key)) == len(setFields) {
+				onInsert = append(onInsert, timestamp)
+			}
+		}
+		if len(onInsert) != 0 {
+			topMap = append(topMap.(primitive.D), primitive.E{Key: "$setOnInsert", Value: onInsert})
+		}
+	}
+
 	return topMap, nil
 }
 
+// removeKey returns the elements of a document without the ones using the key.
+//
+// Parameters:
+// - document: The document.
+// - key: The key to remove.
+//
+// Returns:
+// - primitive.D: The elements of the document using other keys.
+func (dbc *DBCommon) removeKey(document primitive.D, key string) primitive.D {
+	filtered := primitive.D{}
+	for _, element := range document {
+		if element.Key != key {
+			filtered = append(filtered, element)
+		}
+	}
+	return filtered
+}
+
 // buildMySqlDeleteData constructs the conditions and values for a MySQL delete query based on the provided data.
 //
 // This function takes the data and its type, iterates through the fields, and constructs the conditions and values
@@ -846,16 +1058,10 @@ func (dbc *DBCommon) buildMySqlDeleteData(dataType reflect.Type, dataValue refle
 	conditions := ""
 	valuesInterface := make([]interface{}, 0)
 
-	// Iterate through the fields of the data type
-	for i := 0; i < dataType.NumField(); i++ {
-		field := dataType.Field(i)
-
-		rawtags := strings.Split(field.Tag.Get("dbfusion"), ",")
-		tagName := rawtags[0]
-
-		if tagName == "" {
-			continue
-		}
+	// Iterate through the tagged fields of the data type
+	model, _ := metadata.GetInstance().Model(dataType)
+	for _, modelField := range model.Fields {
+		i, field, tagName := modelField.Index, modelField.StructField, modelField.Name
 		value := dataValue.Field(i).Interface()
 
 		// Check if the field is set (not zero or nil)
@@ -863,6 +1069,17 @@ func (dbc *DBCommon) buildMySqlDeleteData(dataType reflect.Type, dataValue refle
 			continue
 		}
 
+		// Match deterministic fields by their ciphertext, the ciphertexts of the other encrypted fields can't be matched
+		if deterministic, ok := dbc.encryptionMode(field); ok {
+			if !deterministic {
+				continue
+			}
+			var err error
+			if value, err = dbc.encryptValue(field, value); err != nil {
+				return "", nil, err
+			}
+		}
+
 		// Create the conditions and add the value as a placeholder
 		if conditions == "" {
 			conditions += fmt.Sprintf("%s = ?", tagName)
@@ -914,25 +1131,44 @@ func (dbc *DBCommon) buildMySqlUpdate(data interface{}, nameData entityData) (st
 	}
 	setString := ""
 	valuesInterface := make([]interface{}, 0)
+	now := time.Now()
 
-	if structType == 1 { // It's a structure
-		for i := 0; i < dataType.NumField(); i++ {
-			field := dataType.Field(i)
+	// The version of optimistic locking is incremented rather than set
+	modelType := nameData.dataType
+	if structType == 1 {
+		modelType = dataType
+	}
+	version, versioned := dbc.versionField(modelType)
 
-			if structType == 1 {
-			}
-			rawtags := strings.Split(field.Tag.Get("dbfusion"), ",")
-			tagName := rawtags[0]
+	if structType == 1 { // It's a structure
+		model, _ := metadata.GetInstance().Model(dataType)
+		for _, modelField := range model.Fields {
+			i, field, tagName := modelField.Index, modelField.StructField, modelField.Name
 
-			if tagName == "" {
+			if versioned && tagName == version.name {
 				continue
 			}
+
 			value := dataValue.Field(i).Interface()
 
+			// Set the update timestamps, in the data as well when it is addressable
+			if timestamp, ok := dbc.autoTimestamp(field, now, autoUpdateTimeOption); ok {
+				if dataValue.Field(i).CanSet() {
+					dataValue.Field(i).Set(timestamp)
+				}
+				value = timestamp.Interface()
+			}
+
 			// Check if the field is set (not zero or nil)
-			if !dbc.isFieldSet(dataValue.Field(i)) {
+			if !dbc.isFieldSet(reflect.ValueOf(value)) {
 				continue
 			}
+
+			// Encrypt the fields with the encrypt option
+			value, err := dbc.encryptValue(field, value)
+			if err != nil {
+				return "", nil, err
+			}
 			if setString == "" {
 				setString += fmt.Sprintf("%s = ?", tagName)
 			} else {
@@ -943,6 +1179,18 @@ func (dbc *DBCommon) buildMySqlUpdate(data interface{}, nameData entityData) (st
 		setString = "SET " + setString
 
 	} else {
+		// Add the update timestamps of the model missing from the map
+		data = dbc.mapWithTimestamps(data, nameData.dataType, now, autoUpdateTimeOption)
+		if versioned {
+			data = dbc.mapWithoutVersion(data, version)
+		}
+
+		// Encrypt the values of the encrypted fields of the model
+		var err error
+		if data, err = dbc.encryptMap(data, nameData.dataType); err != nil {
+			return "", nil, err
+		}
+
 		if value, ok := data.(ftypes.QMap); ok {
 			for key, val := range value {
 				if setString == "" {
@@ -977,6 +1225,15 @@ func (dbc *DBCommon) buildMySqlUpdate(data interface{}, nameData entityData) (st
 			return "", valuesInterface, dbfusionErrors.ErrInvalidType
 		}
 	}
+
+	// Increment the version of optimistic locking
+	if versioned {
+		if setString == "SET " {
+			setString += dbc.versionIncrement(version)
+		} else {
+			setString += "," + dbc.versionIncrement(version)
+		}
+	}
 	return setString, valuesInterface, nil
 }
 
@@ -990,6 +1247,8 @@ func (dbc *DBCommon) buildMySqlUpdate(data interface{}, nameData entityData) (st
 // Parameters:
 // - data: The input data for the update operation.
 // - dbType: The type of database (e.g., connections.MONGO) for which the update operation is being prepared.
+// - model: The model of the entity, declaring the timestamps of updates given as maps.
+// - upsert: Whether the update inserts the record when it doesn't exist, which fills the creation timestamps.
 //
 // Returns:
 // - preUpdateReturn: A struct containing data prepared for the update operation.
@@ -997,15 +1256,35 @@ func (dbc *DBCommon) buildMySqlUpdate(data interface{}, nameData entityData) (st
 //
 // Example:
 //   var userToUpdate User
-//   preUpdateData, err := dbc.preUpdate(userToUpdate, connections.MONGO)
+//   preUpdateData, err := dbc.preUpdate(userToUpdate, connections.MONGO, &User{}, false)
 //   // 'preUpdateData' contains data prepared for the update operation, specific to MongoDB.
-func (dbc *DBCommon) preUpdate(data interface{}, dbType ftypes.DBTypes) (preUpdateData preUpdateReturn, err error) {
+func (dbc *DBCommon) preUpdate(data interface{}, dbType ftypes.DBTypes, model interface{}, upsert bool) (preUpdateData preUpdateReturn, err error) {
+
+	// Updates of write-behind entities could overtake their queued insertions.
+	if err = dbc.rejectWriteBehind(data, model); err != nil {
+		return
+	}
 
 	// Check if the data implements the PreUpdate hook and potentially modify it.
 	if value, ok := interface{}(data).(hooks.PreUpdate); ok {
 		data = value.PreUpdate()
 	}
 
+	// Check the update data against the rules of its validate tags. The SQL backend passes the result model here
+	// and validates the update data itself.
+	if dbType == connections.MONGO {
+		if err = validation.GetInstance().Validate(data); err != nil {
+			return
+		}
+	}
+
+	// Check if the data implements the BeforeUpdate hook and abort the update if it fails.
+	if value, ok := interface{}(data).(hooks.BeforeUpdate); ok {
+		if err = value.BeforeUpdate(dbc.getContext()); err != nil {
+			return
+		}
+	}
+
 	// Retrieve entity-related information, such as entity name, data type, etc.
 	nameData, nameErr := dbc.getEntityName(data)
 
@@ -1022,7 +1301,11 @@ func (dbc *DBCommon) preUpdate(data interface{}, dbType ftypes.DBTypes) (preUpda
 
 	// Depending on the database type, prepare the data for an update operation.
 	if dbType == connections.MONGO {
-		preUpdateData.queryData, err = dbc.buildMongoUpdate(data, nameData)
+		var modelType reflect.Type
+		if model != nil {
+			_, modelType = dbc.checkPtr(model)
+		}
+		preUpdateData.queryData, err = dbc.buildMongoUpdate(data, nameData, modelType, upsert)
 	}
 
 	return
@@ -1047,9 +1330,21 @@ func (dbc *DBCommon) preUpdate(data interface{}, dbType ftypes.DBTypes) (preUpda
 //   // 'preDeleteData' contains data prepared for the delete operation.
 func (dbc *DBCommon) preDelete(data interface{}) (preDeleteData preDeleteReturn, err error) {
 
-	// Check if the data implements the PreUpdate hook and potentially modify it.
-	if value, ok := interface{}(data).(hooks.PreUpdate); ok {
-		data = value.PreUpdate()
+	// Deletions of write-behind entities could overtake their queued insertions.
+	if err = dbc.rejectWriteBehind(data); err != nil {
+		return
+	}
+
+	// Check if the data implements the PreDelete hook and potentially modify it.
+	if value, ok := interface{}(data).(hooks.PreDelete); ok {
+		data = value.PreDelete()
+	}
+
+	// Check if the data implements the BeforeDelete hook and abort the deletion if it fails.
+	if value, ok := interface{}(data).(hooks.BeforeDelete); ok {
+		if err = value.BeforeDelete(dbc.getContext()); err != nil {
+			return
+		}
 	}
 
 	var nameData entityData
@@ -1102,7 +1397,7 @@ func (dbc *DBCommon) preDelete(data interface{}) (preDeleteData preDeleteReturn,
 func (dbc *DBCommon) postDelete(cache *caches.Cache, data interface{}, entityName string, results primitive.M) error {
 
 	// Check if the input data implements the CacheHook interface.
-	if value, ok := interface{}(data).(hooks.CacheHook); ok {
+	if value, ok := interface{}(data).(hooks.CacheHook); ok && cache != nil {
 
 		// Build cache-related keys for this data.
 		oldValues := dbc.getAllCacheValues(value, results, entityName)
@@ -1111,6 +1406,13 @@ func (dbc *DBCommon) postDelete(cache *caches.Cache, data interface{}, entityNam
 		caches.GetInstance().ProceessDeleteCache(*cache, oldValues)
 	}
 
+	// Soft deleted records stay in the database, so the cached query results may still hold them.
+	if _, soft := dbc.useSoftDelete(data); soft && cache != nil {
+		if err := caches.GetInstance().InvalidateQueries(*cache, dbc.currentDB, entityName); err != nil {
+			return err
+		}
+	}
+
 	// Check if the input data implements the PostDelete hook and potentially modify it.
 	if value, ok := interface{}(data).(hooks.PostDelete); ok {
 		data = value.PostDelete()
@@ -1143,8 +1445,15 @@ func (dbc *DBCommon) postDelete(cache *caches.Cache, data interface{}, entityNam
 //   // Perform post-update operations, such as cache management or data modification.
 func (dbc *DBCommon) postUpdate(cache *caches.Cache, result interface{}, entityName string, oldValues []string, newValues []string) error {
 
-	// Update the cache with new values, removing old cache entries.
-	caches.GetInstance().ProceessUpdateCache(*cache, oldValues, newValues, result)
+	// Update the cache with new values, removing old cache entries. The cache holds the ciphertexts of the encrypted
+	// fields of the result.
+	if cache != nil {
+		cached, err := dbc.encryptedCopy(result)
+		if err != nil {
+			return err
+		}
+		caches.GetInstance().ProceessUpdateCache(*cache, oldValues, newValues, cached, dbc.currentDB, entityName)
+	}
 
 	// Check if the input data implements the PostUpdate hook and potentially modify it.
 	if value, ok := interface{}(result).(hooks.PostUpdate); ok {
@@ -1154,6 +1463,52 @@ func (dbc *DBCommon) postUpdate(cache *caches.Cache, result interface{}, entityN
 	return nil
 }
 
+// postUpdateMany removes the cached records of the entity after an update of several records, which can change any of
+// them, and invokes the PostUpdate hook of the model.
+//
+// Parameters:
+// - cache: The cache of the connection, nil if caching is disabled.
+// - model: The model of the entity.
+// - entityName: The name of the updated entity.
+//
+// Returns:
+// - error: An error if the cache of the entity cannot be invalidated.
+func (dbc *DBCommon) postUpdateMany(cache *caches.Cache, model interface{}, entityName string) error {
+	// Invalidate the cache of entities using cache hooks, or the cached queries of soft-deleted entities.
+	if err := dbc.invalidateDeleted(cache, model, entityName); err != nil {
+		return err
+	}
+
+	// Check if the model implements the PostUpdate hook and invoke it.
+	if value, ok := interface{}(model).(hooks.PostUpdate); ok {
+		value.PostUpdate()
+	}
+	return nil
+}
+
+// postDeleteMany removes the cached records of the entity, or the cached queries of a soft-deleted entity, after a
+// delete of several records, whose keys are not known, and invokes the PostDelete hook of the model.
+//
+// Parameters:
+// - cache: The cache of the connection, nil if caching is disabled.
+// - model: The model of the entity, nil for deletes given a table name.
+// - entityName: The name of the entity.
+//
+// Returns:
+// - error: An error if the cache of the entity cannot be invalidated.
+func (dbc *DBCommon) postDeleteMany(cache *caches.Cache, model interface{}, entityName string) error {
+	// Invalidate the cache of entities using cache hooks, or the cached queries of soft-deleted entities.
+	if err := dbc.invalidateDeleted(cache, model, entityName); err != nil {
+		return err
+	}
+
+	// Check if the model implements the PostDelete hook and invoke it.
+	if value, ok := interface{}(model).(hooks.PostDelete); ok {
+		value.PostDelete()
+	}
+	return nil
+}
+
 // getAllCacheValues retrieves all cache keys associated with a data object based on its cache indexes.
 //
 // This function iterates through the cache indexes defined by the data implementing the CacheHook interface,
@@ -1176,35 +1531,31 @@ func (dbc *DBCommon) postUpdate(cache *caches.Cache, result interface{}, entityN
 //   }
 //   entity := "users"
 //   cacheKeys := dbc.getAllCacheValues(userData, tagValues, entity)
-//   // cacheKeys will contain ["testDB_users_1234_John"] based on the cache indexes.
+//   // cacheKeys will contain ["dbfusion:testDB:users:idx:1234_John"] based on the cache indexes.
 func (dbc *DBCommon) getAllCacheValues(data hooks.CacheHook, tagValueMap map[string]interface{}, entityName string) []string {
 
 	// Initialize an empty slice to store cache keys.
 	cacheKeys := make([]string, 0)
 
-	// Iterate through the cache indexes defined by the data object.
-	for _, cacheKey := range data.GetCacheIndexes() {
-		// Split the cacheKey into internal keys.
-		internalKeys := strings.Split(cacheKey, ",")
+	// Iterate through the keys of the cache indexes defined by the data object and its primary key.
+	for _, internalKeys := range dbc.cacheIndexKeys(data) {
 
-		// Initialize a cache key string.
-		cacheKeyString := ""
-
-		// Iterate through internal keys to construct the cache key.
+		// Collect the values of the internal keys to construct the cache key.
+		values := make(map[string]interface{}, len(internalKeys))
 		for _, internalKey := range internalKeys {
 			// Check if the internal key exists in the tagValueMap.
 			if value, ok := tagValueMap[internalKey]; ok {
-				// Append the value to the cache key string, separated by underscores.
-				if cacheKeyString == "" {
-					cacheKeyString += fmt.Sprintf("%v", value)
-				} else {
-					cacheKeyString += fmt.Sprintf("_%v", value)
-				}
+				values[internalKey] = dbc.indexValue(data, internalKey, value)
 			}
 		}
 
-		// Assemble the final cache key with the current database, entity name, and tag values.
-		cacheKeyString = dbc.currentDB + "_" + entityName + "_" + cacheKeyString
+		// Skip indexes without any value, no key is created for them on insertion.
+		if len(values) == 0 {
+			continue
+		}
+
+		// Assemble the final cache key with the current database, entity name, and the tags with their values.
+		cacheKeyString := caches.GetInstance().IndexKey(dbc.currentDB, entityName, caches.GetInstance().IndexValues(values))
 
 		// Append the cache key to the cacheKeys slice.
 		cacheKeys = append(cacheKeys, cacheKeyString)
@@ -1214,6 +1565,265 @@ func (dbc *DBCommon) getAllCacheValues(data hooks.CacheHook, tagValueMap map[str
 	return cacheKeys
 }
 
+// getCachePolicy returns the cache policy of an entity.
+//
+// Parameters:
+// - data: The model of the entity.
+//
+// Returns:
+// - hooks.CachePolicy: The policy returned by the CachePolicyHook of the model, or WriteThrough if it doesn't implement it.
+func (dbc *DBCommon) getCachePolicy(data interface{}) hooks.CachePolicy {
+	if value, ok := interface{}(data).(hooks.CachePolicyHook); ok {
+		return value.GetCachePolicy()
+	}
+	return hooks.WriteThrough
+}
+
+// readThrough caches a record that FindOne had to read from the database when the entity uses the ReadThrough
+// policy. The existing composite key of the record is reused when one of its indexes is still cached, so repeated
+// reads don't leave orphaned payloads behind.
+//
+// Parameters:
+// - cache: A pointer to the Cache interface for cache handling.
+// - result: The record read from the database.
+// - entityName: The name of the entity.
+//
+// Returns:
+// - error: An error, if any, that occurred while caching the record.
+//
+// Note:
+//   Records read with a projection, joins or grouping are partial or don't belong to the entity, so they are never cached.
+//   Neither are records read with WithDeleted or OnlyDeleted, which may be soft deleted, or with AsOf.
+func (dbc *DBCommon) readThrough(cache *caches.Cache, result interface{}, entityName string) error {
+	// Only entities with cache indexes and the ReadThrough policy are cached.
+	hook, ok := interface{}(result).(hooks.CacheHook)
+	if !ok || cache == nil || !dbc.getCachePolicy(result).Has(hooks.ReadThrough) {
+		return nil
+	}
+	if dbc.projection != nil || dbc.joins != "" || dbc.groupBy != "" || dbc.deletedScope != excludeDeleted || dbc.asOf != nil {
+		return nil
+	}
+
+	// Compute the index keys of the record.
+	tagValueMap, err := dbc.createTagValueMap(result)
+	if err != nil {
+		return err
+	}
+	keys := dbc.getAllCacheValues(hook, tagValueMap, entityName)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Cache the ciphertexts of the encrypted fields, the record may have been decrypted as it was read.
+	cached, err := dbc.encryptMap(tagValueMap, reflect.TypeOf(result))
+	if err != nil {
+		return err
+	}
+
+	// Point the indexes to the record, reusing the composite key if the record is already cached.
+	_, err = caches.GetInstance().ProceessUpdateCache(*cache, keys, keys, cached, dbc.currentDB, entityName)
+	return err
+}
+
+// getContext returns the context set by WithContext for the current operation, or context.TODO() if none was set.
+func (dbc *DBCommon) getContext() context.Context {
+	if dbc.ctx == nil {
+		return context.TODO()
+	}
+	return dbc.ctx
+}
+
+// useTransaction reports whether the writes of the data have to run in a transaction.
+//
+// Parameters:
+// - data: The data being written.
+//
+// Returns:
+// - bool: true if the data implements hooks.Transactional and asks for a transaction.
+func (dbc *DBCommon) useTransaction(data interface{}) bool {
+	value, ok := interface{}(data).(hooks.Transactional)
+	return ok && value.UseTransaction()
+}
+
+// afterInsert invokes the AfterInsert hook of the data if it implements it.
+//
+// Parameters:
+// - ctx: The context of the operation, the session context while running in a transaction.
+// - data: The inserted data.
+//
+// Returns:
+// - error: The error returned by the hook.
+func (dbc *DBCommon) afterInsert(ctx context.Context, data interface{}) error {
+	if value, ok := interface{}(data).(hooks.AfterInsert); ok {
+		return value.AfterInsert(ctx)
+	}
+	return nil
+}
+
+// afterUpdate invokes the AfterUpdate hook of the result if it implements it.
+//
+// Parameters:
+// - ctx: The context of the operation, the session context while running in a transaction.
+// - result: The updated data.
+//
+// Returns:
+// - error: The error returned by the hook.
+func (dbc *DBCommon) afterUpdate(ctx context.Context, result interface{}) error {
+	if value, ok := interface{}(result).(hooks.AfterUpdate); ok {
+		return value.AfterUpdate(ctx)
+	}
+	return nil
+}
+
+// afterDelete invokes the AfterDelete hook of the data if it implements it.
+//
+// Parameters:
+// - ctx: The context of the operation, the session context while running in a transaction.
+// - data: The deleted data.
+//
+// Returns:
+// - error: The error returned by the hook.
+func (dbc *DBCommon) afterDelete(ctx context.Context, data interface{}) error {
+	if value, ok := interface{}(data).(hooks.AfterDelete); ok {
+		return value.AfterDelete(ctx)
+	}
+	return nil
+}
+
+// transactionBeginner starts a database transaction for runWrite. It returns the context the operations of the
+// transaction have to use together with the functions committing and rolling back the transaction.
+type transactionBeginner func(ctx context.Context) (txCtx context.Context, commit func() error, rollback func() error, err error)
+
+// runWrite runs a database write followed by the After hook of the data and the post-write operations such as
+// caching. When the data implements hooks.Transactional the write and the After hook run in a transaction, an error
+// from either of them rolls the write back and the post-write operations only run once the transaction committed.
+// Otherwise the write is final, the post-write operations run right after it and the error of the After hook is
+// returned last.
+//
+// Parameters:
+// - data: The data being written, used to check whether a transaction is requested.
+// - begin: Starts a transaction on the database of the connection.
+// - write: Performs the database write.
+// - after: Invokes the After hook of the data.
+// - post: Performs the post-write operations.
+//
+// Returns:
+// - error: The first error returned by the write, the hook, the transaction or the post-write operations.
+func (dbc *DBCommon) runWrite(data interface{}, begin transactionBeginner, write func(ctx context.Context) error, after func(ctx context.Context) error, post func() error) error {
+	ctx := dbc.getContext()
+
+	// Without a transaction the write is final as soon as it succeeds.
+	if !dbc.useTransaction(data) {
+		if err := write(ctx); err != nil {
+			return err
+		}
+		if err := post(); err != nil {
+			return err
+		}
+		return after(ctx)
+	}
+
+	txCtx, commit, rollback, err := begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Roll back if the write or the After hook fails.
+	if err := write(txCtx); err != nil {
+		rollback()
+		return err
+	}
+	if err := after(txCtx); err != nil {
+		rollback()
+		return err
+	}
+	if err := commit(); err != nil {
+		return err
+	}
+
+	// The cache is only touched once the write is committed.
+	return post()
+}
+
+// Use appends middlewares to the chain wrapping every terminal call of the connection, such as InsertOne, FindOne,
+// UpdateAndFindOne, DeleteOne, Paginate and Aggregate. The first middleware registered is the outermost one.
+//
+// Parameters:
+// - middlewares: The middlewares to append to the chain.
+//
+// Example:
+//   con.Use(func(next connections.Handler) connections.Handler {
+//       return func(op *connections.Operation) error {
+//           err := next(op)
+//           log.Println(op.Kind, op.Entity, op.Query, op.Duration, err)
+//           return err
+//       }
+//   })
+func (dbc *DBCommon) Use(middlewares ...connections.Middleware) {
+	dbc.middlewares = append(dbc.middlewares, middlewares...)
+}
+
+// intercept runs an operation through the middleware chain, the last handler of the chain runs the operation itself.
+// The query values are reset once the chain returned, including when a middleware aborted the operation.
+//
+// Parameters:
+// - kind: The kind of the operation.
+// - run: Runs the operation on the database.
+//
+// Returns:
+// - error: The error returned by the chain.
+func (dbc *DBCommon) intercept(kind connections.OperationKind, run func() error) error {
+	defer dbc.refreshValues()
+
+	op := &connections.Operation{
+		Kind:     kind,
+		Database: dbc.currentDB,
+		Entity:   dbc.tableName,
+		Context:  dbc.getContext(),
+	}
+
+	// The last handler runs the operation with the context chosen by the middlewares and records its outcome.
+	var handler connections.Handler = func(op *connections.Operation) error {
+		dbc.ctx = op.Context
+		dbc.operation = op
+		defer func() { dbc.operation = nil }()
+
+		start := time.Now()
+		op.Err = run()
+		op.Duration = time.Since(start)
+		return op.Err
+	}
+
+	// Wrap the handler from the last middleware registered to the first one.
+	for i := len(dbc.middlewares) - 1; i >= 0; i-- {
+		handler = dbc.middlewares[i](handler)
+	}
+	return handler(op)
+}
+
+// traceEntity records the entity of the operation run by the middleware chain once it is resolved from the data.
+//
+// Parameters:
+// - entityName: The name of the table or collection.
+func (dbc *DBCommon) traceEntity(entityName string) {
+	if dbc.operation != nil && entityName != "" {
+		dbc.operation.Entity = entityName
+	}
+}
+
+// traceQuery records the compiled query of the operation run by the middleware chain. An operation running several
+// queries records the last one.
+//
+// Parameters:
+// - query: The SQL statement or the MongoDB filter, document or pipeline.
+// - args: The arguments bound to the SQL statement, or the update document of a MongoDB update.
+func (dbc *DBCommon) traceQuery(query interface{}, args ...interface{}) {
+	if dbc.operation != nil {
+		dbc.operation.Query = query
+		dbc.operation.Args = args
+	}
+}
+
 // refreshValues resets the internal state of the DBCommon instance.
 //
 // This function sets various properties of the DBCommon instance to their initial or empty values,
@@ -1232,4 +1842,8 @@ func (dbc *DBCommon) refreshValues() {
 	dbc.havingString = ""
 	dbc.havingValues = make([]interface{}, 0)
 	dbc.orderBy = ""
+	dbc.ctx = nil
+	dbc.deletedScope = excludeDeleted
+	dbc.hardDelete = false
+	dbc.asOf = nil
 }
@@ -0,0 +1,407 @@
+package implementations
+
+import (
+	"reflect"
+
+	"github.com/glodb/dbfusion/audit"
+	"github.com/glodb/dbfusion/encryption"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/metadata"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// deterministicEncryption is the parameter of the encrypt option giving equal values equal ciphertexts, e.g.
+// `dbfusion:"nationalId,encrypt:deterministic"`.
+const deterministicEncryption = "deterministic"
+
+// SetKeyProvider sets the provider of the keys encrypting the fields with the encrypt option.
+//
+// Parameters:
+// - provider: The key provider.
+//
+// Example:
+//   con.SetKeyProvider(encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": key}))
+func (dbc *DBCommon) SetKeyProvider(provider encryption.KeyProvider) {
+	dbc.keyProvider = provider
+}
+
+// EncryptLookup encrypts a value of a deterministic field with the current key, so that it can be looked up with
+// Where.
+//
+// Parameters:
+// - value: The plaintext value.
+//
+// Returns:
+// - string: The ciphertext stored for the value.
+// - error: ErrKeyProviderRequired without a key provider, or an error if the key can't be read.
+//
+// Example:
+//   nationalID, err := con.EncryptLookup("AB123456")
+//   err = con.Where(ftypes.QMap{"nationalId": nationalID}).FindOne(&customer)
+func (dbc *DBCommon) EncryptLookup(value string) (string, error) {
+	return encryption.GetInstance().Encrypt(dbc.keyProvider, value, true)
+}
+
+// encryptionMode reports whether a field is encrypted, only string fields are.
+//
+// Parameters:
+// - field: The struct field.
+//
+// Returns:
+// - bool: true if the field is encrypted deterministically.
+// - bool: true if the field carries the encrypt option.
+func (dbc *DBCommon) encryptionMode(field reflect.StructField) (bool, bool) {
+	if field.Type.Kind() != reflect.String {
+		return false, false
+	}
+	param, ok := metadata.GetInstance().Tag(field.Tag).Option(encryptOption)
+	return param == deterministicEncryption, ok
+}
+
+// encryptValue encrypts the value of a field carrying the encrypt option. Other values and values which are
+// ciphertexts already, i.e. which decrypt under the key provider, are returned as they are. A value which only starts
+// like a ciphertext is encrypted like any other.
+//
+// Parameters:
+// - field: The struct field.
+// - value: The value written to the field.
+//
+// Returns:
+// - interface{}: The ciphertext, or the value.
+// - error: An error if the value can't be encrypted.
+func (dbc *DBCommon) encryptValue(field reflect.StructField, value interface{}) (interface{}, error) {
+	deterministic, ok := dbc.encryptionMode(field)
+	if !ok {
+		return value, nil
+	}
+	plaintext, ok := value.(string)
+	if !ok {
+		if text := reflect.ValueOf(value); text.IsValid() && text.Kind() == reflect.String {
+			plaintext = text.String()
+		} else {
+			return value, nil
+		}
+	}
+	if dbc.isCiphertext(plaintext) {
+		return plaintext, nil
+	}
+	return encryption.GetInstance().Encrypt(dbc.keyProvider, plaintext, deterministic)
+}
+
+// isCiphertext reports whether a value is a ciphertext decrypting under the key provider.
+func (dbc *DBCommon) isCiphertext(value string) bool {
+	if !encryption.GetInstance().IsEncrypted(value) {
+		return false
+	}
+	_, err := encryption.GetInstance().Decrypt(dbc.keyProvider, value)
+	return err == nil
+}
+
+// encryptedFields returns the fields of a model carrying the encrypt option, keyed by their name in the database.
+//
+// Parameters:
+// - modelType: The type of the model, pointers and slices are dereferenced.
+//
+// Returns:
+// - map[string]reflect.StructField: The encrypted fields, empty if the model has none.
+func (dbc *DBCommon) encryptedFields(modelType reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	model, ok := metadata.GetInstance().Model(modelType)
+	if !ok {
+		return fields
+	}
+	for _, field := range model.Fields {
+		if _, ok := dbc.encryptionMode(field.StructField); ok {
+			fields[field.Name] = field.StructField
+		}
+	}
+	return fields
+}
+
+// encryptMap returns a copy of map data with the values of the encrypted fields of the model encrypted, e.g. the
+// update of a model given as a map.
+//
+// Parameters:
+// - data: The data, a QMap, a DMap or a map[string]interface{}, other types are returned as they are.
+// - modelType: The type of the model declaring the encrypted fields.
+//
+// Returns:
+// - interface{}: The data with the encrypted values, of the same type as data.
+// - error: An error if a value can't be encrypted.
+func (dbc *DBCommon) encryptMap(data interface{}, modelType reflect.Type) (interface{}, error) {
+	fields := dbc.encryptedFields(modelType)
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	// Copy the maps, the caller keeps its values.
+	switch value := data.(type) {
+	case ftypes.QMap:
+		encrypted, err := dbc.encryptEntries(value, fields)
+		return ftypes.QMap(encrypted), err
+	case map[string]interface{}:
+		return dbc.encryptEntries(value, fields)
+	case ftypes.DMap:
+		document, err := dbc.encryptDocument(primitive.D(value), modelType)
+		return ftypes.DMap(document), err
+	}
+	return data, nil
+}
+
+// encryptEntries copies a map and encrypts the values of the encrypted fields.
+func (dbc *DBCommon) encryptEntries(data map[string]interface{}, fields map[string]reflect.StructField) (map[string]interface{}, error) {
+	encrypted := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if field, ok := fields[key]; ok {
+			var err error
+			if value, err = dbc.encryptValue(field, value); err != nil {
+				return nil, err
+			}
+		}
+		encrypted[key] = value
+	}
+	return encrypted, nil
+}
+
+// encryptDocument returns a copy of a MongoDB document with the values of the encrypted fields of the model
+// encrypted, e.g. the $set document of an update.
+//
+// Parameters:
+// - document: The document.
+// - modelType: The type of the model declaring the encrypted fields.
+//
+// Returns:
+// - primitive.D: The document with the encrypted values.
+// - error: An error if a value can't be encrypted.
+func (dbc *DBCommon) encryptDocument(document primitive.D, modelType reflect.Type) (primitive.D, error) {
+	fields := dbc.encryptedFields(modelType)
+	if len(fields) == 0 {
+		return document, nil
+	}
+	encrypted := make(primitive.D, len(document))
+	for i, element := range document {
+		if field, ok := fields[element.Key]; ok {
+			value, err := dbc.encryptValue(field, element.Value)
+			if err != nil {
+				return nil, err
+			}
+			element.Value = value
+		}
+		encrypted[i] = element
+	}
+	return encrypted, nil
+}
+
+// encryptFilter encrypts the values of the deterministic fields of a MongoDB filter built from a struct. Fields
+// encrypted randomly are left out, as their ciphertexts can't be matched.
+//
+// Parameters:
+// - filter: The filter.
+// - modelType: The type of the model declaring the encrypted fields.
+//
+// Returns:
+// - primitive.D: The filter matching the ciphertexts.
+// - error: An error if a value can't be encrypted.
+func (dbc *DBCommon) encryptFilter(filter primitive.D, modelType reflect.Type) (primitive.D, error) {
+	fields := dbc.encryptedFields(modelType)
+	if len(fields) == 0 {
+		return filter, nil
+	}
+	encrypted := primitive.D{}
+	for _, element := range filter {
+		if field, ok := fields[element.Key]; ok {
+			if deterministic, _ := dbc.encryptionMode(field); !deterministic {
+				continue
+			}
+			value, err := dbc.encryptValue(field, element.Value)
+			if err != nil {
+				return nil, err
+			}
+			element.Value = value
+		}
+		encrypted = append(encrypted, element)
+	}
+	return encrypted, nil
+}
+
+// indexValue returns the value of a field in the cache indexes of a record. Deterministic fields are indexed by
+// their ciphertext, the value looked up with Where, whether the record holds its ciphertext or its plaintext.
+//
+// Parameters:
+// - model: The model of the entity.
+// - name: The name of the field in the database.
+// - value: The value of the field.
+//
+// Returns:
+// - interface{}: The indexed value.
+func (dbc *DBCommon) indexValue(model interface{}, name string, value interface{}) interface{} {
+	field, ok := dbc.encryptedFields(reflect.TypeOf(model))[name]
+	if !ok {
+		return value
+	}
+	if deterministic, _ := dbc.encryptionMode(field); !deterministic {
+		return value
+	}
+	encrypted, err := dbc.encryptValue(field, value)
+	if err != nil {
+		return value
+	}
+	return encrypted
+}
+
+// decryptValues returns a copy of the values of a record with its encrypted fields decrypted, the values which can't
+// be decrypted are kept.
+//
+// Parameters:
+// - model: The model of the entity.
+// - values: The values of the record, nil for none.
+//
+// Returns:
+// - map[string]interface{}: The values with the plaintexts of the encrypted fields.
+func (dbc *DBCommon) decryptValues(model interface{}, values map[string]interface{}) map[string]interface{} {
+	fields := dbc.encryptedFields(reflect.TypeOf(model))
+	if len(fields) == 0 || values == nil {
+		return values
+	}
+	decrypted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if _, encrypted := fields[key]; encrypted {
+			value = dbc.decryptValue(value)
+		}
+		decrypted[key] = value
+	}
+	return decrypted
+}
+
+// decryptValue decrypts a value read from an encrypted field, values which can't be decrypted are kept.
+func (dbc *DBCommon) decryptValue(value interface{}) interface{} {
+	if ciphertext, ok := value.(string); ok {
+		if plaintext, err := encryption.GetInstance().Decrypt(dbc.keyProvider, ciphertext); err == nil {
+			return plaintext
+		}
+	}
+	return value
+}
+
+// encryptChanges encrypts the values of the encrypted fields in the changes of an audit entry, so that the audit
+// trail doesn't hold their plaintexts. Values which can't be encrypted are left out.
+//
+// Parameters:
+// - model: The model of the entity.
+// - changes: The changes of the entry, updated in place.
+func (dbc *DBCommon) encryptChanges(model interface{}, changes map[string]audit.Change) {
+	for key, field := range dbc.encryptedFields(reflect.TypeOf(model)) {
+		change, ok := changes[key]
+		if !ok {
+			continue
+		}
+		if encrypted, err := dbc.encryptValue(field, change.Before); err == nil && change.Before != nil {
+			change.Before = encrypted
+		} else {
+			change.Before = nil
+		}
+		if encrypted, err := dbc.encryptValue(field, change.After); err == nil && change.After != nil {
+			change.After = encrypted
+		} else {
+			change.After = nil
+		}
+		changes[key] = change
+	}
+}
+
+// encryptedCopy returns a copy of a record with its encrypted fields encrypted, which is what the cache holds, so
+// that the plaintexts of the fields never reach the cache. Records without encrypted fields are returned as they are.
+//
+// Parameters:
+// - record: A struct or a pointer to a struct, e.g. the result of an update.
+//
+// Returns:
+// - interface{}: The copy of the struct with the ciphertexts, or the record.
+// - error: An error if a value can't be encrypted.
+func (dbc *DBCommon) encryptedCopy(record interface{}) (interface{}, error) {
+	value := reflect.ValueOf(record)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return record, nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct || len(dbc.encryptedFields(value.Type())) == 0 {
+		return record, nil
+	}
+
+	// Encrypt the fields of a copy, the caller keeps the plaintexts.
+	encrypted := reflect.New(value.Type()).Elem()
+	encrypted.Set(value)
+	for i := 0; i < encrypted.NumField(); i++ {
+		field := encrypted.Field(i)
+		if _, ok := dbc.encryptionMode(encrypted.Type().Field(i)); !ok || !field.CanSet() {
+			continue
+		}
+		ciphertext, err := dbc.encryptValue(encrypted.Type().Field(i), field.String())
+		if err != nil {
+			return nil, err
+		}
+		field.SetString(ciphertext.(string))
+	}
+	return encrypted.Interface(), nil
+}
+
+// decryptResult decrypts in place the encrypted fields of records read from the database or the cache.
+//
+// Parameters:
+// - result: A pointer to a struct, or to a slice of structs or of pointers to structs.
+//
+// Returns:
+// - error: An error if a value can't be decrypted.
+func (dbc *DBCommon) decryptResult(result interface{}) error {
+	value := reflect.ValueOf(result)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() == reflect.Slice {
+		for i := 0; i < value.Len(); i++ {
+			element := value.Index(i)
+			if element.Kind() == reflect.Ptr {
+				if element.IsNil() {
+					continue
+				}
+				element = element.Elem()
+			}
+			if err := dbc.decryptStruct(element); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return dbc.decryptStruct(value)
+}
+
+// decryptStruct decrypts in place the encrypted fields of a struct. Values which are not ciphertexts are kept.
+//
+// Parameters:
+// - value: The addressable value of the struct.
+//
+// Returns:
+// - error: An error if a value can't be decrypted.
+func (dbc *DBCommon) decryptStruct(value reflect.Value) error {
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if _, ok := dbc.encryptionMode(value.Type().Field(i)); !ok || !field.CanSet() {
+			continue
+		}
+		plaintext, err := encryption.GetInstance().Decrypt(dbc.keyProvider, field.String())
+		if err != nil {
+			return err
+		}
+		field.SetString(plaintext)
+	}
+	return nil
+}
@@ -0,0 +1,419 @@
+package implementations
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/hooks"
+	"github.com/glodb/dbfusion/metadata"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fields added to the records of history entities.
+const (
+	historySuffix    = "_history"  // Appended to the name of an entity to name its history.
+	historyValidFrom = "validFrom" // The time the version became current, NULL if it is unknown.
+	historyValidTo   = "validTo"   // The time the version was replaced or deleted.
+	historyEntityID  = "entityId"  // The _id of a MongoDB document, the history documents have their own _id.
+	historyMatches   = "__history" // The versions joined to the documents by the lookups of AsOf.
+)
+
+// useHistory reports whether the previous versions of the records of the data are kept in a history entity.
+//
+// Parameters:
+// - data: The data being written.
+//
+// Returns:
+// - bool: true if the data implements hooks.Historical and asks for a history.
+func (dbc *DBCommon) useHistory(data interface{}) bool {
+	value, ok := interface{}(data).(hooks.Historical)
+	return ok && value.UseHistory()
+}
+
+// historyEntityName returns the name of the history table or collection of an entity.
+func (dbc *DBCommon) historyEntityName(entityName string) string {
+	return entityName + historySuffix
+}
+
+// modelColumns returns the names of the fields of a model in the database.
+//
+// Parameters:
+// - modelType: The type of the model, pointers and slices are dereferenced.
+//
+// Returns:
+// - []string: The names of the fields, shared by every caller.
+func (dbc *DBCommon) modelColumns(modelType reflect.Type) []string {
+	model, ok := metadata.GetInstance().Model(modelType)
+	if !ok {
+		return make([]string, 0)
+	}
+	return model.ColumnNames
+}
+
+// createdBefore returns the value of the autoCreateTime field of a model at a moment, which leaves out of AsOf the
+// records inserted after it.
+//
+// Parameters:
+// - modelType: The type of the model, pointers and slices are dereferenced.
+// - asOf: The moment read.
+//
+// Returns:
+// - string: The name of the field.
+// - interface{}: The moment converted to the type of the field.
+// - bool: true if the model has an autoCreateTime field.
+func (dbc *DBCommon) createdBefore(modelType reflect.Type, asOf time.Time) (string, interface{}, bool) {
+	name, field, ok := dbc.fieldWithOption(modelType, autoCreateTimeOption)
+	if !ok {
+		return "", nil, false
+	}
+	value, ok := dbc.autoTimestamp(field, asOf, autoCreateTimeOption)
+	if !ok {
+		return "", nil, false
+	}
+	return name, value.Interface(), true
+}
+
+// createHistoryTableQuery generates the CREATE TABLE query of the history table of a model. The columns of the model
+// lose their keys, since a record has many versions, and the table gets its own key and the validity of the versions.
+//
+// Parameters:
+// - data: The model, a struct with the column definitions in its dbfusion tags.
+// - ifNotExist: Adds IF NOT EXISTS to the query.
+//
+// Returns:
+// - string: The query.
+// - error: ErrInvalidType if data isn't a struct, or ErrPrimaryKeyNotFound if the model has no primary key.
+func (sb *SqlBase) createHistoryTableQuery(data interface{}, ifNotExist bool) (string, error) {
+	// Get the entity name and the primary key of the model.
+	name, err := sb.getEntityName(data)
+	if err != nil {
+		return "", err
+	}
+	if name.dataType.Kind() != reflect.Struct {
+		return "", dbfusionErrors.ErrInvalidType
+	}
+	key, err := sb.modelPrimaryKey(data)
+	if err != nil {
+		return "", err
+	}
+
+	columns := []string{"historyId BIGINT AUTO_INCREMENT PRIMARY KEY"}
+	for _, column := range sb.sqlColumns(name.dataType) {
+		// Keep the type of the column and leave out its keys.
+		column.PrimaryKey, column.AutoIncrement, column.Unique, column.References = false, false, false, nil
+		columns = append(columns, sb.sqlDialect().ColumnDefinition(column))
+	}
+	columns = append(columns,
+		historyValidFrom+" DATETIME(6) NULL",
+		historyValidTo+" DATETIME(6) NOT NULL",
+		fmt.Sprintf("INDEX (%s, %s)", key.name, historyValidTo))
+
+	historyName := sb.historyEntityName(name.entityName)
+	sb.traceEntity(historyName)
+	query := "CREATE TABLE "
+	if ifNotExist {
+		query += "IF NOT EXISTS "
+	}
+	return query + historyName + " (" + strings.Join(columns, ",") + ");", nil
+}
+
+// writeHistory copies the previous version of a record to the history table, in the transaction of the running
+// write if there is one. The version is valid from the end of the version before it, if there is one.
+//
+// Parameters:
+// - ctx: The context of the operation.
+// - entityName: The name of the table.
+// - model: The model of the entity.
+// - previous: The values of the record before the write.
+// - now: The time of the write, which ends the validity of the version.
+//
+// Returns:
+// - error: An error if the version can't be written.
+func (ms *MySql) writeHistory(ctx context.Context, entityName string, model interface{}, previous map[string]interface{}, now time.Time) error {
+	historyName := ms.historyEntityName(entityName)
+
+	// Find the end of the version before the previous one.
+	var validFrom interface{}
+	if key, ok := ms.primaryKey(reflect.TypeOf(model)); ok {
+		if id, ok := previous[key.name]; ok {
+			query := fmt.Sprintf("SELECT MAX(%s) FROM %s WHERE %s = ?", historyValidTo, historyName, key.name)
+			rows, err := ms.executor().QueryContext(ctx, query, id)
+			if err != nil {
+				return err
+			}
+			if rows.Next() {
+				err = rows.Scan(&validFrom)
+			}
+			rows.Close()
+			if err != nil {
+				return err
+			}
+			if bytes, ok := validFrom.([]byte); ok {
+				validFrom = string(bytes)
+			}
+		}
+	}
+
+	// Copy the values of the version, the encrypted fields are read decrypted and written encrypted again.
+	encrypted := ms.encryptedFields(reflect.TypeOf(model))
+	columns := make([]string, 0, len(previous)+2)
+	placeholders := make([]string, 0, len(previous)+2)
+	values := make([]interface{}, 0, len(previous)+2)
+	for _, column := range ms.modelColumns(reflect.TypeOf(model)) {
+		if value, ok := previous[column]; ok {
+			if field, ok := encrypted[column]; ok {
+				var err error
+				if value, err = ms.encryptValue(field, value); err != nil {
+					return err
+				}
+			}
+			columns = append(columns, column)
+			placeholders = append(placeholders, "?")
+			values = append(values, value)
+		}
+	}
+	columns = append(columns, historyValidFrom, historyValidTo)
+	placeholders = append(placeholders, "?", "?")
+	values = append(values, validFrom, now)
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", historyName, strings.Join(columns, ","), strings.Join(placeholders, ","))
+	_, err := ms.executor().ExecContext(ctx, query, values...)
+	return err
+}
+
+// asOfSource returns the derived table AsOf reads instead of a table: the versions of the history valid at the
+// moment, and the current records which were not replaced since then. It is aliased with the name of the table so
+// that the conditions of the query keep working.
+//
+// Parameters:
+// - entityName: The name of the table.
+// - model: The model of the entity, which must have a primary key.
+//
+// Returns:
+// - string: The derived table.
+// - []interface{}: The values of its placeholders, which come before the values of the query.
+// - error: ErrPrimaryKeyNotFound if the model has no primary key.
+func (ms *MySql) asOfSource(entityName string, model interface{}) (string, []interface{}, error) {
+	key, err := ms.modelPrimaryKey(model)
+	if err != nil {
+		return "", nil, err
+	}
+	asOf := *ms.asOf
+	historyName := ms.historyEntityName(entityName)
+	columns := strings.Join(ms.modelColumns(reflect.TypeOf(model)), ",")
+
+	// The versions of the history valid at the moment. The first version of a record has no start, it is bounded by
+	// the creation time of the record like the current records.
+	name, created, bounded := ms.createdBefore(reflect.TypeOf(model), asOf)
+	unknownStart := fmt.Sprintf("%s IS NULL", historyValidFrom)
+	values := []interface{}{}
+	if bounded {
+		unknownStart = fmt.Sprintf("(%s AND %s <= ?)", unknownStart, name)
+		values = append(values, created)
+	}
+	history := fmt.Sprintf("SELECT %s FROM %s WHERE (%s OR %s <= ?) AND %s > ?",
+		columns, historyName, unknownStart, historyValidFrom, historyValidTo)
+	values = append(values, asOf, asOf)
+
+	// The current records, unless a version of the history was still valid at the moment.
+	current := fmt.Sprintf("SELECT %s FROM %s AS live WHERE NOT EXISTS (SELECT 1 FROM %s AS past WHERE past.%s = live.%s AND past.%s > ?)",
+		columns, entityName, historyName, key.name, key.name, historyValidTo)
+	values = append(values, asOf)
+	if bounded {
+		current = fmt.Sprintf("%s AND live.%s <= ?", current, name)
+		values = append(values, created)
+	}
+
+	return fmt.Sprintf("(%s UNION ALL %s) AS %s", history, current, entityName), values, nil
+}
+
+// findSource returns the table the next find reads from: the table of the entity, or the derived table of AsOf.
+//
+// Parameters:
+// - entityName: The name of the table.
+// - model: The model of the entity.
+// - values: The values of the query.
+//
+// Returns:
+// - string: The table to read from.
+// - []interface{}: The values of the query, led by the values of the derived table.
+// - error: ErrPrimaryKeyNotFound if AsOf is set and the model has no primary key.
+func (ms *MySql) findSource(entityName string, model interface{}, values []interface{}) (string, []interface{}, error) {
+	if ms.asOf == nil {
+		return entityName, values, nil
+	}
+	source, sourceValues, err := ms.asOfSource(entityName, model)
+	if err != nil {
+		return "", nil, err
+	}
+	return source, append(sourceValues, values...), nil
+}
+
+// writeHistory copies the previous version of a document to the history collection, in the transaction of the
+// running write if there is one. The version is valid from the end of the version before it, if there is one.
+//
+// Parameters:
+// - ctx: The context of the operation, the session context while running in a transaction.
+// - entityName: The name of the collection.
+// - model: The model of the entity.
+// - previous: The document before the write.
+// - now: The time of the write, which ends the validity of the version.
+//
+// Returns:
+// - error: An error if the version can't be written.
+func (mc *MongoConnection) writeHistory(ctx context.Context, entityName string, model interface{}, previous primitive.M, now time.Time) error {
+	collection := mc.client.Database(mc.currentDB).Collection(mc.historyEntityName(entityName))
+
+	// The history documents have their own _id, the _id of the document is kept as entityId.
+	version := primitive.M{}
+	for key, value := range previous {
+		version[key] = value
+	}
+	version[historyEntityID] = previous["_id"]
+	delete(version, "_id")
+
+	// Find the end of the version before the previous one.
+	keyName := mc.historyKeyName(model)
+	var last struct {
+		ValidTo time.Time `bson:"validTo"`
+	}
+	opts := options.FindOne().SetSort(primitive.D{{Key: historyValidTo, Value: -1}}).SetProjection(primitive.D{{Key: historyValidTo, Value: 1}})
+	err := collection.FindOne(ctx, primitive.D{{Key: keyName, Value: version[keyName]}}, opts).Decode(&last)
+	if err == nil {
+		version[historyValidFrom] = last.ValidTo
+	} else {
+		version[historyValidFrom] = nil
+	}
+	version[historyValidTo] = now
+
+	_, err = collection.InsertOne(ctx, version)
+	return err
+}
+
+// recordDeletion copies the last version of a deleted document to the history and records the deletion in the audit
+// trail once it succeeded.
+//
+// Parameters:
+// - ctx: The context of the operation.
+// - err: The error of the deletion.
+// - entityName: The name of the collection.
+// - data: The deleted data.
+// - deleted: The document before the deletion.
+//
+// Returns:
+// - error: The error of the deletion, or of the history and the audit entry.
+func (mc *MongoConnection) recordDeletion(ctx context.Context, err error, entityName string, data interface{}, deleted primitive.M) error {
+	if err == nil && mc.useHistory(data) {
+		err = mc.writeHistory(ctx, entityName, data, deleted, time.Now())
+	}
+	return mc.auditDeletion(ctx, err, entityName, data, deleted)
+}
+
+// historyKeyName returns the field of the history documents holding the primary key of a model, entityId when the
+// primary key is the _id.
+func (mc *MongoConnection) historyKeyName(model interface{}) string {
+	if key, ok := mc.primaryKey(reflect.TypeOf(model)); ok && key.name != "_id" {
+		return key.name
+	}
+	return historyEntityID
+}
+
+// asOfPipeline returns the aggregation pipeline AsOf runs on the history collection: the versions valid at the
+// moment, followed by the current documents which were not replaced since then, filtered by the query.
+//
+// Parameters:
+// - entityName: The name of the collection.
+// - model: The model of the entity.
+// - query: The filter of the operation.
+//
+// Returns:
+// - primitive.A: The pipeline, without sort, skip and limit.
+func (mc *MongoConnection) asOfPipeline(entityName string, model interface{}, query primitive.D) primitive.A {
+	asOf := *mc.asOf
+	keyName := "_id"
+	if key, ok := mc.primaryKey(reflect.TypeOf(model)); ok {
+		keyName = key.name
+	}
+
+	// The current documents, unless a version of the history was still valid at the moment.
+	current := primitive.A{}
+	name, created, bounded := mc.createdBefore(reflect.TypeOf(model), asOf)
+	if bounded {
+		current = append(current, primitive.D{{Key: "$match", Value: primitive.D{{Key: name, Value: primitive.D{{Key: "$lte", Value: created}}}}}})
+	}
+	current = append(current,
+		primitive.D{{Key: "$lookup", Value: primitive.D{
+			{Key: "from", Value: mc.historyEntityName(entityName)},
+			{Key: "let", Value: primitive.D{{Key: "key", Value: "$" + keyName}}},
+			{Key: "pipeline", Value: primitive.A{
+				primitive.D{{Key: "$match", Value: primitive.D{{Key: "$expr", Value: primitive.D{{Key: "$and", Value: primitive.A{
+					primitive.D{{Key: "$eq", Value: primitive.A{"$" + mc.historyKeyName(model), "$$key"}}},
+					primitive.D{{Key: "$gt", Value: primitive.A{"$" + historyValidTo, asOf}}},
+				}}}}}}},
+				primitive.D{{Key: "$limit", Value: 1}},
+			}},
+			{Key: "as", Value: historyMatches},
+		}}},
+		primitive.D{{Key: "$match", Value: primitive.D{{Key: historyMatches, Value: primitive.D{{Key: "$size", Value: 0}}}}}},
+		primitive.D{{Key: "$project", Value: primitive.D{{Key: historyMatches, Value: 0}}}},
+	)
+
+	// The versions of the history valid at the moment, with the _id of their document. The first version of a
+	// document has no start, it is bounded by the creation time of the document like the current documents.
+	unknownStart := primitive.D{{Key: historyValidFrom, Value: nil}}
+	if bounded {
+		unknownStart = append(unknownStart, primitive.E{Key: name, Value: primitive.D{{Key: "$lte", Value: created}}})
+	}
+	pipeline := primitive.A{
+		primitive.D{{Key: "$match", Value: primitive.D{
+			{Key: "$or", Value: primitive.A{
+				unknownStart,
+				primitive.D{{Key: historyValidFrom, Value: primitive.D{{Key: "$lte", Value: asOf}}}},
+			}},
+			{Key: historyValidTo, Value: primitive.D{{Key: "$gt", Value: asOf}}},
+		}}},
+		primitive.D{{Key: "$addFields", Value: primitive.D{{Key: "_id", Value: "$" + historyEntityID}}}},
+		primitive.D{{Key: "$project", Value: primitive.D{{Key: historyEntityID, Value: 0}, {Key: historyValidFrom, Value: 0}, {Key: historyValidTo, Value: 0}}}},
+		primitive.D{{Key: "$unionWith", Value: primitive.D{{Key: "coll", Value: entityName}, {Key: "pipeline", Value: current}}}},
+	}
+	if len(query) != 0 {
+		pipeline = append(pipeline, primitive.D{{Key: "$match", Value: query}})
+	}
+	return pipeline
+}
+
+// findAsOf reads the documents of a collection as they were at the moment set with AsOf.
+//
+// Parameters:
+// - entityName: The name of the collection.
+// - model: The model of the entity.
+// - query: The filter of the operation.
+// - limit: The maximum number of documents, 0 for no limit.
+//
+// Returns:
+// - *mongo.Cursor: The cursor over the documents.
+// - error: An error if the aggregation fails.
+func (mc *MongoConnection) findAsOf(entityName string, model interface{}, query primitive.D, limit int64) (*mongo.Cursor, error) {
+	pipeline := mc.asOfPipeline(entityName, model, query)
+	if mc.sort != nil {
+		pipeline = append(pipeline, primitive.D{{Key: "$sort", Value: mc.sort}})
+	}
+	if mc.skip != 0 {
+		pipeline = append(pipeline, primitive.D{{Key: "$skip", Value: mc.skip}})
+	}
+	if limit != 0 {
+		pipeline = append(pipeline, primitive.D{{Key: "$limit", Value: limit}})
+	}
+	if mc.projection != nil {
+		pipeline = append(pipeline, primitive.D{{Key: "$project", Value: mc.projection}})
+	}
+
+	mc.traceQuery(pipeline)
+	return mc.client.Database(mc.currentDB).Collection(mc.historyEntityName(entityName)).Aggregate(mc.getContext(), pipeline)
+}
@@ -0,0 +1,142 @@
+package implementations
+
+import (
+	"strings"
+
+	"github.com/glodb/dbfusion/hooks"
+)
+
+// Kinds of the indexes declared by the index hooks of the models.
+const (
+	normalIndex        = "normal"   // Declared by hooks.NormalIndexes.
+	uniqueIndex        = "unique"   // Declared by hooks.UniqueIndexes.
+	textIndex          = "text"     // Declared by hooks.TextIndexes.
+	twoDIndex          = "2d"       // Declared by hooks.TwoDimensionalIndexes.
+	twoDSphereIndex    = "2dsphere" // Declared by hooks.TwoDimensionalSpatialIndexes.
+	hashedIndex        = "hashed"   // Declared by hooks.HashedIndexes.
+	sparseIndex        = "sparse"   // Declared by hooks.SparseIndexes.
+	specIndex          = "spec"     // Declared by hooks.IndexSpecs.
+	indexNameSeparator = "="        // Separates the name of an index from its keys, e.g. "byEmail=email:1,phone:-1".
+)
+
+// indexKey is a key of an index, a field and its sort direction or its type.
+type indexKey struct {
+	field      string
+	descending bool
+	keyType    string // The type of a key which is not sorted, e.g. "text" or "2dsphere".
+}
+
+// indexDefinition is an index declared by an index hook of a model.
+type indexDefinition struct {
+	name   string           // The name of the index, empty to let the database name it.
+	kind   string           // The kind of the index, the hook declaring it.
+	keys   []indexKey       // The keys of the index, more than one for a compound index.
+	unique bool             // true for a unique index.
+	sparse bool             // true for a sparse index.
+	spec   *hooks.IndexSpec // The options of an index declared by hooks.IndexSpecs, nil for the other hooks.
+}
+
+// fields returns the fields of the keys of the index.
+func (index indexDefinition) fields() []string {
+	fields := make([]string, 0, len(index.keys))
+	for _, key := range index.keys {
+		fields = append(fields, key.field)
+	}
+	return fields
+}
+
+// parseIndexDefinition reads an index written in an index hook, the keys separated by commas with an optional sort
+// direction or type, and an optional name before an equal sign.
+//
+// Parameters:
+// - index: The index, e.g. "email:1,phone:-1" or "byEmail=email:1,phone:-1".
+// - kind: The kind of the index.
+//
+// Returns:
+// - indexDefinition: The index, without keys if the index is empty.
+//
+// Example:
+//
+//	index := dbc.parseIndexDefinition("byEmail=email:1,phone:-1", normalIndex)
+func (dbc *DBCommon) parseIndexDefinition(index string, kind string) indexDefinition {
+	definition := indexDefinition{kind: kind, keys: make([]indexKey, 0), unique: kind == uniqueIndex, sparse: kind == sparseIndex}
+
+	// Split the name from the keys.
+	if name, keys, ok := strings.Cut(index, indexNameSeparator); ok {
+		definition.name = strings.TrimSpace(name)
+		index = keys
+	}
+
+	// Read the fields and their directions, ascending unless -1, or their types.
+	for _, key := range strings.Split(index, ",") {
+		field, direction, _ := strings.Cut(strings.TrimSpace(key), ":")
+		if field == "" {
+			continue
+		}
+		direction = strings.TrimSpace(direction)
+		parsed := indexKey{field: field, descending: direction == "-1"}
+		if direction != "" && direction != "1" && direction != "-1" {
+			parsed.keyType = direction
+		}
+		definition.keys = append(definition.keys, parsed)
+	}
+	return definition
+}
+
+// indexDefinitions returns the indexes declared by the index hooks a model implements, in the order of the hooks.
+//
+// Parameters:
+// - data: The model.
+//
+// Returns:
+// - []indexDefinition: The indexes, empty if the model implements no index hook.
+func (dbc *DBCommon) indexDefinitions(data interface{}) []indexDefinition {
+	declared := make(map[string][]string)
+	if val, ok := data.(hooks.NormalIndexes); ok {
+		declared[normalIndex] = val.GetNormalIndexes()
+	}
+	if val, ok := data.(hooks.UniqueIndexes); ok {
+		declared[uniqueIndex] = val.GetUniqueIndexes()
+	}
+	if val, ok := data.(hooks.TextIndexes); ok && val.GetTextIndex() != "" {
+		declared[textIndex] = []string{val.GetTextIndex()}
+	}
+	if val, ok := data.(hooks.TwoDimensionalIndexes); ok {
+		declared[twoDIndex] = val.Get2DIndexes()
+	}
+	if val, ok := data.(hooks.TwoDimensionalSpatialIndexes); ok {
+		declared[twoDSphereIndex] = val.Get2DSpatialIndexes()
+	}
+	if val, ok := data.(hooks.HashedIndexes); ok {
+		declared[hashedIndex] = val.GetHashedIndexes()
+	}
+	if val, ok := data.(hooks.SparseIndexes); ok {
+		declared[sparseIndex] = val.GetSparseIndexes()
+	}
+
+	definitions := make([]indexDefinition, 0)
+	for _, kind := range []string{normalIndex, uniqueIndex, textIndex, twoDIndex, twoDSphereIndex, hashedIndex, sparseIndex} {
+		for _, index := range declared[kind] {
+			if definition := dbc.parseIndexDefinition(index, kind); len(definition.keys) > 0 {
+				definitions = append(definitions, definition)
+			}
+		}
+	}
+
+	// Add the indexes specified with their options.
+	if val, ok := data.(hooks.IndexSpecs); ok {
+		for _, spec := range val.GetIndexSpecs() {
+			spec := spec
+			definition := dbc.parseIndexDefinition(spec.Keys, specIndex)
+			if len(definition.keys) == 0 {
+				continue
+			}
+			if spec.Name != "" {
+				definition.name = spec.Name
+			}
+			definition.unique, definition.sparse, definition.spec = spec.Unique, spec.Sparse, &spec
+			definitions = append(definitions, definition)
+		}
+	}
+	return definitions
+}
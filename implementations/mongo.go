@@ -2,13 +2,14 @@ package implementations
 
 import (
 	"context"
-	"log"
 	"math"
-	"strconv"
 	"strings"
+	"time"
 
+	"github.com/glodb/dbfusion/audit"
 	"github.com/glodb/dbfusion/conditions"
 	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
 	"github.com/glodb/dbfusion/ftypes"
 	"github.com/glodb/dbfusion/hooks"
 	"github.com/glodb/dbfusion/queryoptions"
@@ -139,6 +140,9 @@ func (mc *MongoConnection) Connect(uri string) error {
 	// Set the connected client in the MongoConnection struct for future use.
 	mc.client = client
 
+	// Keep the addresses of the servers, which namespace the write-behind queues.
+	mc.server = strings.Join(clientOptions.Hosts, ",")
+
 	// Return nil, indicating a successful connection.
 	return nil
 }
@@ -189,6 +193,13 @@ func (mc *MongoConnection) Table(tablename string) connections.MongoConnection {
 // is passed as a pointer to the method. Any errors encountered during insertion are
 // returned as an error value.
 func (mc *MongoConnection) InsertOne(data interface{}) error {
+	return mc.intercept(connections.OpInsertOne, func() error {
+		return mc.insertOne(data)
+	})
+}
+
+// insertOne runs InsertOne as the last handler of the middleware chain.
+func (mc *MongoConnection) insertOne(data interface{}) error {
 	// Defer the resetting of connection state to ensure cleanup even if an error occurs.
 	defer mc.refreshValues()
 
@@ -199,14 +210,34 @@ func (mc *MongoConnection) InsertOne(data interface{}) error {
 		return err
 	}
 
-	// Use the MongoDB client to insert the document into the specified collection.
-	_, err = mc.client.Database(mc.currentDB).Collection(preCreateData.entityName).InsertOne(context.TODO(), preCreateData.mData)
-
-	if err == nil {
-		// Handle any post-insertion operations, such as caching.
-		err = mc.postInsert(mc.cache, preCreateData.Data, preCreateData.mData, mc.currentDB, preCreateData.entityName)
+	// Write-behind entities are cached and queued, the worker writes them to the collection later on.
+	if mc.getCachePolicy(data).Has(hooks.WriteBehind) {
+		return mc.insertWriteBehind("mongo", mc.writeQueuedRecord, preCreateData)
 	}
-	return err
+
+	// Insert the document and run the AfterInsert hook, in a transaction if the data asks for one,
+	// then handle any post-insertion operations, such as caching.
+	return mc.runWrite(preCreateData.Data, mc.beginTransaction,
+		func(ctx context.Context) error {
+			mc.traceQuery(preCreateData.mData)
+			insertResult, err := mc.client.Database(mc.currentDB).Collection(preCreateData.entityName).InsertOne(ctx, preCreateData.mData)
+			if err != nil || !mc.useAudit(preCreateData.Data) {
+				return err
+			}
+
+			// Record the insertion in the audit trail, identified by the _id generated by the driver without a key.
+			entry := mc.auditEntry(ctx, preCreateData.entityName, audit.Insert, preCreateData.Data, nil, preCreateData.mData)
+			if entry.EntityID == nil {
+				entry.EntityID = insertResult.InsertedID
+			}
+			return mc.writeAudit(ctx, entry)
+		},
+		func(ctx context.Context) error {
+			return mc.afterInsert(ctx, preCreateData.Data)
+		},
+		func() error {
+			return mc.postInsert(mc.cache, preCreateData.Data, preCreateData.mData, mc.currentDB, preCreateData.entityName)
+		})
 }
 
 // FindOne retrieves a single document from the specified MongoDB collection based on the provided query conditions.
@@ -231,6 +262,13 @@ func (mc *MongoConnection) InsertOne(data interface{}) error {
 // In the above example, the 'FindOne' method is used to retrieve a single document from the "users" collection
 // where the "name" field matches "Alice". The retrieved data is decoded into the 'user' variable.
 func (mc *MongoConnection) FindOne(result interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
+	return mc.intercept(connections.OpFindOne, func() error {
+		return mc.findOne(result, dbFusionOptions...)
+	})
+}
+
+// findOne runs FindOne as the last handler of the middleware chain.
+func (mc *MongoConnection) findOne(result interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
 	// Defer the resetting of connection state to ensure cleanup even if an error occurs.
 	defer mc.refreshValues()
 
@@ -248,12 +286,36 @@ func (mc *MongoConnection) FindOne(result interface{}, dbFusionOptions ...queryo
 		mc.whereQuery = &conditions.MongoData{}
 	}
 
+	// Leave out the soft-deleted documents unless they are asked for.
+	whereData := mc.whereQuery.(conditions.DBFusionData)
+	whereData.SetQuery(mc.scopeMongoQuery(result, mc.deletedScope, whereData.GetQuery().(primitive.D)))
+
 	// Prepare for pre-find operations and retrieve pre-find data.
 	prefindReturn, err := mc.preFind(mc.cache, result, dbFusionOptions...)
 	if err != nil {
 		return err
 	}
 
+	// Read the document as it was at the moment set with AsOf from the history.
+	if mc.asOf != nil {
+		query, _ := prefindReturn.query.(primitive.D)
+		cursor, err := mc.findAsOf(prefindReturn.entityName, result, query, 1)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(mc.getContext())
+		if !cursor.Next(mc.getContext()) {
+			if err := cursor.Err(); err != nil {
+				return err
+			}
+			return mongo.ErrNoDocuments
+		}
+		if err := cursor.Decode(result); err != nil {
+			return err
+		}
+		return mc.decryptResult(result)
+	}
+
 	// Check if the query should be executed against the database.
 	if prefindReturn.queryDatabase {
 		// Create options for the FindOne operation, including projection, skip, and sort.
@@ -269,12 +331,24 @@ func (mc *MongoConnection) FindOne(result interface{}, dbFusionOptions ...queryo
 		}
 
 		// Execute the FindOne operation to retrieve a single document.
-		err = mc.client.Database(mc.currentDB).Collection(prefindReturn.entityName).FindOne(context.TODO(), prefindReturn.query, &opts).Decode(result)
+		mc.traceQuery(prefindReturn.query)
+		err = mc.client.Database(mc.currentDB).Collection(prefindReturn.entityName).FindOne(mc.getContext(), prefindReturn.query, &opts).Decode(result)
+		if err != nil {
+			return err
+		}
+
+		// Cache the document read from the database for read-through entities.
+		err = mc.readThrough(mc.cache, result, prefindReturn.entityName)
 		if err != nil {
 			return err
 		}
 	}
 
+	// Decrypt the fields with the encrypt option, of the document read from the database or the cache.
+	if err := mc.decryptResult(result); err != nil {
+		return err
+	}
+
 	// Handle any post-find operations, such as caching.
 	err = mc.postFind(mc.cache, result, prefindReturn.entityName, dbFusionOptions...)
 	return err
@@ -303,6 +377,13 @@ func (mc *MongoConnection) FindOne(result interface{}, dbFusionOptions ...queryo
 // In the above example, the 'UpdateAndFindOne' method is used to update a document in the "users" collection where
 // the "name" field matches "Alice". The updated document is decoded into the 'updatedUser' variable, and if it doesn't exist, it is inserted.
 func (mc *MongoConnection) UpdateAndFindOne(data interface{}, result interface{}, upsert bool) error {
+	return mc.intercept(connections.OpUpdateAndFindOne, func() error {
+		return mc.updateAndFindOne(data, result, upsert)
+	})
+}
+
+// updateAndFindOne runs UpdateAndFindOne as the last handler of the middleware chain.
+func (mc *MongoConnection) updateAndFindOne(data interface{}, result interface{}, upsert bool) error {
 	// Defer the resetting of connection state to ensure cleanup even if an error occurs.
 	defer mc.refreshValues()
 
@@ -320,8 +401,11 @@ func (mc *MongoConnection) UpdateAndFindOne(data interface{}, result interface{}
 		fusionQuery = &conditions.MongoData{}
 	}
 
+	// Soft-deleted documents are only updated when WithDeleted or OnlyDeleted selects them.
+	fusionQuery.SetQuery(mc.scopeMongoQuery(result, mc.deletedScope, fusionQuery.GetQuery().(primitive.D)))
+
 	// Prepare for pre-update operations and retrieve pre-update data.
-	preUpdateReturn, err := mc.preUpdate(data, connections.MONGO)
+	preUpdateReturn, err := mc.preUpdate(data, connections.MONGO, result, upsert)
 	if err != nil {
 		return err
 	}
@@ -337,50 +421,148 @@ func (mc *MongoConnection) UpdateAndFindOne(data interface{}, result interface{}
 	opts.SetUpsert(upsert)
 	opts.SetReturnDocument(options.After)
 
+	// Versioned documents are only updated if they still hold the version carried by the data, or else the version
+	// read before the update.
+	_, resultType := mc.checkPtr(result)
+	version, versioned := mc.versionField(resultType)
+	var expected interface{}
+	checkVersion := false
+	if versioned {
+		expected, checkVersion = mc.expectedVersion(data, version)
+	}
+
 	// Initialize variables for cache update.
 	updateCache := false
 	oldKeys := []string{}
 	newKeys := []string{}
 	var cacheHook hooks.CacheHook
+	audited := mc.useAudit(result)
+	historical := mc.useHistory(result)
+
+	// Update the document and run the AfterUpdate hook, in a transaction if the result asks for one.
+	write := func(ctx context.Context) error {
+		// Read the document before the update for the audit trail and the history, an upsert doesn't find any.
+		var before primitive.M
+		if audited || historical {
+			err := mc.client.Database(mc.currentDB).Collection(preUpdateReturn.entityName).FindOne(ctx, fusionQuery.GetQuery().(primitive.D)).Decode(&before)
+			if err != nil && err != mongo.ErrNoDocuments {
+				return err
+			}
+		}
+
+		// Check if the 'result' implements the CacheHook interface.
+		if value, ok := interface{}(result).(hooks.CacheHook); ok {
+			// Attempt to retrieve the existing document before the update.
+			err := mc.client.Database(mc.currentDB).Collection(preUpdateReturn.entityName).FindOne(ctx, fusionQuery.GetQuery().(primitive.D)).Decode(result)
+			if err != nil {
+				return err
+			}
+
+			// Create a tag map from the updated document.
+			tagMapValue, err := mc.createTagValueMap(result)
+			if err == nil {
+				// Get old cache keys before the update.
+				oldKeys = mc.getAllCacheValues(value, tagMapValue, preUpdateReturn.entityName)
+				updateCache = true
+				cacheHook = value
+			}
 
-	// Check if the 'result' implements the CacheHook interface.
-	if value, ok := interface{}(result).(hooks.CacheHook); ok {
-		// Attempt to retrieve the existing document before the update.
-		err = mc.client.Database(mc.currentDB).Collection(preUpdateReturn.entityName).FindOne(context.TODO(), fusionQuery.GetQuery().(primitive.D)).Decode(result)
+			// Expect the version of the document read when the data doesn't carry one.
+			if versioned && !checkVersion {
+				if expected, checkVersion = mc.structVersion(result, version); !checkVersion {
+					expected, checkVersion = 0, true
+				}
+			}
+		}
+
+		// Without a cache hook, read the version of the document, unless it was read for the audit trail or the
+		// history. An upsert doesn't find any document and expects no version.
+		if versioned && !checkVersion {
+			current := before
+			if current == nil {
+				opts := options.FindOne().SetProjection(primitive.D{{Key: version.name, Value: 1}})
+				err := mc.client.Database(mc.currentDB).Collection(preUpdateReturn.entityName).FindOne(ctx, fusionQuery.GetQuery().(primitive.D), opts).Decode(&current)
+				if err != nil && err != mongo.ErrNoDocuments {
+					return err
+				}
+			}
+			if current != nil {
+				if expected, checkVersion = current[version.name], true; expected == nil {
+					expected = 0
+				}
+			}
+		}
+
+		// Restrict the update to the expected version, a version implies the document exists so it is never upserted.
+		query := fusionQuery.GetQuery().(primitive.D)
+		if checkVersion {
+			query = mc.andMongoFilter(query, mc.versionFilter(version, expected))
+			opts.SetUpsert(false)
+		}
+
+		// Perform the FindOneAndUpdate operation to update and retrieve the document.
+		mc.traceQuery(query, preUpdateReturn.queryData)
+		collection := mc.client.Database(mc.currentDB).Collection(preUpdateReturn.entityName)
+		err := collection.FindOneAndUpdate(ctx, query, preUpdateReturn.queryData.(primitive.D), &opts).Decode(result)
+		if err == mongo.ErrNoDocuments && checkVersion {
+			// The document is stale if it still exists with another version.
+			count, countErr := collection.CountDocuments(ctx, fusionQuery.GetQuery().(primitive.D))
+			if countErr != nil {
+				return countErr
+			}
+			if count != 0 {
+				return dbfusionErrors.ErrStaleVersion
+			}
+		}
+		if err == nil && checkVersion {
+			// Keep the version of the data in step with the database.
+			mc.setStructVersion(data, version, expected)
+		}
 		if err != nil {
 			return err
 		}
 
-		// Create a tag map from the updated document.
-		tagMapValue, err := mc.createTagValueMap(result)
-		if err == nil {
-			// Get old cache keys before the update.
-			oldKeys = mc.getAllCacheValues(value, tagMapValue, preUpdateReturn.entityName)
-			updateCache = true
-			cacheHook = value
+		// Decrypt the fields with the encrypt option.
+		if err := mc.decryptResult(result); err != nil {
+			return err
 		}
-	}
 
-	// Perform the FindOneAndUpdate operation to update and retrieve the document.
-	err = mc.client.Database(mc.currentDB).Collection(preUpdateReturn.entityName).FindOneAndUpdate(
-		context.TODO(),
-		fusionQuery.GetQuery().(primitive.D),
-		preUpdateReturn.queryData.(primitive.D),
-		&opts,
-	).Decode(result)
-	if err != nil {
-		return err
-	}
+		// Copy the previous version of the document to the history.
+		if historical && before != nil {
+			if err := mc.writeHistory(ctx, preUpdateReturn.entityName, result, before, time.Now()); err != nil {
+				return err
+			}
+		}
+		if !audited {
+			return nil
+		}
 
-	// If cache update is needed, get new cache keys after the update.
-	if updateCache {
-		tagMapValue, _ := mc.createTagValueMap(result)
-		newKeys = mc.getAllCacheValues(cacheHook, tagMapValue, preUpdateReturn.entityName)
-	}
+		// Record the update in the audit trail, or the insertion of an upserted document.
+		after, err := mc.createTagValueMap(result)
+		if err != nil {
+			return err
+		}
+		operation := audit.Update
+		if before == nil {
+			operation = audit.Insert
+		}
+		return mc.writeAudit(ctx, mc.auditEntry(ctx, preUpdateReturn.entityName, operation, result, mc.documentValues(before), after))
+	}
+
+	return mc.runWrite(result, mc.beginTransaction, write,
+		func(ctx context.Context) error {
+			return mc.afterUpdate(ctx, result)
+		},
+		func() error {
+			// If cache update is needed, get new cache keys after the update.
+			if updateCache {
+				tagMapValue, _ := mc.createTagValueMap(result)
+				newKeys = mc.getAllCacheValues(cacheHook, tagMapValue, preUpdateReturn.entityName)
+			}
 
-	// Handle any post-update operations, such as caching.
-	err = mc.postUpdate(mc.cache, result, preUpdateReturn.entityName, oldKeys, newKeys)
-	return err
+			// Handle any post-update operations, such as caching.
+			return mc.postUpdate(mc.cache, result, preUpdateReturn.entityName, oldKeys, newKeys)
+		})
 }
 
 // DeleteOne deletes a document from the specified MongoDB collection based on provided query conditions or data.
@@ -401,6 +583,13 @@ func (mc *MongoConnection) UpdateAndFindOne(data interface{}, result interface{}
 // In the above example, the 'DeleteOne' method is used to delete a document in the "users" collection where
 // the "name" field matches "Alice".
 func (mc *MongoConnection) DeleteOne(sliceData ...interface{}) error {
+	return mc.intercept(connections.OpDeleteOne, func() error {
+		return mc.deleteOne(sliceData...)
+	})
+}
+
+// deleteOne runs DeleteOne as the last handler of the middleware chain.
+func (mc *MongoConnection) deleteOne(sliceData ...interface{}) error {
 	// Defer the resetting of connection state to ensure cleanup even if an error occurs.
 	defer mc.refreshValues()
 
@@ -423,153 +612,936 @@ func (mc *MongoConnection) DeleteOne(sliceData ...interface{}) error {
 		mc.whereQuery = &conditions.MongoData{}
 	}
 
-	// Prepare for pre-delete operations and retrieve pre-delete data.
-	preDeleteData, err := mc.preDelete(data)
+	// Prepare for pre-delete operations and retrieve pre-delete data.
+	preDeleteData, err := mc.preDelete(data)
+	if err != nil {
+		return err
+	}
+
+	// Documents of soft-deleted models get their deletion time set instead of being removed. Deletions by query
+	// conditions use the model last used with the collection.
+	model := data
+	if model == nil {
+		model = mc.entityModel(preDeleteData.entityName)
+	}
+	softDelete, soft := mc.useSoftDelete(model)
+
+	var results primitive.M
+
+	// Delete the document and run the AfterDelete hook, in a transaction if the data asks for one.
+	write := func(ctx context.Context) error {
+		// Check if specific data is provided for document identification (delete by data).
+		if data != nil {
+			// Build a MongoDB-compatible query to identify the document based on data.
+			deleteQuery, err := mc.encryptFilter(mc.buildMongoData(preDeleteData.dataType, preDeleteData.dataValue), preDeleteData.dataType)
+			if err != nil {
+				return err
+			}
+
+			// A struct holding its primary key matches the document of the key only.
+			if key, ok := mc.primaryKey(preDeleteData.dataType); ok {
+				if id, ok := mc.structID(data, key); ok {
+					deleteQuery = primitive.D{{Key: key.name, Value: id}}
+				}
+			}
+
+			// Set the deletion time of the document if it is not deleted yet, keeping it to update the cache.
+			if soft {
+				deleteQuery = mc.scopeMongoQuery(data, excludeDeleted, deleteQuery)
+				update := primitive.D{{Key: "$set", Value: primitive.D{{Key: softDelete.name, Value: mc.deletionTime(softDelete)}}}}
+				mc.traceQuery(deleteQuery, update)
+				err := mc.client.Database(mc.currentDB).Collection(preDeleteData.entityName).FindOneAndUpdate(ctx, deleteQuery, update).Decode(&results)
+				return mc.recordDeletion(ctx, err, preDeleteData.entityName, data, results)
+			}
+
+			// Attempt to find and delete the document identified by the query.
+			mc.traceQuery(deleteQuery)
+			err = mc.client.Database(mc.currentDB).Collection(preDeleteData.entityName).FindOneAndDelete(ctx, deleteQuery).Decode(&results)
+			return mc.recordDeletion(ctx, err, preDeleteData.entityName, data, results)
+		}
+
+		// Delete documents based on query conditions (delete by query).
+		// Simple delete operation without checking the cache, the cache is invalidated afterwards for soft deletes.
+		query := mc.whereQuery.(conditions.DBFusionData).GetQuery()
+		if soft {
+			query = mc.scopeMongoQuery(model, excludeDeleted, query.(primitive.D))
+			update := primitive.D{{Key: "$set", Value: primitive.D{{Key: softDelete.name, Value: mc.deletionTime(softDelete)}}}}
+			mc.traceQuery(query, update)
+			_, err := mc.client.Database(mc.currentDB).Collection(preDeleteData.entityName).UpdateOne(ctx, query, update)
+			return err
+		}
+		mc.traceQuery(query)
+		_, err := mc.client.Database(mc.currentDB).Collection(preDeleteData.entityName).DeleteOne(ctx, query)
+		return err
+	}
+
+	return mc.runWrite(data, mc.beginTransaction, write,
+		func(ctx context.Context) error {
+			return mc.afterDelete(ctx, data)
+		},
+		func() error {
+			// The keys of a document soft deleted by query conditions are not known.
+			if data == nil && soft {
+				if err := mc.invalidateDeleted(mc.cache, model, preDeleteData.entityName); err != nil {
+					return err
+				}
+			}
+
+			// Handle any post-delete operations, such as cache updates.
+			return mc.postDelete(mc.cache, data, preDeleteData.entityName, results)
+		})
+}
+
+// DisConnect closes the connection to the MongoDB server.
+//
+// Returns:
+// - An error if the disconnection encounters any issues, otherwise returns nil.
+//
+// This method gracefully closes the connection to the MongoDB server that was previously established using the 'Connect' method.
+// It is important to call this method when you are finished using the MongoDB connection to release resources and maintain proper cleanup.
+//
+// Example Usage:
+//   mc := MongoConnection{}
+//   err := mc.Connect("mongodb://localhost:27017")
+//   if err != nil {
+//       log.Fatal("Failed to connect to MongoDB:", err)
+//   }
+//   // Perform MongoDB operations...
+//   err = mc.DisConnect() // Close the MongoDB connection when done.
+func (mc *MongoConnection) DisConnect() error {
+	// Stop writing queued write-behind records, the remaining ones stay queued in the cache.
+	mc.stopWriteBehind()
+
+	// Close the MongoDB client connection gracefully.
+	return mc.client.Disconnect(context.TODO())
+}
+
+// StartWriteBehind starts writing the documents queued by write-behind insertions to their collections. The worker
+// is started automatically by the first write-behind insertion, calling this method at startup writes the documents
+// queued by previous processes right away.
+//
+// Returns:
+// - An error if the cache doesn't provide queues, otherwise returns nil.
+func (mc *MongoConnection) StartWriteBehind() error {
+	_, err := mc.startWriteBehind("mongo", mc.writeQueuedRecord)
+	return err
+}
+
+// writeQueuedRecord writes a document queued by a write-behind insertion to its collection.
+//
+// Parameters:
+// - dbName: The name of the database the document was queued for.
+// - entityName: The name of the collection.
+// - data: The document as a map of field names to values.
+//
+// Returns:
+// - An error if the insertion fails, otherwise returns nil.
+func (mc *MongoConnection) writeQueuedRecord(dbName string, entityName string, data map[string]interface{}) error {
+	_, err := mc.client.Database(dbName).Collection(entityName).InsertOne(context.TODO(), data)
+	return err
+}
+
+// Paginate performs pagination on a MongoDB query and retrieves a specific page of results.
+//
+// Parameters:
+// - results: A pointer to the slice where the query results should be stored.
+// - pageNumber: The page number to retrieve (1-based index).
+//
+// Returns:
+// - PaginationResults: A struct containing pagination information.
+// - error: An error if the pagination encounters any issues, otherwise returns nil.
+//
+// This method is used to paginate the results of a MongoDB query, allowing you to retrieve a specific page of data.
+// It takes a pointer to a slice where the query results should be stored and the page number to retrieve.
+// The method calculates the total number of documents matching the query, total pages, and other pagination details.
+// It then retrieves the specified page of results and populates the provided slice with the data.
+//
+// Example Usage:
+//   mc := MongoConnection{}
+//   err := mc.Connect("mongodb://localhost:27017")
+//   if err != nil {
+//       log.Fatal("Failed to connect to MongoDB:", err)
+//   }
+//   var results []YourDataType
+//   pageNumber := 1
+//   paginationInfo, err := mc.Paginate(&results, pageNumber)
+//   if err != nil {
+//       log.Fatal("Failed to paginate query:", err)
+//   }
+//   // Process the paginated results and use paginationInfo to display pagination controls.
+func (mc *MongoConnection) Paginate(results interface{}, pageNumber int) (paginationResults connections.PaginationResults, err error) {
+	err = mc.intercept(connections.OpPaginate, func() error {
+		paginationResults, err = mc.paginate(results, pageNumber)
+		return err
+	})
+	return
+}
+
+// paginate runs Paginate as the last handler of the middleware chain.
+func (mc *MongoConnection) paginate(results interface{}, pageNumber int) (connections.PaginationResults, error) {
+	// Ensure that MongoDB Fusion data is available for the query.
+	if mc.whereQuery != nil {
+		query, err := utils.GetInstance().GetMongoFusionData(mc.whereQuery)
+		if err != nil {
+			return connections.PaginationResults{}, err
+		}
+		mc.whereQuery = query
+	} else {
+		mc.whereQuery = &conditions.MongoData{}
+	}
+
+	// Leave out the soft-deleted documents unless they are asked for.
+	whereData := mc.whereQuery.(conditions.DBFusionData)
+	whereData.SetQuery(mc.scopeMongoQuery(results, mc.deletedScope, whereData.GetQuery().(primitive.D)))
+
+	// Initialize pagination results.
+	var paginationResults connections.PaginationResults
+
+	// Configure options for the MongoDB query.
+	opts := options.FindOptions{}
+	if mc.projection != nil {
+		opts.SetProjection(mc.projection)
+	}
+
+	// Count the total number of documents matching the query.
+	count, err := mc.client.Database(mc.currentDB).Collection(mc.tableName).CountDocuments(mc.getContext(), mc.whereQuery.(conditions.DBFusionData).GetQuery())
+	if err != nil {
+		return connections.PaginationResults{}, err
+	}
+
+	// Populate paginationResults with count and pagination details.
+	paginationResults.TotalDocuments = count
+	paginationResults.TotalPages = int64(math.Ceil((float64(count) / float64(mc.pageSize))))
+	paginationResults.Limit = int64(mc.pageSize)
+	paginationResults.CurrentPage = int64(pageNumber)
+
+	// Calculate the skip and limit based on the requested page number.
+	mc.limit = int64(mc.pageSize)
+	mc.skip = int64((pageNumber - 1) * mc.pageSize)
+	opts.SetSkip(mc.skip)
+	opts.SetSort(mc.sort)
+	opts.SetLimit(mc.limit)
+
+	// Execute the MongoDB query with pagination options.
+	mc.traceQuery(mc.whereQuery.(conditions.DBFusionData).GetQuery())
+	cursor, err := mc.client.Database(mc.currentDB).Collection(mc.tableName).Find(mc.getContext(), mc.whereQuery.(conditions.DBFusionData).GetQuery(), &opts)
+	if err != nil {
+		return connections.PaginationResults{}, err
+	}
+
+	// Decode and store the results in the provided slice, decrypting the fields with the encrypt option.
+	if err = cursor.All(mc.getContext(), results); err != nil {
+		return connections.PaginationResults{}, err
+	}
+	if err = mc.decryptResult(results); err != nil {
+		return connections.PaginationResults{}, err
+	}
+
+	return paginationResults, nil
+}
+
+// WarmCache streams every document of an entity from the MongoDB collection and rebuilds its cache indexes and
+// payloads. Documents are written to the cache in batches, optionally limited to a number of documents per second.
+//
+// Parameters:
+// - model: The model of the entity, a struct implementing hooks.CacheHook or a map[string]interface{} used
+//   together with Table and WarmCacheOptions.Indexes.
+// - dbFusionOptions: Optional options for batching, rate limiting and progress.
+//
+// Returns:
+// - connections.WarmCacheResults: The counts of processed, cached and skipped documents and the duration.
+// - An error if reading the documents or writing the cache fails, otherwise returns nil.
+//
+// Example Usage:
+//   results, err := mc.WarmCache(&User{}, queryoptions.WarmCacheOptions{
+//       BatchSize: 1000,
+//       Progress:  func(processed int64, cached int64) { log.Println(processed, cached) },
+//   })
+func (mc *MongoConnection) WarmCache(model interface{}, dbFusionOptions ...queryoptions.WarmCacheOptions) (connections.WarmCacheResults, error) {
+	// Defer the resetting of connection state to ensure cleanup even if an error occurs.
+	defer mc.refreshValues()
+
+	// Get the entity name of the model.
+	nameData, err := mc.getEntityName(model)
+	if err != nil {
+		return connections.WarmCacheResults{}, err
+	}
+
+	// Prepare the warmer which validates the cache and the indexes.
+	warmer, err := newCacheWarmer(&mc.DBCommon, model, nameData.entityName, dbFusionOptions...)
+	if err != nil {
+		return connections.WarmCacheResults{}, err
+	}
+
+	// Stream the documents of the collection into the warmer.
+	err = mc.streamEntity(nameData.entityName, 0, warmer.options.BatchSize, warmer.add)
+	if err != nil {
+		return connections.WarmCacheResults{}, err
+	}
+
+	// Write the remaining documents and return the results.
+	return warmer.finish()
+}
+
+// VerifyCache compares the cached entries of an entity with its documents in the MongoDB collection. It recomputes
+// the expected index keys of every document, or of a random sample of them, and reports the keys that are missing or
+// point to a stale payload. Optionally orphaned payloads are looked up and everything found is repaired.
+//
+// Parameters:
+// - model: The model of the entity, a struct implementing hooks.CacheHook or a map[string]interface{} used
+//   together with Table and VerifyCacheOptions.Indexes.
+// - dbFusionOptions: Optional options for sampling, orphans and repair.
+//
+// Returns:
+// - connections.CacheVerificationReport: The missing keys, stale keys and orphaned payloads found.
+// - An error if reading the documents or the cache fails, otherwise returns nil.
+//
+// Example Usage:
+//   report, err := mc.VerifyCache(&User{}, queryoptions.VerifyCacheOptions{CheckOrphans: true, Repair: true})
+func (mc *MongoConnection) VerifyCache(model interface{}, dbFusionOptions ...queryoptions.VerifyCacheOptions) (connections.CacheVerificationReport, error) {
+	// Defer the resetting of connection state to ensure cleanup even if an error occurs.
+	defer mc.refreshValues()
+
+	// Get the entity name of the model.
+	nameData, err := mc.getEntityName(model)
+	if err != nil {
+		return connections.CacheVerificationReport{}, err
+	}
+
+	// Prepare the verifier which validates the cache and the indexes.
+	verifier, err := newCacheVerifier(&mc.DBCommon, model, nameData.entityName, dbFusionOptions...)
+	if err != nil {
+		return connections.CacheVerificationReport{}, err
+	}
+
+	// Stream the documents of the collection, or a sample of them, into the verifier.
+	err = mc.streamEntity(nameData.entityName, verifier.options.SampleSize, verifier.options.BatchSize, verifier.add)
+	if err != nil {
+		return connections.CacheVerificationReport{}, err
+	}
+
+	// Verify the remaining documents and return the report.
+	return verifier.finish()
+}
+
+// streamEntity reads the documents of a collection one by one and passes each of them as a map to a callback.
+// The query conditions and projection of the connection are applied. Documents are read as maps because their field
+// names are the tag names used on insertion.
+//
+// Parameters:
+// - entityName: The name of the collection to be read.
+// - sampleSize: The number of randomly chosen documents to read, or 0 to read every document.
+// - batchSize: The number of documents fetched from the server at once.
+// - each: The callback receiving the documents, an error stops the stream.
+//
+// Returns:
+// - An error if the query fails, a document cannot be decoded or the callback fails, otherwise returns nil.
+func (mc *MongoConnection) streamEntity(entityName string, sampleSize int, batchSize int, each func(map[string]interface{}) error) error {
+	// Restrict the documents to the query conditions if they are provided.
+	var query interface{} = bson.M{}
+	if mc.whereQuery != nil {
+		whereQuery, err := utils.GetInstance().GetMongoFusionData(mc.whereQuery)
+		if err != nil {
+			return err
+		}
+		query = whereQuery.GetQuery()
+	}
+
+	collection := mc.client.Database(mc.currentDB).Collection(entityName)
+	var cursor *mongo.Cursor
+	var err error
+	if sampleSize > 0 {
+		// Pick a random sample of the matching documents.
+		pipeline := bson.A{bson.M{"$match": query}, bson.M{"$sample": bson.M{"size": sampleSize}}}
+		if mc.projection != nil {
+			pipeline = append(pipeline, bson.M{"$project": mc.projection})
+		}
+		cursor, err = collection.Aggregate(context.TODO(), pipeline, options.Aggregate().SetBatchSize(int32(batchSize)))
+	} else {
+		// Stream every matching document, fetching them in batches.
+		opts := options.Find().SetBatchSize(int32(batchSize))
+		if mc.projection != nil {
+			opts.SetProjection(mc.projection)
+		}
+		cursor, err = collection.Find(context.TODO(), query, opts)
+	}
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		data := make(map[string]interface{})
+		if err := cursor.Decode(&data); err != nil {
+			return err
+		}
+		if err := each(data); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (mc *MongoConnection) InsertMany(interface{}) error {
+	return nil
+}
+
+// FindMany retrieves every document of the MongoDB collection matching the conditions set with Where.
+//
+// Parameters:
+// - results: A pointer to the slice the documents will be decoded into.
+// - dbFusionOptions: Optional FindOptions, the documents are always read from the database.
+//
+// Returns:
+// - An error if the retrieval encounters any issues, otherwise returns nil.
+//
+// The projection, skip, limit and sort set on the connection are applied. Soft-deleted documents are left out unless
+// WithDeleted or OnlyDeleted is called.
+//
+// Example Usage:
+//   var users []User
+//   err := mc.Where(ftypes.QMap{"status": "active"}).Sort("createdAt", false).FindMany(&users)
+func (mc *MongoConnection) FindMany(results interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
+	return mc.intercept(connections.OpFindMany, func() error {
+		return mc.findMany(results, dbFusionOptions...)
+	})
+}
+
+// findMany runs FindMany as the last handler of the middleware chain.
+func (mc *MongoConnection) findMany(results interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
+	// Defer the resetting of connection state to ensure cleanup even if an error occurs.
+	defer mc.refreshValues()
+
+	// Check if there are specific query conditions provided in 'whereQuery'.
+	var query primitive.D
+	if mc.whereQuery != nil {
+		// Convert the 'whereQuery' into a MongoDB-compatible query.
+		fusionQuery, err := utils.GetInstance().GetMongoFusionData(mc.whereQuery)
+		if err != nil {
+			return err
+		}
+		query = fusionQuery.GetQuery().(primitive.D)
+	}
+
+	// Get the collection of the documents.
+	entityName, err := mc.getResultsEntityName(results)
+	if err != nil {
+		return err
+	}
+
+	// Leave out the soft-deleted documents unless they are asked for.
+	query = mc.scopeMongoQuery(results, mc.deletedScope, query)
+	if query == nil {
+		query = primitive.D{}
+	}
+
+	// Read the documents as they were at the moment set with AsOf from the history.
+	if mc.asOf != nil {
+		cursor, err := mc.findAsOf(entityName, results, query, mc.limit)
+		if err != nil {
+			return err
+		}
+		if err := cursor.All(mc.getContext(), results); err != nil {
+			return err
+		}
+		return mc.decryptResult(results)
+	}
+
+	// Create options for the Find operation, including projection, skip, limit, and sort.
+	opts := options.FindOptions{}
+	if mc.projection != nil {
+		opts.SetProjection(mc.projection)
+	}
+	if mc.skip != 0 {
+		opts.SetSkip(mc.skip)
+	}
+	if mc.limit != 0 {
+		opts.SetLimit(mc.limit)
+	}
+	if mc.sort != nil {
+		opts.SetSort(mc.sort)
+	}
+
+	// Execute the Find operation and decode every document into the results.
+	mc.traceQuery(query)
+	cursor, err := mc.client.Database(mc.currentDB).Collection(entityName).Find(mc.getContext(), query, &opts)
+	if err != nil {
+		return err
+	}
+	if err := cursor.All(mc.getContext(), results); err != nil {
+		return err
+	}
+
+	// Decrypt the fields with the encrypt option.
+	return mc.decryptResult(results)
+}
+
+// UpdateMany updates every document of the MongoDB collection matching the conditions set with Where.
+//
+// Parameters:
+// - data: The data used to update the documents, a struct or a map.
+// - model: The model of the entity, declaring the timestamps of updates given as maps and the cache of the entity.
+// - upsert: If true, insert a document when none matches the conditions.
+//
+// Returns:
+// - An error if the update operation encounters any issues, otherwise returns nil.
+//
+// The cached documents of entities implementing CacheHook are invalidated, as any of them may have changed.
+//
+// Example Usage:
+//   err := mc.Where(ftypes.QMap{"status": "inactive"}).UpdateMany(ftypes.QMap{"status": "archived"}, &User{}, false)
+func (mc *MongoConnection) UpdateMany(data interface{}, model interface{}, upsert bool) error {
+	return mc.intercept(connections.OpUpdateMany, func() error {
+		return mc.updateMany(data, model, upsert)
+	})
+}
+
+// updateMany runs UpdateMany as the last handler of the middleware chain.
+func (mc *MongoConnection) updateMany(data interface{}, model interface{}, upsert bool) error {
+	// Defer the resetting of connection state to ensure cleanup even if an error occurs.
+	defer mc.refreshValues()
+
+	var fusionQuery conditions.DBFusionData
+	// Check if there are specific query conditions provided in 'whereQuery'.
+	if mc.whereQuery != nil {
+		// Convert the 'whereQuery' into a MongoDB-compatible query.
+		query, err := utils.GetInstance().GetMongoFusionData(mc.whereQuery)
+		if err != nil {
+			return err
+		}
+		fusionQuery = query
+	} else {
+		// If no query conditions are provided, initialize 'whereQuery' as an empty MongoData.
+		fusionQuery = &conditions.MongoData{}
+	}
+
+	// Soft-deleted documents are only updated when WithDeleted or OnlyDeleted selects them.
+	fusionQuery.SetQuery(mc.scopeMongoQuery(model, mc.deletedScope, fusionQuery.GetQuery().(primitive.D)))
+
+	// Prepare for pre-update operations and retrieve pre-update data.
+	preUpdateReturn, err := mc.preUpdate(data, connections.MONGO, model, upsert)
+	if err != nil {
+		return err
+	}
+
+	// Read the matching documents before the update for the audit trail.
+	audited := mc.useAudit(model)
+	var records []map[string]interface{}
+	if audited {
+		if records, err = mc.readAuditDocuments(mc.getContext(), preUpdateReturn.entityName, fusionQuery.GetQuery()); err != nil {
+			return err
+		}
+	}
+
+	// Update every matching document.
+	mc.traceQuery(fusionQuery.GetQuery(), preUpdateReturn.queryData)
+	updateResult, err := mc.client.Database(mc.currentDB).Collection(preUpdateReturn.entityName).UpdateMany(
+		mc.getContext(),
+		fusionQuery.GetQuery().(primitive.D),
+		preUpdateReturn.queryData.(primitive.D),
+		options.Update().SetUpsert(upsert),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Record the update of every matched document in the audit trail, and the insertion of an upserted one.
+	if audited {
+		updates := mc.updateValues(data)
+		entries := mc.auditEntries(mc.getContext(), preUpdateReturn.entityName, audit.Update, model, records, updates)
+		if updateResult.UpsertedID != nil {
+			inserted := mc.overlay(updates, map[string]interface{}{"_id": updateResult.UpsertedID})
+			entries = append(entries, mc.auditEntry(mc.getContext(), preUpdateReturn.entityName, audit.Insert, model, nil, inserted))
+		}
+		if err = mc.writeAudit(mc.getContext(), entries...); err != nil {
+			return err
+		}
+	}
+
+	// Invalidate the cached documents and run the post-update hooks.
+	return mc.postUpdateMany(mc.cache, model, preUpdateReturn.entityName)
+}
+
+// DeleteMany deletes every document of the MongoDB collection matching the conditions set with Where.
+//
+// Parameters:
+// - sliceData: The model of the entity as optional first argument, the collection set with Table is used without it.
+//
+// Returns:
+// - An error if the deletion encounters any issues, otherwise returns nil.
+//
+// Documents of models with a softdelete field get their deletion time set instead of being removed, unless HardDelete
+// is called. The cached documents of entities implementing CacheHook are invalidated.
+//
+// Example Usage:
+//   err := mc.Where(ftypes.QMap{"status": "inactive"}).DeleteMany(&User{})
+func (mc *MongoConnection) DeleteMany(sliceData ...interface{}) error {
+	return mc.intercept(connections.OpDeleteMany, func() error {
+		return mc.deleteMany(sliceData...)
+	})
+}
+
+// deleteMany runs DeleteMany as the last handler of the middleware chain.
+func (mc *MongoConnection) deleteMany(sliceData ...interface{}) error {
+	// Defer the resetting of connection state to ensure cleanup even if an error occurs.
+	defer mc.refreshValues()
+
+	var model interface{}
+	if len(sliceData) != 0 {
+		model = sliceData[0]
+	}
+
+	// Check if there are specific query conditions provided in 'whereQuery'.
+	query := primitive.D{}
+	if mc.whereQuery != nil {
+		// Convert the 'whereQuery' into a MongoDB-compatible query.
+		fusionQuery, err := utils.GetInstance().GetMongoFusionData(mc.whereQuery)
+		if err != nil {
+			return err
+		}
+		query = fusionQuery.GetQuery().(primitive.D)
+	}
+
+	// Prepare for pre-delete operations and retrieve pre-delete data.
+	preDeleteData, err := mc.preDelete(model)
+	if err != nil {
+		return err
+	}
+
+	// Only the documents that are not deleted yet are soft deleted.
+	softDelete, soft := mc.useSoftDelete(model)
+	if soft {
+		query = mc.scopeMongoQuery(model, excludeDeleted, query)
+	}
+
+	// Read the matching documents before the deletion for the audit trail.
+	audited := mc.useAudit(model)
+	var records []map[string]interface{}
+	if audited {
+		if records, err = mc.readAuditDocuments(mc.getContext(), preDeleteData.entityName, query); err != nil {
+			return err
+		}
+	}
+
+	collection := mc.client.Database(mc.currentDB).Collection(preDeleteData.entityName)
+	if soft {
+		// Set the deletion time of the matching documents.
+		update := primitive.D{{Key: "$set", Value: primitive.D{{Key: softDelete.name, Value: mc.deletionTime(softDelete)}}}}
+		mc.traceQuery(query, update)
+		_, err = collection.UpdateMany(mc.getContext(), query, update)
+	} else {
+		// Remove the matching documents.
+		mc.traceQuery(query)
+		_, err = collection.DeleteMany(mc.getContext(), query)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Record the deletion of every matched document in the audit trail.
+	if audited {
+		if err = mc.writeAudit(mc.getContext(), mc.auditEntries(mc.getContext(), preDeleteData.entityName, audit.Delete, model, records, nil)...); err != nil {
+			return err
+		}
+	}
+
+	// Invalidate the cached documents and run the post-delete hooks.
+	return mc.postDeleteMany(mc.cache, model, preDeleteData.entityName)
+}
+
+// Restore clears the soft delete field of the deleted documents matching the conditions set with Where.
+//
+// Parameters:
+// - model: The model of the entity, a struct with a field carrying the softdelete option.
+//
+// Returns:
+// - dbfusionErrors.ErrSoftDeleteNotSupported if the model isn't soft deleted, an error if the update encounters any
+//   issues, otherwise returns nil.
+//
+// Example Usage:
+//   err := mc.Where(ftypes.QMap{"email": "alice@example.com"}).Restore(&User{})
+func (mc *MongoConnection) Restore(model interface{}) error {
+	return mc.intercept(connections.OpRestore, func() error {
+		return mc.restore(model)
+	})
+}
+
+// restore runs Restore as the last handler of the middleware chain.
+func (mc *MongoConnection) restore(model interface{}) error {
+	// Defer the resetting of connection state to ensure cleanup even if an error occurs.
+	defer mc.refreshValues()
+
+	// Only models with a soft delete field can be restored.
+	softDelete, ok := mc.softDeleteField(model)
+	if !ok {
+		return dbfusionErrors.ErrSoftDeleteNotSupported
+	}
+
+	// Check if there are specific query conditions provided in 'whereQuery'.
+	query := primitive.D{}
+	if mc.whereQuery != nil {
+		// Convert the 'whereQuery' into a MongoDB-compatible query.
+		fusionQuery, err := utils.GetInstance().GetMongoFusionData(mc.whereQuery)
+		if err != nil {
+			return err
+		}
+		query = fusionQuery.GetQuery().(primitive.D)
+	}
+
+	// Get the collection of the model.
+	nameData, err := mc.getEntityName(model)
 	if err != nil {
 		return err
 	}
 
-	var deleteQuery primitive.D
-	var results primitive.M
-
-	// Check if specific data is provided for document identification (delete by data).
-	if data != nil {
-		// Build a MongoDB-compatible query to identify the document based on data.
-		deleteQuery = mc.buildMongoData(preDeleteData.dataType, preDeleteData.dataValue)
-
-		// Attempt to find and delete the document identified by the query.
-		err = mc.client.Database(mc.currentDB).Collection(preDeleteData.entityName).FindOneAndDelete(context.TODO(), deleteQuery).Decode(&results)
-	} else {
-		// Delete documents based on query conditions (delete by query).
-
-		// Simple delete operation without checking the cache, as cache is not relevant in this case.
-		_, err = mc.client.Database(mc.currentDB).Collection(preDeleteData.entityName).DeleteOne(context.TODO(), mc.whereQuery.(conditions.DBFusionData).GetQuery())
+	// Read the matching deleted documents before restoring them for the audit trail.
+	query = mc.scopeMongoQuery(model, deletedOnly, query)
+	audited := mc.useAudit(model)
+	var records []map[string]interface{}
+	if audited {
+		if records, err = mc.readAuditDocuments(mc.getContext(), nameData.entityName, query); err != nil {
+			return err
+		}
 	}
 
+	// Reset the soft delete field of the matching deleted documents.
+	update := primitive.D{{Key: "$set", Value: primitive.D{{Key: softDelete.name, Value: softDelete.zero}}}}
+	mc.traceQuery(query, update)
+	_, err = mc.client.Database(mc.currentDB).Collection(nameData.entityName).UpdateMany(mc.getContext(), query, update)
 	if err != nil {
 		return err
 	}
 
-	// Handle any post-delete operations, such as cache updates.
-	err = mc.postDelete(mc.cache, data, preDeleteData.entityName, results)
+	// Record the restoration of every matched document in the audit trail as an update.
+	if audited {
+		restored := map[string]interface{}{softDelete.name: softDelete.zero}
+		if err = mc.writeAudit(mc.getContext(), mc.auditEntries(mc.getContext(), nameData.entityName, audit.Update, model, records, restored)...); err != nil {
+			return err
+		}
+	}
 
-	return err
+	// Invalidate the cached documents and run the post-update hooks.
+	return mc.postUpdateMany(mc.cache, model, nameData.entityName)
 }
 
-// DisConnect closes the connection to the MongoDB server.
+// FindByID retrieves the document of a primary key from the MongoDB collection, or from the cache when the entity is
+// cached, since the primary key is always one of its cache indexes.
 //
-// Returns:
-// - An error if the disconnection encounters any issues, otherwise returns nil.
+// Parameters:
+// - id (interface{}): The primary key of the document, converted to the type of the primary key field,
+//   hex strings to ObjectIDs.
+// - result (interface{}): A pointer to the struct where the retrieved data will be stored, it gives the primary key.
+// - dbFusionOptions (...queryoptions.FindOptions): Optional FindOptions.
 //
-// This method gracefully closes the connection to the MongoDB server that was previously established using the 'Connect' method.
-// It is important to call this method when you are finished using the MongoDB connection to release resources and maintain proper cleanup.
+// Returns:
+// - error: dbfusionErrors.ErrPrimaryKeyNotFound if the model has no primary key, an error if the retrieval fails,
+//   or nil if successful.
 //
-// Example Usage:
-//   mc := MongoConnection{}
-//   err := mc.Connect("mongodb://localhost:27017")
-//   if err != nil {
-//       log.Fatal("Failed to connect to MongoDB:", err)
-//   }
-//   // Perform MongoDB operations...
-//   err = mc.DisConnect() // Close the MongoDB connection when done.
-func (mc *MongoConnection) DisConnect() error {
-	// Close the MongoDB client connection gracefully.
-	return mc.client.Disconnect(context.TODO())
+// Example:
+//   var user User
+//   err := mc.FindByID("64f1c2a9e4b0a1b2c3d4e5f6", &user)
+func (mc *MongoConnection) FindByID(id interface{}, result interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
+	return mc.intercept(connections.OpFindByID, func() error {
+		return mc.findByID(id, result, dbFusionOptions...)
+	})
 }
 
-// Paginate performs pagination on a MongoDB query and retrieves a specific page of results.
+// findByID runs FindByID as the last handler of the middleware chain.
+func (mc *MongoConnection) findByID(id interface{}, result interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
+	// Match the document of the key, then find it like FindOne.
+	if err := mc.whereID(result, id); err != nil {
+		return err
+	}
+	return mc.findOne(result, dbFusionOptions...)
+}
+
+// UpdateByID updates the document of a primary key in the MongoDB collection and retrieves the updated document.
 //
 // Parameters:
-// - results: A pointer to the slice where the query results should be stored.
-// - pageNumber: The page number to retrieve (1-based index).
+// - id (interface{}): The primary key of the document, converted to the type of the primary key field,
+//   hex strings to ObjectIDs.
+// - data (interface{}): The data to update the document with.
+// - result (interface{}): A pointer to the struct where the updated data will be stored, it gives the primary key.
 //
 // Returns:
-// - PaginationResults: A struct containing pagination information.
-// - error: An error if the pagination encounters any issues, otherwise returns nil.
-//
-// This method is used to paginate the results of a MongoDB query, allowing you to retrieve a specific page of data.
-// It takes a pointer to a slice where the query results should be stored and the page number to retrieve.
-// The method calculates the total number of documents matching the query, total pages, and other pagination details.
-// It then retrieves the specified page of results and populates the provided slice with the data.
+// - error: dbfusionErrors.ErrPrimaryKeyNotFound if the model has no primary key, an error if the update fails,
+//   or nil if successful.
 //
-// Example Usage:
-//   mc := MongoConnection{}
-//   err := mc.Connect("mongodb://localhost:27017")
-//   if err != nil {
-//       log.Fatal("Failed to connect to MongoDB:", err)
-//   }
-//   var results []YourDataType
-//   pageNumber := 1
-//   paginationInfo, err := mc.Paginate(&results, pageNumber)
-//   if err != nil {
-//       log.Fatal("Failed to paginate query:", err)
-//   }
-//   // Process the paginated results and use paginationInfo to display pagination controls.
-func (mc *MongoConnection) Paginate(results interface{}, pageNumber int) (connections.PaginationResults, error) {
-	// Ensure that MongoDB Fusion data is available for the query.
-	if mc.whereQuery != nil {
-		query, err := utils.GetInstance().GetMongoFusionData(mc.whereQuery)
-		if err != nil {
-			return connections.PaginationResults{}, err
-		}
-		mc.whereQuery = query
-	} else {
-		mc.whereQuery = &conditions.MongoData{}
+// Example:
+//   var user User
+//   err := mc.UpdateByID("64f1c2a9e4b0a1b2c3d4e5f6", ftypes.QMap{"name": "Alice"}, &user)
+func (mc *MongoConnection) UpdateByID(id interface{}, data interface{}, result interface{}) error {
+	return mc.intercept(connections.OpUpdateByID, func() error {
+		return mc.updateByID(id, data, result)
+	})
+}
+
+// updateByID runs UpdateByID as the last handler of the middleware chain.
+func (mc *MongoConnection) updateByID(id interface{}, data interface{}, result interface{}) error {
+	// Match the document of the key, then update it like UpdateAndFindOne without inserting a missing document.
+	if err := mc.whereID(result, id); err != nil {
+		return err
 	}
+	return mc.updateAndFindOne(data, result, false)
+}
 
-	// Initialize pagination results.
-	var paginationResults connections.PaginationResults
+// DeleteByID removes the document of a primary key from the MongoDB collection, or soft deletes it, and removes it
+// from the cache.
+//
+// Parameters:
+// - id (interface{}): The primary key of the document, converted to the type of the primary key field,
+//   hex strings to ObjectIDs.
+// - model (interface{}): The model of the entity, it gives the collection and the primary key.
+//
+// Returns:
+// - error: dbfusionErrors.ErrPrimaryKeyNotFound if the model has no primary key, an error if the deletion fails,
+//   or nil if successful.
+//
+// Example:
+//   err := mc.DeleteByID("64f1c2a9e4b0a1b2c3d4e5f6", &User{})
+func (mc *MongoConnection) DeleteByID(id interface{}, model interface{}) error {
+	return mc.intercept(connections.OpDeleteByID, func() error {
+		return mc.deleteByID(id, model)
+	})
+}
 
-	// Configure options for the MongoDB query.
-	opts := options.FindOptions{}
-	if mc.projection != nil {
-		opts.SetProjection(mc.projection)
+// deleteByID runs DeleteByID as the last handler of the middleware chain.
+func (mc *MongoConnection) deleteByID(id interface{}, model interface{}) error {
+	key, err := mc.modelPrimaryKey(model)
+	if err != nil {
+		mc.refreshValues()
+		return err
 	}
-
-	// Count the total number of documents matching the query.
-	count, err := mc.client.Database(mc.currentDB).Collection(mc.tableName).CountDocuments(context.TODO(), mc.whereQuery.(conditions.DBFusionData).GetQuery())
+	id, err = mc.normalizeID(key, id)
 	if err != nil {
-		return connections.PaginationResults{}, err
+		mc.refreshValues()
+		return err
 	}
 
-	// Populate paginationResults with count and pagination details.
-	paginationResults.TotalDocuments = count
-	paginationResults.TotalPages = int64(math.Ceil((float64(count) / float64(mc.pageSize))))
-	paginationResults.Limit = int64(mc.pageSize)
-	paginationResults.CurrentPage = int64(pageNumber)
-
-	// Calculate the skip and limit based on the requested page number.
-	mc.limit = int64(mc.pageSize)
-	mc.skip = int64((pageNumber - 1) * mc.pageSize)
-	opts.SetSkip(mc.skip)
-	opts.SetSort(mc.sort)
-	opts.SetLimit(mc.limit)
+	// Delete a new instance of the model holding the key, which matches the document of the key only.
+	return mc.deleteOne(mc.modelWithID(model, key, id))
+}
 
-	// Execute the MongoDB query with pagination options.
-	cursor, err := mc.client.Database(mc.currentDB).Collection(mc.tableName).Find(context.TODO(), mc.whereQuery.(conditions.DBFusionData).GetQuery(), &opts)
+// whereID sets the WHERE condition of the connection to the document of a primary key. The connection is reset when
+// the model has no primary key.
+//
+// Parameters:
+// - model (interface{}): The model of the entity.
+// - id (interface{}): The primary key of the document.
+//
+// Returns:
+// - error: dbfusionErrors.ErrPrimaryKeyNotFound if the model has no primary key.
+func (mc *MongoConnection) whereID(model interface{}, id interface{}) error {
+	key, err := mc.modelPrimaryKey(model)
+	if err == nil {
+		id, err = mc.normalizeID(key, id)
+	}
 	if err != nil {
-		return connections.PaginationResults{}, err
+		mc.refreshValues()
+		return err
 	}
+	mc.whereQuery = ftypes.QMap{key.name: id}
+	return nil
+}
 
-	// Decode and store the results in the provided slice.
-	if err = cursor.All(context.TODO(), results); err != nil {
-		return connections.PaginationResults{}, err
-	}
+// WithContext sets the context of the next operation.
+//
+// Parameters:
+// - ctx: The context of the next operation.
+//
+// Returns:
+// - connections.MongoConnection: A reference to the MongoConnection for method chaining.
+//
+// The context is passed to the MongoDB driver and to the context-aware hooks such as BeforeInsert and AfterUpdate.
+// It is reset once the operation completes, like the other query values.
+func (mc *MongoConnection) WithContext(ctx context.Context) connections.MongoConnection {
+	mc.ctx = ctx
+	return mc
+}
 
-	return paginationResults, nil
+// WithDeleted includes the soft-deleted documents in the results of the next find or aggregation.
+//
+// Returns:
+// - connections.MongoConnection: A reference to the MongoConnection for method chaining.
+//
+// Documents read this way are never cached, as the cache only holds documents that are not deleted.
+func (mc *MongoConnection) WithDeleted() connections.MongoConnection {
+	mc.deletedScope = includeDeleted
+	return mc
 }
 
-func (mc *MongoConnection) InsertMany(interface{}) error {
-	return nil
+// OnlyDeleted restricts the results of the next find or aggregation to the soft-deleted documents.
+//
+// Returns:
+// - connections.MongoConnection: A reference to the MongoConnection for method chaining.
+//
+// Documents read this way are never cached, as the cache only holds documents that are not deleted.
+func (mc *MongoConnection) OnlyDeleted() connections.MongoConnection {
+	mc.deletedScope = deletedOnly
+	return mc
 }
-func (mc *MongoConnection) FindMany(interface{}, ...queryoptions.FindOptions) error {
-	return nil
+
+// HardDelete removes the documents of the next delete from the collection, even if their model has a softdelete field.
+//
+// Returns:
+// - connections.MongoConnection: A reference to the MongoConnection for method chaining.
+func (mc *MongoConnection) HardDelete() connections.MongoConnection {
+	mc.hardDelete = true
+	return mc
 }
-func (mc *MongoConnection) UpdateMany(interface{}, interface{}, bool) error {
-	return nil
+
+// AsOf reads the documents of historical entities as they were at a moment on the next FindOne or FindMany, from
+// the versions copied to their history collection and the documents which were not replaced since then.
+//
+// Parameters:
+// - t (time.Time): The moment read.
+//
+// Returns:
+// - connections.MongoConnection: A reference to the MongoConnection for method chaining.
+//
+// Documents read this way are never cached, as the cache only holds the current documents.
+//
+// Example:
+//   err := mc.AsOf(time.Now().Add(-time.Hour)).Where(ftypes.QMap{"email": "bob@example.com"}).FindOne(&user)
+func (mc *MongoConnection) AsOf(t time.Time) connections.MongoConnection {
+	mc.asOf = &t
+	return mc
 }
-func (mc *MongoConnection) DeleteMany(...interface{}) error {
-	return nil
+
+// beginTransaction starts a session with a transaction, the writes made with the returned context belong to the
+// transaction until it is committed or aborted. It implements transactionBeginner for runWrite.
+//
+// Parameters:
+// - ctx: The context of the operation.
+//
+// Returns:
+// - context.Context: The session context the operations of the transaction have to use.
+// - func() error: Commits the transaction and ends the session.
+// - func() error: Aborts the transaction and ends the session.
+// - error: An error if the session or the transaction cannot be started, or nil if successful.
+//
+// Transactions require MongoDB to run as a replica set or a sharded cluster.
+func (mc *MongoConnection) beginTransaction(ctx context.Context) (context.Context, func() error, func() error, error) {
+	// Start a session and a transaction in it.
+	session, err := mc.client.StartSession()
+	if err != nil {
+		return ctx, nil, nil, err
+	}
+	if err = session.StartTransaction(); err != nil {
+		session.EndSession(ctx)
+		return ctx, nil, nil, err
+	}
+	txCtx := mongo.NewSessionContext(ctx, session)
+
+	commit := func() error {
+		defer session.EndSession(ctx)
+		return session.CommitTransaction(txCtx)
+	}
+	rollback := func() error {
+		defer session.EndSession(ctx)
+		return session.AbortTransaction(txCtx)
+	}
+	return txCtx, commit, rollback, nil
 }
 
 // Skip sets the number of documents to skip in a MongoDB query.
@@ -688,64 +1660,6 @@ func (mc *MongoConnection) SetPageSize(limit int) {
 	mc.pageSize = limit
 }
 
-// parseSortableIndexes parses a list of sortable indexes and converts them into MongoDB-compatible index definitions.
-//
-// Parameters:
-// - indexes: A slice of sortable index definitions in the format "field:order", e.g., "field1:1,field2:-1".
-//
-// Returns:
-// - []bson.D: A slice of MongoDB-compatible index definitions, where each definition is a BSON document (bson.D).
-//
-// This method is used to convert a list of sortable indexes into MongoDB-compatible index definitions.
-// Each sortable index definition is in the format "field:order", where "field" is the index field name,
-// and "order" indicates the sorting order (1 for ascending, -1 for descending).
-// The method parses these definitions and constructs BSON documents for each index.
-// The resulting slice contains MongoDB-compatible index definitions.
-func (mc *MongoConnection) parseSortableIndexes(indexes []string) []bson.D {
-	mongoIndexes := []bson.D{}
-	for _, index := range indexes {
-		compounds := strings.Split(index, ",")
-		localIndex := bson.D{}
-		for _, compoundIndex := range compounds {
-			indexValues := strings.Split(compoundIndex, ":")
-			if len(indexValues) > 1 {
-				intVal, _ := strconv.ParseInt(indexValues[1], 10, 64)
-				singleIndex := bson.E{Key: indexValues[0], Value: intVal}
-				localIndex = append(localIndex, singleIndex)
-			}
-		}
-		mongoIndexes = append(mongoIndexes, localIndex)
-	}
-	return mongoIndexes
-}
-
-// parseIndexes parses a list of index fields and converts them into MongoDB-compatible index definitions.
-//
-// Parameters:
-// - indexes: A slice of index field names.
-// - indexName: The name of the index.
-//
-// Returns:
-// - []bson.D: A slice of MongoDB-compatible index definitions, where each definition is a BSON document (bson.D).
-//
-// This method is used to convert a list of index fields into MongoDB-compatible index definitions.
-// Each index definition consists of the field name and the index name.
-// The method constructs BSON documents for each index with the specified field and index name.
-// The resulting slice contains MongoDB-compatible index definitions.
-func (mc *MongoConnection) parseIndexes(indexes []string, indexName string) []bson.D {
-	mongoIndexes := []bson.D{}
-	for _, index := range indexes {
-		compounds := strings.Split(index, ",")
-		localIndex := bson.D{}
-		for _, compoundIndex := range compounds {
-			singleIndex := bson.E{Key: compoundIndex, Value: indexName}
-			localIndex = append(localIndex, singleIndex)
-		}
-		mongoIndexes = append(mongoIndexes, localIndex)
-	}
-	return mongoIndexes
-}
-
 // CreateIndexes creates indexes on a MongoDB collection based on the specified data structure's index configurations.
 //
 // Parameters:
@@ -757,118 +1671,58 @@ func (mc *MongoConnection) parseIndexes(indexes []string, indexName string) []bs
 // - error: An error if index creation fails; otherwise, it returns nil.
 //
 // This method creates indexes on a MongoDB collection based on the index configurations specified in the provided data structure.
-// It uses hooks interfaces to determine which indexes to create and their configurations.
+// It uses hooks interfaces to determine which indexes to create and their configurations. An index written with a
+// name before an equal sign, e.g. "byEmail=email:1,phone:-1", is given that name.
 func (mc *MongoConnection) CreateIndexes(data interface{}) error {
+	return mc.intercept(connections.OpCreateIndexes, func() error {
+		return mc.createIndexes(data)
+	})
+}
+
+// createIndexes runs CreateIndexes as the last handler of the middleware chain.
+func (mc *MongoConnection) createIndexes(data interface{}) error {
 	// Get the entity name from the provided data structure.
 	name, _ := mc.getEntityName(data)
 
-	// Create Normal Indexes if the data structure implements the NormalIndexes hook.
-	if val, ok := interface{}(data).(hooks.NormalIndexes); ok {
-		indexes := val.GetNormalIndexes()
-		compundIndexes := mc.parseSortableIndexes(indexes)
-		for _, index := range compundIndexes {
-			indexModel := mongo.IndexModel{
-				Keys: index,
-			}
-			_, err := mc.client.Database(mc.currentDB).Collection(name.entityName).Indexes().CreateOne(context.TODO(), indexModel)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	// Create Unique Indexes if the data structure implements the UniqueIndexes hook.
-	if val, ok := interface{}(data).(hooks.UniqueIndexes); ok {
-		indexes := val.GetUniqueIndexes()
-		compundIndexes := mc.parseSortableIndexes(indexes)
-		for _, index := range compundIndexes {
-			indexModel := mongo.IndexModel{
-				Keys:    index,
-				Options: options.Index().SetUnique(true),
-			}
-			_, err := mc.client.Database(mc.currentDB).Collection(name.entityName).Indexes().CreateOne(context.TODO(), indexModel)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	// Create Text Index if the data structure implements the TextIndexes hook.
-	if val, ok := interface{}(data).(hooks.TextIndexes); ok {
-		indexes := val.GetTextIndex()
-		compundIndexes := mc.parseIndexes([]string{indexes}, "text")
-		for _, index := range compundIndexes {
-			indexModel := mongo.IndexModel{
-				Keys: index,
-			}
-			_, err := mc.client.Database(mc.currentDB).Collection(name.entityName).Indexes().CreateOne(context.TODO(), indexModel)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	// Create 2D Indexes if the data structure implements the TwoDimensionalIndexes hook.
-	if val, ok := interface{}(data).(hooks.TwoDimensionalIndexes); ok {
-		indexes := val.Get2DIndexes()
-		compundIndexes := mc.parseIndexes(indexes, "2d")
-		for _, index := range compundIndexes {
-			indexModel := mongo.IndexModel{
-				Keys: index,
-			}
-			_, err := mc.client.Database(mc.currentDB).Collection(name.entityName).Indexes().CreateOne(context.TODO(), indexModel)
-			if err != nil {
-				return err
-			}
+	// Create the indexes declared by the index hooks the data structure implements.
+	for _, definition := range mc.indexDefinitions(data) {
+		_, err := mc.client.Database(mc.currentDB).Collection(name.entityName).Indexes().CreateOne(mc.getContext(), mc.mongoIndexModel(definition))
+		if err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	// Create 2DSphere Indexes if the data structure implements the TwoDimensionalSpatialIndexes hook.
-	if val, ok := interface{}(data).(hooks.TwoDimensionalSpatialIndexes); ok {
-		indexes := val.Get2DSpatialIndexes()
-		compundIndexes := mc.parseIndexes(indexes, "2dsphere")
-		for _, index := range compundIndexes {
-			indexModel := mongo.IndexModel{
-				Keys: index,
-			}
-			_, err := mc.client.Database(mc.currentDB).Collection(name.entityName).Indexes().CreateOne(context.TODO(), indexModel)
-			if err != nil {
-				return err
-			}
-		}
-	}
+// RunCommand runs a database command on the current database, such as collMod, createIndexes or renameCollection.
+//
+// Parameters:
+// - command (interface{}): The command document, a primitive.D or a bson.D keeping the name of the command first.
+// - result (interface{}): A pointer the reply of the server is decoded into, nil to discard it.
+//
+// Returns:
+// - error: An error if the command fails or its reply can't be decoded, or nil if successful.
+//
+// Example:
+//   err := mc.RunCommand(primitive.D{{Key: "collMod", Value: "users"}, {Key: "validationLevel", Value: "moderate"}}, nil)
+func (mc *MongoConnection) RunCommand(command interface{}, result interface{}) error {
+	return mc.intercept(connections.OpRunCommand, func() error {
+		return mc.runCommand(command, result)
+	})
+}
 
-	// Create Hashed Indexes if the data structure implements the HashedIndexes hook.
-	if val, ok := interface{}(data).(hooks.HashedIndexes); ok {
-		indexes := val.GetHashedIndexes()
-		compundIndexes := mc.parseIndexes(indexes, "hashed")
-		for _, index := range compundIndexes {
-			indexModel := mongo.IndexModel{
-				Keys: index,
-			}
-			_, err := mc.client.Database(mc.currentDB).Collection(name.entityName).Indexes().CreateOne(context.TODO(), indexModel)
-			if err != nil {
-				return err
-			}
-		}
+// runCommand runs RunCommand as the last handler of the middleware chain.
+func (mc *MongoConnection) runCommand(command interface{}, result interface{}) error {
+	// Record the command and run it on the current database.
+	mc.traceQuery(command)
+	reply := mc.client.Database(mc.currentDB).RunCommand(mc.getContext(), command)
+	if reply.Err() != nil {
+		return reply.Err()
 	}
 
-	// Create Sparse Indexes if the data structure implements the SparseIndexes hook.
-	if val, ok := interface{}(data).(hooks.SparseIndexes); ok {
-		indexes := val.GetSparseIndexes()
-		compundIndexes := mc.parseSortableIndexes(indexes)
-		for _, index := range compundIndexes {
-			indexModel := mongo.IndexModel{
-				Keys:    index,
-				Options: options.Index().SetSparse(true),
-			}
-			log.Println(mc.parseSortableIndexes(indexes))
-			_, err := mc.client.Database(mc.currentDB).Collection(name.entityName).Indexes().CreateOne(context.TODO(), indexModel)
-			log.Println(err)
-			if err != nil {
-				return err
-			}
-		}
+	// Decode the reply when the caller asked for it.
+	if result != nil {
+		return reply.Decode(result)
 	}
 	return nil
 }
@@ -1225,6 +2079,28 @@ func (mc *MongoConnection) createAggregation() bson.A {
 	return pipelines
 }
 
+// scopePipeline starts an aggregation pipeline with a '$match' stage leaving out the soft-deleted documents, unless
+// WithDeleted or OnlyDeleted is called, when the collection holds a soft-deleted model. Results decoded into maps,
+// grouped rows or other projections use the model last used with the collection.
+//
+// Parameters:
+// - data: The results of the aggregation, whose elements give the model.
+// - pipeline: The stages of the aggregation.
+//
+// Returns:
+// - bson.A: The pipeline starting with the stage of the soft delete scope if needed.
+func (mc *MongoConnection) scopePipeline(data interface{}, pipeline bson.A) bson.A {
+	model := data
+	if _, ok := mc.softDeleteField(model); !ok {
+		model = mc.entityModel(mc.tableName)
+	}
+	filter := mc.scopeMongoQuery(model, mc.deletedScope, nil)
+	if filter == nil {
+		return pipeline
+	}
+	return append(bson.A{primitive.D{{Key: "$match", Value: filter}}}, pipeline...)
+}
+
 // Aggregate performs an aggregation query on the MongoDB collection using the specified aggregation stages.
 // It constructs and executes an aggregation pipeline based on the configured stages and options.
 //
@@ -1239,22 +2115,36 @@ func (mc *MongoConnection) createAggregation() bson.A {
 // using the MongoDB Go driver. It then decodes the result of the aggregation into the provided 'data' interface{}.
 // After execution, it cleans up the aggregation and query options to prepare for future operations.
 func (mc *MongoConnection) Aggregate(data interface{}) error {
+	// Clean up the aggregation stages even if a middleware aborted the aggregation.
+	defer mc.refreshAggregation()
+
+	return mc.intercept(connections.OpAggregate, func() error {
+		return mc.aggregate(data)
+	})
+}
+
+// aggregate runs Aggregate as the last handler of the middleware chain.
+func (mc *MongoConnection) aggregate(data interface{}) error {
 	// Clean up aggregation and query options after execution.
 	defer mc.refreshAggregation()
 	defer mc.refreshValues()
 
-	// Execute the aggregation query on the MongoDB collection.
-	cursor, err := mc.client.Database(mc.currentDB).Collection(mc.tableName).Aggregate(context.TODO(), mc.createAggregation())
+	// Execute the aggregation query on the MongoDB collection, leaving out the soft-deleted documents unless they are
+	// asked for.
+	pipeline := mc.scopePipeline(data, mc.createAggregation())
+	mc.traceQuery(pipeline)
+	cursor, err := mc.client.Database(mc.currentDB).Collection(mc.tableName).Aggregate(mc.getContext(), pipeline)
 	if err != nil {
 		return err
 	}
 
 	// Decode the result of the aggregation into the provided 'data' interface{}.
-	if err = cursor.All(context.TODO(), data); err != nil {
+	if err = cursor.All(mc.getContext(), data); err != nil {
 		return err
 	}
 
-	return nil
+	// Decrypt the fields with the encrypt option of the documents decoded into models.
+	return mc.decryptResult(data)
 }
 
 // AggregatePaginate performs an aggregation query on the MongoDB collection with pagination support.
@@ -1274,6 +2164,18 @@ func (mc *MongoConnection) Aggregate(data interface{}) error {
 // After obtaining the total count, it calculates pagination information and applies the appropriate '$skip' and '$limit' stages
 // to retrieve the desired page of data. The result is then decoded into the provided 'data' interface{}.
 func (mc *MongoConnection) AggregatePaginate(data interface{}, pageNumber int) (paginationResults connections.PaginationResults, err error) {
+	// Clean up the aggregation stages even if a middleware aborted the aggregation.
+	defer mc.refreshAggregation()
+
+	err = mc.intercept(connections.OpAggregatePaginate, func() error {
+		paginationResults, err = mc.aggregatePaginate(data, pageNumber)
+		return err
+	})
+	return
+}
+
+// aggregatePaginate runs AggregatePaginate as the last handler of the middleware chain.
+func (mc *MongoConnection) aggregatePaginate(data interface{}, pageNumber int) (paginationResults connections.PaginationResults, err error) {
 	// Clean up aggregation and query options after execution.
 	defer mc.refreshAggregation()
 	defer mc.refreshValues()
@@ -1281,8 +2183,8 @@ func (mc *MongoConnection) AggregatePaginate(data interface{}, pageNumber int) (
 	// Create an aggregation stage to count the total documents.
 	countGoupStage := bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: nil}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}}
 
-	// Initialize the aggregation pipeline with stages.
-	pipelines := primitive.A{}
+	// Initialize the aggregation pipeline with stages, leaving out the soft-deleted documents unless they are asked for.
+	pipelines := mc.scopePipeline(data, primitive.A{})
 
 	// Add a '$match' stage if 'mc.match' is specified.
 	if mc.match != nil {
@@ -1299,11 +2201,12 @@ func (mc *MongoConnection) AggregatePaginate(data interface{}, pageNumber int) (
 
 	// Perform the aggregation to get the total count of documents.
 	countData := []ftypes.QMap{}
-	cursor, err := mc.client.Database(mc.currentDB).Collection(mc.tableName).Aggregate(context.TODO(), pipelines)
+	mc.traceQuery(pipelines)
+	cursor, err := mc.client.Database(mc.currentDB).Collection(mc.tableName).Aggregate(mc.getContext(), pipelines)
 	if err != nil {
 		return
 	}
-	if err = cursor.All(context.TODO(), &countData); err != nil {
+	if err = cursor.All(mc.getContext(), &countData); err != nil {
 		return
 	}
 
@@ -1322,13 +2225,16 @@ func (mc *MongoConnection) AggregatePaginate(data interface{}, pageNumber int) (
 		mc.skipAggregate = int((pageNumber - 1) * mc.pageSize)
 
 		// Execute the aggregation query with pagination parameters.
-		cursor, err = mc.client.Database(mc.currentDB).Collection(mc.tableName).Aggregate(context.TODO(), mc.createAggregation())
+		pipeline := mc.scopePipeline(data, mc.createAggregation())
+		mc.traceQuery(pipeline)
+		cursor, err = mc.client.Database(mc.currentDB).Collection(mc.tableName).Aggregate(mc.getContext(), pipeline)
 		if err != nil {
 			return
 		}
-		if err = cursor.All(context.TODO(), data); err != nil {
+		if err = cursor.All(mc.getContext(), data); err != nil {
 			return
 		}
+		err = mc.decryptResult(data)
 	}
 	return
 }
@@ -0,0 +1,237 @@
+package implementations
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// mongoFieldSample collects the values of a field across the sampled documents of a collection.
+type mongoFieldSample struct {
+	name    string
+	types   map[reflect.Type]bool // The Go types of the values which are not null.
+	null    bool                  // true if the field is null in a document.
+	present int                   // The number of documents holding the field.
+}
+
+// DescribeCollection samples the documents of a collection to infer the fields of its model, and lists its indexes,
+// to generate the model with schema.Generate.
+//
+// Parameters:
+// - collection: The name of the collection.
+// - sampleSize: The number of documents sampled with $sample, 0 or less to read every document.
+//
+// Returns:
+// - schema.Table: The collection, its fields in the order they first appear in the sampled documents.
+// - error: ErrEntityNotFound if the collection holds no document, or an error if it can't be read.
+//
+// The fields missing from some documents are omitempty and the fields which are null in some documents are
+// pointers. Fields holding both integers and doubles are float64, and fields holding values of other different types
+// are interface{}. Embedded documents are maps and arrays are slices of interface{}.
+//
+// Example:
+//   table, err := mc.DescribeCollection("users", 1000)
+//   source, err := schema.Generate("models", table)
+func (mc *MongoConnection) DescribeCollection(collection string, sampleSize int) (schema.Table, error) {
+	described := schema.Table{}
+	err := mc.intercept(connections.OpDescribe, func() error {
+		var err error
+		described, err = mc.describeCollection(collection, sampleSize)
+		return err
+	})
+	return described, err
+}
+
+// describeCollection runs DescribeCollection as the last handler of the middleware chain.
+func (mc *MongoConnection) describeCollection(collection string, sampleSize int) (schema.Table, error) {
+	described := schema.Table{Name: collection, Collection: true, Columns: make([]schema.TableColumn, 0), Indexes: make([]schema.TableIndex, 0)}
+
+	// Sample the documents, keeping the order of their fields.
+	pipeline := mongo.Pipeline{}
+	if sampleSize > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$sample", Value: bson.D{{Key: "size", Value: sampleSize}}}})
+	}
+	mc.traceQuery(pipeline)
+	cursor, err := mc.client.Database(mc.currentDB).Collection(collection).Aggregate(mc.getContext(), pipeline)
+	if err != nil {
+		return described, err
+	}
+	documents := make([]bson.D, 0)
+	if err := cursor.All(mc.getContext(), &documents); err != nil {
+		return described, err
+	}
+	if len(documents) == 0 {
+		return described, dbfusionErrors.ErrEntityNotFound
+	}
+
+	// Collect the types of the values of each field.
+	samples := make([]*mongoFieldSample, 0)
+	byName := make(map[string]*mongoFieldSample)
+	for _, document := range documents {
+		for _, element := range document {
+			sample, exists := byName[element.Key]
+			if !exists {
+				sample = &mongoFieldSample{name: element.Key, types: make(map[reflect.Type]bool)}
+				byName[element.Key] = sample
+				samples = append(samples, sample)
+			}
+			sample.present++
+			if valueType := mongoValueType(element.Value); valueType != nil {
+				sample.types[valueType] = true
+			} else {
+				sample.null = true
+			}
+		}
+	}
+	for _, sample := range samples {
+		described.Columns = append(described.Columns, mongoFieldColumn(sample, len(documents)))
+	}
+
+	// List the indexes, leaving out the index of _id.
+	indexCursor, err := mc.client.Database(mc.currentDB).Collection(collection).Indexes().List(mc.getContext())
+	if err != nil {
+		return described, err
+	}
+	indexes := make([]mongoIndex, 0)
+	if err := indexCursor.All(mc.getContext(), &indexes); err != nil {
+		return described, err
+	}
+	for _, index := range indexes {
+		if index.Name != mongoIDIndex {
+			described.Indexes = append(described.Indexes, mongoTableIndex(index))
+		}
+	}
+	return described, nil
+}
+
+// mongoValueType returns the Go type of the field of a model holding a decoded value, nil for null.
+func mongoValueType(value interface{}) reflect.Type {
+	switch value.(type) {
+	case nil:
+		return nil
+	case primitive.DateTime, primitive.Timestamp:
+		return reflect.TypeOf(time.Time{})
+	case primitive.Binary:
+		return reflect.TypeOf([]byte{})
+	case primitive.D, primitive.M:
+		return reflect.TypeOf(map[string]interface{}{})
+	case primitive.A:
+		return reflect.TypeOf([]interface{}{})
+	}
+	return reflect.TypeOf(value)
+}
+
+// mongoFieldColumn describes a sampled field, with the type holding all its values.
+//
+// Parameters:
+// - sample: The values of the field.
+// - documents: The number of sampled documents.
+//
+// Returns:
+// - schema.TableColumn: The field, omitempty if some documents miss it and a pointer if it is null in some.
+func mongoFieldColumn(sample *mongoFieldSample, documents int) schema.TableColumn {
+	column := schema.TableColumn{Name: sample.name, PrimaryKey: sample.name == "_id", OmitEmpty: sample.present < documents}
+
+	// Widen the integers to the largest type seen, the numbers to float64, the other mixes to interface{}.
+	int32Type, int64Type, float64Type := reflect.TypeOf(int32(0)), reflect.TypeOf(int64(0)), reflect.TypeOf(float64(0))
+	numbers := 0
+	for _, valueType := range []reflect.Type{int32Type, int64Type, float64Type} {
+		if sample.types[valueType] {
+			numbers++
+		}
+	}
+	switch {
+	case len(sample.types) == 1:
+		for valueType := range sample.types {
+			column.GoType = valueType
+		}
+	case numbers == len(sample.types) && sample.types[float64Type]:
+		column.GoType = float64Type
+	case numbers == len(sample.types) && numbers > 0:
+		column.GoType = int64Type
+	default:
+		column.GoType = reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+
+	// Fields which are null in some documents and can't hold nil are pointers.
+	switch column.GoType.Kind() {
+	case reflect.Interface, reflect.Map, reflect.Slice:
+	default:
+		if sample.null {
+			column.GoType = reflect.PtrTo(column.GoType)
+		}
+	}
+	return column
+}
+
+// mongoTableIndex describes an existing index. Indexes whose keys are all of the text, 2d, 2dsphere or hashed type
+// are of this kind, and the indexes with options the other hooks can't express are declared by hooks.IndexSpecs.
+func mongoTableIndex(index mongoIndex) schema.TableIndex {
+	described := schema.TableIndex{Name: index.Name, Kind: schema.NormalIndex, Keys: make([]string, 0, len(index.Key))}
+
+	// Read the keys, the fields of a text index being the ones it weights.
+	keyTypes := make(map[string]bool)
+	fields := make([]string, 0, len(index.Key))
+	for _, key := range index.Key {
+		if key.Key == "_fts" || key.Key == "_ftsx" {
+			continue
+		}
+		value := fmt.Sprint(key.Value)
+		described.Keys = append(described.Keys, key.Key+":"+value)
+		fields = append(fields, key.Key)
+		if value == "1" || value == "-1" {
+			value = ""
+		}
+		keyTypes[value] = true
+	}
+	for _, weight := range index.Weights {
+		described.Keys = append(described.Keys, weight.Key+":text")
+		keyTypes["text"] = true
+		fields = append(fields, weight.Key)
+	}
+
+	// Index the keys of a single type with the hook of the type, without their type.
+	if len(keyTypes) == 1 {
+		for keyType := range keyTypes {
+			switch keyType {
+			case schema.TextIndex, schema.TwoDIndex, schema.TwoDSphereIndex, schema.HashedIndex:
+				described.Kind, described.Keys = keyType, fields
+			}
+		}
+	}
+
+	// Declare the options with the hook of their kind, or with hooks.IndexSpecs.
+	options := 0
+	for _, set := range []bool{index.Unique, index.Sparse, index.Hidden, index.ExpireAfterSeconds != nil} {
+		if set {
+			options++
+		}
+	}
+	switch {
+	case options == 0:
+	case options == 1 && index.Unique && described.Kind == schema.NormalIndex:
+		described.Kind = schema.UniqueIndex
+	case options == 1 && index.Sparse && described.Kind == schema.NormalIndex:
+		described.Kind = schema.SparseIndex
+	default:
+		described.Unique, described.Sparse, described.Hidden = index.Unique, index.Sparse, index.Hidden
+		if index.ExpireAfterSeconds != nil {
+			expiry := int32(*index.ExpireAfterSeconds)
+			described.ExpireAfterSeconds = &expiry
+		}
+		if described.Kind != schema.NormalIndex {
+			for i, field := range described.Keys {
+				described.Keys[i] = field + ":" + described.Kind
+			}
+		}
+		described.Kind = schema.SpecIndex
+	}
+	return described
+}
@@ -0,0 +1,263 @@
+package implementations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/queryoptions"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoIDIndex is the name of the index MongoDB keeps on the _id field of every collection.
+const mongoIDIndex = "_id_"
+
+// mongoIndex is an existing index of a collection, as listed by listIndexes.
+type mongoIndex struct {
+	Name               string `bson:"name"`
+	Key                bson.D `bson:"key"`
+	Unique             bool   `bson:"unique"`
+	Sparse             bool   `bson:"sparse"`
+	Hidden             bool   `bson:"hidden"`
+	ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+	Weights            bson.D `bson:"weights"` // The fields of a text index, whose keys are _fts and _ftsx.
+}
+
+// mongoIndexModel returns the index model of a declared index.
+//
+// Parameters:
+// - definition: The index.
+//
+// Returns:
+// - mongo.IndexModel: The index model, the keys valued by their direction, or by their type for the text, 2d,
+//   2dsphere and hashed indexes, with the options of an index declared by hooks.IndexSpecs.
+func (mc *MongoConnection) mongoIndexModel(definition indexDefinition) mongo.IndexModel {
+	keys := bson.D{}
+	for _, key := range definition.keys {
+		keys = append(keys, bson.E{Key: key.field, Value: mongoKeyValue(definition, key)})
+	}
+
+	indexOptions := options.Index()
+	if definition.name != "" {
+		indexOptions.SetName(definition.name)
+	}
+	if definition.unique {
+		indexOptions.SetUnique(true)
+	}
+	if definition.sparse {
+		indexOptions.SetSparse(true)
+	}
+
+	// Set the options only hooks.IndexSpecs expresses.
+	if spec := definition.spec; spec != nil {
+		if spec.Hidden {
+			indexOptions.SetHidden(true)
+		}
+		if spec.ExpireAfterSeconds != nil {
+			indexOptions.SetExpireAfterSeconds(*spec.ExpireAfterSeconds)
+		}
+		if spec.PartialFilterExpression != nil {
+			indexOptions.SetPartialFilterExpression(spec.PartialFilterExpression)
+		}
+		if spec.WildcardProjection != nil {
+			indexOptions.SetWildcardProjection(spec.WildcardProjection)
+		}
+		if collation := spec.Collation; collation != nil {
+			indexOptions.SetCollation(&options.Collation{
+				Locale:          collation.Locale,
+				CaseLevel:       collation.CaseLevel,
+				CaseFirst:       collation.CaseFirst,
+				Strength:        collation.Strength,
+				NumericOrdering: collation.NumericOrdering,
+				Alternate:       collation.Alternate,
+				MaxVariable:     collation.MaxVariable,
+				Normalization:   collation.Normalization,
+				Backwards:       collation.Backwards,
+			})
+		}
+	}
+	return mongo.IndexModel{Keys: keys, Options: indexOptions}
+}
+
+// mongoKeyValue returns the value of a key of a declared index, 1 or -1, or the type of the key, e.g. the kind of the
+// text, 2d, 2dsphere and hashed indexes.
+func mongoKeyValue(definition indexDefinition, key indexKey) interface{} {
+	if key.keyType != "" {
+		return key.keyType
+	}
+	switch definition.kind {
+	case textIndex, twoDIndex, twoDSphereIndex, hashedIndex:
+		return definition.kind
+	}
+	if key.descending {
+		return int32(-1)
+	}
+	return int32(1)
+}
+
+// mongoIndexName returns the name of a declared index, the name MongoDB gives it if the hook doesn't name it, e.g.
+// "email_1_phone_-1".
+func mongoIndexName(definition indexDefinition) string {
+	if definition.name != "" {
+		return definition.name
+	}
+	parts := make([]string, 0, len(definition.keys))
+	for _, key := range definition.keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.field, mongoKeyValue(definition, key)))
+	}
+	return strings.Join(parts, "_")
+}
+
+// mongoIndexMatches reports whether an existing index has the name, or the keys and options, of a declared index.
+func mongoIndexMatches(index mongoIndex, definition indexDefinition) bool {
+	if index.Name == mongoIndexName(definition) {
+		return true
+	}
+	if index.Unique != definition.unique || index.Sparse != definition.sparse || len(index.Key) != len(definition.keys) {
+		return false
+	}
+
+	// Compare the keys in order, the numbers being stored as int32, int64 or double.
+	for i, key := range definition.keys {
+		if index.Key[i].Key != key.field || fmt.Sprint(index.Key[i].Value) != fmt.Sprint(mongoKeyValue(definition, key)) {
+			return false
+		}
+	}
+	return true
+}
+
+// mongoIndexChanged reports whether the TTL expiry or the hidden flag of an existing index differ from the ones of
+// the declared index it matches, the two options collMod can change.
+func mongoIndexChanged(index mongoIndex, definition indexDefinition) bool {
+	hidden, expiry := false, (*int32)(nil)
+	if definition.spec != nil {
+		hidden, expiry = definition.spec.Hidden, definition.spec.ExpireAfterSeconds
+	}
+	if index.Hidden != hidden || (index.ExpireAfterSeconds == nil) != (expiry == nil) {
+		return true
+	}
+	return expiry != nil && *index.ExpireAfterSeconds != int64(*expiry)
+}
+
+// SyncIndexes compares the indexes of a collection with the ones declared by the index hooks of its model. The
+// missing indexes are created like CreateIndexes creates them, the TTL expiry and the hidden flag of the existing
+// ones are changed with collMod, and the undeclared ones are dropped with DropUndeclared. The _id index is never
+// dropped.
+//
+// Parameters:
+// - data: The model implementing the index hooks.
+// - dbFusionOptions: Optional options to only report the differences or to drop the undeclared indexes.
+//
+// Returns:
+// - connections.IndexSyncReport: The missing, changed, undeclared, created and dropped indexes.
+// - error: An error if the indexes can't be listed, created, changed or dropped.
+//
+// Example:
+//   report, err := mc.SyncIndexes(User{}, queryoptions.SyncIndexesOptions{DropUndeclared: true})
+func (mc *MongoConnection) SyncIndexes(data interface{}, dbFusionOptions ...queryoptions.SyncIndexesOptions) (connections.IndexSyncReport, error) {
+	report := connections.IndexSyncReport{}
+	err := mc.intercept(connections.OpSyncIndexes, func() error {
+		var err error
+		report, err = mc.syncIndexes(data, dbFusionOptions...)
+		return err
+	})
+	return report, err
+}
+
+// syncIndexes runs SyncIndexes as the last handler of the middleware chain.
+func (mc *MongoConnection) syncIndexes(data interface{}, dbFusionOptions ...queryoptions.SyncIndexesOptions) (connections.IndexSyncReport, error) {
+	report := connections.IndexSyncReport{Missing: []string{}, Changed: []string{}, Undeclared: []string{}, Created: []string{}, Dropped: []string{}}
+	syncOptions := queryoptions.SyncIndexesOptions{}
+	if len(dbFusionOptions) > 0 {
+		syncOptions = dbFusionOptions[0]
+	}
+
+	// List the existing indexes of the collection of the model.
+	name, err := mc.getEntityName(data)
+	if err != nil {
+		return report, err
+	}
+	indexView := mc.client.Database(mc.currentDB).Collection(name.entityName).Indexes()
+	cursor, err := indexView.List(mc.getContext())
+	if err != nil {
+		return report, err
+	}
+	existing := make([]mongoIndex, 0)
+	if err := cursor.All(mc.getContext(), &existing); err != nil {
+		return report, err
+	}
+	definitions := mc.indexDefinitions(data)
+
+	// Create the declared indexes no existing index matches, and change the options of the ones which differ.
+	for _, definition := range definitions {
+		found := false
+		for _, index := range existing {
+			if found || !mongoIndexMatches(index, definition) {
+				continue
+			}
+			found = true
+			if !mongoIndexChanged(index, definition) {
+				continue
+			}
+			report.Changed = append(report.Changed, index.Name)
+			if syncOptions.DryRun {
+				continue
+			}
+			if err := mc.changeIndex(name.entityName, index.Name, definition); err != nil {
+				return report, err
+			}
+		}
+		if found {
+			continue
+		}
+		report.Missing = append(report.Missing, mongoIndexName(definition))
+		if syncOptions.DryRun {
+			continue
+		}
+		if _, err := indexView.CreateOne(mc.getContext(), mc.mongoIndexModel(definition)); err != nil {
+			return report, err
+		}
+		report.Created = append(report.Created, mongoIndexName(definition))
+	}
+
+	// Drop the existing indexes no declared index matches when asked to.
+	sort.Slice(existing, func(i, j int) bool { return existing[i].Name < existing[j].Name })
+	for _, index := range existing {
+		declared := index.Name == mongoIDIndex
+		for _, definition := range definitions {
+			declared = declared || mongoIndexMatches(index, definition)
+		}
+		if declared {
+			continue
+		}
+		report.Undeclared = append(report.Undeclared, index.Name)
+		if syncOptions.DryRun || !syncOptions.DropUndeclared {
+			continue
+		}
+		if _, err := indexView.DropOne(mc.getContext(), index.Name); err != nil {
+			return report, err
+		}
+		report.Dropped = append(report.Dropped, index.Name)
+	}
+	return report, nil
+}
+
+// changeIndex sets the TTL expiry and the hidden flag of an existing index to the ones of its declaration.
+//
+// Parameters:
+// - collection: The name of the collection.
+// - index: The name of the existing index.
+// - definition: The declared index.
+//
+// Returns:
+// - error: An error if collMod fails.
+func (mc *MongoConnection) changeIndex(collection string, index string, definition indexDefinition) error {
+	change := bson.D{{Key: "name", Value: index}, {Key: "hidden", Value: definition.spec != nil && definition.spec.Hidden}}
+	if definition.spec != nil && definition.spec.ExpireAfterSeconds != nil {
+		change = append(change, bson.E{Key: "expireAfterSeconds", Value: int64(*definition.spec.ExpireAfterSeconds)})
+	}
+	return mc.client.Database(mc.currentDB).RunCommand(mc.getContext(), bson.D{{Key: "collMod", Value: collection}, {Key: "index", Value: change}}).Err()
+}
@@ -0,0 +1,83 @@
+package implementations
+
+import (
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/validation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateCollection creates the collection of a model with a $jsonSchema validator, so that MongoDB rejects the
+// documents which don't match the model. The schema is derived by validation.JSONSchema from the types of the fields
+// and the rules of their validate tags: required fields, enums, nested structs and arrays. When the collection
+// already exists its validator is replaced with collMod.
+//
+// Parameters:
+// - data: The model, a struct or a pointer to a struct.
+// - opts: Optional options setting the validation level and action of the collection.
+//
+// Returns:
+// - error: ErrInvalidType if the model is not a struct, ErrValidationRuleNotSupported if a validate tag is invalid,
+//   or an error if the collection can't be created or modified.
+//
+// Example:
+//   err := mc.CreateCollection(User{}, queryoptions.CreateCollectionOptions{
+//       ValidationLevel:  queryoptions.ValidationLevelModerate,
+//       ValidationAction: queryoptions.ValidationActionError,
+//   })
+func (mc *MongoConnection) CreateCollection(data interface{}, opts ...queryoptions.CreateCollectionOptions) error {
+	return mc.intercept(connections.OpCreateCollection, func() error {
+		return mc.createCollection(data, opts...)
+	})
+}
+
+// createCollection runs CreateCollection as the last handler of the middleware chain.
+func (mc *MongoConnection) createCollection(data interface{}, opts ...queryoptions.CreateCollectionOptions) error {
+	collectionOptions := queryoptions.CreateCollectionOptions{}
+	if len(opts) > 0 {
+		collectionOptions = opts[0]
+	}
+
+	// Derive the validator from the model.
+	name, err := mc.getEntityName(data)
+	if err != nil {
+		return err
+	}
+	schema, err := validation.GetInstance().JSONSchema(data)
+	if err != nil {
+		return err
+	}
+	validator := bson.D{{Key: "$jsonSchema", Value: schema}}
+
+	// Look for the collection.
+	database := mc.client.Database(mc.currentDB)
+	names, err := database.ListCollectionNames(mc.getContext(), bson.D{{Key: "name", Value: name.entityName}})
+	if err != nil {
+		return err
+	}
+
+	// Replace the validator of an existing collection.
+	if len(names) > 0 {
+		command := bson.D{{Key: "collMod", Value: name.entityName}, {Key: "validator", Value: validator}}
+		if collectionOptions.ValidationLevel != "" {
+			command = append(command, bson.E{Key: "validationLevel", Value: collectionOptions.ValidationLevel})
+		}
+		if collectionOptions.ValidationAction != "" {
+			command = append(command, bson.E{Key: "validationAction", Value: collectionOptions.ValidationAction})
+		}
+		mc.traceQuery(command)
+		return database.RunCommand(mc.getContext(), command).Err()
+	}
+
+	// Create the collection with the validator otherwise.
+	createOptions := options.CreateCollection().SetValidator(validator)
+	if collectionOptions.ValidationLevel != "" {
+		createOptions.SetValidationLevel(collectionOptions.ValidationLevel)
+	}
+	if collectionOptions.ValidationAction != "" {
+		createOptions.SetValidationAction(collectionOptions.ValidationAction)
+	}
+	mc.traceQuery(validator)
+	return database.CreateCollection(mc.getContext(), name.entityName, createOptions)
+}
@@ -1,25 +1,58 @@
 package implementations
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"math"
+	"reflect"
+	"strings"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 
+	"github.com/glodb/dbfusion/audit"
 	"github.com/glodb/dbfusion/conditions"
 	"github.com/glodb/dbfusion/connections"
 	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/ftypes"
 	"github.com/glodb/dbfusion/hooks"
 	"github.com/glodb/dbfusion/joins"
 	"github.com/glodb/dbfusion/queryoptions"
 	"github.com/glodb/dbfusion/utils"
+	"github.com/glodb/dbfusion/validation"
 )
 
 // MySql represents a type for interacting with a MySQL database. It embeds the SqlBase type to reuse its methods and fields.
 type MySql struct {
 	SqlBase         // Embedding SqlBase for code reuse.
 	db      *sql.DB // db is a reference to a MySQL database connection.
+	tx      *sql.Tx // tx is the transaction of the running write, nil outside of transactions.
+}
+
+// tracedExecutor records the statements and arguments it runs before handing them to the underlying executor.
+type tracedExecutor struct {
+	sqlExecutor
+	trace func(query interface{}, args ...interface{})
+}
+
+// ExecContext records the statement and executes it.
+func (te tracedExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	te.trace(query, args...)
+	return te.sqlExecutor.ExecContext(ctx, query, args...)
+}
+
+// QueryContext records the statement and runs the query.
+func (te tracedExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	te.trace(query, args...)
+	return te.sqlExecutor.QueryContext(ctx, query, args...)
+}
+
+// sqlExecutor is implemented by both *sql.DB and *sql.Tx, so that the same queries run inside and outside of
+// transactions.
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
 
 func (ms *MySql) ConnectWithCertificate(uri string, filePath string) error {
@@ -45,6 +78,11 @@ func (ms *MySql) Connect(uri string) error {
 	// Set the established database connection in the MySql instance.
 	ms.db = db
 
+	// Keep the address of the server, which namespaces the write-behind queues. sql.Open already validated the DSN.
+	if config, err := mysql.ParseDSN(uri); err == nil {
+		ms.server = config.Addr
+	}
+
 	// Return nil, indicating a successful connection.
 	return nil
 }
@@ -72,6 +110,13 @@ func (ms *MySql) Table(tablename string) connections.SQLConnection {
 // Returns:
 // - error: An error if the insertion operation fails, or nil if successful.
 func (ms *MySql) InsertOne(data interface{}) error {
+	return ms.intercept(connections.OpInsertOne, func() error {
+		return ms.insertOne(data)
+	})
+}
+
+// insertOne runs InsertOne as the last handler of the middleware chain.
+func (ms *MySql) insertOne(data interface{}) error {
 	// Ensure that values are reset after the operation.
 	defer ms.refreshValues()
 
@@ -82,14 +127,35 @@ func (ms *MySql) InsertOne(data interface{}) error {
 		return err
 	}
 
-	// Execute the SQL insert query with the provided values.
-	_, err = ms.db.Exec(query, values...)
-
-	// Perform post-insert operations if the insert was successful.
-	if err == nil {
-		err = ms.postInsert(ms.cache, preCreateData.Data, preCreateData.mData, ms.currentDB, preCreateData.entityName)
+	// Write-behind entities are cached and queued, the worker writes them to the database later on.
+	if ms.getCachePolicy(data).Has(hooks.WriteBehind) {
+		return ms.insertWriteBehind("mysql", ms.writeQueuedRecord, preCreateData)
 	}
 
+	// Execute the SQL insert query and the AfterInsert hook, in a transaction if the data asks for one,
+	// then perform the post-insert operations.
+	err = ms.runWrite(preCreateData.Data, ms.beginTransaction,
+		func(ctx context.Context) error {
+			result, err := ms.executor().ExecContext(ctx, query, values...)
+			if err != nil {
+				return err
+			}
+
+			// Read back the primary key generated by the database so that it is cached and set in the data.
+			if err := ms.readInsertID(result, preCreateData); err != nil || !ms.useAudit(preCreateData.Data) {
+				return err
+			}
+
+			// Record the insertion in the audit trail.
+			return ms.writeAudit(ctx, ms.auditEntry(ctx, preCreateData.entityName, audit.Insert, preCreateData.Data, nil, preCreateData.mData))
+		},
+		func(ctx context.Context) error {
+			return ms.afterInsert(ctx, preCreateData.Data)
+		},
+		func() error {
+			return ms.postInsert(ms.cache, preCreateData.Data, preCreateData.mData, ms.currentDB, preCreateData.entityName)
+		})
+
 	// Return any errors encountered during the operation.
 	return err
 }
@@ -103,6 +169,13 @@ func (ms *MySql) InsertOne(data interface{}) error {
 // Returns:
 // - error: An error if the retrieval operation fails, or nil if successful.
 func (ms *MySql) FindOne(result interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
+	return ms.intercept(connections.OpFindOne, func() error {
+		return ms.findOne(result, dbFusionOptions...)
+	})
+}
+
+// findOne runs FindOne as the last handler of the middleware chain.
+func (ms *MySql) findOne(result interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
 	// Ensure that values are reset after the operation.
 	defer ms.refreshValues()
 
@@ -123,6 +196,9 @@ func (ms *MySql) FindOne(result interface{}, dbFusionOptions ...queryoptions.Fin
 		ms.whereQuery = &conditions.SqlData{}
 	}
 
+	// Leave out the soft-deleted records unless they are asked for.
+	valuesInterface = ms.scopeSqlQuery(result, ms.deletedScope, valuesInterface)
+
 	// Prepare for the preFind operation.
 	prefindReturn, err := ms.preFind(ms.cache, result, dbFusionOptions...)
 
@@ -138,11 +214,15 @@ func (ms *MySql) FindOne(result interface{}, dbFusionOptions ...queryoptions.Fin
 			valuesInterface = append(valuesInterface, ms.havingValues...)
 		}
 
-		// Create the SQL SELECT query for retrieving one record.
-		query := ms.createFindQuery(prefindReturn.entityName, true)
+		// Create the SQL SELECT query for retrieving one record, from the versions of the history with AsOf.
+		source, queryValues, err := ms.findSource(prefindReturn.entityName, result, valuesInterface)
+		if err != nil {
+			return err
+		}
+		query := ms.createFindQuery(source, true)
 
 		// Execute the query and retrieve the data.
-		rows, err := ms.db.Query(query, valuesInterface...)
+		rows, err := ms.executor().QueryContext(ms.getContext(), query, queryValues...)
 		if err != nil {
 			return err
 		}
@@ -150,6 +230,19 @@ func (ms *MySql) FindOne(result interface{}, dbFusionOptions ...queryoptions.Fin
 		if err != nil {
 			return err
 		}
+
+		// Cache the record read from the database for read-through entities.
+		err = ms.readThrough(ms.cache, result, prefindReturn.entityName)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Decrypt the record read from the cache, the records read from the database are decrypted as they are read.
+	if !prefindReturn.queryDatabase {
+		if err := ms.decryptResult(result); err != nil {
+			return err
+		}
 	}
 
 	// Perform post-find operations.
@@ -167,6 +260,13 @@ func (ms *MySql) FindOne(result interface{}, dbFusionOptions ...queryoptions.Fin
 // Returns:
 // - error: An error if the update operation fails, or nil if successful.
 func (ms *MySql) UpdateAndFindOne(data interface{}, result interface{}, upsert bool) error {
+	return ms.intercept(connections.OpUpdateAndFindOne, func() error {
+		return ms.updateAndFindOne(data, result, upsert)
+	})
+}
+
+// updateAndFindOne runs UpdateAndFindOne as the last handler of the middleware chain.
+func (ms *MySql) updateAndFindOne(data interface{}, result interface{}, upsert bool) error {
 	// Ensure that values are reset after the operation.
 	defer ms.refreshValues()
 
@@ -187,14 +287,25 @@ func (ms *MySql) UpdateAndFindOne(data interface{}, result interface{}, upsert b
 		ms.whereQuery = &conditions.SqlData{}
 	}
 
+	// Soft-deleted records are only updated when WithDeleted or OnlyDeleted selects them.
+	valuesInterface = ms.scopeSqlQuery(result, ms.deletedScope, valuesInterface)
+
+	// Check the update data against the rules of its validate tags.
+	if err := validation.GetInstance().Validate(data); err != nil {
+		return err
+	}
+
 	// Prepare for the preUpdate operation.
-	preUpdateReturn, err := ms.preUpdate(result, connections.MYSQL)
+	preUpdateReturn, err := ms.preUpdate(result, connections.MYSQL, result, upsert)
+	if err != nil {
+		return err
+	}
 
 	// Create a SQL SELECT query to retrieve the record.
 	query := ms.createFindQuery(preUpdateReturn.entityName, true)
 
 	// Execute the query to retrieve the record.
-	rows, err := ms.db.Query(query, valuesInterface...)
+	rows, err := ms.executor().QueryContext(ms.getContext(), query, valuesInterface...)
 	if err != nil {
 		return err
 	}
@@ -205,6 +316,30 @@ func (ms *MySql) UpdateAndFindOne(data interface{}, result interface{}, upsert b
 		return err
 	}
 
+	// Versioned records are only updated if they still hold the version carried by the data, or else the version
+	// just read.
+	version, versioned := ms.versionField(preUpdateReturn.dataType)
+	checkVersion := versioned && rowsCount != 0
+	var expected interface{}
+	if checkVersion {
+		var ok bool
+		if expected, ok = ms.expectedVersion(data, version); !ok {
+			if expected, ok = ms.structVersion(result, version); !ok {
+				expected = 0
+			}
+		}
+	}
+
+	// Keep the values of the record before the update for the audit trail and the history.
+	audited := ms.useAudit(result)
+	historical := ms.useHistory(result)
+	var before map[string]interface{}
+	if (audited || historical) && rowsCount != 0 {
+		if before, err = ms.createTagValueMap(result); err != nil {
+			return err
+		}
+	}
+
 	oldValues := make([]string, 0)
 	newValues := make([]string, 0)
 	updateCache := false
@@ -222,18 +357,28 @@ func (ms *MySql) UpdateAndFindOne(data interface{}, result interface{}, upsert b
 		}
 	}
 
-	// Check if the record is not found, and upsert is enabled.
-	if rowsCount == 0 && upsert {
-		// Insert the record into the database.
-		query, values, _, err := ms.createSqlInsert(data)
-		if err != nil {
-			return err
-		}
-		_, err = ms.db.Exec(query, values...)
-		if err != nil {
-			return err
+	// Write the record and run the AfterUpdate hook, in a transaction if the result asks for one.
+	write := func(ctx context.Context) error {
+		// Check if the record is not found, and upsert is enabled.
+		if rowsCount == 0 && upsert {
+			// Insert the record into the database with the timestamps of the model and its encrypted fields encrypted.
+			insertData, err := ms.encryptMap(ms.mapWithTimestamps(data, preUpdateReturn.dataType, time.Now(), autoCreateTimeOption, autoUpdateTimeOption), preUpdateReturn.dataType)
+			if err != nil {
+				return err
+			}
+			query, values, insertCreateData, err := ms.createSqlInsert(insertData)
+			if err != nil {
+				return err
+			}
+			_, err = ms.executor().ExecContext(ctx, query, values...)
+			if err != nil || !audited {
+				return err
+			}
+
+			// Record the upserted record in the audit trail as an insertion.
+			return ms.writeAudit(ctx, ms.auditEntry(ctx, preUpdateReturn.entityName, audit.Insert, result, nil, insertCreateData.mData))
 		}
-	} else {
+
 		// Update the record in the database.
 		commands, setValues, err := ms.buildMySqlUpdate(data,
 			entityData{
@@ -246,26 +391,66 @@ func (ms *MySql) UpdateAndFindOne(data interface{}, result interface{}, upsert b
 			return err
 		}
 		setValues = append(setValues, valuesInterface...)
+		if checkVersion {
+			condition, conditionValues := ms.versionCondition(version, expected)
+			ms.andSqlCondition(condition, conditionValues)
+			setValues = append(setValues, conditionValues...)
+		}
 		query := ms.createUpdateQuery(preUpdateReturn.entityName, commands, true)
-		_, err = ms.db.Exec(query, setValues...)
-
+		sqlResult, err := ms.executor().ExecContext(ctx, query, setValues...)
 		if err != nil {
 			return err
 		}
-	}
 
-	// Merge the results of the select query and data provided to update the cache values.
-	merged := ms.merge(data, result)
+		if checkVersion {
+			// Nothing was updated if another update changed the version since it was read.
+			affected, err := sqlResult.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				return dbfusionErrors.ErrStaleVersion
+			}
+
+			// Keep the version of the data and the result, and of the payload cached from it, in step with the database.
+			ms.setStructVersion(data, version, expected)
+			ms.setStructVersion(result, version, expected)
+		}
+
+		// Copy the previous version of the record to the history.
+		if historical && before != nil {
+			if err := ms.writeHistory(ctx, preUpdateReturn.entityName, result, before, time.Now()); err != nil {
+				return err
+			}
+		}
 
-	if updateCache {
-		// Create a map of tag values from the merged result.
-		tagMapValue, _ := ms.createTagValueMap(merged)
-		// Get the new cache values and update the cache.
-		newValues = ms.getAllCacheValues(cacheHook, tagMapValue, preUpdateReturn.entityName)
+		// Record the update of an existing record in the audit trail.
+		if !audited || before == nil {
+			return nil
+		}
+		after := ms.overlay(before, ms.updateValues(data))
+		if checkVersion {
+			after[version.name], _ = ms.structVersion(result, version)
+		}
+		return ms.writeAudit(ctx, ms.auditEntry(ctx, preUpdateReturn.entityName, audit.Update, result, before, after))
 	}
-	err = ms.postUpdate(ms.cache, result, preUpdateReturn.entityName, oldValues, newValues)
 
-	return nil
+	return ms.runWrite(result, ms.beginTransaction, write,
+		func(ctx context.Context) error {
+			return ms.afterUpdate(ctx, result)
+		},
+		func() error {
+			// Merge the results of the select query and data provided to update the cache values.
+			merged := ms.merge(data, result)
+
+			if updateCache {
+				// Create a map of tag values from the merged result.
+				tagMapValue, _ := ms.createTagValueMap(merged)
+				// Get the new cache values and update the cache.
+				newValues = ms.getAllCacheValues(cacheHook, tagMapValue, preUpdateReturn.entityName)
+			}
+			return ms.postUpdate(ms.cache, result, preUpdateReturn.entityName, oldValues, newValues)
+		})
 }
 
 // DeleteOne deletes a record from the MySQL database table based on the provided conditions.
@@ -276,6 +461,13 @@ func (ms *MySql) UpdateAndFindOne(data interface{}, result interface{}, upsert b
 // Returns:
 // - error: An error if the delete operation fails, or nil if successful.
 func (ms *MySql) DeleteOne(sliceData ...interface{}) error {
+	return ms.intercept(connections.OpDeleteOne, func() error {
+		return ms.deleteOne(sliceData...)
+	})
+}
+
+// deleteOne runs DeleteOne as the last handler of the middleware chain.
+func (ms *MySql) deleteOne(sliceData ...interface{}) error {
 	// Ensure that values are reset after the operation.
 	defer ms.refreshValues()
 
@@ -304,37 +496,122 @@ func (ms *MySql) DeleteOne(sliceData ...interface{}) error {
 		return err
 	}
 
-	if data != nil { // Need to delete from a struct
-		whereConditions, dataInterface, err := ms.buildMySqlDeleteData(preDeleteData.dataType, preDeleteData.dataValue)
-		selectQuery := fmt.Sprintf("SELECT * from %s LIMIT 1", preDeleteData.entityName)
-		if whereConditions != "" {
-			selectQuery = fmt.Sprintf("SELECT * from %s WHERE %s LIMIT 1", preDeleteData.entityName, whereConditions)
-		}
+	// Records of soft-deleted models get their deletion time set instead of being removed. Deletions by WHERE
+	// conditions use the model last used with the entity.
+	model := data
+	if model == nil {
+		model = ms.entityModel(preDeleteData.entityName)
+	}
+	softDelete, soft := ms.useSoftDelete(model)
+	whereValues := ms.whereQuery.(conditions.DBFusionData).GetValues().([]interface{})
+	if data == nil && soft {
+		whereValues = ms.scopeSqlQuery(model, excludeDeleted, whereValues)
+	}
 
-		// Execute the SELECT query to check if the record exists.
-		rows, err := ms.db.Query(selectQuery, dataInterface...)
-		if err != nil {
-			return err
-		}
-		rowsCount, err := ms.readSqlDataFromRows(rows, preDeleteData.dataType, preDeleteData.dataValue)
+	// Delete the record and run the AfterDelete hook, in a transaction if the data asks for one.
+	var deletedData map[string]interface{}
+	write := func(ctx context.Context) error {
+		if data != nil { // Need to delete from a struct
+			whereConditions, dataInterface, err := ms.buildMySqlDeleteData(preDeleteData.dataType, preDeleteData.dataValue)
+			if err != nil {
+				return err
+			}
+
+			// A struct holding its primary key matches the record of the key only.
+			if key, ok := ms.primaryKey(preDeleteData.dataType); ok {
+				if id, ok := ms.structID(data, key); ok {
+					whereConditions = fmt.Sprintf("%s = ?", key.name)
+					dataInterface = []interface{}{id}
+				}
+			}
+
+			// Only records that are not deleted yet can be soft deleted.
+			if soft {
+				condition, conditionValues := ms.softDeleteCondition(softDelete, excludeDeleted)
+				if whereConditions != "" {
+					whereConditions = fmt.Sprintf("%s AND %s", whereConditions, condition)
+				} else {
+					whereConditions = condition
+				}
+				dataInterface = append(dataInterface, conditionValues...)
+			}
+			selectQuery := fmt.Sprintf("SELECT * from %s LIMIT 1", preDeleteData.entityName)
+			if whereConditions != "" {
+				selectQuery = fmt.Sprintf("SELECT * from %s WHERE %s LIMIT 1", preDeleteData.entityName, whereConditions)
+			}
+
+			// Execute the SELECT query to check if the record exists.
+			rows, err := ms.executor().QueryContext(ctx, selectQuery, dataInterface...)
+			if err != nil {
+				return err
+			}
+			rowsCount, err := ms.readSqlDataFromRows(rows, preDeleteData.dataType, preDeleteData.dataValue)
+			if err != nil || rowsCount != 1 {
+				return err
+			}
+
+			// If the record exists, create a DELETE query, or the UPDATE query of a soft delete, and execute it.
+			if soft {
+				deleteQuery := ms.createSoftDeleteQuery(preDeleteData.entityName, softDelete.name, whereConditions, true)
+				_, err = ms.executor().ExecContext(ctx, deleteQuery, append([]interface{}{ms.deletionTime(softDelete)}, dataInterface...)...)
+			} else {
+				deleteQuery := ms.createDeleteQuery(preDeleteData.entityName, whereConditions, true)
+				_, err = ms.executor().ExecContext(ctx, deleteQuery, dataInterface...)
+			}
+			if err != nil {
+				return err
+			}
 
-		if rowsCount == 1 {
-			// If the record exists, create a DELETE query and execute it.
-			deleteQuery := ms.createDeleteQuery(preDeleteData.entityName, whereConditions, true)
-			_, err := ms.db.Query(deleteQuery, dataInterface...)
+			// Keep the values of the deleted record to remove it from the cache.
+			deletedData, err = ms.createTagValueMap(data)
 			if err != nil {
 				return err
 			}
+
+			// Copy the last version of the record to the history.
+			if ms.useHistory(data) {
+				if err := ms.writeHistory(ctx, preDeleteData.entityName, data, deletedData, time.Now()); err != nil {
+					return err
+				}
+			}
+			if !ms.useAudit(data) {
+				return nil
+			}
+
+			// Record the deletion in the audit trail.
+			return ms.writeAudit(ctx, ms.auditEntry(ctx, preDeleteData.entityName, audit.Delete, data, deletedData, nil))
 		}
-	} else { // Need to delete based on WHERE conditions
-		// Create a DELETE query and execute it.
-		deleteQuery := ms.createDeleteQuery(preDeleteData.entityName, "", true)
-		_, err := ms.db.Query(deleteQuery, ms.whereQuery.(conditions.DBFusionData).GetValues().([]interface{})...)
-		if err != nil {
+
+		// Need to delete based on WHERE conditions
+		// Set the deletion time of a record that is not deleted yet, or create a DELETE query, and execute it.
+		if soft {
+			deleteQuery := ms.createSoftDeleteQuery(preDeleteData.entityName, softDelete.name, "", true)
+			_, err := ms.executor().ExecContext(ctx, deleteQuery, append([]interface{}{ms.deletionTime(softDelete)}, whereValues...)...)
 			return err
 		}
+		deleteQuery := ms.createDeleteQuery(preDeleteData.entityName, "", true)
+		_, err := ms.executor().ExecContext(ctx, deleteQuery, whereValues...)
+		return err
 	}
-	return nil
+
+	return ms.runWrite(data, ms.beginTransaction, write,
+		func(ctx context.Context) error {
+			return ms.afterDelete(ctx, data)
+		},
+		func() error {
+			// Nothing was deleted if the record of the struct wasn't found.
+			if data != nil && deletedData == nil {
+				return nil
+			}
+
+			// The keys of a record soft deleted by WHERE conditions are not known.
+			if data == nil && soft {
+				if err := ms.invalidateDeleted(ms.cache, model, preDeleteData.entityName); err != nil {
+					return err
+				}
+			}
+			return ms.postDelete(ms.cache, data, preDeleteData.entityName, deletedData)
+		})
 }
 
 // DisConnect disconnects from the MySQL database.
@@ -342,9 +619,52 @@ func (ms *MySql) DeleteOne(sliceData ...interface{}) error {
 // Returns:
 // - error: An error if the disconnection fails, or nil if successful.
 func (ms *MySql) DisConnect() error {
+	// Stop writing queued write-behind records, the remaining ones stay queued in the cache.
+	ms.stopWriteBehind()
 	return ms.db.Close()
 }
 
+// StartWriteBehind starts writing the records queued by write-behind insertions to the database. The worker is
+// started automatically by the first write-behind insertion, calling this method at startup writes the records
+// queued by previous processes right away.
+//
+// Returns:
+// - error: An error if the cache doesn't provide queues, or nil if successful.
+func (ms *MySql) StartWriteBehind() error {
+	_, err := ms.startWriteBehind("mysql", ms.writeQueuedRecord)
+	return err
+}
+
+// writeQueuedRecord writes a record queued by a write-behind insertion to its table.
+//
+// Parameters:
+// - dbName (string): The name of the database the record was queued for, which qualifies the table.
+// - entityName (string): The name of the table.
+// - data (map[string]interface{}): The record as a map of column names to values.
+//
+// Returns:
+// - error: An error if the insertion fails, or nil if successful.
+func (ms *MySql) writeQueuedRecord(dbName string, entityName string, data map[string]interface{}) error {
+	keys := make([]string, 0, len(data))
+	placeholders := make([]string, 0, len(data))
+	values := make([]interface{}, 0, len(data))
+	for key, value := range data {
+		keys = append(keys, key)
+		placeholders = append(placeholders, "?")
+		values = append(values, value)
+	}
+
+	// The table is qualified with the database, which may differ from the one selected by the connection.
+	tableName := entityName
+	if dbName != "" {
+		tableName = dbName + "." + entityName
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", tableName, strings.Join(keys, ","), strings.Join(placeholders, ","))
+	_, err := ms.db.Exec(query, values...)
+	return err
+}
+
 // Paginate fetches a page of results from the MySQL database and populates the provided results interface.
 //
 // Parameters:
@@ -354,7 +674,16 @@ func (ms *MySql) DisConnect() error {
 // Returns:
 // - paginationResults: A struct containing pagination information.
 // - error: An error if the pagination process fails, or nil if successful.
-func (ms *MySql) Paginate(results interface{}, pageNumber int) (connections.PaginationResults, error) {
+func (ms *MySql) Paginate(results interface{}, pageNumber int) (paginationResults connections.PaginationResults, err error) {
+	err = ms.intercept(connections.OpPaginate, func() error {
+		paginationResults, err = ms.paginate(results, pageNumber)
+		return err
+	})
+	return
+}
+
+// paginate runs Paginate as the last handler of the middleware chain.
+func (ms *MySql) paginate(results interface{}, pageNumber int) (connections.PaginationResults, error) {
 	defer ms.refreshValues()
 
 	// Check if a whereQuery exists and convert it to SQL format if necessary
@@ -368,11 +697,14 @@ func (ms *MySql) Paginate(results interface{}, pageNumber int) (connections.Pagi
 		ms.whereQuery = &conditions.SqlData{}
 	}
 
+	// Leave out the soft-deleted records unless they are asked for.
+	valuesInterface := ms.scopeSqlQuery(results, ms.deletedScope, ms.whereQuery.(conditions.DBFusionData).GetValues().([]interface{}))
+
 	var paginationResults connections.PaginationResults
 	countQuery := ms.createCountQuery(ms.tableName)
 
 	var count int64
-	row, err := ms.db.Query(countQuery)
+	row, err := ms.executor().QueryContext(ms.getContext(), countQuery, valuesInterface...)
 	if err != nil {
 		return paginationResults, err
 	}
@@ -402,7 +734,7 @@ func (ms *MySql) Paginate(results interface{}, pageNumber int) (connections.Pagi
 	ms.skip = int64(pageNumber * ms.pageSize)
 
 	findQuery := ms.createFindQuery(ms.tableName, false)
-	rows, err := ms.db.Query(findQuery)
+	rows, err := ms.executor().QueryContext(ms.getContext(), findQuery, valuesInterface...)
 
 	if err != nil {
 		return paginationResults, err
@@ -423,6 +755,13 @@ func (ms *MySql) Paginate(results interface{}, pageNumber int) (connections.Pagi
 // Returns:
 // - error: An error if the table creation process fails, or nil if successful.
 func (ms *MySql) CreateTable(data interface{}, ifNotExist bool) error {
+	return ms.intercept(connections.OpCreateTable, func() error {
+		return ms.createTable(data, ifNotExist)
+	})
+}
+
+// createTable runs CreateTable as the last handler of the middleware chain.
+func (ms *MySql) createTable(data interface{}, ifNotExist bool) error {
 	// Generate the SQL query for creating the table
 	query, err := ms.createTableQuery(data, ifNotExist)
 	if err != nil {
@@ -430,97 +769,861 @@ func (ms *MySql) CreateTable(data interface{}, ifNotExist bool) error {
 	}
 
 	// Execute the SQL query to create the table
-	_, err = ms.db.Exec(query)
+	_, err = ms.executor().ExecContext(ms.getContext(), query)
 	return err
 }
 
-// New methods for bulk operations.
-func (ms *MySql) InsertMany(interface{}) error {
-	return nil
-}
-func (mc *MySql) FindMany(interface{}, ...queryoptions.FindOptions) error {
-	return nil
-}
-func (ms *MySql) UpdateMany(interface{}, interface{}, bool) error {
-	return nil
-}
-func (ms *MySql) DeleteMany(...interface{}) error {
-	return nil
-}
-
-// Skip sets the number of records to skip when performing a query.
+// CreateAuditTable creates the table the audit entries of the entities implementing hooks.Auditable are written to,
+// named by SetAuditEntity or audit.DefaultEntity.
 //
 // Parameters:
-// - skip: The number of records to skip.
+// - ifNotExist (bool): Indicates whether to create the table only if it doesn't exist.
 //
 // Returns:
-// - connections.SQLConnection: The MySQL connection instance for method chaining.
-func (ms *MySql) Skip(skip int64) connections.SQLConnection {
-	ms.skip = skip
-	return ms
+// - error: An error if the table creation fails, or nil if successful.
+//
+// Example:
+//   err := ms.CreateAuditTable(true)
+func (ms *MySql) CreateAuditTable(ifNotExist bool) error {
+	return ms.intercept(connections.OpCreateTable, func() error {
+		return ms.createAuditTable(ifNotExist)
+	})
 }
 
-// Limit sets the maximum number of records to retrieve when performing a query.
-//
-// Parameters:
-// - limit: The maximum number of records to retrieve.
-//
-// Returns:
-// - connections.SQLConnection: The MySQL connection instance for method chaining.
-func (ms *MySql) Limit(limit int64) connections.SQLConnection {
-	ms.limit = limit
-	return ms
+// createAuditTable runs CreateAuditTable as the last handler of the middleware chain.
+func (ms *MySql) createAuditTable(ifNotExist bool) error {
+	// Record the audit table as the entity of the operation and create it.
+	ms.traceEntity(ms.auditEntityName())
+	_, err := ms.executor().ExecContext(ms.getContext(), ms.createAuditTableQuery(ms.auditEntityName(), ifNotExist))
+	return err
 }
 
-// Select specifies the columns to include in the query result.
+// CreateHistoryTable creates the table the previous versions of a model implementing hooks.Historical are copied to,
+// named after the table of the model with the "_history" suffix.
 //
 // Parameters:
-// - keys: A map where the keys represent column names, and the values indicate whether to include the column.
+// - tableType (interface{}): The model of the entity, which must have a primary key.
+// - ifNotExist (bool): Indicates whether to create the table only if it doesn't exist.
 //
 // Returns:
-// - connections.SQLConnection: The MySQL connection instance for method chaining.
-func (ms *MySql) Select(keys map[string]bool) connections.SQLConnection {
-	selectionKeys := make([]string, 0)
+// - error: An error if the table creation fails, or nil if successful.
+//
+// Example:
+//   err := ms.CreateHistoryTable(models.Product{}, true)
+func (ms *MySql) CreateHistoryTable(tableType interface{}, ifNotExist bool) error {
+	return ms.intercept(connections.OpCreateTable, func() error {
+		return ms.createHistoryTable(tableType, ifNotExist)
+	})
+}
 
-	for key, val := range keys {
-		if val {
-			selectionKeys = append(selectionKeys, key)
-		}
+// createHistoryTable runs CreateHistoryTable as the last handler of the middleware chain.
+func (ms *MySql) createHistoryTable(tableType interface{}, ifNotExist bool) error {
+	// Generate the query, which records the history table as the entity of the operation.
+	query, err := ms.createHistoryTableQuery(tableType, ifNotExist)
+	if err != nil {
+		return err
 	}
-	ms.projection = selectionKeys
-	return ms
+	_, err = ms.executor().ExecContext(ms.getContext(), query)
+	return err
 }
 
-// Sort sets the sorting order for query results based on a specified column.
+// New methods for bulk operations.
+func (ms *MySql) InsertMany(interface{}) error {
+	return nil
+}
+
+// FindMany retrieves every record of the MySQL database table matching the conditions set with Where.
 //
 // Parameters:
-// - sortKey: The name of the column to sort by.
-// - sortdesc: An optional boolean parameter to indicate descending sorting (default is ascending).
+// - results (interface{}): A pointer to the slice of structs where the retrieved records will be stored.
+// - dbFusionOptions (...queryoptions.FindOptions): Optional FindOptions, the records are always read from the database.
 //
 // Returns:
-// - connections.SQLConnection: The MySQL connection instance for method chaining.
-func (ms *MySql) Sort(sortKey string, sortdesc ...bool) connections.SQLConnection {
-	sortString := sortKey
-	sortVal := " ASC"
-	if len(sortdesc) > 0 {
-		if !sortdesc[0] {
-			sortVal = " DESC"
-		}
-	}
-	sortString += sortVal
-	if ms.sort != nil {
-		sortedValues := ms.sort.(string)
-		if sortedValues != "" {
-			sortedValues += "," + sortString
+// - error: An error if the retrieval operation fails, or nil if successful.
+//
+// Soft-deleted records are left out unless WithDeleted or OnlyDeleted is called.
+func (ms *MySql) FindMany(results interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
+	return ms.intercept(connections.OpFindMany, func() error {
+		return ms.findMany(results, dbFusionOptions...)
+	})
+}
+
+// findMany runs FindMany as the last handler of the middleware chain.
+func (ms *MySql) findMany(results interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
+	// Ensure that values are reset after the operation.
+	defer ms.refreshValues()
+
+	// Initialize valuesInterface to store query values.
+	valuesInterface := make([]interface{}, 0)
+
+	// Check if a WHERE condition is specified.
+	if ms.whereQuery != nil {
+		// Get the SQL fusion data and update the WHERE condition.
+		query, err := utils.GetInstance().GetSqlFusionData(ms.whereQuery)
+		if err != nil {
+			return err
 		}
-		ms.sort = sortedValues
+		ms.whereQuery = query
+		valuesInterface = append(valuesInterface, query.GetValues().([]interface{})...)
 	} else {
-		ms.sort = sortString
+		// If no WHERE condition is provided, create an empty one.
+		ms.whereQuery = &conditions.SqlData{}
 	}
-	return ms
+
+	// Get the table of the records.
+	entityName, err := ms.getResultsEntityName(results)
+	if err != nil {
+		return err
+	}
+
+	// Leave out the soft-deleted records unless they are asked for.
+	valuesInterface = ms.scopeSqlQuery(results, ms.deletedScope, valuesInterface)
+
+	// Append any HAVING values to valuesInterface.
+	if len(ms.havingValues) != 0 {
+		valuesInterface = append(valuesInterface, ms.havingValues...)
+	}
+
+	// Read from the versions of the history with AsOf.
+	source, valuesInterface, err := ms.findSource(entityName, results, valuesInterface)
+	if err != nil {
+		return err
+	}
+
+	// Execute the query and read the records into the results.
+	rows, err := ms.executor().QueryContext(ms.getContext(), ms.createFindQuery(source, false), valuesInterface...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return ms.readSqlRowsToArray(rows, results)
 }
 
-// Where specifies the WHERE clause for query filtering.
+// UpdateMany updates every record of the MySQL database table matching the conditions set with Where.
+//
+// Parameters:
+// - data (interface{}): The data to update the records with, a struct or a map.
+// - model (interface{}): The model of the entity, used for the table name, its timestamps and its cache.
+// - upsert (bool): Indicates whether to insert the data when no record matches the conditions.
+//
+// Returns:
+// - error: An error if the update operation fails, or nil if successful.
+//
+// The cached records of entities implementing CacheHook are invalidated, as any of them may have changed.
+func (ms *MySql) UpdateMany(data interface{}, model interface{}, upsert bool) error {
+	return ms.intercept(connections.OpUpdateMany, func() error {
+		return ms.updateMany(data, model, upsert)
+	})
+}
+
+// updateMany runs UpdateMany as the last handler of the middleware chain.
+func (ms *MySql) updateMany(data interface{}, model interface{}, upsert bool) error {
+	// Ensure that values are reset after the operation.
+	defer ms.refreshValues()
+
+	// Initialize valuesInterface to store query values.
+	valuesInterface := make([]interface{}, 0)
+
+	// Check if a WHERE condition is specified.
+	if ms.whereQuery != nil {
+		// Get the SQL fusion data and update the WHERE condition.
+		query, err := utils.GetInstance().GetSqlFusionData(ms.whereQuery)
+		if err != nil {
+			return err
+		}
+		ms.whereQuery = query
+		valuesInterface = append(valuesInterface, query.GetValues().([]interface{})...)
+	} else {
+		// If no WHERE condition is provided, create an empty one.
+		ms.whereQuery = &conditions.SqlData{}
+	}
+
+	// Soft-deleted records are only updated when WithDeleted or OnlyDeleted selects them.
+	valuesInterface = ms.scopeSqlQuery(model, ms.deletedScope, valuesInterface)
+
+	// Check the update data against the rules of its validate tags.
+	if err := validation.GetInstance().Validate(data); err != nil {
+		return err
+	}
+
+	// Prepare for the preUpdate operation.
+	preUpdateReturn, err := ms.preUpdate(model, connections.MYSQL, model, upsert)
+	if err != nil {
+		return err
+	}
+
+	// Insert the data when upserting and no record matches the conditions.
+	if upsert {
+		rows, err := ms.executor().QueryContext(ms.getContext(), ms.createCountQuery(preUpdateReturn.entityName), valuesInterface...)
+		if err != nil {
+			return err
+		}
+		var count int64
+		for rows.Next() {
+			err = rows.Scan(&count)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			insertData, err := ms.encryptMap(ms.mapWithTimestamps(data, preUpdateReturn.dataType, time.Now(), autoCreateTimeOption, autoUpdateTimeOption), preUpdateReturn.dataType)
+			if err != nil {
+				return err
+			}
+			query, values, insertCreateData, err := ms.createSqlInsert(insertData)
+			if err != nil {
+				return err
+			}
+			if _, err = ms.executor().ExecContext(ms.getContext(), query, values...); err != nil {
+				return err
+			}
+
+			// Record the upserted record in the audit trail as an insertion.
+			if ms.useAudit(model) {
+				entry := ms.auditEntry(ms.getContext(), preUpdateReturn.entityName, audit.Insert, model, nil, insertCreateData.mData)
+				if err = ms.writeAudit(ms.getContext(), entry); err != nil {
+					return err
+				}
+			}
+			return ms.postUpdateMany(ms.cache, model, preUpdateReturn.entityName)
+		}
+	}
+
+	// Read the matching records before the update for the audit trail.
+	audited := ms.useAudit(model)
+	var records []map[string]interface{}
+	if audited {
+		if records, err = ms.readAuditRecords(ms.getContext(), preUpdateReturn.entityName, preUpdateReturn.dataType, valuesInterface); err != nil {
+			return err
+		}
+	}
+
+	// Update the matching records.
+	commands, setValues, err := ms.buildMySqlUpdate(data, entityData{
+		entityName: preUpdateReturn.entityName,
+		dataType:   preUpdateReturn.dataType,
+		dataValue:  preUpdateReturn.dataValue,
+		structType: preUpdateReturn.structType})
+	if err != nil {
+		return err
+	}
+	setValues = append(setValues, valuesInterface...)
+	query := ms.createUpdateQuery(preUpdateReturn.entityName, commands, false)
+	if _, err = ms.executor().ExecContext(ms.getContext(), query, setValues...); err != nil {
+		return err
+	}
+
+	// Record the update of every matched record in the audit trail.
+	if audited {
+		entries := ms.auditEntries(ms.getContext(), preUpdateReturn.entityName, audit.Update, model, records, ms.updateValues(data))
+		if err = ms.writeAudit(ms.getContext(), entries...); err != nil {
+			return err
+		}
+	}
+
+	// Invalidate the cached records and run the post-update hooks.
+	return ms.postUpdateMany(ms.cache, model, preUpdateReturn.entityName)
+}
+
+// DeleteMany deletes every record of the MySQL database table matching the conditions set with Where.
+//
+// Parameters:
+// - sliceData (...interface{}): The model of the entity as optional first argument, the table set with Table is
+//   used without it.
+//
+// Returns:
+// - error: An error if the delete operation fails, or nil if successful.
+//
+// Records of models with a softdelete field get their deletion time set instead of being removed, unless HardDelete
+// is called. The cached records of entities implementing CacheHook are invalidated.
+func (ms *MySql) DeleteMany(sliceData ...interface{}) error {
+	return ms.intercept(connections.OpDeleteMany, func() error {
+		return ms.deleteMany(sliceData...)
+	})
+}
+
+// deleteMany runs DeleteMany as the last handler of the middleware chain.
+func (ms *MySql) deleteMany(sliceData ...interface{}) error {
+	// Ensure that values are reset after the operation.
+	defer ms.refreshValues()
+
+	var model interface{}
+	if len(sliceData) != 0 {
+		model = sliceData[0]
+	}
+
+	// Initialize valuesInterface to store query values.
+	valuesInterface := make([]interface{}, 0)
+
+	// Check if a WHERE condition is specified.
+	if ms.whereQuery != nil {
+		// Get the SQL fusion data and update the WHERE condition.
+		query, err := utils.GetInstance().GetSqlFusionData(ms.whereQuery)
+		if err != nil {
+			return err
+		}
+		ms.whereQuery = query
+		valuesInterface = append(valuesInterface, query.GetValues().([]interface{})...)
+	} else {
+		// If no WHERE condition is provided, create an empty one.
+		ms.whereQuery = &conditions.SqlData{}
+	}
+
+	// Prepare for the preDelete operation.
+	preDeleteData, err := ms.preDelete(model)
+	if err != nil {
+		return err
+	}
+
+	// Only the records that are not deleted yet are soft deleted.
+	softDelete, soft := ms.useSoftDelete(model)
+	if soft {
+		valuesInterface = ms.scopeSqlQuery(model, excludeDeleted, valuesInterface)
+	}
+
+	// Read the matching records before the deletion for the audit trail.
+	audited := ms.useAudit(model)
+	var records []map[string]interface{}
+	if audited {
+		if records, err = ms.readAuditRecords(ms.getContext(), preDeleteData.entityName, preDeleteData.dataType, valuesInterface); err != nil {
+			return err
+		}
+	}
+
+	if soft {
+		// Set the deletion time of the matching records.
+		query := ms.createSoftDeleteQuery(preDeleteData.entityName, softDelete.name, "", false)
+		_, err = ms.executor().ExecContext(ms.getContext(), query, append([]interface{}{ms.deletionTime(softDelete)}, valuesInterface...)...)
+	} else {
+		// Remove the matching records.
+		query := ms.createDeleteQuery(preDeleteData.entityName, "", false)
+		_, err = ms.executor().ExecContext(ms.getContext(), query, valuesInterface...)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Record the deletion of every matched record in the audit trail.
+	if audited {
+		if err = ms.writeAudit(ms.getContext(), ms.auditEntries(ms.getContext(), preDeleteData.entityName, audit.Delete, model, records, nil)...); err != nil {
+			return err
+		}
+	}
+
+	// Invalidate the cached records and run the post-delete hooks.
+	return ms.postDeleteMany(ms.cache, model, preDeleteData.entityName)
+}
+
+// Restore clears the soft delete field of the deleted records matching the conditions set with Where.
+//
+// Parameters:
+// - model (interface{}): The model of the entity, a struct with a field carrying the softdelete option.
+//
+// Returns:
+// - error: dbfusionErrors.ErrSoftDeleteNotSupported if the model isn't soft deleted, an error if the update fails,
+//   or nil if successful.
+//
+// Example:
+//   err := ms.Where(ftypes.QMap{"email": "alice@example.com"}).Restore(&User{})
+func (ms *MySql) Restore(model interface{}) error {
+	return ms.intercept(connections.OpRestore, func() error {
+		return ms.restore(model)
+	})
+}
+
+// restore runs Restore as the last handler of the middleware chain.
+func (ms *MySql) restore(model interface{}) error {
+	// Ensure that values are reset after the operation.
+	defer ms.refreshValues()
+
+	// Only models with a soft delete field can be restored.
+	softDelete, ok := ms.softDeleteField(model)
+	if !ok {
+		return dbfusionErrors.ErrSoftDeleteNotSupported
+	}
+
+	// Initialize valuesInterface to store query values.
+	valuesInterface := make([]interface{}, 0)
+
+	// Check if a WHERE condition is specified.
+	if ms.whereQuery != nil {
+		// Get the SQL fusion data and update the WHERE condition.
+		query, err := utils.GetInstance().GetSqlFusionData(ms.whereQuery)
+		if err != nil {
+			return err
+		}
+		ms.whereQuery = query
+		valuesInterface = append(valuesInterface, query.GetValues().([]interface{})...)
+	} else {
+		// If no WHERE condition is provided, create an empty one.
+		ms.whereQuery = &conditions.SqlData{}
+	}
+
+	// Get the table of the model.
+	nameData, err := ms.getEntityName(model)
+	if err != nil {
+		return err
+	}
+
+	// Read the matching deleted records before restoring them for the audit trail.
+	valuesInterface = ms.scopeSqlQuery(model, deletedOnly, valuesInterface)
+	audited := ms.useAudit(model)
+	var records []map[string]interface{}
+	if audited {
+		if records, err = ms.readAuditRecords(ms.getContext(), nameData.entityName, nameData.dataType, valuesInterface); err != nil {
+			return err
+		}
+	}
+
+	// Reset the soft delete field of the matching deleted records.
+	query := ms.createSoftDeleteQuery(nameData.entityName, softDelete.name, "", false)
+	if _, err = ms.executor().ExecContext(ms.getContext(), query, append([]interface{}{softDelete.zero}, valuesInterface...)...); err != nil {
+		return err
+	}
+
+	// Record the restoration of every matched record in the audit trail as an update.
+	if audited {
+		restored := map[string]interface{}{softDelete.name: softDelete.zero}
+		if err = ms.writeAudit(ms.getContext(), ms.auditEntries(ms.getContext(), nameData.entityName, audit.Update, model, records, restored)...); err != nil {
+			return err
+		}
+	}
+
+	// Invalidate the cached records and run the post-update hooks.
+	return ms.postUpdateMany(ms.cache, model, nameData.entityName)
+}
+
+// FindByID retrieves the record of a primary key from the MySQL database table, or from the cache when the entity is
+// cached, since the primary key is always one of its cache indexes.
+//
+// Parameters:
+// - id (interface{}): The primary key of the record, converted to the type of the primary key field.
+// - result (interface{}): A pointer to the struct where the retrieved data will be stored, it gives the primary key.
+// - dbFusionOptions (...queryoptions.FindOptions): Optional FindOptions.
+//
+// Returns:
+// - error: dbfusionErrors.ErrPrimaryKeyNotFound if the model has no primary key, an error if the retrieval fails,
+//   or nil if successful.
+//
+// Example:
+//   var user User
+//   err := ms.FindByID(42, &user)
+func (ms *MySql) FindByID(id interface{}, result interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
+	return ms.intercept(connections.OpFindByID, func() error {
+		return ms.findByID(id, result, dbFusionOptions...)
+	})
+}
+
+// findByID runs FindByID as the last handler of the middleware chain.
+func (ms *MySql) findByID(id interface{}, result interface{}, dbFusionOptions ...queryoptions.FindOptions) error {
+	// Match the record of the key, then find it like FindOne.
+	if err := ms.whereID(result, id); err != nil {
+		return err
+	}
+	return ms.findOne(result, dbFusionOptions...)
+}
+
+// UpdateByID updates the record of a primary key in the MySQL database table and retrieves the updated record.
+//
+// Parameters:
+// - id (interface{}): The primary key of the record, converted to the type of the primary key field.
+// - data (interface{}): The data to update the record with.
+// - result (interface{}): A pointer to the struct where the updated data will be stored, it gives the primary key.
+//
+// Returns:
+// - error: dbfusionErrors.ErrPrimaryKeyNotFound if the model has no primary key, an error if the update fails,
+//   or nil if successful.
+//
+// Example:
+//   var user User
+//   err := ms.UpdateByID(42, ftypes.QMap{"name": "Alice"}, &user)
+func (ms *MySql) UpdateByID(id interface{}, data interface{}, result interface{}) error {
+	return ms.intercept(connections.OpUpdateByID, func() error {
+		return ms.updateByID(id, data, result)
+	})
+}
+
+// updateByID runs UpdateByID as the last handler of the middleware chain.
+func (ms *MySql) updateByID(id interface{}, data interface{}, result interface{}) error {
+	// Match the record of the key, then update it like UpdateAndFindOne without inserting a missing record.
+	if err := ms.whereID(result, id); err != nil {
+		return err
+	}
+	return ms.updateAndFindOne(data, result, false)
+}
+
+// DeleteByID removes the record of a primary key from the MySQL database table, or soft deletes it, and removes it
+// from the cache.
+//
+// Parameters:
+// - id (interface{}): The primary key of the record, converted to the type of the primary key field.
+// - model (interface{}): The model of the entity, it gives the table and the primary key.
+//
+// Returns:
+// - error: dbfusionErrors.ErrPrimaryKeyNotFound if the model has no primary key, an error if the deletion fails,
+//   or nil if successful.
+//
+// Example:
+//   err := ms.DeleteByID(42, &User{})
+func (ms *MySql) DeleteByID(id interface{}, model interface{}) error {
+	return ms.intercept(connections.OpDeleteByID, func() error {
+		return ms.deleteByID(id, model)
+	})
+}
+
+// deleteByID runs DeleteByID as the last handler of the middleware chain.
+func (ms *MySql) deleteByID(id interface{}, model interface{}) error {
+	key, err := ms.modelPrimaryKey(model)
+	if err != nil {
+		ms.refreshValues()
+		return err
+	}
+	id, err = ms.normalizeID(key, id)
+	if err != nil {
+		ms.refreshValues()
+		return err
+	}
+
+	// Delete a new instance of the model holding the key, which matches the record of the key only.
+	return ms.deleteOne(ms.modelWithID(model, key, id))
+}
+
+// whereID sets the WHERE condition of the connection to the record of a primary key. The connection is reset when
+// the model has no primary key.
+//
+// Parameters:
+// - model (interface{}): The model of the entity.
+// - id (interface{}): The primary key of the record.
+//
+// Returns:
+// - error: dbfusionErrors.ErrPrimaryKeyNotFound if the model has no primary key.
+func (ms *MySql) whereID(model interface{}, id interface{}) error {
+	key, err := ms.modelPrimaryKey(model)
+	if err == nil {
+		id, err = ms.normalizeID(key, id)
+	}
+	if err != nil {
+		ms.refreshValues()
+		return err
+	}
+	ms.whereQuery = ftypes.QMap{key.name + " = ": id}
+	return nil
+}
+
+// WarmCache streams every record of an entity from the MySQL database and rebuilds its cache indexes and payloads.
+// Records are written to the cache in batches, optionally limited to a number of records per second.
+//
+// Parameters:
+// - model (interface{}): The model of the entity, a struct implementing hooks.CacheHook or a map[string]interface{}
+//   used together with Table and WarmCacheOptions.Indexes.
+// - dbFusionOptions (...queryoptions.WarmCacheOptions): Optional options for batching, rate limiting and progress.
+//
+// Returns:
+// - connections.WarmCacheResults: The counts of processed, cached and skipped records and the duration.
+// - error: An error if reading the records or writing the cache fails, or nil if successful.
+//
+// Example:
+//   results, err := ms.Where(map[string]interface{}{"active": true}).WarmCache(&User{},
+//       queryoptions.WarmCacheOptions{BatchSize: 1000, RatePerSecond: 5000})
+func (ms *MySql) WarmCache(model interface{}, dbFusionOptions ...queryoptions.WarmCacheOptions) (connections.WarmCacheResults, error) {
+	// Ensure that values are reset after the operation.
+	defer ms.refreshValues()
+
+	// Get the entity name and the type the records are read into.
+	nameData, err := ms.getEntityName(model)
+	if err != nil {
+		return connections.WarmCacheResults{}, err
+	}
+
+	// Prepare the warmer which validates the cache and the indexes.
+	warmer, err := newCacheWarmer(&ms.DBCommon, model, nameData.entityName, dbFusionOptions...)
+	if err != nil {
+		return connections.WarmCacheResults{}, err
+	}
+
+	// Stream the records of the entity into the warmer.
+	err = ms.streamEntity(nameData, 0, warmer.add)
+	if err != nil {
+		return connections.WarmCacheResults{}, err
+	}
+
+	// Write the remaining records and return the results.
+	return warmer.finish()
+}
+
+// VerifyCache compares the cached entries of an entity with its records in the MySQL database. It recomputes the
+// expected index keys of every record, or of a random sample of them, and reports the keys that are missing or point
+// to a stale payload. Optionally orphaned payloads are looked up and everything found is repaired.
+//
+// Parameters:
+// - model (interface{}): The model of the entity, a struct implementing hooks.CacheHook or a map[string]interface{}
+//   used together with Table and VerifyCacheOptions.Indexes.
+// - dbFusionOptions (...queryoptions.VerifyCacheOptions): Optional options for sampling, orphans and repair.
+//
+// Returns:
+// - connections.CacheVerificationReport: The missing keys, stale keys and orphaned payloads found.
+// - error: An error if reading the records or the cache fails, or nil if successful.
+//
+// Example:
+//   report, err := ms.VerifyCache(&User{}, queryoptions.VerifyCacheOptions{SampleSize: 1000, CheckOrphans: true})
+func (ms *MySql) VerifyCache(model interface{}, dbFusionOptions ...queryoptions.VerifyCacheOptions) (connections.CacheVerificationReport, error) {
+	// Ensure that values are reset after the operation.
+	defer ms.refreshValues()
+
+	// Get the entity name and the type the records are read into.
+	nameData, err := ms.getEntityName(model)
+	if err != nil {
+		return connections.CacheVerificationReport{}, err
+	}
+
+	// Prepare the verifier which validates the cache and the indexes.
+	verifier, err := newCacheVerifier(&ms.DBCommon, model, nameData.entityName, dbFusionOptions...)
+	if err != nil {
+		return connections.CacheVerificationReport{}, err
+	}
+
+	// Stream the records of the entity, or a sample of them, into the verifier.
+	err = ms.streamEntity(nameData, verifier.options.SampleSize, verifier.add)
+	if err != nil {
+		return connections.CacheVerificationReport{}, err
+	}
+
+	// Verify the remaining records and return the report.
+	return verifier.finish()
+}
+
+// streamEntity reads the records of an entity one by one and passes each of them as a tag-value map to a callback.
+// The WHERE condition, projection and sorting of the connection are applied to the query.
+//
+// Parameters:
+// - nameData (entityData): The entity to be read, struct entities are read into their type first.
+// - sampleSize (int): The number of randomly chosen records to read, or 0 to read every record.
+// - each (func(map[string]interface{}) error): The callback receiving the records, an error stops the stream.
+//
+// Returns:
+// - error: An error if the query fails, a record cannot be read or the callback fails, or nil if successful.
+func (ms *MySql) streamEntity(nameData entityData, sampleSize int, each func(map[string]interface{}) error) error {
+	// Struct entities are read into their type so that values have the same types as on insertion.
+	var dataType reflect.Type
+	if nameData.structType == 1 {
+		dataType = nameData.dataType
+	}
+
+	// Restrict the records to the WHERE condition if one is provided.
+	valuesInterface := make([]interface{}, 0)
+	if ms.whereQuery != nil {
+		query, err := utils.GetInstance().GetSqlFusionData(ms.whereQuery)
+		if err != nil {
+			return err
+		}
+		ms.whereQuery = query
+		valuesInterface = append(valuesInterface, query.GetValues().([]interface{})...)
+	}
+
+	// Pick a random sample of the records when requested.
+	if sampleSize > 0 {
+		ms.sort = "RAND()"
+		ms.limit = int64(sampleSize)
+	}
+
+	// Stream the records of the entity.
+	rows, err := ms.executor().QueryContext(ms.getContext(), ms.createFindQuery(nameData.entityName, false), valuesInterface...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		data, err := ms.readSqlRowToMap(rows, columnNames, dataType)
+		if err != nil {
+			return err
+		}
+		if err := each(data); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// WithContext sets the context of the next operation. The context is passed to the database driver and to the
+// context-aware hooks such as BeforeInsert and AfterUpdate, and is reset once the operation completes.
+//
+// Parameters:
+// - ctx: The context of the next operation.
+//
+// Returns:
+// - connections.SQLConnection: The MySQL connection instance for method chaining.
+func (ms *MySql) WithContext(ctx context.Context) connections.SQLConnection {
+	ms.ctx = ctx
+	return ms
+}
+
+// WithDeleted includes the soft-deleted records in the results of the next find. Records read this way are never
+// cached.
+//
+// Returns:
+// - connections.SQLConnection: The MySQL connection instance for method chaining.
+func (ms *MySql) WithDeleted() connections.SQLConnection {
+	ms.deletedScope = includeDeleted
+	return ms
+}
+
+// OnlyDeleted restricts the results of the next find to the soft-deleted records. Records read this way are never
+// cached.
+//
+// Returns:
+// - connections.SQLConnection: The MySQL connection instance for method chaining.
+func (ms *MySql) OnlyDeleted() connections.SQLConnection {
+	ms.deletedScope = deletedOnly
+	return ms
+}
+
+// HardDelete removes the records of the next delete from the table, even if their model has a softdelete field.
+//
+// Returns:
+// - connections.SQLConnection: The MySQL connection instance for method chaining.
+func (ms *MySql) HardDelete() connections.SQLConnection {
+	ms.hardDelete = true
+	return ms
+}
+
+// AsOf reads the records of historical entities as they were at a moment on the next FindOne or FindMany, from the
+// versions copied to their history table and the records which were not replaced since then.
+//
+// Parameters:
+// - t (time.Time): The moment read.
+//
+// Returns:
+// - connections.SQLConnection: The MySQL connection instance for method chaining.
+//
+// Records read this way are never cached, as the cache only holds the current records.
+//
+// Example:
+//   err := ms.AsOf(time.Now().Add(-time.Hour)).Where(ftypes.QMap{"id = ": 1}).FindOne(&user)
+func (ms *MySql) AsOf(t time.Time) connections.SQLConnection {
+	ms.asOf = &t
+	return ms
+}
+
+// executor returns the transaction of the running write if there is one, or the database otherwise. The queries
+// run through it are recorded on the operation of the middleware chain.
+func (ms *MySql) executor() sqlExecutor {
+	if ms.tx != nil {
+		return tracedExecutor{sqlExecutor: ms.tx, trace: ms.traceQuery}
+	}
+	return tracedExecutor{sqlExecutor: ms.db, trace: ms.traceQuery}
+}
+
+// beginTransaction starts a transaction used by the queries of the running write until it is committed or rolled
+// back. It implements transactionBeginner for runWrite.
+//
+// Parameters:
+// - ctx: The context of the operation.
+//
+// Returns:
+// - context.Context: The context the queries of the transaction use, unchanged for MySQL.
+// - func() error: Commits the transaction.
+// - func() error: Rolls the transaction back.
+// - error: An error if the transaction cannot be started, or nil if successful.
+func (ms *MySql) beginTransaction(ctx context.Context) (context.Context, func() error, func() error, error) {
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctx, nil, nil, err
+	}
+	ms.tx = tx
+
+	commit := func() error {
+		ms.tx = nil
+		return tx.Commit()
+	}
+	rollback := func() error {
+		ms.tx = nil
+		return tx.Rollback()
+	}
+	return ctx, commit, rollback, nil
+}
+
+// Skip sets the number of records to skip when performing a query.
+//
+// Parameters:
+// - skip: The number of records to skip.
+//
+// Returns:
+// - connections.SQLConnection: The MySQL connection instance for method chaining.
+func (ms *MySql) Skip(skip int64) connections.SQLConnection {
+	ms.skip = skip
+	return ms
+}
+
+// Limit sets the maximum number of records to retrieve when performing a query.
+//
+// Parameters:
+// - limit: The maximum number of records to retrieve.
+//
+// Returns:
+// - connections.SQLConnection: The MySQL connection instance for method chaining.
+func (ms *MySql) Limit(limit int64) connections.SQLConnection {
+	ms.limit = limit
+	return ms
+}
+
+// Select specifies the columns to include in the query result.
+//
+// Parameters:
+// - keys: A map where the keys represent column names, and the values indicate whether to include the column.
+//
+// Returns:
+// - connections.SQLConnection: The MySQL connection instance for method chaining.
+func (ms *MySql) Select(keys map[string]bool) connections.SQLConnection {
+	selectionKeys := make([]string, 0)
+
+	for key, val := range keys {
+		if val {
+			selectionKeys = append(selectionKeys, key)
+		}
+	}
+	ms.projection = selectionKeys
+	return ms
+}
+
+// Sort sets the sorting order for query results based on a specified column.
+//
+// Parameters:
+// - sortKey: The name of the column to sort by.
+// - sortdesc: An optional boolean parameter to indicate descending sorting (default is ascending).
+//
+// Returns:
+// - connections.SQLConnection: The MySQL connection instance for method chaining.
+func (ms *MySql) Sort(sortKey string, sortdesc ...bool) connections.SQLConnection {
+	sortString := sortKey
+	sortVal := " ASC"
+	if len(sortdesc) > 0 {
+		if !sortdesc[0] {
+			sortVal = " DESC"
+		}
+	}
+	sortString += sortVal
+	if ms.sort != nil {
+		sortedValues := ms.sort.(string)
+		if sortedValues != "" {
+			sortedValues += "," + sortString
+		}
+		ms.sort = sortedValues
+	} else {
+		ms.sort = sortString
+	}
+	return ms
+}
+
+// Where specifies the WHERE clause for query filtering.
 //
 // Parameters:
 // - query: The query condition for filtering.
@@ -603,7 +1706,21 @@ func (ms *MySql) Having(data interface{}) connections.SQLConnection {
 //
 // Returns:
 // - error: An error, if any, encountered during query execution.
-func (ms *MySql) ExecuteSQL(sql string, args ...interface{}) error { return nil }
+//
+// Example:
+//   err := ms.ExecuteSQL("ALTER TABLE users ADD COLUMN nickname VARCHAR(255) NULL")
+func (ms *MySql) ExecuteSQL(sql string, args ...interface{}) error {
+	return ms.intercept(connections.OpExecuteSQL, func() error {
+		return ms.executeSQL(sql, args...)
+	})
+}
+
+// executeSQL runs ExecuteSQL as the last handler of the middleware chain.
+func (ms *MySql) executeSQL(sql string, args ...interface{}) error {
+	// Run the statement, in the transaction of the connection if it has one.
+	_, err := ms.executor().ExecContext(ms.getContext(), sql, args...)
+	return err
+}
 
 // SetPageSize sets the page size for paginated query results.
 //
@@ -0,0 +1,251 @@
+package implementations
+
+import (
+	"crypto/rand"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/hooks"
+	"github.com/glodb/dbfusion/metadata"
+	"github.com/glodb/dbfusion/schema"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Strategies generating the primary keys of inserted records, given as the parameter of the pk option, e.g.
+// `dbfusion:"id,pk:ulid"`.
+const (
+	autoIncrementStrategy = "autoincr" // The database generates the key, MySQL inserts read it back.
+	objectIDStrategy      = "objectid" // A MongoDB ObjectID, or its hex string for string fields.
+	ulidStrategy          = "ulid"     // A ULID string.
+	uuidStrategy          = "uuid"     // A random UUID string (version 4).
+)
+
+// objectIDType is the type of MongoDB ObjectIDs.
+var objectIDType = reflect.TypeOf(primitive.ObjectID{})
+
+// primaryKey describes the field of a model holding its primary key.
+type primaryKey struct {
+	name     string              // The name of the field in the database.
+	field    reflect.StructField // The struct field.
+	strategy string              // The strategy generating the keys of inserted records, empty if the caller sets them.
+}
+
+// primaryKey looks for the primary key of a model: the field carrying the pk option, else the field whose SQL
+// definition holds PRIMARY KEY, else the field named _id or id.
+//
+// Parameters:
+// - modelType: The type of the model, pointers and slices are dereferenced.
+//
+// Returns:
+// - primaryKey: The primary key of the model.
+// - bool: true if the model has a primary key.
+func (dbc *DBCommon) primaryKey(modelType reflect.Type) (primaryKey, bool) {
+	model, ok := metadata.GetInstance().Model(modelType)
+	if !ok || model.PrimaryKey == nil {
+		return primaryKey{}, false
+	}
+	field := model.PrimaryKey
+	key := primaryKey{name: field.Name, field: field.StructField}
+
+	// Infer the strategy of keys generated by the database and of ObjectIDs.
+	key.strategy, _ = field.Option(pkOption)
+	if key.strategy == "" {
+		definition := strings.ToUpper(strings.Join(field.Parts[1:], " "))
+		if _, ok := field.Option(schema.AutoIncrementOption); ok || strings.Contains(definition, "AUTO_INCREMENT") {
+			key.strategy = autoIncrementStrategy
+		} else if field.StructField.Type == objectIDType {
+			key.strategy = objectIDStrategy
+		}
+	}
+	return key, true
+}
+
+// modelPrimaryKey returns the primary key of a model, or an error when it has none.
+//
+// Parameters:
+// - model: The model of the entity, a struct, a pointer to a struct or a pointer to a slice of them.
+//
+// Returns:
+// - primaryKey: The primary key of the model.
+// - error: ErrPrimaryKeyNotFound if the model has no primary key.
+func (dbc *DBCommon) modelPrimaryKey(model interface{}) (primaryKey, error) {
+	key, ok := dbc.primaryKey(reflect.TypeOf(model))
+	if !ok {
+		return primaryKey{}, dbfusionErrors.ErrPrimaryKeyNotFound
+	}
+	return key, nil
+}
+
+// generateID returns a new key for an inserted record, following the strategy of the primary key.
+//
+// Parameters:
+// - key: The primary key of the model.
+//
+// Returns:
+// - reflect.Value: The new key converted to the type of the field.
+// - bool: true if the strategy generates the key in dbFusion, false for keys generated by the database.
+func (dbc *DBCommon) generateID(key primaryKey) (reflect.Value, bool) {
+	var id interface{}
+	switch key.strategy {
+	case objectIDStrategy:
+		objectID := primitive.NewObjectID()
+		if key.field.Type == objectIDType {
+			id = objectID
+		} else {
+			id = objectID.Hex()
+		}
+	case ulidStrategy:
+		id = ulid.Make().String()
+	case uuidStrategy:
+		id = dbc.newUUID()
+	default:
+		return reflect.Value{}, false
+	}
+
+	// Only fields which can hold the key are filled.
+	value := reflect.ValueOf(id)
+	if !value.Type().ConvertibleTo(key.field.Type) {
+		return reflect.Value{}, false
+	}
+	return value.Convert(key.field.Type), true
+}
+
+// newUUID returns a random UUID (version 4) in its canonical form.
+func (dbc *DBCommon) newUUID() string {
+	var uuid [16]byte
+	_, _ = rand.Read(uuid[:])
+
+	// Set the version and the variant bits.
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
+
+// structID returns the primary key held by a struct.
+//
+// Parameters:
+// - data: The struct, or a pointer to it.
+// - key: The primary key of the model.
+//
+// Returns:
+// - interface{}: The key of the struct.
+// - bool: true if the key is set.
+func (dbc *DBCommon) structID(data interface{}, key primaryKey) (interface{}, bool) {
+	if data == nil {
+		return nil, false
+	}
+	dataValue, dataType := dbc.checkPtr(data)
+	if dataType.Kind() != reflect.Struct {
+		return nil, false
+	}
+	fieldValue := dataValue.FieldByName(key.field.Name)
+	if !fieldValue.IsValid() || !dbc.isFieldSet(fieldValue) {
+		return nil, false
+	}
+	return fieldValue.Interface(), true
+}
+
+// setStructID sets the primary key of a struct passed by pointer, e.g. to the key read back after an insert.
+//
+// Parameters:
+// - data: A pointer to the struct.
+// - key: The primary key of the model.
+// - id: The key, converted to the type of the field.
+func (dbc *DBCommon) setStructID(data interface{}, key primaryKey, id interface{}) {
+	dataValue, dataType := dbc.checkPtr(data)
+	if dataType.Kind() != reflect.Struct {
+		return
+	}
+	fieldValue := dataValue.FieldByName(key.field.Name)
+	value := reflect.ValueOf(id)
+	if !fieldValue.IsValid() || !fieldValue.CanSet() || !value.IsValid() || !value.Type().ConvertibleTo(fieldValue.Type()) {
+		return
+	}
+	fieldValue.Set(value.Convert(fieldValue.Type()))
+}
+
+// normalizeID converts an ID passed to the ByID methods to the type of the primary key, e.g. an int to an int64 or
+// the hex string of an ObjectID to the ObjectID.
+//
+// Parameters:
+// - key: The primary key of the model.
+// - id: The ID passed by the caller.
+//
+// Returns:
+// - interface{}: The ID in the type of the primary key.
+// - error: An error if a hex string isn't a valid ObjectID.
+func (dbc *DBCommon) normalizeID(key primaryKey, id interface{}) (interface{}, error) {
+	if hex, ok := id.(string); ok && key.field.Type == objectIDType {
+		return primitive.ObjectIDFromHex(hex)
+	}
+	value := reflect.ValueOf(id)
+	if !value.IsValid() || value.Type() == key.field.Type || !value.Type().ConvertibleTo(key.field.Type) {
+		return id, nil
+	}
+
+	// Numbers are only converted to numbers, converting them to strings would give runes.
+	if key.field.Type.Kind() == reflect.String && value.Kind() != reflect.String {
+		return id, nil
+	}
+	return value.Convert(key.field.Type).Interface(), nil
+}
+
+// modelWithID returns a new instance of a model holding only a primary key, which deletes the record of the key.
+//
+// Parameters:
+// - model: The model of the entity, a struct or a pointer to a struct.
+// - key: The primary key of the model.
+// - id: The key, in the type of the primary key.
+//
+// Returns:
+// - interface{}: A pointer to the new instance.
+func (dbc *DBCommon) modelWithID(model interface{}, key primaryKey, id interface{}) interface{} {
+	modelType := reflect.TypeOf(model)
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	instance := reflect.New(modelType)
+	dbc.setStructID(instance.Interface(), key, id)
+	return instance.Interface()
+}
+
+// cacheIndexes returns the cache indexes of an entity, led by its primary key which is always indexed so that
+// FindByID is served by the cache.
+//
+// Parameters:
+// - hook: The entity implementing CacheHook.
+//
+// Returns:
+// - []string: The cache indexes of the entity and its primary key.
+func (dbc *DBCommon) cacheIndexes(hook hooks.CacheHook) []string {
+	// The indexes are read from the record itself, they may depend on its values.
+	indexes := hook.GetCacheIndexes()
+	key, ok := dbc.primaryKey(reflect.TypeOf(hook))
+	if !ok {
+		return indexes
+	}
+	for _, index := range indexes {
+		if index == key.name {
+			return indexes
+		}
+	}
+	return append([]string{key.name}, indexes...)
+}
+
+// cacheIndexKeys returns the fields of each cache index of an entity, in the order of cacheIndexes.
+//
+// Parameters:
+// - hook: The entity implementing CacheHook.
+//
+// Returns:
+// - [][]string: The fields of each cache index.
+func (dbc *DBCommon) cacheIndexKeys(hook hooks.CacheHook) [][]string {
+	keys := make([][]string, 0)
+	for _, index := range dbc.cacheIndexes(hook) {
+		keys = append(keys, strings.Split(index, ","))
+	}
+	return keys
+}
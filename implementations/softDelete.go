@@ -0,0 +1,253 @@
+package implementations
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/conditions"
+	"github.com/glodb/dbfusion/hooks"
+	"github.com/glodb/dbfusion/metadata"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// softDeleteScope selects the records of soft-deleted entities an operation works on.
+type softDeleteScope int
+
+const (
+	excludeDeleted softDeleteScope = iota // Only the records that are not deleted, the default.
+	includeDeleted                        // Every record, set by WithDeleted.
+	deletedOnly                           // Only the deleted records, set by OnlyDeleted.
+)
+
+// softDeleteField describes the field of a model carrying the softdelete option.
+type softDeleteField struct {
+	name  string              // The name of the field in the database.
+	field reflect.StructField // The struct field, which gives the type of the deletion time.
+	zero  interface{}         // The value of records that are not deleted, nil for pointers.
+}
+
+// softDeleteField looks for the field carrying the softdelete option in a model, e.g.
+// `dbfusion:"deletedAt,softdelete"`. Pointers and slices are dereferenced so that results of finds can be passed.
+//
+// Parameters:
+// - model: The model of the entity, a struct, a pointer to a struct or a pointer to a slice of them.
+//
+// Returns:
+// - softDeleteField: The field holding the deletion time.
+// - bool: true if the model is soft deleted.
+func (dbc *DBCommon) softDeleteField(model interface{}) (softDeleteField, bool) {
+	if model == nil {
+		return softDeleteField{}, false
+	}
+	name, field, ok := dbc.fieldWithOption(reflect.TypeOf(model), softDeleteOption)
+	if !ok {
+		return softDeleteField{}, false
+	}
+
+	// Records that are not deleted hold the zero value of the field, or NULL for pointers.
+	var zero interface{}
+	if field.Type.Kind() != reflect.Ptr {
+		zero = reflect.Zero(field.Type).Interface()
+	}
+	return softDeleteField{name: name, field: field, zero: zero}, true
+}
+
+// deletionTime returns the value stored in the soft delete field of deleted records.
+//
+// Parameters:
+// - field: The soft delete field.
+//
+// Returns:
+// - interface{}: The current time converted to the type of the field.
+func (dbc *DBCommon) deletionTime(field softDeleteField) interface{} {
+	if value, ok := dbc.autoTimestamp(field.field, time.Now(), softDeleteOption); ok {
+		return value.Interface()
+	}
+	return time.Now()
+}
+
+// softDeleteCondition returns the SQL condition restricting the records to a soft delete scope.
+//
+// Parameters:
+// - field: The soft delete field.
+// - scope: The records to keep.
+//
+// Returns:
+// - string: The condition, empty if every record is kept.
+// - []interface{}: The values of the placeholders of the condition.
+func (dbc *DBCommon) softDeleteCondition(field softDeleteField, scope softDeleteScope) (string, []interface{}) {
+	switch scope {
+	case excludeDeleted:
+		if field.zero == nil {
+			return fmt.Sprintf("%s IS NULL", field.name), []interface{}{}
+		}
+		return fmt.Sprintf("(%s IS NULL OR %s = ?)", field.name, field.name), []interface{}{field.zero}
+	case deletedOnly:
+		if field.zero == nil {
+			return fmt.Sprintf("%s IS NOT NULL", field.name), []interface{}{}
+		}
+		return fmt.Sprintf("(%s IS NOT NULL AND %s <> ?)", field.name, field.name), []interface{}{field.zero}
+	}
+	return "", []interface{}{}
+}
+
+// scopeSqlQuery restricts the SQL WHERE condition of the connection to the records of the soft delete scope when the
+// model is soft deleted. The condition of the scope is appended to the query, so its values follow the values of the
+// query.
+//
+// Parameters:
+// - model: The model of the entity.
+// - scope: The records to keep.
+// - values: The values of the WHERE condition.
+//
+// Returns:
+// - []interface{}: The values of the WHERE condition followed by the values of the scope.
+func (dbc *DBCommon) scopeSqlQuery(model interface{}, scope softDeleteScope, values []interface{}) []interface{} {
+	field, ok := dbc.softDeleteField(model)
+	if !ok {
+		return values
+	}
+	condition, conditionValues := dbc.softDeleteCondition(field, scope)
+	if condition == "" {
+		return values
+	}
+
+	// Combine the condition of the scope with the WHERE condition.
+	dbc.andSqlCondition(condition, conditionValues)
+	return append(values, conditionValues...)
+}
+
+// andSqlCondition appends a condition to the SQL WHERE condition of the connection with AND.
+//
+// Parameters:
+// - condition: The SQL condition.
+// - conditionValues: The values of the placeholders of the condition.
+func (dbc *DBCommon) andSqlCondition(condition string, conditionValues []interface{}) {
+	whereData, ok := dbc.whereQuery.(*conditions.SqlData)
+	if !ok {
+		whereData = &conditions.SqlData{}
+		dbc.whereQuery = whereData
+	}
+	if whereData.Query != "" {
+		whereData.Query = fmt.Sprintf("(%s) AND %s", whereData.Query, condition)
+	} else {
+		whereData.Query = condition
+	}
+	whereData.Values = append(append([]interface{}{}, whereData.Values...), conditionValues...)
+}
+
+// softDeleteFilter returns the MongoDB filter restricting the documents to a soft delete scope. Missing fields match
+// nil, so documents inserted before the field was added count as not deleted.
+//
+// Parameters:
+// - field: The soft delete field.
+// - scope: The documents to keep.
+//
+// Returns:
+// - primitive.D: The filter, nil if every document is kept.
+func (dbc *DBCommon) softDeleteFilter(field softDeleteField, scope softDeleteScope) primitive.D {
+	notDeleted := primitive.A{nil}
+	if field.zero != nil {
+		notDeleted = append(notDeleted, field.zero)
+	}
+
+	switch scope {
+	case excludeDeleted:
+		return primitive.D{{Key: field.name, Value: primitive.D{{Key: "$in", Value: notDeleted}}}}
+	case deletedOnly:
+		return primitive.D{{Key: field.name, Value: primitive.D{{Key: "$nin", Value: notDeleted}}}}
+	}
+	return nil
+}
+
+// scopeMongoQuery restricts a MongoDB filter to the documents of the soft delete scope when the model is soft deleted.
+//
+// Parameters:
+// - model: The model of the entity.
+// - scope: The documents to keep.
+// - query: The filter of the operation.
+//
+// Returns:
+// - primitive.D: The filter combined with the filter of the scope.
+func (dbc *DBCommon) scopeMongoQuery(model interface{}, scope softDeleteScope, query primitive.D) primitive.D {
+	field, ok := dbc.softDeleteField(model)
+	if !ok {
+		return query
+	}
+	return dbc.andMongoFilter(query, dbc.softDeleteFilter(field, scope))
+}
+
+// andMongoFilter combines two MongoDB filters with $and.
+//
+// Parameters:
+// - query: The filter of the operation.
+// - filter: The filter to add, nil to keep the filter of the operation.
+//
+// Returns:
+// - primitive.D: The combined filter.
+func (dbc *DBCommon) andMongoFilter(query primitive.D, filter primitive.D) primitive.D {
+	if filter == nil {
+		return query
+	}
+	if len(query) == 0 {
+		return filter
+	}
+	return primitive.D{{Key: "$and", Value: primitive.A{query, filter}}}
+}
+
+// useSoftDelete reports whether a delete of the model sets its soft delete field instead of removing the record.
+//
+// Parameters:
+// - model: The model of the entity.
+//
+// Returns:
+// - softDeleteField: The soft delete field.
+// - bool: true unless HardDelete was called or the model isn't soft deleted.
+func (dbc *DBCommon) useSoftDelete(model interface{}) (softDeleteField, bool) {
+	if dbc.hardDelete {
+		return softDeleteField{}, false
+	}
+	return dbc.softDeleteField(model)
+}
+
+// entityModel returns a new instance of the model last used with an entity of the current database, so that the
+// operations given conditions only, e.g. DeleteOne with Where, soft delete the records of soft-deleted entities.
+//
+// Parameters:
+// - entityName: The name of the entity.
+//
+// Returns:
+// - interface{}: A pointer to the zero value of the model, nil if the entity wasn't used with a model yet.
+func (dbc *DBCommon) entityModel(entityName string) interface{} {
+	model, ok := metadata.GetInstance().Entity(dbc.currentDB, entityName)
+	if !ok {
+		return nil
+	}
+	return reflect.New(model.Type).Interface()
+}
+
+// invalidateDeleted removes from the cache the entries that may still hold records deleted by conditions: every entry
+// of the entity for models with cache indexes, else the cached query results of soft-deleted entities, which keep
+// the records in the database.
+//
+// Parameters:
+// - cache: The cache of the connection, nil if caching is disabled.
+// - model: The model of the entity.
+// - entityName: The name of the entity.
+//
+// Returns:
+// - error: An error if the cache can't be scanned or its keys can't be deleted.
+func (dbc *DBCommon) invalidateDeleted(cache *caches.Cache, model interface{}, entityName string) error {
+	if cache == nil {
+		return nil
+	}
+	if _, ok := interface{}(model).(hooks.CacheHook); ok {
+		return caches.GetInstance().InvalidateEntity(*cache, dbc.currentDB, entityName)
+	}
+	if _, soft := dbc.useSoftDelete(model); soft {
+		return caches.GetInstance().InvalidateQueries(*cache, dbc.currentDB, entityName)
+	}
+	return nil
+}
@@ -9,6 +9,9 @@ import (
 	"github.com/glodb/dbfusion/conditions"
 	"github.com/glodb/dbfusion/dbfusionErrors"
 	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/hooks"
+	"github.com/glodb/dbfusion/metadata"
+	"github.com/glodb/dbfusion/schema"
 	"github.com/glodb/dbfusion/utils"
 )
 
@@ -16,6 +19,27 @@ type SqlBase struct {
 	DBCommon
 }
 
+// sqlColumns returns the SQL columns of a model in the dialect of the connection, read once per model type.
+//
+// Parameters:
+// - modelType: The type of the model, pointers and slices are dereferenced.
+//
+// Returns:
+// - []schema.Column: The columns, shared by every caller, empty if the model is not a struct.
+func (sb *SqlBase) sqlColumns(modelType reflect.Type) []schema.Column {
+	model, ok := metadata.GetInstance().Model(modelType)
+	if !ok {
+		return make([]schema.Column, 0)
+	}
+	return model.Columns(sb.sqlDialect())
+}
+
+// sqlDialect returns the dialect of the connection. The SQL connections only speak MySQL, the other dialects of the
+// schema package generate the DDL of models for other databases.
+func (sb *SqlBase) sqlDialect() schema.Dialect {
+	return schema.MySQL
+}
+
 // createSqlInsert generates an SQL INSERT query and associated data for inserting a new record into a SQL database table.
 //
 // Parameters:
@@ -349,6 +373,43 @@ func (sb *SqlBase) createDeleteQuery(entityName string, whereConditions string,
 	return query
 }
 
+// createSoftDeleteQuery generates an SQL UPDATE query setting the soft delete column of the records, which deletes
+// them without removing them from the table.
+//
+// Parameters:
+// - entityName: The name of the table.
+// - column: The name of the soft delete column, set to the first placeholder of the query.
+// - whereConditions: The WHERE conditions of the query, the stored whereQuery is used if empty.
+// - limitOne: A boolean indicating whether to limit the query to one row.
+//
+// Returns:
+// - query: The SQL UPDATE query string.
+//
+// Example Usage:
+//
+//   deleteQuery := createSoftDeleteQuery("users", "deletedAt", "email = ?", true)
+//   // deleteQuery: "UPDATE users SET deletedAt = ? WHERE email = ? LIMIT 1"
+func (sb *SqlBase) createSoftDeleteQuery(entityName string, column string, whereConditions string, limitOne bool) string {
+	// Soft deletes are updates of the soft delete column.
+	setCommand := fmt.Sprintf("SET %s = ?", column)
+	if whereConditions == "" {
+		return sb.createUpdateQuery(entityName, setCommand, limitOne)
+	}
+
+	// Use the WHERE conditions provided, then limit the rows as createUpdateQuery does.
+	query := fmt.Sprintf("UPDATE %s %s", entityName, setCommand)
+	if sb.joins != "" {
+		query = fmt.Sprintf(query+" %s", sb.joins)
+	}
+	query = fmt.Sprintf(query+" WHERE %s", whereConditions)
+	if limitOne {
+		query = fmt.Sprintf(query+" LIMIT %d", 1)
+	} else if sb.limit != 0 {
+		query = fmt.Sprintf(query+" LIMIT %d", sb.limit)
+	}
+	return query
+}
+
 // readSqlDataFromRows reads data from the given SQL rows and populates a struct or slice of structs based on the provided data type.
 //
 // Parameters:
@@ -397,15 +458,10 @@ func (sb *SqlBase) readSqlDataFromRows(rows *sql.Rows, dataType reflect.Type, da
 		columnData[i] = &v
 	}
 
-	// Create a map to associate tag names with struct fields for efficient assignment.
-	tagField := make(map[string]reflect.Value)
-	for i := 0; i < dataType.NumField(); i++ {
-		field := dataType.Field(i)
-
-		rawtags := strings.Split(field.Tag.Get("dbfusion"), ",")
-		tagName := rawtags[0]
-		tagField[tagName] = dataValue.Field(i)
-	}
+	// Use the pointers of the generated mapper of the model when it has one, or the metadata of the model to
+	// associate tag names with struct fields.
+	pointers := sb.fieldPointers(dataValue)
+	model, _ := metadata.GetInstance().Model(dataType)
 
 	// Iterate through the rows of the SQL result set.
 	for rows.Next() {
@@ -418,8 +474,10 @@ func (sb *SqlBase) readSqlDataFromRows(rows *sql.Rows, dataType reflect.Type, da
 
 		// Map the scanned column data to the corresponding struct fields based on column names.
 		for idx, name := range columnNames {
-			if field, ok := tagField[name]; ok {
-				utils.GetInstance().AssignData(columnData[idx], field)
+			if pointer, ok := pointers[name]; ok {
+				utils.GetInstance().AssignTo(columnData[idx], pointer)
+			} else if field, ok := model.Field(name); ok {
+				utils.GetInstance().AssignField(columnData[idx], dataValue, field)
 			}
 		}
 	}
@@ -429,6 +487,11 @@ func (sb *SqlBase) readSqlDataFromRows(rows *sql.Rows, dataType reflect.Type, da
 		return 0, err
 	}
 
+	// Decrypt the fields with the encrypt option.
+	if err := sb.decryptStruct(dataValue); err != nil {
+		return 0, err
+	}
+
 	// Return the number of successfully processed rows and any encountered error.
 	return rowsCount, nil
 }
@@ -474,10 +537,13 @@ func (sb *SqlBase) readSqlRowsToArray(rows *sql.Rows, results interface{}) error
 		columnData[i] = &v
 	}
 
+	// Get the tagged fields of the slice's element type.
+	elementType := resultSliceType.Elem()
+	model, _ := metadata.GetInstance().Model(elementType)
+
 	// Iterate through rows and populate the newSlice.
 	for rows.Next() {
 		// Create a new element of the slice's element type.
-		elementType := resultSliceType.Elem()
 		newElement := reflect.New(elementType).Elem()
 
 		// Scan the row into the fields of the newElement.
@@ -485,11 +551,21 @@ func (sb *SqlBase) readSqlRowsToArray(rows *sql.Rows, results interface{}) error
 			return err
 		}
 
-		// Get field names from struct tags and assign data to struct fields.
-		tagField := sb.getFieldNames(elementType, newElement)
+		// Assign data to the pointers of the generated mapper of the model when it has one.
+		if pointers := sb.fieldPointers(newElement); pointers != nil {
+			for idx, name := range columnNames {
+				if pointer, ok := pointers[name]; ok {
+					utils.GetInstance().AssignTo(columnData[idx], pointer)
+				}
+			}
+			newSlice = reflect.Append(newSlice, newElement)
+			continue
+		}
+
+		// Assign data to the struct fields named by the columns.
 		for idx, name := range columnNames {
-			if fieldName, ok := tagField[name]; ok {
-				utils.GetInstance().AssignData(columnData[idx], newElement.FieldByName(fieldName))
+			if field, ok := model.Field(name); ok {
+				utils.GetInstance().AssignField(columnData[idx], newElement, field)
 			}
 		}
 
@@ -500,48 +576,108 @@ func (sb *SqlBase) readSqlRowsToArray(rows *sql.Rows, results interface{}) error
 	// Set the populated newSlice to the results pointer.
 	reflect.ValueOf(results).Elem().Set(newSlice)
 
-	return nil
+	// Decrypt the fields with the encrypt option.
+	return sb.decryptResult(results)
 }
 
-// getFieldNames retrieves field names from struct tags for the given struct type and maps them to their corresponding
-// tag names. It is used to associate column names from SQL query results with struct field names.
+// readSqlRowToMap converts the current row of an SQL result set into a map of column names to values.
 //
 // Parameters:
-// - structType: The reflect.Type of the struct for which field names should be retrieved.
-// - dataValue: A reflect.Value of the struct instance that corresponds to structType.
+// - rows: A pointer to an SQL Rows result positioned on the row to be read.
+// - columnNames: The column names of the result set.
+// - dataType: The struct type of the entity, or nil when the entity is read as a map.
 //
 // Returns:
-// - tagField: A map where the keys are tag names and the values are corresponding struct field names.
+// - map[string]interface{}: The values of the row keyed by column name.
+// - error: An error, if any, that occurred while scanning the row.
 //
-// The getFieldNames method is responsible for extracting field names from struct tags and creating a mapping between
-// the tag names used in SQL queries and the actual struct field names. This mapping is crucial for correctly assigning
-// query results to struct fields during data retrieval.
+// When a struct type is provided, the row is first assigned to a new struct of that type so that the values have the
+// same types as the ones cached on insertion. Otherwise byte slices returned by the driver are converted to strings.
 //
 // Example Usage:
 //
-//   type User struct {
-//       ID   int    `dbfusion:"user_id"`
-//       Name string `dbfusion:"user_name"`
+//   columnNames, _ := rows.Columns()
+//   for rows.Next() {
+//       data, err := sb.readSqlRowToMap(rows, columnNames, reflect.TypeOf(User{}))
 //   }
-//
-//   var u User
-//   tagField := getFieldNames(reflect.TypeOf(u), reflect.ValueOf(u))
-//   // Result: tagField map with {"user_id": "ID", "user_name": "Name"}
-//
-// In this example, the getFieldNames method takes the reflect.Type of a User struct and a reflect.Value of an instance
-// of that struct. It extracts the tag names ("user_id" and "user_name") and associates them with their respective
-// struct field names ("ID" and "Name") in the returned tagField map.
-func (sb *SqlBase) getFieldNames(structType reflect.Type, dataValue reflect.Value) map[string]string {
-	tagField := make(map[string]string)
-	for i := 0; i < structType.NumField(); i++ {
-		field := structType.Field(i)
-		rawtags := strings.Split(field.Tag.Get("dbfusion"), ",")
-		tagName := rawtags[0]
-		if tagName != "" {
-			tagField[tagName] = field.Name
+func (sb *SqlBase) readSqlRowToMap(rows *sql.Rows, columnNames []string, dataType reflect.Type) (map[string]interface{}, error) {
+	// Create a slice of interface{} to hold the column data.
+	columnData := make([]interface{}, len(columnNames))
+	for i := range columnData {
+		var v interface{}
+		columnData[i] = &v
+	}
+
+	// Scan the row data into columnData.
+	if err := rows.Scan(columnData...); err != nil {
+		return nil, err
+	}
+
+	// Without a struct type the raw column values are returned.
+	if dataType == nil {
+		data := make(map[string]interface{}, len(columnNames))
+		for idx, name := range columnNames {
+			value := *columnData[idx].(*interface{})
+			if bytes, ok := value.([]byte); ok {
+				value = string(bytes)
+			}
+			data[name] = value
+		}
+		return data, nil
+	}
+
+	// Assign the column data to a new struct and read it back as a tag-value map.
+	element := reflect.New(dataType).Elem()
+	if pointers := sb.fieldPointers(element); pointers != nil {
+		for idx, name := range columnNames {
+			if pointer, ok := pointers[name]; ok {
+				utils.GetInstance().AssignTo(columnData[idx], pointer)
+			}
+		}
+		return sb.createTagValueMap(element.Interface())
+	}
+	model, _ := metadata.GetInstance().Model(dataType)
+	for idx, name := range columnNames {
+		if field, ok := model.Field(name); ok {
+			utils.GetInstance().AssignField(columnData[idx], element, field)
 		}
 	}
-	return tagField
+	return sb.createTagValueMap(element.Interface())
+}
+
+// fieldPointers returns pointers to the tagged fields of a struct keyed by their tag name, read with the generated
+// mapper of the model.
+//
+// Parameters:
+// - dataValue: An addressable reflect.Value of the struct.
+//
+// Returns:
+// - map[string]interface{}: The pointers to the fields, or nil if the model has no mapper or the value isn't
+//   addressable.
+//
+// Example Usage:
+//
+//   element := reflect.New(reflect.TypeOf(User{})).Elem()
+//   pointers := sb.fieldPointers(element)
+//   // Result: {"id": &user.ID, "email": &user.Email} when User has a generated mapper
+func (sb *SqlBase) fieldPointers(dataValue reflect.Value) map[string]interface{} {
+	if !dataValue.CanAddr() {
+		return nil
+	}
+	scanner, ok := dataValue.Addr().Interface().(hooks.FieldScanner)
+	if !ok {
+		return nil
+	}
+	mapper, ok := dataValue.Addr().Interface().(hooks.FieldMapper)
+	if !ok {
+		return nil
+	}
+	columns, fieldPointers := mapper.DBFusionColumns(), scanner.DBFusionPointers()
+	pointers := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		pointers[column] = fieldPointers[i]
+	}
+	return pointers
 }
 
 // createTableQuery generates a SQL query to create a database table based on the structure of a provided data interface.
@@ -553,44 +689,48 @@ func (sb *SqlBase) getFieldNames(structType reflect.Type, dataValue reflect.Valu
 // - query: A string representing the SQL query to create the table.
 // - error: An error if any issues occur during query generation.
 func (sb *SqlBase) createTableQuery(data interface{}, ifNotExist bool) (string, error) {
-	query := ""
-
 	// Get the entity name and data type from the provided data interface.
 	name, err := sb.getEntityName(data)
 	if err != nil {
 		return "", err
 	}
 
-	// Determine whether to include "IF NOT EXISTS" in the CREATE TABLE query.
-	if ifNotExist {
-		query = `CREATE TABLE IF NOT EXISTS ` + name.entityName + ` (`
-	} else {
-		query = `CREATE TABLE ` + name.entityName + ` (`
-	}
-
-	dataType := name.dataType
-
 	// Check if the data type is a struct, as we can only generate table schemas from structs.
-	if dataType.Kind() != reflect.Struct {
+	if name.dataType.Kind() != reflect.Struct {
 		return "", dbfusionErrors.ErrInvalidType
 	}
 
-	columns := ""
-
-	// Iterate over the fields of the struct to generate column definitions.
-	for i := 0; i < dataType.NumField(); i++ {
-		field := dataType.Field(i)
-		tags := strings.Split(field.Tag.Get("dbfusion"), ",")
-
-		if columns != "" {
-			columns += ","
-		}
+	// Read the columns from the tags of the fields, inferring the types the tags don't set, and write the query.
+	columns := sb.sqlColumns(name.dataType)
+	return schema.CreateTableQuery(sb.sqlDialect(), name.entityName, columns, ifNotExist) + ";", nil
+}
 
-		// Join struct tags to form the column definition (e.g., "column_name PRIMARY KEY,other_column").
-		columns += strings.Join(tags, " ")
+// readInsertID reads back the primary key generated by the database for an inserted struct whose primary key uses
+// the autoincr strategy and was left empty. The key is added to the inserted values, so that the record is cached
+// under it, and set in the struct when it was passed by pointer.
+//
+// Parameters:
+// - result: The result of the INSERT query.
+// - preCreateData: The data of the insertion.
+//
+// Returns:
+// - error: An error if the database can't return the generated key.
+func (sb *SqlBase) readInsertID(result sql.Result, preCreateData preCreateReturn) error {
+	key, ok := sb.primaryKey(reflect.TypeOf(preCreateData.Data))
+	if !ok || key.strategy != autoIncrementStrategy {
+		return nil
 	}
 
-	query += columns + ");"
+	// Keys set by the caller are kept.
+	if _, set := preCreateData.mData[key.name]; set {
+		return nil
+	}
 
-	return query, nil
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	preCreateData.mData[key.name] = id
+	sb.setStructID(preCreateData.Data, key, id)
+	return nil
 }
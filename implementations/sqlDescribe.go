@@ -0,0 +1,212 @@
+package implementations
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/schema"
+)
+
+// DescribeTable reads the columns, foreign keys and indexes of an existing table, to generate its model with
+// schema.Generate.
+//
+// Parameters:
+// - table: The name of the table.
+//
+// Returns:
+// - schema.Table: The table, its columns in the order of the table. The primary key, the unique indexes on a single
+//   column and the foreign keys are described by the columns, the other indexes by the indexes of the table.
+// - error: ErrEntityNotFound if the table doesn't exist, or an error if the schema can't be read.
+//
+// The types which the dialect infers from the Go type and the size of a column are left out of the column, the
+// other ones, e.g. "decimal(10,2)" or "enum('new','paid')", are kept to be written in the dbtype tag. A composite
+// primary key is left out, the dbfusion tag declaring the primary key on a single column.
+//
+// Example:
+//   table, err := ms.DescribeTable("users")
+//   source, err := schema.Generate("models", table)
+func (ms *MySql) DescribeTable(table string) (schema.Table, error) {
+	described := schema.Table{}
+	err := ms.intercept(connections.OpDescribe, func() error {
+		var err error
+		described, err = ms.describeTable(table)
+		return err
+	})
+	return described, err
+}
+
+// describeTable runs DescribeTable as the last handler of the middleware chain.
+func (ms *MySql) describeTable(table string) (schema.Table, error) {
+	described := schema.Table{Name: table, Columns: make([]schema.TableColumn, 0), Indexes: make([]schema.TableIndex, 0)}
+
+	// Read the columns, their foreign keys and the indexes of the table.
+	columns, err := ms.liveColumns(table)
+	if err != nil {
+		return described, err
+	}
+	if len(columns) == 0 {
+		return described, dbfusionErrors.ErrEntityNotFound
+	}
+	references, err := ms.liveReferences(table)
+	if err != nil {
+		return described, err
+	}
+	indexes, err := ms.liveIndexes(table)
+	if err != nil {
+		return described, err
+	}
+
+	// Describe the indexes the columns can't declare, and the keys the columns declare.
+	primaryKey := ""
+	unique := make(map[string]bool)
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		index := indexes[name]
+		switch {
+		case name == "PRIMARY":
+			if len(index.columns) == 1 {
+				primaryKey = index.columns[0]
+			}
+		case index.unique && len(index.columns) == 1 && index.indexType != "FULLTEXT" && index.indexType != "SPATIAL":
+			unique[index.columns[0]] = true
+		case !index.unique && len(index.columns) == 1 && references[index.columns[0]] != nil:
+			// The index MySQL creates for a foreign key, which the fk option declares.
+		default:
+			described.Indexes = append(described.Indexes, sqlTableIndex(index))
+		}
+	}
+
+	// Describe the columns in the order of the table.
+	for _, name := range liveColumnOrder(columns) {
+		described.Columns = append(described.Columns, ms.describeColumn(columns[name], name == primaryKey, unique[name], references[name]))
+	}
+	return described, nil
+}
+
+// describeColumn describes an existing column, its type being left out when the dialect infers it.
+//
+// Parameters:
+// - live: The column read from INFORMATION_SCHEMA.COLUMNS.
+// - primaryKey: true if the column is the primary key.
+// - unique: true if the column has a unique index of its own.
+// - reference: The column referenced by a foreign key of the column, nil without one.
+//
+// Returns:
+// - schema.TableColumn: The column, with the Go type of its values, a pointer if it is nullable.
+func (ms *MySql) describeColumn(live liveColumn, primaryKey bool, unique bool, reference *schema.Reference) schema.TableColumn {
+	goType, size := ms.sqlDialect().GoType(live.columnType)
+	column := schema.TableColumn{
+		Name:          live.name,
+		GoType:        goType,
+		Size:          size,
+		PrimaryKey:    primaryKey,
+		AutoIncrement: live.autoIncrement,
+		Unique:        unique,
+		References:    reference,
+	}
+
+	// Keep the type when the dialect infers another one from the field.
+	if normalizeColumnType(ms.sqlDialect().ColumnType(goType, size)) != normalizeColumnType(live.columnType) {
+		column.Type = live.columnType
+		column.Size = 0
+	}
+	if live.nullable && !primaryKey {
+		column.GoType = reflect.PtrTo(goType)
+	}
+	if live.defaultValue.Valid && !strings.EqualFold(live.defaultValue.String, "NULL") {
+		value := sqlDefaultLiteral(live, goType)
+		column.Default = &value
+	}
+	return column
+}
+
+// sqlDefaultLiteral writes the default of an existing column as a SQL literal or expression, MySQL reporting the
+// string defaults without their quotes and the expressions without their parentheses.
+func sqlDefaultLiteral(live liveColumn, goType reflect.Type) string {
+	value := live.defaultValue.String
+	upper := strings.ToUpper(value)
+	switch {
+	case strings.HasPrefix(upper, "CURRENT_TIMESTAMP") || strings.HasPrefix(upper, "NOW("):
+		return value
+	case live.generated:
+		return "(" + value + ")"
+	case strings.HasPrefix(value, "'"):
+		return value
+	}
+	switch goType.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// sqlTableIndex describes an existing index, FULLTEXT indexes being text indexes and SPATIAL indexes 2dsphere
+// indexes.
+func sqlTableIndex(index liveIndex) schema.TableIndex {
+	described := schema.TableIndex{Name: index.name, Kind: schema.NormalIndex, Keys: make([]string, 0, len(index.columns))}
+	switch {
+	case index.indexType == "FULLTEXT":
+		described.Kind = schema.TextIndex
+	case index.indexType == "SPATIAL":
+		described.Kind = schema.TwoDSphereIndex
+	case index.unique:
+		described.Kind = schema.UniqueIndex
+	}
+	for i, column := range index.columns {
+		switch {
+		case described.Kind == schema.TextIndex || described.Kind == schema.TwoDSphereIndex:
+			described.Keys = append(described.Keys, column)
+		case i < len(index.descending) && index.descending[i]:
+			described.Keys = append(described.Keys, column+":-1")
+		default:
+			described.Keys = append(described.Keys, column+":1")
+		}
+	}
+	return described
+}
+
+// liveReferences reads the foreign keys of a table on a single column.
+//
+// Parameters:
+// - table: The name of the table.
+//
+// Returns:
+// - map[string]*schema.Reference: The referenced columns keyed by the referencing column.
+// - error: An error if the foreign keys can't be read.
+func (ms *MySql) liveReferences(table string) (map[string]*schema.Reference, error) {
+	rows, err := ms.executor().QueryContext(ms.getContext(),
+		"SELECT CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND REFERENCED_TABLE_NAME IS NOT NULL",
+		ms.currentDB, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Count the columns of each constraint to leave out the composite foreign keys.
+	constraints := make(map[string][]string)
+	references := make(map[string]*schema.Reference)
+	for rows.Next() {
+		constraint, column, reference := "", "", schema.Reference{}
+		if err := rows.Scan(&constraint, &column, &reference.Table, &reference.Column); err != nil {
+			return nil, err
+		}
+		constraints[constraint] = append(constraints[constraint], column)
+		references[column] = &reference
+	}
+	for _, columns := range constraints {
+		if len(columns) > 1 {
+			for _, column := range columns {
+				delete(references, column)
+			}
+		}
+	}
+	return references, rows.Err()
+}
@@ -0,0 +1,335 @@
+package implementations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/schema"
+)
+
+// maxIndexName is the longest index name MySQL accepts.
+const maxIndexName = 64
+
+// sqlIndexTypes maps the kinds of indexes MySQL supports to the modifier of their CREATE INDEX statement. Hashed and
+// sparse indexes have no MySQL equivalent.
+var sqlIndexTypes = map[string]string{
+	normalIndex:     "",
+	uniqueIndex:     "UNIQUE",
+	textIndex:       "FULLTEXT",
+	twoDIndex:       "SPATIAL",
+	twoDSphereIndex: "SPATIAL",
+}
+
+// sqlIndexPrefixes are the prefixes of the names given to the indexes the hooks don't name.
+var sqlIndexPrefixes = map[string]string{
+	normalIndex:     "idx",
+	uniqueIndex:     "uniq",
+	textIndex:       "ft",
+	twoDIndex:       "sp",
+	twoDSphereIndex: "sp",
+}
+
+// CreateIndexes creates the indexes declared by the index hooks of a model on its table. Normal and unique indexes,
+// compound or not, are created with their sort directions, text indexes are FULLTEXT indexes and two-dimensional
+// indexes are SPATIAL indexes. Hashed and sparse indexes are left out, MySQL having no equivalent.
+//
+// Parameters:
+// - data: The model implementing the index hooks, e.g. hooks.NormalIndexes and hooks.UniqueIndexes.
+//
+// Returns:
+// - error: An error if an index can't be created; otherwise, it returns nil.
+//
+// Indexes are named by the part of the hook before an equal sign, e.g. "byEmail=email:1,phone:-1", or after their
+// kind and columns otherwise, e.g. "idx_email_phone". The indexes whose name already exists, and the ones matching an
+// existing index on the same columns, are skipped, so that calling CreateIndexes again creates nothing.
+//
+// Example:
+//   err := ms.CreateIndexes(User{})
+func (ms *MySql) CreateIndexes(data interface{}) error {
+	return ms.intercept(connections.OpCreateIndexes, func() error {
+		return ms.createIndexes(data)
+	})
+}
+
+// createIndexes runs CreateIndexes as the last handler of the middleware chain.
+func (ms *MySql) createIndexes(data interface{}) error {
+	// Get the table of the model and its existing indexes.
+	name, err := ms.getEntityName(data)
+	if err != nil {
+		return err
+	}
+	existing, err := ms.liveIndexes(name.entityName)
+	if err != nil {
+		return err
+	}
+
+	// Create the missing indexes one after another.
+	for _, definition := range sqlMissingIndexes(ms.indexDefinitions(data), existing) {
+		if _, err := ms.executor().ExecContext(ms.getContext(), createIndexStatement(name.entityName, definition)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncIndexes compares the indexes of a table with the ones declared by the index hooks of its model. The missing
+// indexes are created like CreateIndexes creates them, and the undeclared ones are dropped with DropUndeclared. The
+// primary key, the unique indexes of the columns declared unique in their tags and the indexes of the foreign keys
+// count as declared and are never dropped.
+//
+// Parameters:
+// - data: The model implementing the index hooks.
+// - dbFusionOptions: Optional options to only report the differences or to drop the undeclared indexes.
+//
+// Returns:
+// - connections.IndexSyncReport: The missing, undeclared, created and dropped indexes.
+// - error: An error if the indexes can't be read, created or dropped.
+//
+// Example:
+//   report, err := ms.SyncIndexes(User{}, queryoptions.SyncIndexesOptions{DryRun: true})
+//   if !report.InSync() {
+//       // The indexes drifted from the model.
+//   }
+func (ms *MySql) SyncIndexes(data interface{}, dbFusionOptions ...queryoptions.SyncIndexesOptions) (connections.IndexSyncReport, error) {
+	report := connections.IndexSyncReport{}
+	err := ms.intercept(connections.OpSyncIndexes, func() error {
+		var err error
+		report, err = ms.syncIndexes(data, dbFusionOptions...)
+		return err
+	})
+	return report, err
+}
+
+// syncIndexes runs SyncIndexes as the last handler of the middleware chain.
+func (ms *MySql) syncIndexes(data interface{}, dbFusionOptions ...queryoptions.SyncIndexesOptions) (connections.IndexSyncReport, error) {
+	report := connections.IndexSyncReport{Missing: []string{}, Changed: []string{}, Undeclared: []string{}, Created: []string{}, Dropped: []string{}}
+	syncOptions := queryoptions.SyncIndexesOptions{}
+	if len(dbFusionOptions) > 0 {
+		syncOptions = dbFusionOptions[0]
+	}
+
+	// Get the table of the model and its existing indexes.
+	name, err := ms.getEntityName(data)
+	if err != nil {
+		return report, err
+	}
+	existing, err := ms.liveIndexes(name.entityName)
+	if err != nil {
+		return report, err
+	}
+	definitions := ms.indexDefinitions(data)
+	columns := ms.sqlColumns(name.dataType)
+
+	// Find the existing indexes the model doesn't declare, before the missing ones are added to them.
+	undeclared := make([]string, 0)
+	for _, index := range existing {
+		if !sqlIndexDeclared(index, definitions, columns) {
+			undeclared = append(undeclared, index.name)
+		}
+	}
+	sort.Strings(undeclared)
+
+	// Create the missing indexes.
+	for _, definition := range sqlMissingIndexes(definitions, existing) {
+		report.Missing = append(report.Missing, sqlIndexName(definition))
+		if syncOptions.DryRun {
+			continue
+		}
+		if _, err := ms.executor().ExecContext(ms.getContext(), createIndexStatement(name.entityName, definition)); err != nil {
+			return report, err
+		}
+		report.Created = append(report.Created, sqlIndexName(definition))
+	}
+
+	// Drop the undeclared indexes when asked to.
+	for _, index := range undeclared {
+		report.Undeclared = append(report.Undeclared, index)
+		if syncOptions.DryRun || !syncOptions.DropUndeclared {
+			continue
+		}
+		if _, err := ms.executor().ExecContext(ms.getContext(), fmt.Sprintf("DROP INDEX %s ON %s", index, name.entityName)); err != nil {
+			return report, err
+		}
+		report.Dropped = append(report.Dropped, index)
+	}
+	return report, nil
+}
+
+// sqlMissingIndexes returns the declared indexes MySQL supports whose name or definition doesn't exist.
+//
+// Parameters:
+// - definitions: The indexes declared by the model.
+// - existing: The existing indexes of the table keyed by name, the missing ones are added to it.
+//
+// Returns:
+// - []indexDefinition: The missing indexes, in the order of the declarations.
+func sqlMissingIndexes(definitions []indexDefinition, existing map[string]liveIndex) []indexDefinition {
+	missing := make([]indexDefinition, 0)
+	for _, definition := range definitions {
+		if _, supported := sqlIndexTypes[definition.kind]; !supported {
+			continue
+		}
+
+		// Skip the indexes whose name or definition already exists.
+		name := sqlIndexName(definition)
+		if _, exists := existing[name]; exists || sqlIndexExists(existing, definition) {
+			continue
+		}
+		missing = append(missing, definition)
+		existing[name] = sqlLiveIndex(name, definition)
+	}
+	return missing
+}
+
+// createIndexStatement returns the CREATE INDEX statement of a declared index.
+//
+// Parameters:
+// - table: The name of the table.
+// - definition: The index.
+//
+// Returns:
+// - string: The statement, e.g. "CREATE UNIQUE INDEX uniq_email ON users (email)".
+func createIndexStatement(table string, definition indexDefinition) string {
+	// Only the BTREE indexes take sort directions.
+	modifier := sqlIndexTypes[definition.kind]
+	columns := make([]string, 0, len(definition.keys))
+	for _, key := range definition.keys {
+		if key.descending && modifier != "FULLTEXT" && modifier != "SPATIAL" {
+			columns = append(columns, key.field+" DESC")
+		} else {
+			columns = append(columns, key.field)
+		}
+	}
+	statement := "CREATE INDEX"
+	if modifier != "" {
+		statement = "CREATE " + modifier + " INDEX"
+	}
+	return fmt.Sprintf("%s %s ON %s (%s)", statement, sqlIndexName(definition), table, strings.Join(columns, ", "))
+}
+
+// sqlIndexDeclared reports whether the model declares an existing index, in its index hooks or in its tags.
+//
+// Parameters:
+// - index: The existing index.
+// - definitions: The indexes declared by the index hooks of the model.
+// - columns: The columns of the model, whose tags declare the primary key, the unique columns and the foreign keys.
+//
+// Returns:
+// - bool: true if the index is the primary key, declared by a hook, or backs a unique column or a foreign key.
+func sqlIndexDeclared(index liveIndex, definitions []indexDefinition, columns []schema.Column) bool {
+	if index.name == "PRIMARY" {
+		return true
+	}
+
+	// Look for the index in the hooks, by name or by definition.
+	for _, definition := range definitions {
+		if _, supported := sqlIndexTypes[definition.kind]; !supported {
+			continue
+		}
+		if strings.EqualFold(sqlIndexName(definition), index.name) || sqlIndexMatches(index, definition) {
+			return true
+		}
+	}
+
+	// Look for the unique column or the foreign key the index backs, declared by the options or by the column
+	// definition written in the tag, e.g. "INT,NOT NULL REFERENCES users(id)".
+	for _, column := range columns {
+		unique := column.Unique || sqlDefinitionDeclares(column, "UNIQUE")
+		if unique && index.unique && len(index.columns) == 1 && strings.EqualFold(index.columns[0], column.Name) {
+			return true
+		}
+		references := column.References != nil || sqlDefinitionDeclares(column, "REFERENCES")
+		if references && len(index.columns) > 0 && strings.EqualFold(index.columns[0], column.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlDefinitionDeclares reports whether the column definition written in the dbfusion tag of a column holds a
+// keyword, MySQL creating an index for UNIQUE and REFERENCES.
+//
+// Parameters:
+// - column: The column.
+// - keyword: The upper case keyword, e.g. "UNIQUE".
+//
+// Returns:
+// - bool: true if a part of the definition holds the keyword.
+func sqlDefinitionDeclares(column schema.Column, keyword string) bool {
+	parts := strings.Split(column.Field.Tag.Get("dbfusion"), ",")
+	for _, part := range parts[1:] {
+		if schema.IsOption(part) {
+			continue
+		}
+		for _, word := range strings.Fields(strings.ToUpper(part)) {
+			if word == keyword {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sqlIndexName returns the name of a declared index, the kind and the columns of the index if the hook doesn't name
+// it, shortened to the longest name MySQL accepts.
+func sqlIndexName(definition indexDefinition) string {
+	if definition.name != "" {
+		return definition.name
+	}
+	name := sqlIndexPrefixes[definition.kind] + "_" + strings.Join(definition.fields(), "_")
+	if len(name) > maxIndexName {
+		name = name[:maxIndexName]
+	}
+	return name
+}
+
+// sqlLiveIndex returns the existing index a declared index becomes once created.
+func sqlLiveIndex(name string, definition indexDefinition) liveIndex {
+	index := liveIndex{name: name, unique: definition.unique, indexType: "BTREE"}
+	if modifier := sqlIndexTypes[definition.kind]; modifier == "FULLTEXT" || modifier == "SPATIAL" {
+		index.indexType = modifier
+	}
+	for _, key := range definition.keys {
+		index.columns = append(index.columns, key.field)
+		index.descending = append(index.descending, key.descending && index.indexType == "BTREE")
+	}
+	return index
+}
+
+// sqlIndexExists reports whether an existing index has the definition of a declared index, e.g. the unique index
+// of a column declared UNIQUE in its dbfusion tag.
+func sqlIndexExists(existing map[string]liveIndex, definition indexDefinition) bool {
+	for _, index := range existing {
+		if sqlIndexMatches(index, definition) {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlIndexMatches reports whether an existing index has the columns, directions, uniqueness and type of a declared
+// index.
+func sqlIndexMatches(index liveIndex, definition indexDefinition) bool {
+	declared := sqlLiveIndex("", definition)
+	if index.name == "PRIMARY" || index.unique != declared.unique || len(index.columns) != len(declared.columns) {
+		return false
+	}
+
+	// Compare the FULLTEXT and SPATIAL types, the other types being BTREE ones.
+	indexType := index.indexType
+	if indexType != "FULLTEXT" && indexType != "SPATIAL" {
+		indexType = "BTREE"
+	}
+	if indexType != declared.indexType {
+		return false
+	}
+	for i, column := range index.columns {
+		if !strings.EqualFold(column, declared.columns[i]) || (i < len(index.descending) && index.descending[i] != declared.descending[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package implementations
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/glodb/dbfusion/metadata"
+	"github.com/glodb/dbfusion/schema"
+	"github.com/glodb/dbfusion/set"
+)
+
+// Options of the dbfusion tag interpreted by dbFusion itself. They follow the field name in the tag, e.g.
+// `dbfusion:"createdAt,autoCreateTime"`, and some of them take a parameter after a colon.
+const (
+	omitEmptyOption      = "omitempty"      // Skips the field when it holds its zero value.
+	autoCreateTimeOption = "autoCreateTime" // Fills the field with the time of the insertion.
+	autoUpdateTimeOption = "autoUpdateTime" // Fills the field with the time of the insertion and of every update.
+	softDeleteOption     = "softdelete"     // Deletes set the field to the time of the deletion instead of removing the record.
+	versionOption        = "version"        // Holds the version of the record checked and incremented by updates.
+	pkOption             = "pk"             // Holds the primary key, the parameter names the strategy generating it.
+	encryptOption        = "encrypt"        // Encrypts the string field at rest, the parameter "deterministic" allows lookups.
+)
+
+// tagOptions lists the options of the dbfusion tag which are not part of the column definitions of a SQL table, the
+// options above and the ones describing the columns, see schema.TagOptions.
+var tagOptions = set.ConvertArray(schema.TagOptions)
+
+// isTagOption reports whether a part of the dbfusion tag is an option interpreted by dbFusion.
+//
+// Parameters:
+// - tag: A part of the dbfusion tag, e.g. "autoCreateTime:milli".
+//
+// Returns:
+// - bool: true if the part is an option of dbFusion rather than a column definition.
+func (dbc *DBCommon) isTagOption(tag string) bool {
+	name, _, _ := strings.Cut(strings.TrimSpace(tag), ":")
+	return tagOptions.Contains(name)
+}
+
+// fieldWithOption looks for the first field of a struct type carrying an option in its dbfusion tag.
+//
+// Parameters:
+// - modelType: The type of the model, pointers and slices are dereferenced.
+// - option: The name of the option.
+//
+// Returns:
+// - string: The name of the field in the database.
+// - reflect.StructField: The struct field.
+// - bool: true if a field carries the option.
+func (dbc *DBCommon) fieldWithOption(modelType reflect.Type, option string) (string, reflect.StructField, bool) {
+	model, ok := metadata.GetInstance().Model(modelType)
+	if !ok {
+		return "", reflect.StructField{}, false
+	}
+	field, ok := model.FieldWithOption(option)
+	if !ok {
+		return "", reflect.StructField{}, false
+	}
+	return field.Name, field.StructField, true
+}
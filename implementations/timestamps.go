@@ -0,0 +1,147 @@
+package implementations
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/metadata"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// milliTimeUnit is the parameter of the timestamp options storing unix milliseconds instead of seconds,
+// e.g. `dbfusion:"createdAt,autoCreateTime:milli"`.
+const milliTimeUnit = "milli"
+
+// timeType is the reflect.Type of time.Time, compared with the types of the timestamp fields.
+var timeType = reflect.TypeOf(time.Time{})
+
+// autoTimestamp returns the current time for a field carrying one of the timestamp options, converted to the type of
+// the field: time.Time fields get the time itself and integer fields get unix seconds, or milliseconds with the
+// "milli" parameter.
+//
+// Parameters:
+// - field: The struct field.
+// - now: The current time.
+// - options: The timestamp options to look for, autoCreateTimeOption, autoUpdateTimeOption or softDeleteOption.
+//
+// Returns:
+// - reflect.Value: The timestamp converted to the type of the field.
+// - bool: true if the field carries one of the options and its type holds timestamps.
+func (dbc *DBCommon) autoTimestamp(field reflect.StructField, now time.Time, options ...string) (reflect.Value, bool) {
+	tag := metadata.GetInstance().Tag(field.Tag)
+
+	for _, option := range options {
+		unit, ok := tag.Option(option)
+		if !ok {
+			continue
+		}
+
+		// Convert the time to the type of the field.
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+			timestamp := now.Unix()
+			if unit == milliTimeUnit {
+				timestamp = now.UnixMilli()
+			}
+			return reflect.ValueOf(timestamp).Convert(field.Type), true
+		case reflect.Struct:
+			if field.Type == timeType {
+				return reflect.ValueOf(now), true
+			}
+		case reflect.Ptr:
+			if field.Type.Elem() == timeType {
+				return reflect.ValueOf(&now), true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// modelTimestamps returns the current time for every field of a model carrying one of the timestamp options, in the
+// order of the fields. It fills the timestamps of updates and upserts given as maps, which don't carry any tag.
+//
+// Parameters:
+// - modelType: The type of the model, timestamps are only found on structs.
+// - now: The current time.
+// - options: The timestamp options to look for.
+//
+// Returns:
+// - primitive.D: The names of the fields and their timestamps.
+func (dbc *DBCommon) modelTimestamps(modelType reflect.Type, now time.Time, options ...string) primitive.D {
+	timestamps := primitive.D{}
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return timestamps
+	}
+
+	model, _ := metadata.GetInstance().Model(modelType)
+	for _, field := range model.Fields {
+		if value, ok := dbc.autoTimestamp(field.StructField, now, options...); ok {
+			timestamps = append(timestamps, primitive.E{Key: field.Name, Value: value.Interface()})
+		}
+	}
+	return timestamps
+}
+
+// mapWithTimestamps adds the timestamps of the model to the fields of map data that don't set them already. The data
+// is copied so that the map of the caller is left unchanged. Other data is returned as is.
+//
+// Parameters:
+// - data: The data of the write, a ftypes.QMap, ftypes.DMap or map[string]interface{}.
+// - modelType: The type of the model declaring the timestamp fields.
+// - now: The current time.
+// - options: The timestamp options to fill.
+//
+// Returns:
+// - interface{}: The data with the timestamps, of the same type as data.
+func (dbc *DBCommon) mapWithTimestamps(data interface{}, modelType reflect.Type, now time.Time, options ...string) interface{} {
+	timestamps := dbc.modelTimestamps(modelType, now, options...)
+	if len(timestamps) == 0 {
+		return data
+	}
+
+	switch value := data.(type) {
+	case ftypes.QMap:
+		return ftypes.QMap(dbc.copyWithTimestamps(value, timestamps))
+	case map[string]interface{}:
+		return dbc.copyWithTimestamps(value, timestamps)
+	case ftypes.DMap:
+		// Append the timestamps missing from the ordered map.
+		withTimestamps := append(ftypes.DMap{}, value...)
+		for _, timestamp := range timestamps {
+			found := false
+			for _, element := range value {
+				if element.Key == timestamp.Key {
+					found = true
+					break
+				}
+			}
+			if !found {
+				withTimestamps = append(withTimestamps, timestamp)
+			}
+		}
+		return withTimestamps
+	}
+	return data
+}
+
+// copyWithTimestamps copies a map and adds the timestamps it doesn't set already.
+//
+// Parameters:
+// - data: The map to copy.
+// - timestamps: The names of the timestamp fields and their values.
+//
+// Returns:
+// - map[string]interface{}: The copy with the timestamps.
+func (dbc *DBCommon) copyWithTimestamps(data map[string]interface{}, timestamps primitive.D) map[string]interface{} {
+	withTimestamps := make(map[string]interface{}, len(data)+len(timestamps))
+	for key, value := range data {
+		withTimestamps[key] = value
+	}
+	for _, timestamp := range timestamps {
+		if _, ok := withTimestamps[timestamp.Key]; !ok {
+			withTimestamps[timestamp.Key] = timestamp.Value
+		}
+	}
+	return withTimestamps
+}
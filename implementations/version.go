@@ -0,0 +1,218 @@
+package implementations
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/metadata"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// versionField describes the field of a model carrying the version option used for optimistic locking.
+type versionField struct {
+	name  string              // The name of the field in the database.
+	field reflect.StructField // The struct field, an integer.
+}
+
+// versionField looks for the integer field carrying the version option in a model, e.g. `dbfusion:"version,version"`.
+//
+// Parameters:
+// - modelType: The type of the model.
+//
+// Returns:
+// - versionField: The field holding the version.
+// - bool: true if the model is versioned.
+func (dbc *DBCommon) versionField(modelType reflect.Type) (versionField, bool) {
+	name, field, ok := dbc.fieldWithOption(modelType, versionOption)
+	if !ok || !dbc.isInteger(field.Type) {
+		return versionField{}, false
+	}
+	return versionField{name: name, field: field}, true
+}
+
+// isInteger reports whether a type holds versions.
+func (dbc *DBCommon) isInteger(fieldType reflect.Type) bool {
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// initialVersion returns the version of inserted records, 1 converted to the type of the field.
+//
+// Parameters:
+// - field: The struct field.
+//
+// Returns:
+// - reflect.Value: The initial version.
+// - bool: true if the field carries the version option.
+func (dbc *DBCommon) initialVersion(field reflect.StructField) (reflect.Value, bool) {
+	if _, ok := metadata.GetInstance().Tag(field.Tag).Option(versionOption); !ok || !dbc.isInteger(field.Type) {
+		return reflect.Value{}, false
+	}
+	return reflect.ValueOf(1).Convert(field.Type), true
+}
+
+// expectedVersion returns the version an update expects to find in the database, which is the version carried by
+// the update data: the version field of a struct when it is set, or the version key of a map.
+//
+// Parameters:
+// - data: The update data.
+// - version: The version field of the model.
+//
+// Returns:
+// - interface{}: The expected version.
+// - bool: true if the data carries a version.
+func (dbc *DBCommon) expectedVersion(data interface{}, version versionField) (interface{}, bool) {
+	switch value := data.(type) {
+	case ftypes.QMap:
+		expected, ok := value[version.name]
+		return expected, ok
+	case map[string]interface{}:
+		expected, ok := value[version.name]
+		return expected, ok
+	case ftypes.DMap:
+		for _, element := range value {
+			if element.Key == version.name {
+				return element.Value, true
+			}
+		}
+		return nil, false
+	}
+
+	// Read the version field of structs.
+	return dbc.structVersion(data, version)
+}
+
+// structVersion returns the version held by a struct.
+//
+// Parameters:
+// - data: The struct, or a pointer to it.
+// - version: The version field of the model.
+//
+// Returns:
+// - interface{}: The version of the struct.
+// - bool: true if the struct has the version field and it is set.
+func (dbc *DBCommon) structVersion(data interface{}, version versionField) (interface{}, bool) {
+	if data == nil {
+		return nil, false
+	}
+	dataValue, dataType := dbc.checkPtr(data)
+	if dataType.Kind() != reflect.Struct {
+		return nil, false
+	}
+	fieldValue := dataValue.FieldByName(version.field.Name)
+	if !fieldValue.IsValid() || !dbc.isFieldSet(fieldValue) {
+		return nil, false
+	}
+	return fieldValue.Interface(), true
+}
+
+// setStructVersion sets the version field of a struct passed by pointer to the version following the expected one,
+// which keeps the struct, and the payload cached from it, in step with the database.
+//
+// Parameters:
+// - data: A pointer to the struct.
+// - version: The version field of the model.
+// - expected: The version the update found in the database.
+func (dbc *DBCommon) setStructVersion(data interface{}, version versionField, expected interface{}) {
+	dataValue, dataType := dbc.checkPtr(data)
+	if dataType.Kind() != reflect.Struct {
+		return
+	}
+	fieldValue := dataValue.FieldByName(version.field.Name)
+	if !fieldValue.IsValid() || !fieldValue.CanSet() {
+		return
+	}
+
+	// Increment the expected version in the type of the field.
+	current := reflect.ValueOf(expected)
+	if !current.IsValid() || !dbc.isInteger(current.Type()) {
+		return
+	}
+	next := reflect.ValueOf(current.Convert(reflect.TypeOf(int64(0))).Int() + 1)
+	fieldValue.Set(next.Convert(fieldValue.Type()))
+}
+
+// versionCondition returns the SQL condition matching the expected version. Records inserted without a version hold
+// NULL, which counts as version 0.
+//
+// Parameters:
+// - version: The version field of the model.
+// - expected: The expected version.
+//
+// Returns:
+// - string: The condition.
+// - []interface{}: The values of the placeholders of the condition.
+func (dbc *DBCommon) versionCondition(version versionField, expected interface{}) (string, []interface{}) {
+	return fmt.Sprintf("COALESCE(%s, 0) = ?", version.name), []interface{}{expected}
+}
+
+// versionIncrement returns the SQL command incrementing the version of the records.
+//
+// Parameters:
+// - version: The version field of the model.
+//
+// Returns:
+// - string: The command, to be added to the SET clause of an update.
+func (dbc *DBCommon) versionIncrement(version versionField) string {
+	return fmt.Sprintf("%s = COALESCE(%s, 0) + 1", version.name, version.name)
+}
+
+// versionFilter returns the MongoDB filter matching the expected version. Documents inserted without a version don't
+// have the field, which counts as version 0.
+//
+// Parameters:
+// - version: The version field of the model.
+// - expected: The expected version.
+//
+// Returns:
+// - primitive.D: The filter.
+func (dbc *DBCommon) versionFilter(version versionField, expected interface{}) primitive.D {
+	if expected == nil || reflect.ValueOf(expected).IsZero() {
+		return primitive.D{{Key: version.name, Value: primitive.D{{Key: "$in", Value: primitive.A{nil, expected}}}}}
+	}
+	return primitive.D{{Key: version.name, Value: expected}}
+}
+
+// mapWithoutVersion removes the version from map update data, the version is incremented by the update itself. The
+// data is copied so that the map of the caller is left unchanged. Other data is returned as is.
+//
+// Parameters:
+// - data: The update data.
+// - version: The version field of the model.
+//
+// Returns:
+// - interface{}: The data without the version, of the same type as data.
+func (dbc *DBCommon) mapWithoutVersion(data interface{}, version versionField) interface{} {
+	switch value := data.(type) {
+	case ftypes.QMap:
+		return ftypes.QMap(dbc.copyWithoutKey(value, version.name))
+	case map[string]interface{}:
+		return dbc.copyWithoutKey(value, version.name)
+	case ftypes.DMap:
+		return ftypes.DMap(dbc.removeKey(append(primitive.D{}, value...), version.name))
+	}
+	return data
+}
+
+// copyWithoutKey copies a map without one of its keys.
+//
+// Parameters:
+// - data: The map to copy.
+// - key: The key to leave out.
+//
+// Returns:
+// - map[string]interface{}: The copy without the key.
+func (dbc *DBCommon) copyWithoutKey(data map[string]interface{}, key string) map[string]interface{} {
+	withoutKey := make(map[string]interface{}, len(data))
+	for dataKey, value := range data {
+		if dataKey != key {
+			withoutKey[dataKey] = value
+		}
+	}
+	return withoutKey
+}
@@ -0,0 +1,345 @@
+package implementations
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/codec"
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/hooks"
+)
+
+// maxWriteBehindBackoff caps the delay between two attempts of a failed write-behind record.
+const maxWriteBehindBackoff = 30 * time.Second
+
+// writeBehindPoll is how long the worker waits for a record before checking whether it has to stop.
+const writeBehindPoll = time.Second
+
+// writeBehindItem is a record queued by a write-behind insertion. It is encoded with BSON so that the values of the
+// record, e.g. time.Time and primitive.ObjectID, are written to the database with the types they were queued with.
+type writeBehindItem struct {
+	DBName     string                 `bson:"db"`                  // The name of the database.
+	EntityName string                 `bson:"entity"`              // The name of the entity.
+	Data       map[string]interface{} `bson:"data"`                // The record as a map of field names to values.
+	Attempts   int                    `bson:"attempts"`            // The number of failed attempts so far.
+	NotBefore  time.Time              `bson:"notBefore,omitempty"` // The time the next attempt is due at after a failure.
+	Error      string                 `bson:"error,omitempty"`     // The last error, kept for the dead letter queue.
+}
+
+// writeBehindWriter writes a queued record to the database.
+type writeBehindWriter func(dbName string, entityName string, data map[string]interface{}) error
+
+// writeBehindWorker takes the records queued by write-behind insertions into a database and writes them in the
+// background. Failed records are queued again with an exponential backoff before being moved to a dead letter queue.
+type writeBehindWorker struct {
+	dbName        string                                               // The database whose queue the worker consumes.
+	queue         caches.Queue                                         // The queue provided by the cache.
+	queueKey      string                                               // The key of the queue.
+	processingKey string                                               // The key of the list holding the record being written.
+	deadKey       string                                               // The key of the queue holding the records that could not be written.
+	write         writeBehindWriter                                    // Writes a record to the database.
+	report        func(writeBehindError *connections.WriteBehindError) // Passes failures to the handler of the connection.
+	stop          chan struct{}                                        // Closed to stop the worker.
+	done          chan struct{}                                        // Closed once the worker stopped.
+
+	postponed []byte    // The first record found not due since a record was last written, nil if there is none.
+	nextDue   time.Time // The earliest time a record found not due since then is due at.
+}
+
+// SetWriteBehindErrorHandler sets the function called by the write-behind workers when a queued record can't be
+// written or the queue fails. Failures are not reported without a handler.
+//
+// Parameters:
+// - handler: The function receiving the failures, nil to stop reporting them.
+//
+// Example:
+//
+//	con.SetWriteBehindErrorHandler(func(err *connections.WriteBehindError) {
+//	    log.Println("dbfusion:", err)
+//	})
+func (dbc *DBCommon) SetWriteBehindErrorHandler(handler connections.WriteBehindErrorHandler) {
+	dbc.writeBehindErrors.Store(handler)
+}
+
+// reportWriteBehindError passes a failure of a write-behind worker to the handler of the connection, if any.
+func (dbc *DBCommon) reportWriteBehindError(writeBehindError *connections.WriteBehindError) {
+	if handler, ok := dbc.writeBehindErrors.Load().(connections.WriteBehindErrorHandler); ok && handler != nil {
+		handler(writeBehindError)
+	}
+}
+
+// startWriteBehind starts the write-behind worker of the current database unless it is already running. Records left
+// in the processing list by a previous process are queued again first.
+//
+// Parameters:
+// - driver: The name of the database driver, the queues of different drivers are kept apart.
+// - write: The function writing a queued record to the database.
+//
+// Returns:
+// - *writeBehindWorker: The worker of the current database.
+// - error: ErrNoValidCacheFound, ErrCacheQueueNotSupported or an error from requeuing the records.
+func (dbc *DBCommon) startWriteBehind(driver string, write writeBehindWriter) (*writeBehindWorker, error) {
+	dbc.writeBehindMutex.Lock()
+	defer dbc.writeBehindMutex.Unlock()
+
+	if worker, ok := dbc.writeBehind[dbc.currentDB]; ok {
+		return worker, nil
+	}
+
+	queue, err := dbc.getQueue()
+	if err != nil {
+		return nil, err
+	}
+
+	processor := caches.GetInstance()
+	worker := &writeBehindWorker{
+		dbName:        dbc.currentDB,
+		queue:         queue,
+		queueKey:      processor.QueueKey(dbc.server, dbc.currentDB, driver+":writebehind"),
+		processingKey: processor.QueueKey(dbc.server, dbc.currentDB, driver+":writebehind:processing"),
+		deadKey:       processor.QueueKey(dbc.server, dbc.currentDB, driver+":writebehind:dead"),
+		write:         write,
+		report:        dbc.reportWriteBehindError,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	// Records that were being written when a previous process stopped are written again.
+	if err := queue.Requeue(worker.processingKey, worker.queueKey); err != nil {
+		return nil, err
+	}
+
+	if dbc.writeBehind == nil {
+		dbc.writeBehind = make(map[string]*writeBehindWorker)
+	}
+	dbc.writeBehind[dbc.currentDB] = worker
+	go worker.run()
+	return worker, nil
+}
+
+// stopWriteBehind stops the write-behind workers of the connection and waits for the records being written.
+// Records still queued stay in the cache and are written once a worker is started again.
+func (dbc *DBCommon) stopWriteBehind() {
+	dbc.writeBehindMutex.Lock()
+	defer dbc.writeBehindMutex.Unlock()
+
+	for dbName, worker := range dbc.writeBehind {
+		close(worker.stop)
+		<-worker.done
+		delete(dbc.writeBehind, dbName)
+	}
+}
+
+// getQueue returns the cache of the connection as a Queue.
+//
+// Returns:
+// - caches.Queue: The queue provided by the cache.
+// - error: ErrNoValidCacheFound if no cache is set or ErrCacheQueueNotSupported if it doesn't provide queues.
+func (dbc *DBCommon) getQueue() (caches.Queue, error) {
+	if dbc.cache == nil {
+		return nil, dbfusionErrors.ErrNoValidCacheFound
+	}
+	queue, ok := (*dbc.cache).(caches.Queue)
+	if !ok {
+		return nil, dbfusionErrors.ErrCacheQueueNotSupported
+	}
+	return queue, nil
+}
+
+// insertWriteBehind caches a record of a write-behind entity, runs its post-insert hooks and queues it to be
+// written to the database by the worker of the current database, which is started if it isn't running yet.
+//
+// Parameters:
+// - driver: The name of the database driver.
+// - write: The function writing a queued record to the database.
+// - preCreateData: The prepared insertion.
+//
+// Returns:
+// - error: An error if the record cannot be cached or queued.
+func (dbc *DBCommon) insertWriteBehind(driver string, write writeBehindWriter, preCreateData preCreateReturn) error {
+	// Make sure the worker runs, which also validates that the cache provides queues.
+	worker, err := dbc.startWriteBehind(driver, write)
+	if err != nil {
+		return err
+	}
+
+	// The record is cached before it is queued so that it can be found as soon as the insertion is acknowledged.
+	err = dbc.postInsert(dbc.cache, preCreateData.Data, preCreateData.mData, dbc.currentDB, preCreateData.entityName)
+	if err != nil {
+		return err
+	}
+
+	item, err := codec.GetBSONInstance().Encode(writeBehindItem{DBName: dbc.currentDB, EntityName: preCreateData.entityName, Data: preCreateData.mData})
+	if err != nil {
+		return err
+	}
+	return worker.queue.Enqueue(worker.queueKey, item)
+}
+
+// rejectWriteBehind rejects the updates and deletions of write-behind entities, which would reach the database
+// before the insertions of the same records still waiting in the queue.
+//
+// Parameters:
+// - models: The data and models of the operation, nil ones are skipped.
+//
+// Returns:
+// - error: ErrWriteBehindNotSupported if any of them uses the WriteBehind policy.
+func (dbc *DBCommon) rejectWriteBehind(models ...interface{}) error {
+	for _, model := range models {
+		if model != nil && dbc.getCachePolicy(model).Has(hooks.WriteBehind) {
+			return dbfusionErrors.ErrWriteBehindNotSupported
+		}
+	}
+	return nil
+}
+
+// run takes the queued records one by one and writes them until the worker is stopped.
+func (wb *writeBehindWorker) run() {
+	defer close(wb.done)
+
+	for {
+		select {
+		case <-wb.stop:
+			return
+		default:
+		}
+
+		value, err := wb.queue.Dequeue(wb.queueKey, wb.processingKey, writeBehindPoll)
+		if err != nil {
+			wb.report(&connections.WriteBehindError{DBName: wb.dbName, Err: err})
+			wb.wait(writeBehindPoll)
+			continue
+		}
+		if value == nil {
+			continue
+		}
+
+		wb.process(value)
+	}
+}
+
+// process writes a single record. A failed record is queued again with an increased attempt count and the time its
+// backoff delay ends, or moved to the dead letter queue once CACHE_WRITE_BEHIND_RETRIES attempts failed.
+//
+// Parameters:
+// - value: The encoded record as returned by Dequeue.
+func (wb *writeBehindWorker) process(value []byte) {
+	item := writeBehindItem{}
+	if err := codec.GetBSONInstance().Decode(value, &item); err != nil {
+		// A record that can't be decoded is moved as it is to the dead letter queue for inspection.
+		wb.requeue(value, wb.deadKey, &connections.WriteBehindError{DBName: wb.dbName, Dead: true, Err: err})
+		return
+	}
+
+	if time.Now().Before(item.NotBefore) {
+		wb.postpone(value, item.NotBefore)
+		return
+	}
+
+	wb.postponed = nil
+	err := wb.write(item.DBName, item.EntityName, item.Data)
+	if err == nil {
+		wb.ack(value)
+		return
+	}
+
+	item.Attempts++
+	item.Error = err.Error()
+	item.NotBefore = time.Now().Add(wb.backoff(item.Attempts))
+	writeBehindError := &connections.WriteBehindError{DBName: wb.dbName, EntityName: item.EntityName, Attempts: item.Attempts, Err: err}
+
+	retry, encodeErr := codec.GetBSONInstance().Encode(item)
+	if encodeErr != nil {
+		// A record that can't be encoded again is given up, keeping the original for inspection.
+		retry = value
+		writeBehindError.Dead = true
+	}
+
+	target := wb.queueKey
+	if item.Attempts >= caches.CACHE_WRITE_BEHIND_RETRIES {
+		writeBehindError.Dead = true
+	}
+	if writeBehindError.Dead {
+		target = wb.deadKey
+	}
+	wb.requeue(retry, target, writeBehindError)
+}
+
+// requeue reports a failed record and moves it to the queue or the dead letter queue, acknowledging the original.
+//
+// Parameters:
+// - value: The encoded record to be queued.
+// - target: The key of the queue or the dead letter queue.
+// - writeBehindError: The failure of the record.
+func (wb *writeBehindWorker) requeue(value []byte, target string, writeBehindError *connections.WriteBehindError) {
+	wb.report(writeBehindError)
+	if err := wb.queue.Enqueue(target, value); err != nil {
+		// The record stays in the processing list and is queued again by the next worker.
+		wb.report(&connections.WriteBehindError{DBName: wb.dbName, EntityName: writeBehindError.EntityName, Err: err})
+		return
+	}
+	wb.ack(value)
+}
+
+// postpone moves a record that isn't due yet back to the tail of the queue, so that the records queued behind it are
+// written in the meantime. Once the first postponed record comes around again without any record written since,
+// every queued record is waiting for its backoff and the worker sleeps until the earliest one is due.
+//
+// Parameters:
+// - value: The encoded record as returned by Dequeue.
+// - notBefore: The time the record is due at.
+func (wb *writeBehindWorker) postpone(value []byte, notBefore time.Time) {
+	if wb.postponed != nil && bytes.Equal(wb.postponed, value) {
+		delay := time.Until(wb.nextDue)
+		if delay > writeBehindPoll {
+			delay = writeBehindPoll
+		}
+		wb.postponed = nil
+		wb.wait(delay)
+		wb.process(value)
+		return
+	}
+
+	if wb.postponed == nil {
+		wb.postponed = value
+		wb.nextDue = notBefore
+	} else if notBefore.Before(wb.nextDue) {
+		wb.nextDue = notBefore
+	}
+
+	if err := wb.queue.Enqueue(wb.queueKey, value); err != nil {
+		// The record stays in the processing list and is queued again by the next worker.
+		wb.report(&connections.WriteBehindError{DBName: wb.dbName, Err: err})
+		return
+	}
+	wb.ack(value)
+}
+
+// ack acknowledges a processed record, reporting failures as the record would only be written once more.
+func (wb *writeBehindWorker) ack(value []byte) {
+	if err := wb.queue.Ack(wb.processingKey, value); err != nil {
+		wb.report(&connections.WriteBehindError{DBName: wb.dbName, Err: err})
+	}
+}
+
+// backoff returns the delay before the given attempt, doubling CACHE_WRITE_BEHIND_BACKOFF with every attempt.
+func (wb *writeBehindWorker) backoff(attempts int) time.Duration {
+	delay := caches.CACHE_WRITE_BEHIND_BACKOFF
+	for i := 1; i < attempts && delay < maxWriteBehindBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxWriteBehindBackoff {
+		delay = maxWriteBehindBackoff
+	}
+	return delay
+}
+
+// wait sleeps for the delay unless the worker is stopped in the meantime.
+func (wb *writeBehindWorker) wait(delay time.Duration) {
+	select {
+	case <-wb.stop:
+	case <-time.After(delay):
+	}
+}
@@ -0,0 +1,309 @@
+package mapper
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// utilsPath is the package of the helper checking the fields whose zero value can't be compared.
+const utilsPath = "github.com/glodb/dbfusion/utils"
+
+// numericTypes are the predeclared types compared with 0.
+var numericTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true, "float32": true, "float64": true,
+	"complex64": true, "complex128": true, "byte": true, "rune": true,
+}
+
+// comparableTypes are the types of other packages compared with a zero value, keyed by package and type.
+var comparableTypes = map[string]string{
+	"time.Time": "(%s.Time{})",
+	"go.mongodb.org/mongo-driver/bson/primitive.ObjectID": "%s.NilObjectID",
+}
+
+// Model is a struct whose mapper is generated.
+type Model struct {
+	Name   string  // The name of the struct.
+	Fields []Field // The tagged fields of the struct, in the order they are declared.
+}
+
+// Field is a tagged field of a model.
+type Field struct {
+	Name   string // The name of the field.
+	Column string // The name in the dbfusion tag of the field.
+	IsSet  string // The expression reporting whether the field holds a value other than its zero value, "%s" standing for the field.
+}
+
+// Parse reads the models of the package in a directory.
+//
+// Parameters:
+// - dir: The directory of the package, the test files being left out.
+// - typeNames: The structs to read, every struct with a tagged field when empty.
+//
+// Returns:
+// - string: The name of the package.
+// - []Model: The models, in the order of typeNames or sorted by name when typeNames is empty.
+// - map[string]string: The imports the set checks of the fields need, keyed by path with their name.
+// - error: An error if the package can't be parsed or a struct of typeNames doesn't exist.
+//
+// Example:
+//   packageName, models, imports, err := mapper.Parse(".", "User", "Order")
+func Parse(dir string, typeNames ...string) (string, []Model, map[string]string, error) {
+	packages, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if len(packages) != 1 {
+		return "", nil, nil, fmt.Errorf("expected one package in %s, found %d", dir, len(packages))
+	}
+
+	// Read the structs of every file, with the imports of their file.
+	packageName := ""
+	found := make(map[string]Model)
+	imports := make(map[string]string)
+	for name, pkg := range packages {
+		packageName = name
+		for _, file := range pkg.Files {
+			fileImports := importNames(file)
+			for _, declaration := range file.Decls {
+				general, ok := declaration.(*ast.GenDecl)
+				if !ok || general.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range general.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					structType, ok := typeSpec.Type.(*ast.StructType)
+					if !ok || typeSpec.TypeParams != nil {
+						continue
+					}
+					found[typeSpec.Name.Name] = parseModel(typeSpec.Name.Name, structType, fileImports, imports)
+				}
+			}
+		}
+	}
+
+	// Keep the requested structs, or every struct with a tagged field.
+	models := make([]Model, 0)
+	if len(typeNames) == 0 {
+		for _, model := range found {
+			if len(model.Fields) > 0 {
+				models = append(models, model)
+			}
+		}
+		sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	}
+	for _, typeName := range typeNames {
+		model, ok := found[typeName]
+		if !ok {
+			return "", nil, nil, fmt.Errorf("struct %s not found in %s", typeName, dir)
+		}
+		models = append(models, model)
+	}
+	return packageName, models, usedImports(models, imports), nil
+}
+
+// parseModel reads the tagged fields of a struct.
+//
+// Parameters:
+// - name: The name of the struct.
+// - structType: The struct.
+// - fileImports: The paths of the packages imported by the file of the struct, keyed by name.
+// - imports: The imports of the set checks, the ones the fields need are added to it.
+//
+// Returns:
+// - Model: The model.
+func parseModel(name string, structType *ast.StructType, fileImports map[string]string, imports map[string]string) Model {
+	model := Model{Name: name, Fields: make([]Field, 0)}
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		column := strings.Split(reflect.StructTag(tag).Get("dbfusion"), ",")[0]
+		if column == "" {
+			continue
+		}
+
+		// Embedded fields are named after their type.
+		names := make([]string, 0, len(field.Names))
+		for _, fieldName := range field.Names {
+			names = append(names, fieldName.Name)
+		}
+		if len(names) == 0 {
+			names = append(names, embeddedName(field.Type))
+		}
+		isSet := isSetExpression(field.Type, fileImports, imports)
+		for _, fieldName := range names {
+			model.Fields = append(model.Fields, Field{Name: fieldName, Column: column, IsSet: isSet})
+		}
+	}
+	return model
+}
+
+// isSetExpression returns the expression reporting whether a field of a type holds a value other than its zero
+// value, like the reflection comparing it with its zero value does. The types which may not be comparable are checked
+// with the helper of the utils package.
+func isSetExpression(fieldType ast.Expr, fileImports map[string]string, imports map[string]string) string {
+	switch typed := fieldType.(type) {
+	case *ast.Ident:
+		switch {
+		case typed.Name == "string":
+			return `%s != ""`
+		case typed.Name == "bool":
+			return "%s"
+		case numericTypes[typed.Name]:
+			return "%s != 0"
+		case typed.Name == "any" || typed.Name == "error":
+			return "%s != nil"
+		}
+	case *ast.StarExpr, *ast.MapType, *ast.FuncType, *ast.ChanType, *ast.InterfaceType:
+		return "%s != nil"
+	case *ast.ArrayType:
+		if typed.Len == nil {
+			return "%s != nil"
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := typed.X.(*ast.Ident); ok {
+			path := fileImports[pkg.Name]
+			if zero, ok := comparableTypes[path+"."+typed.Sel.Name]; ok {
+				imports[path] = pkg.Name
+				return "%s != " + fmt.Sprintf(zero, pkg.Name)
+			}
+		}
+	}
+	imports[utilsPath] = "utils"
+	return "utils.GetInstance().IsSet(%s)"
+}
+
+// importNames returns the paths of the packages a file imports, keyed by the name they are used with.
+func importNames(file *ast.File) map[string]string {
+	names := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[name] = path
+	}
+	return names
+}
+
+// usedImports returns the imports the set checks of the fields of the models use.
+func usedImports(models []Model, imports map[string]string) map[string]string {
+	used := make(map[string]string)
+	for path, name := range imports {
+		for _, model := range models {
+			for _, field := range model.Fields {
+				if strings.Contains(field.IsSet, name+".") {
+					used[path] = name
+				}
+			}
+		}
+	}
+	return used
+}
+
+// embeddedName returns the name of an embedded field, the name of its type.
+func embeddedName(fieldType ast.Expr) string {
+	switch typed := fieldType.(type) {
+	case *ast.StarExpr:
+		return embeddedName(typed.X)
+	case *ast.SelectorExpr:
+		return typed.Sel.Name
+	case *ast.Ident:
+		return typed.Name
+	}
+	return ""
+}
+
+// Generate writes the source of the mappers of models.
+//
+// Parameters:
+// - packageName: The package of the models.
+// - models: The models, read by Parse.
+// - imports: The imports of the set checks, keyed by path with their name.
+//
+// Returns:
+// - []byte: The formatted source.
+// - error: An error if the source can't be formatted.
+//
+// Example:
+//   packageName, models, imports, err := mapper.Parse(".", "User")
+//   source, err := mapper.Generate(packageName, models, imports)
+func Generate(packageName string, models []Model, imports map[string]string) ([]byte, error) {
+	source := strings.Builder{}
+	source.WriteString("// Code generated by dbfusion mapper. DO NOT EDIT.\n\n")
+	source.WriteString("package " + packageName + "\n\n")
+
+	// Import the packages of the set checks under the name the models use.
+	if len(imports) > 0 {
+		standard, others := make([]string, 0), make([]string, 0)
+		for path := range imports {
+			if strings.Contains(strings.Split(path, "/")[0], ".") {
+				others = append(others, path)
+			} else {
+				standard = append(standard, path)
+			}
+		}
+		sort.Strings(standard)
+		sort.Strings(others)
+		source.WriteString("import (\n")
+		for i, paths := range [][]string{standard, others} {
+			if i > 0 && len(standard) > 0 && len(others) > 0 {
+				source.WriteString("\n")
+			}
+			for _, path := range paths {
+				if name := imports[path]; name != path[strings.LastIndex(path, "/")+1:] {
+					source.WriteString(name + " ")
+				}
+				source.WriteString(strconv.Quote(path) + "\n")
+			}
+		}
+		source.WriteString(")\n\n")
+	}
+
+	for _, model := range models {
+		writeMapper(&source, model)
+	}
+	return format.Source([]byte(source.String()))
+}
+
+// writeMapper writes the methods of the mapper of a model.
+func writeMapper(source *strings.Builder, model Model) {
+	receiver := strings.ToLower(model.Name[:1])
+	columnsVar := "dbfusion" + strings.ToUpper(model.Name[:1]) + model.Name[1:] + "Columns"
+
+	columns, values, set, pointers := make([]string, 0), make([]string, 0), make([]string, 0), make([]string, 0)
+	for _, field := range model.Fields {
+		selector := receiver + "." + field.Name
+		columns = append(columns, strconv.Quote(field.Column))
+		values = append(values, selector)
+		set = append(set, fmt.Sprintf(field.IsSet, selector))
+		pointers = append(pointers, "&"+selector)
+	}
+
+	fmt.Fprintf(source, "// %s are the columns of the tagged fields of %s.\nvar %s = []string{%s}\n\n",
+		columnsVar, model.Name, columnsVar, strings.Join(columns, ", "))
+	fmt.Fprintf(source, "// DBFusionColumns returns the columns of the tagged fields of %s.\nfunc (%s %s) DBFusionColumns() []string {\nreturn %s\n}\n\n",
+		model.Name, receiver, model.Name, columnsVar)
+	fmt.Fprintf(source, "// DBFusionValues returns the values of the tagged fields of %s.\nfunc (%s %s) DBFusionValues() []interface{} {\nreturn []interface{}{%s}\n}\n\n",
+		model.Name, receiver, model.Name, strings.Join(values, ", "))
+	fmt.Fprintf(source, "// DBFusionSet reports whether the tagged fields of %s hold a value other than their zero value.\nfunc (%s %s) DBFusionSet() []bool {\nreturn []bool{%s}\n}\n\n",
+		model.Name, receiver, model.Name, strings.Join(set, ", "))
+	fmt.Fprintf(source, "// DBFusionPointers returns pointers to the tagged fields of %s.\nfunc (%s *%s) DBFusionPointers() []interface{} {\nreturn []interface{}{%s}\n}\n\n",
+		model.Name, receiver, model.Name, strings.Join(pointers, ", "))
+}
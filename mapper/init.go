@@ -0,0 +1,11 @@
+// Package mapper generates the mappers of the models, the methods of hooks.FieldMapper and hooks.FieldScanner which
+// read and write the tagged fields of a model without reflection. The mappers are generated from the source of the
+// package declaring the models, usually with go generate and the mapper command of cmd/dbfusion:
+//
+// Example:
+//   //go:generate go run github.com/glodb/dbfusion/cmd/dbfusion mapper -type User,Order
+//
+// The tagged fields are the fields whose dbfusion tag has a name, like the ones dbFusion reads with reflection, so a
+// model behaves the same with and without its mapper. Mappers are generated for structs only, generic types being
+// left out.
+package mapper
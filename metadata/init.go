@@ -0,0 +1,10 @@
+// Package metadata holds the metadata of the models, read once per struct type from the dbfusion tags of their
+// fields and shared by every connection. dbFusion reads the fields of the models on every insertion, update, query
+// and row scanned, and the registry spares it from parsing their tags each time.
+//
+// Example:
+//   model, ok := metadata.GetInstance().Model(reflect.TypeOf(User{}))
+//   for _, field := range model.Fields {
+//       value := reflect.ValueOf(user).Field(field.Index).Interface()
+//   }
+package metadata
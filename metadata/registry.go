@@ -0,0 +1,268 @@
+package metadata
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/glodb/dbfusion/schema"
+)
+
+// scannerType is the type of the interface of the fields reading SQL values themselves.
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// Tag is a parsed dbfusion tag.
+type Tag struct {
+	Name    string            // The name of the field in the database, the first part of the tag.
+	Parts   []string          // The parts of the tag separated by commas, the name first.
+	Options map[string]string // The parameter of each part after the name keyed by the part before its colon, e.g. "pk" for "pk:ulid".
+}
+
+// Option looks for an option in the tag.
+//
+// Parameters:
+// - option: The name of the option.
+//
+// Returns:
+// - string: The parameter of the option, empty if it has none.
+// - bool: true if the tag carries the option.
+func (t *Tag) Option(option string) (string, bool) {
+	param, ok := t.Options[option]
+	return param, ok
+}
+
+// Field is a field of a model with a dbfusion tag naming it.
+type Field struct {
+	*Tag
+
+	Index       int                 // The index of the field in the struct, for reflect.Value.Field.
+	StructField reflect.StructField // The struct field.
+	TypeName    string              // The name of the type of the field, e.g. "int64" or "time.Time".
+	Scanner     bool                // true if a pointer to the field implements sql.Scanner.
+	Nested      *Model              // The metadata of a struct field, embedded or not, nil for the other types.
+}
+
+// Model is the metadata of a struct type.
+type Model struct {
+	Type        reflect.Type // The struct type.
+	Fields      []*Field     // The tagged fields, in the order they are declared.
+	ColumnNames []string     // The names of the tagged fields in the database, in the order of Fields.
+	PrimaryKey  *Field       // The field holding the primary key, nil if the model has none.
+
+	byName  map[string]*Field
+	columns sync.Map // The SQL columns of the model keyed by the name of their dialect.
+}
+
+// Field looks for a tagged field by its name in the database, the last field declared with the name if many are.
+//
+// Parameters:
+// - name: The name of the field in the database.
+//
+// Returns:
+// - *Field: The field.
+// - bool: true if the model has a field with the name.
+func (m *Model) Field(name string) (*Field, bool) {
+	field, ok := m.byName[name]
+	return field, ok
+}
+
+// FieldWithOption looks for the first field carrying an option in its tag.
+//
+// Parameters:
+// - option: The name of the option.
+//
+// Returns:
+// - *Field: The field.
+// - bool: true if a field carries the option.
+func (m *Model) FieldWithOption(option string) (*Field, bool) {
+	for _, field := range m.Fields {
+		if _, ok := field.Option(option); ok {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// Columns returns the SQL columns of the model, read with schema.Columns the first time a dialect asks for them. The
+// columns are shared and must not be modified.
+//
+// Parameters:
+// - dialect: The dialect inferring the column types.
+//
+// Returns:
+// - []schema.Column: The columns.
+func (m *Model) Columns(dialect schema.Dialect) []schema.Column {
+	if columns, ok := m.columns.Load(dialect.Name()); ok {
+		return columns.([]schema.Column)
+	}
+	columns, _ := m.columns.LoadOrStore(dialect.Name(), schema.Columns(m.Type, dialect))
+	return columns.([]schema.Column)
+}
+
+// registry caches the metadata of the models and the parsed tags, safe for concurrent use.
+type registry struct {
+	models   sync.Map // The *Model of each struct type.
+	tags     sync.Map // The *Tag of each struct tag.
+	entities sync.Map // The *Model last used with each entity, keyed by the database and the entity name.
+}
+
+var (
+	instance *registry
+	once     sync.Once
+)
+
+// GetInstance returns a single instance of the singleton.
+func GetInstance() *registry {
+	once.Do(func() {
+		instance = &registry{}
+	})
+	return instance
+}
+
+// Model returns the metadata of a model, read the first time the type is asked for.
+//
+// Parameters:
+// - modelType: The type of the model, pointers and slices are dereferenced.
+//
+// Returns:
+// - *Model: The metadata, shared by every caller and never modified.
+// - bool: false if the model is not a struct.
+//
+// Example:
+//   model, ok := metadata.GetInstance().Model(reflect.TypeOf(&users))
+func (r *registry) Model(modelType reflect.Type) (*Model, bool) {
+	if modelType == nil {
+		return nil, false
+	}
+	for modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	if model, ok := r.models.Load(modelType); ok {
+		return model.(*Model), true
+	}
+	model, _ := r.models.LoadOrStore(modelType, r.parseModel(modelType))
+	return model.(*Model), true
+}
+
+// RegisterEntity records the model an entity of a database is used with, so that operations given conditions only can
+// find the model of their entity with Entity.
+//
+// Parameters:
+// - dbName: The name of the database.
+// - entityName: The name of the entity.
+// - modelType: The type of the model, pointers and slices are dereferenced. Types that are not structs are ignored.
+func (r *registry) RegisterEntity(dbName string, entityName string, modelType reflect.Type) {
+	model, ok := r.Model(modelType)
+	if !ok {
+		return
+	}
+	key := dbName + "." + entityName
+	if registered, ok := r.entities.Load(key); ok && registered.(*Model) == model {
+		return
+	}
+	r.entities.Store(key, model)
+}
+
+// Entity returns the model last registered with RegisterEntity for an entity of a database.
+//
+// Parameters:
+// - dbName: The name of the database.
+// - entityName: The name of the entity.
+//
+// Returns:
+// - *Model: The metadata of the model.
+// - bool: false if no model was registered for the entity.
+func (r *registry) Entity(dbName string, entityName string) (*Model, bool) {
+	model, ok := r.entities.Load(dbName + "." + entityName)
+	if !ok {
+		return nil, false
+	}
+	return model.(*Model), true
+}
+
+// Tag returns the parsed dbfusion tag of a struct tag, parsed the first time the struct tag is asked for.
+//
+// Parameters:
+// - structTag: The struct tag of a field.
+//
+// Returns:
+// - *Tag: The dbfusion tag, shared by every caller and never modified. Its name is empty if the field has none.
+//
+// Example:
+//   if _, ok := metadata.GetInstance().Tag(field.Tag).Option("encrypt"); ok {
+//       // The field is encrypted
+//   }
+func (r *registry) Tag(structTag reflect.StructTag) *Tag {
+	if tag, ok := r.tags.Load(structTag); ok {
+		return tag.(*Tag)
+	}
+
+	// Keep the first parameter of options given twice.
+	parts := strings.Split(structTag.Get("dbfusion"), ",")
+	tag := &Tag{Name: parts[0], Parts: parts, Options: make(map[string]string)}
+	for _, part := range parts[1:] {
+		name, param, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if _, exists := tag.Options[name]; !exists {
+			tag.Options[name] = param
+		}
+	}
+	stored, _ := r.tags.LoadOrStore(structTag, tag)
+	return stored.(*Tag)
+}
+
+// parseModel reads the metadata of a struct type.
+func (r *registry) parseModel(modelType reflect.Type) *Model {
+	model := &Model{Type: modelType, Fields: make([]*Field, 0), ColumnNames: make([]string, 0), byName: make(map[string]*Field)}
+
+	// Read the tagged fields, leaving out the ones tagged "-" like the schema package, ranking the candidates of the
+	// primary key: the pk option first, else the field whose SQL definition holds PRIMARY KEY, else the field named
+	// _id or id.
+	var candidates [4]*Field
+	for i := 0; i < modelType.NumField(); i++ {
+		structField := modelType.Field(i)
+		tag := r.Tag(structField.Tag)
+		if tag.Name == "" || tag.Name == "-" {
+			continue
+		}
+
+		field := &Field{
+			Tag:         tag,
+			Index:       i,
+			StructField: structField,
+			TypeName:    structField.Type.String(),
+			Scanner:     reflect.PtrTo(structField.Type).Implements(scannerType),
+		}
+		if structField.Type.Kind() == reflect.Struct {
+			field.Nested, _ = r.Model(structField.Type)
+		}
+		model.Fields = append(model.Fields, field)
+		model.ColumnNames = append(model.ColumnNames, tag.Name)
+		model.byName[tag.Name] = field
+
+		rank := -1
+		if _, ok := tag.Option(schema.PrimaryKeyOption); ok {
+			rank = 0
+		} else if strings.Contains(strings.ToUpper(strings.Join(tag.Parts[1:], " ")), "PRIMARY KEY") {
+			rank = 1
+		} else if tag.Name == "_id" {
+			rank = 2
+		} else if tag.Name == "id" {
+			rank = 3
+		}
+		if rank >= 0 && candidates[rank] == nil {
+			candidates[rank] = field
+		}
+	}
+	for _, candidate := range candidates {
+		if candidate != nil {
+			model.PrimaryKey = candidate
+			break
+		}
+	}
+	return model
+}
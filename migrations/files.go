@@ -0,0 +1,160 @@
+package migrations
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Directions of the steps of a migration, the second to last part of the name of a migration file.
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+// Extensions of the migration files.
+const (
+	ExtensionSQL        = "sql"  // SQL statements run on MySQL.
+	ExtensionJSON       = "json" // MongoDB command documents run with RunCommand.
+	ExtensionJavaScript = "js"   // MongoDB shell scripts run with the mongo shell.
+)
+
+// File is a step of a migration read from a file named <version>_<name>.<direction>.<extension>, e.g.
+// 20240101120000_add_users_nickname.up.sql.
+type File struct {
+	Version   int64  // The version of the migration.
+	Name      string // The name of the migration.
+	Direction string // DirectionUp or DirectionDown.
+	Extension string // ExtensionSQL, ExtensionJSON or ExtensionJavaScript.
+	Path      string // The path of the file.
+}
+
+// ReadDir lists the migration files of a directory, ordered by version. Files which are not named like migration
+// files, such as a README, are ignored.
+//
+// Parameters:
+// - dir: The directory.
+//
+// Returns:
+// - []File: The migration files.
+// - error: ErrMigrationFileNotSupported if a migration file has an invalid version or an unknown extension, or an
+//   error if the directory can't be read.
+//
+// Example:
+//   files, err := migrations.ReadDir("migrations")
+func ReadDir(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]File, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		// Split <version>_<name>.<direction>.<extension>, skipping the files without a direction.
+		parts := strings.Split(entry.Name(), ".")
+		if len(parts) != 3 || (parts[1] != DirectionUp && parts[1] != DirectionDown) {
+			continue
+		}
+		version, name, _ := strings.Cut(parts[0], "_")
+		file := File{Name: name, Direction: parts[1], Extension: parts[2], Path: filepath.Join(dir, entry.Name())}
+		if file.Version, err = strconv.ParseInt(version, 10, 64); err != nil || file.Version <= 0 {
+			return nil, fmt.Errorf("%w: %s has no valid version", dbfusionErrors.ErrMigrationFileNotSupported, entry.Name())
+		}
+		switch file.Extension {
+		case ExtensionSQL, ExtensionJSON, ExtensionJavaScript:
+		default:
+			return nil, fmt.Errorf("%w: %s", dbfusionErrors.ErrMigrationFileNotSupported, entry.Name())
+		}
+		files = append(files, file)
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Version < files[j].Version })
+	return files, nil
+}
+
+// SplitSQL splits a SQL script into its statements. A statement ends with a semicolon at the end of a line, lines
+// starting with "--" are comments and are left out.
+//
+// Parameters:
+// - script: The script.
+//
+// Returns:
+// - []string: The statements without their semicolon.
+//
+// Example:
+//   statements := migrations.SplitSQL("ALTER TABLE users ADD COLUMN nickname VARCHAR(255);\nCREATE INDEX nickname ON users (nickname);")
+func SplitSQL(script string) []string {
+	statements := make([]string, 0)
+	statement := ""
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		// Add the line to the statement and close it on a trailing semicolon.
+		statement += line + "\n"
+		if strings.HasSuffix(trimmed, ";") {
+			statement = strings.TrimSuffix(strings.TrimSpace(statement), ";")
+			if strings.TrimSpace(statement) != "" {
+				statements = append(statements, statement)
+			}
+			statement = ""
+		}
+	}
+
+	// Keep the last statement when its semicolon is missing.
+	if statement = strings.TrimSpace(statement); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// ParseCommands parses the MongoDB commands of a JSON file, a command document or an array of them in extended
+// JSON. The order of the keys is kept, as the name of a command has to come first.
+//
+// Parameters:
+// - script: The content of the file.
+//
+// Returns:
+// - []bson.D: The command documents.
+// - error: An error if the content is not a document or an array of documents.
+//
+// Example:
+//   commands, err := migrations.ParseCommands([]byte(`[{"collMod": "users", "validationLevel": "moderate"}]`))
+func ParseCommands(script []byte) ([]bson.D, error) {
+	script = bytes.TrimSpace(script)
+	if len(script) == 0 {
+		return nil, nil
+	}
+
+	// A single document is a list of one command.
+	if script[0] != '[' {
+		command := bson.D{}
+		if err := bson.UnmarshalExtJSON(script, false, &command); err != nil {
+			return nil, err
+		}
+		return []bson.D{command}, nil
+	}
+
+	// Arrays can't be unmarshalled on their own, they are wrapped in a document.
+	wrapped := struct {
+		Commands []bson.D `bson:"commands"`
+	}{}
+	document := append(append([]byte(`{"commands":`), script...), '}')
+	if err := bson.UnmarshalExtJSON(document, false, &wrapped); err != nil {
+		return nil, err
+	}
+	return wrapped.Commands, nil
+}
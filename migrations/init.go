@@ -0,0 +1,27 @@
+// Package migrations applies versioned changes to the schema of a database. Each migration has a version, a name,
+// an up step applying it and an optional down step reverting it. The versions applied are recorded in the
+// schema_migrations table or collection, and a lock keeps two instances from migrating at the same time.
+//
+// Migrations are written as Go functions or as files. Go migrations are registered from an init function:
+//
+//   func init() {
+//       migrations.Register(migrations.Migration{
+//           Version: 20240101120000,
+//           Name:    "add_users_nickname",
+//           Up: func(ctx context.Context, con connections.Connection) error {
+//               return con.(connections.SQLConnection).ExecuteSQL("ALTER TABLE users ADD COLUMN nickname VARCHAR(255) NULL")
+//           },
+//       })
+//   }
+//
+// Files are named <version>_<name>.up.<ext> and <version>_<name>.down.<ext> and loaded with LoadDir. MySQL runs
+// .sql files, MongoDB runs .json files holding a command document or an array of them, and .js files through the
+// mongo shell.
+//
+// Example:
+//   migrator := migrations.NewMigrator(con, migrations.Options{})
+//   if err := migrator.LoadDir("migrations"); err != nil {
+//       return err
+//   }
+//   applied, err := migrator.Migrate(ctx)
+package migrations
@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+)
+
+// Func runs a step of a migration on the connection of the migrator, a connections.SQLConnection or a
+// connections.MongoConnection.
+type Func func(ctx context.Context, con connections.Connection) error
+
+// Migration is a versioned change to the schema of a database.
+type Migration struct {
+	Version int64  // The version ordering the migrations, e.g. a timestamp like 20240101120000.
+	Name    string // A short description of the change.
+	Up      Func   // Applies the change.
+	Down    Func   // Reverts the change, nil if it can't be reverted.
+}
+
+// Status describes a migration known to the migrator or recorded as applied in the database.
+type Status struct {
+	Version   int64     // The version of the migration.
+	Name      string    // The name of the migration.
+	Applied   bool      // true if the migration is recorded as applied.
+	AppliedAt time.Time // The time the migration was applied, zero if it is pending.
+	Missing   bool      // true if the migration is applied but not known to the migrator.
+}
+
+var (
+	registry      []Migration // The migrations registered with Register.
+	registryMutex sync.Mutex  // Guards the registry.
+)
+
+// Register adds a Go migration to the migrations of every migrator created afterwards. It is meant to be called
+// from init functions and panics, like sql.Register, if the migration is invalid or its version is registered twice.
+//
+// Parameters:
+// - migration: The migration.
+//
+// Example:
+//   func init() {
+//       migrations.Register(migrations.Migration{Version: 20240101120000, Name: "add_users_nickname", Up: up, Down: down})
+//   }
+func Register(migration Migration) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if migration.Version <= 0 || migration.Up == nil {
+		panic(fmt.Errorf("migrations: %w: %d_%s", dbfusionErrors.ErrMigrationInvalid, migration.Version, migration.Name))
+	}
+	for _, registered := range registry {
+		if registered.Version == migration.Version {
+			panic(fmt.Errorf("migrations: %w: %d", dbfusionErrors.ErrMigrationVersionConflict, migration.Version))
+		}
+	}
+	registry = append(registry, migration)
+}
+
+// Registered returns the migrations added with Register, ordered by version.
+//
+// Returns:
+// - []Migration: A copy of the registered migrations.
+func Registered() []Migration {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	migrations := append([]Migration(nil), registry...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
@@ -0,0 +1,436 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+)
+
+// Defaults of the migrator options.
+const (
+	DefaultEntity      = "schema_migrations" // The table or collection of the applied migrations.
+	DefaultLockTimeout = 15 * time.Minute    // The age after which a lock is considered stale.
+	DefaultShell       = "mongosh"           // The mongo shell running the JavaScript migrations.
+)
+
+// Options configures a Migrator.
+type Options struct {
+	Entity        string        // The table or collection of the applied migrations, DefaultEntity if empty. The lock is kept in Entity + "_lock".
+	LockTimeout   time.Duration // The age after which a lock left by a stopped instance is taken over, DefaultLockTimeout if zero. The lock is renewed between migrations, so it must exceed the longest migration.
+	Owner         string        // The name of the instance recorded in the lock, the host name and process ID if empty.
+	Shell         string        // The mongo shell running the .js migrations, DefaultShell if empty.
+	ShellURI      string        // The URI the mongo shell connects to, required by .js migrations.
+	ShellDatabase string        // The database the .js migrations run on, the one of ShellURI if empty.
+}
+
+// Migrator applies and reverts the migrations of a connection.
+type Migrator struct {
+	con        connections.Connection
+	options    Options
+	store      *store
+	migrations map[int64]Migration
+}
+
+// NewMigrator creates a migrator for a connection, holding the migrations added with Register.
+//
+// Parameters:
+// - con: A connections.SQLConnection or a connections.MongoConnection.
+// - options: The options of the migrator.
+//
+// Returns:
+// - *Migrator: The migrator.
+//
+// Example:
+//   migrator := migrations.NewMigrator(con, migrations.Options{LockTimeout: time.Minute})
+func NewMigrator(con connections.Connection, options Options) *Migrator {
+	// Fill the options left empty with their defaults.
+	if options.Entity == "" {
+		options.Entity = DefaultEntity
+	}
+	if options.LockTimeout <= 0 {
+		options.LockTimeout = DefaultLockTimeout
+	}
+	if options.Owner == "" {
+		host, _ := os.Hostname()
+		options.Owner = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	if options.Shell == "" {
+		options.Shell = DefaultShell
+	}
+
+	migrator := &Migrator{
+		con:        con,
+		options:    options,
+		store:      &store{con: con, entity: options.Entity, lockEntity: options.Entity + "_lock"},
+		migrations: make(map[int64]Migration),
+	}
+	for _, migration := range Registered() {
+		migrator.migrations[migration.Version] = migration
+	}
+	return migrator
+}
+
+// Add adds migrations to the migrator.
+//
+// Parameters:
+// - migrations: The migrations.
+//
+// Returns:
+// - error: ErrMigrationInvalid if a migration has no positive version or no up step, or
+//   ErrMigrationVersionConflict if another migration has its version.
+//
+// Example:
+//   err := migrator.Add(migrations.Migration{Version: 20240101120000, Name: "add_users_nickname", Up: up, Down: down})
+func (m *Migrator) Add(migrations ...Migration) error {
+	for _, migration := range migrations {
+		if migration.Version <= 0 || migration.Up == nil {
+			return fmt.Errorf("%w: %d_%s", dbfusionErrors.ErrMigrationInvalid, migration.Version, migration.Name)
+		}
+		if _, ok := m.migrations[migration.Version]; ok {
+			return fmt.Errorf("%w: %d", dbfusionErrors.ErrMigrationVersionConflict, migration.Version)
+		}
+		m.migrations[migration.Version] = migration
+	}
+	return nil
+}
+
+// LoadDir adds the migrations of the files of a directory, see ReadDir. The up and down files of a version make one
+// migration, a migration without a down file can't be rolled back.
+//
+// Parameters:
+// - dir: The directory.
+//
+// Returns:
+// - error: ErrMigrationFileNotSupported if a file can't be run on the connection or a version has no up file,
+//   ErrMigrationShellRequired for .js files without Options.ShellURI, ErrMigrationVersionConflict if a version is
+//   used twice, or an error if a file can't be read or parsed.
+//
+// Example:
+//   err := migrator.LoadDir("migrations")
+func (m *Migrator) LoadDir(dir string) error {
+	files, err := ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	// Pair the up and down files of each version.
+	loaded := make(map[int64]*Migration)
+	versions := make([]int64, 0)
+	for _, file := range files {
+		step, err := m.fileStep(file)
+		if err != nil {
+			return err
+		}
+
+		migration, ok := loaded[file.Version]
+		if !ok {
+			migration = &Migration{Version: file.Version, Name: file.Name}
+			loaded[file.Version] = migration
+			versions = append(versions, file.Version)
+		}
+		if migration.Name != file.Name {
+			return fmt.Errorf("%w: %s", dbfusionErrors.ErrMigrationVersionConflict, file.Path)
+		}
+
+		target := &migration.Up
+		if file.Direction == DirectionDown {
+			target = &migration.Down
+		}
+		if *target != nil {
+			return fmt.Errorf("%w: %s", dbfusionErrors.ErrMigrationVersionConflict, file.Path)
+		}
+		*target = step
+	}
+
+	// Add the migrations once every file is read.
+	for _, version := range versions {
+		if loaded[version].Up == nil {
+			return fmt.Errorf("%w: %d_%s has no up file", dbfusionErrors.ErrMigrationFileNotSupported, version, loaded[version].Name)
+		}
+		if err := m.Add(*loaded[version]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileStep reads a migration file and returns the step running it.
+//
+// Parameters:
+// - file: The migration file.
+//
+// Returns:
+// - Func: The step.
+// - error: ErrMigrationFileNotSupported if the connection can't run the file, ErrMigrationShellRequired for .js
+//   files without Options.ShellURI, or an error if the file can't be read or parsed.
+func (m *Migrator) fileStep(file File) (Func, error) {
+	_, isSQL := m.con.(connections.SQLConnection)
+	_, isMongo := m.con.(connections.MongoConnection)
+
+	switch {
+	case file.Extension == ExtensionSQL && isSQL:
+		// Run the statements of the file one after another.
+		script, err := os.ReadFile(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		statements := SplitSQL(string(script))
+		return func(ctx context.Context, con connections.Connection) error {
+			for _, statement := range statements {
+				if err := con.(connections.SQLConnection).WithContext(ctx).ExecuteSQL(statement); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+
+	case file.Extension == ExtensionJSON && isMongo:
+		// Run the commands of the file one after another.
+		script, err := os.ReadFile(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		commands, err := ParseCommands(script)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Path, err)
+		}
+		return func(ctx context.Context, con connections.Connection) error {
+			for _, command := range commands {
+				if err := con.(connections.MongoConnection).WithContext(ctx).RunCommand(command, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+
+	case file.Extension == ExtensionJavaScript && isMongo:
+		// Run the script with the mongo shell, MongoDB no longer evaluates JavaScript on the server.
+		if m.options.ShellURI == "" {
+			return nil, fmt.Errorf("%w: %s", dbfusionErrors.ErrMigrationShellRequired, file.Path)
+		}
+		path, err := filepath.Abs(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, con connections.Connection) error {
+			return m.runShell(ctx, path)
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", dbfusionErrors.ErrMigrationFileNotSupported, file.Path)
+}
+
+// runShell runs a JavaScript file with the mongo shell.
+//
+// Parameters:
+// - ctx: The context of the migration, cancelling it stops the shell.
+// - path: The absolute path of the file.
+//
+// Returns:
+// - error: An error with the output of the shell if the script fails.
+func (m *Migrator) runShell(ctx context.Context, path string) error {
+	script := fmt.Sprintf("load(%s)", strconv.Quote(path))
+	if m.options.ShellDatabase != "" {
+		script = fmt.Sprintf("db = db.getSiblingDB(%s); %s", strconv.Quote(m.options.ShellDatabase), script)
+	}
+
+	output, err := exec.CommandContext(ctx, m.options.Shell, m.options.ShellURI, "--quiet", "--eval", script).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", path, err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// Migrations returns the migrations of the migrator, ordered by version.
+//
+// Returns:
+// - []Migration: The migrations.
+func (m *Migrator) Migrations() []Migration {
+	migrations := make([]Migration, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
+
+// Migrate applies the pending migrations ordered by version, each one being recorded once its up step succeeded.
+// Migrations older than the last one applied, e.g. merged from another branch, are applied as well.
+//
+// Parameters:
+// - ctx: The context of the migrations.
+//
+// Returns:
+// - []Migration: The migrations applied, up to the one which failed.
+// - error: ErrMigrationLocked if another instance is migrating, or the error of the migration which failed.
+//
+// Example:
+//   applied, err := migrator.Migrate(ctx)
+func (m *Migrator) Migrate(ctx context.Context) ([]Migration, error) {
+	applied := make([]Migration, 0)
+	err := m.withLock(ctx, func(refresh func() error) error {
+		records, err := m.store.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Apply the migrations which are not recorded yet.
+		for _, migration := range m.Migrations() {
+			if _, ok := records[migration.Version]; ok {
+				continue
+			}
+			if err := refresh(); err != nil {
+				return err
+			}
+			if err := migration.Up(ctx, m.con); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			if err := m.store.record(ctx, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Rollback reverts the last applied migrations, the one with the highest version first.
+//
+// Parameters:
+// - ctx: The context of the migrations.
+// - steps: The number of migrations reverted, 1 if less than 1.
+//
+// Returns:
+// - []Migration: The migrations reverted, up to the one which failed.
+// - error: ErrMigrationLocked if another instance is migrating, ErrMigrationNotFound if an applied migration is not
+//   known to the migrator, ErrMigrationIrreversible if it has no down step, or the error of the migration which failed.
+//
+// Example:
+//   reverted, err := migrator.Rollback(ctx, 1)
+func (m *Migrator) Rollback(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		steps = 1
+	}
+
+	reverted := make([]Migration, 0)
+	err := m.withLock(ctx, func(refresh func() error) error {
+		records, err := m.store.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Revert the applied migrations from the highest version.
+		versions := make([]int64, 0, len(records))
+		for version := range records {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for i := 0; i < steps && i < len(versions); i++ {
+			migration, ok := m.migrations[versions[i]]
+			if !ok {
+				return fmt.Errorf("%w: %d_%s", dbfusionErrors.ErrMigrationNotFound, versions[i], records[versions[i]].Name)
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("%w: %d_%s", dbfusionErrors.ErrMigrationIrreversible, migration.Version, migration.Name)
+			}
+			if err := refresh(); err != nil {
+				return err
+			}
+			if err := migration.Down(ctx, m.con); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			if err := m.store.remove(ctx, migration.Version); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status returns the migrations known to the migrator and the ones recorded as applied, ordered by version.
+//
+// Parameters:
+// - ctx: The context of the operation.
+//
+// Returns:
+// - []Status: The status of every migration.
+// - error: An error if the applied migrations can't be read.
+//
+// Example:
+//   statuses, err := migrator.Status(ctx)
+//   for _, status := range statuses {
+//       fmt.Println(status.Version, status.Name, status.Applied)
+//   }
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.store.create(ctx); err != nil {
+		return nil, err
+	}
+	records, err := m.store.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge the known migrations with the applied ones.
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.Migrations() {
+		record, applied := records[migration.Version]
+		statuses = append(statuses, Status{Version: migration.Version, Name: migration.Name, Applied: applied, AppliedAt: record.AppliedAt})
+	}
+	for version, record := range records {
+		if _, ok := m.migrations[version]; !ok {
+			statuses = append(statuses, Status{Version: version, Name: record.Name, Applied: true, AppliedAt: record.AppliedAt, Missing: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// withLock runs a function holding the migration lock, creating the tracking tables first.
+//
+// Parameters:
+// - ctx: The context of the operation.
+// - run: The function, calling refresh before every migration to renew the lock.
+//
+// Returns:
+// - error: The error of the function, or an error if the lock can't be taken or released.
+func (m *Migrator) withLock(ctx context.Context, run func(refresh func() error) error) (err error) {
+	if err = m.store.create(ctx); err != nil {
+		return err
+	}
+	if err = m.store.lock(ctx, m.options.Owner, m.options.LockTimeout); err != nil {
+		return err
+	}
+
+	// Release the lock even if the function failed, reporting the failure of the function first.
+	defer func() {
+		if unlockErr := m.store.unlock(ctx, m.options.Owner); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	// Renew the lock before the migrations once a third of its timeout elapsed, the connection being busy while a
+	// migration runs. A lock taken over meanwhile stops the migrations.
+	refreshed := time.Now()
+	return run(func() error {
+		if time.Since(refreshed) < m.options.LockTimeout/3 {
+			return nil
+		}
+		if err := m.store.refresh(ctx, m.options.Owner); err != nil {
+			return err
+		}
+		refreshed = time.Now()
+		return nil
+	})
+}
@@ -0,0 +1,224 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/ftypes"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lockID is the key of the single record of the lock table or collection.
+const lockID = "lock"
+
+// appliedMigration is a record of the schema_migrations table or collection.
+type appliedMigration struct {
+	Version   int64     `dbfusion:"version" bson:"version"`
+	Name      string    `dbfusion:"name" bson:"name"`
+	AppliedAt time.Time `dbfusion:"appliedAt" bson:"appliedAt"`
+}
+
+// migrationLock is the record held by the instance running the migrations.
+type migrationLock struct {
+	ID       string    `dbfusion:"id" bson:"_id"`
+	Owner    string    `dbfusion:"owner" bson:"owner"`
+	LockedAt time.Time `dbfusion:"lockedAt" bson:"lockedAt"`
+}
+
+// store reads and writes the applied migrations and the lock of a connection.
+type store struct {
+	con        connections.Connection
+	entity     string // The table or collection of the applied migrations.
+	lockEntity string // The table or collection of the lock.
+}
+
+// table returns the connection set up for an operation on a table or collection.
+func (s *store) table(ctx context.Context, name string) connections.Connection {
+	switch con := s.con.(type) {
+	case connections.SQLConnection:
+		return con.WithContext(ctx).Table(name)
+	case connections.MongoConnection:
+		return con.WithContext(ctx).Table(name)
+	}
+	return s.con
+}
+
+// where returns the connection set up for an operation on the records of a table or collection matching every
+// value of the query.
+func (s *store) where(ctx context.Context, name string, query ftypes.QMap) connections.Connection {
+	switch con := s.con.(type) {
+	case connections.SQLConnection:
+		// The keys of SQL conditions carry their operator.
+		sqlQuery := ftypes.QMap{}
+		for key, value := range query {
+			sqlQuery[key+" = "] = value
+		}
+		return con.WithContext(ctx).Table(name).Where(sqlQuery)
+	case connections.MongoConnection:
+		return con.WithContext(ctx).Table(name).Where(query)
+	}
+	return s.con
+}
+
+// lockKey returns the name of the key of the lock record, MongoDB keys its documents with _id.
+func (s *store) lockKey() string {
+	if _, ok := s.con.(connections.MongoConnection); ok {
+		return "_id"
+	}
+	return "id"
+}
+
+// create creates the tables of the applied migrations and of the lock if they don't exist. MongoDB creates its
+// collections on the first insertion.
+//
+// Parameters:
+// - ctx: The context of the operation.
+//
+// Returns:
+// - error: An error if a table can't be created.
+func (s *store) create(ctx context.Context) error {
+	con, ok := s.con.(connections.SQLConnection)
+	if !ok {
+		return nil
+	}
+
+	err := con.WithContext(ctx).ExecuteSQL(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, appliedAt DATETIME(6) NOT NULL)", s.entity))
+	if err != nil {
+		return err
+	}
+	return con.WithContext(ctx).ExecuteSQL(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id VARCHAR(64) NOT NULL PRIMARY KEY, owner VARCHAR(255) NOT NULL, lockedAt DATETIME(6) NOT NULL)", s.lockEntity))
+}
+
+// applied reads the applied migrations.
+//
+// Parameters:
+// - ctx: The context of the operation.
+//
+// Returns:
+// - map[int64]appliedMigration: The applied migrations keyed by version.
+// - error: An error if they can't be read.
+func (s *store) applied(ctx context.Context) (map[int64]appliedMigration, error) {
+	records := make([]appliedMigration, 0)
+	if err := s.table(ctx, s.entity).FindMany(&records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]appliedMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// record records a migration as applied.
+func (s *store) record(ctx context.Context, migration Migration) error {
+	return s.table(ctx, s.entity).InsertOne(map[string]interface{}{
+		"version":   migration.Version,
+		"name":      migration.Name,
+		"appliedAt": time.Now().UTC().Truncate(time.Millisecond),
+	})
+}
+
+// remove removes the record of a rolled back migration.
+func (s *store) remove(ctx context.Context, version int64) error {
+	return s.where(ctx, s.entity, ftypes.QMap{"version": version}).DeleteMany()
+}
+
+// lock takes the lock by inserting its single record. A lock older than the timeout is considered left by an
+// instance which stopped while migrating, it is removed and taken again.
+//
+// Parameters:
+// - ctx: The context of the operation.
+// - owner: The name of the instance taking the lock.
+// - timeout: The age after which a lock is stale.
+//
+// Returns:
+// - error: ErrMigrationLocked if another instance holds the lock, or an error if it can't be taken.
+func (s *store) lock(ctx context.Context, owner string, timeout time.Duration) error {
+	for attempt := 0; attempt < 2; attempt++ {
+		// Only one insertion of the lock record succeeds, its key being unique.
+		insertErr := s.table(ctx, s.lockEntity).InsertOne(map[string]interface{}{
+			s.lockKey(): lockID,
+			"owner":     owner,
+			"lockedAt":  time.Now().UTC().Truncate(time.Millisecond),
+		})
+		if insertErr == nil {
+			return nil
+		}
+
+		// Read the lock held, the insertion failed for another reason when there is none.
+		locks := make([]migrationLock, 0)
+		if err := s.where(ctx, s.lockEntity, ftypes.QMap{s.lockKey(): lockID}).FindMany(&locks); err != nil {
+			return err
+		}
+		if len(locks) == 0 {
+			return insertErr
+		}
+		if time.Since(locks[0].LockedAt) < timeout {
+			return fmt.Errorf("%w: held by %s since %s", dbfusionErrors.ErrMigrationLocked, locks[0].Owner, locks[0].LockedAt.Format(time.RFC3339))
+		}
+
+		// Remove the stale lock, unless another instance replaced it meanwhile, and try again.
+		err := s.where(ctx, s.lockEntity, ftypes.QMap{s.lockKey(): lockID, "lockedAt": locks[0].LockedAt}).DeleteMany()
+		if err != nil {
+			return err
+		}
+	}
+	return dbfusionErrors.ErrMigrationLocked
+}
+
+// unlock releases the lock taken by the owner.
+func (s *store) unlock(ctx context.Context, owner string) error {
+	err := s.where(ctx, s.lockEntity, ftypes.QMap{s.lockKey(): lockID, "owner": owner}).DeleteMany()
+	if errors.Is(err, dbfusionErrors.ErrNoRecordFound) {
+		return nil
+	}
+	return err
+}
+
+// refresh renews the lock taken by the owner so that other instances don't take it over as stale while the
+// migrations run.
+//
+// Parameters:
+// - ctx: The context of the operation.
+// - owner: The name of the instance holding the lock.
+//
+// Returns:
+// - error: ErrMigrationLocked if the lock was taken over by another instance, or an error if it can't be renewed.
+func (s *store) refresh(ctx context.Context, owner string) error {
+	lockedAt := time.Now().UTC().Truncate(time.Millisecond)
+
+	// Only the lock record of the owner is renewed.
+	switch con := s.con.(type) {
+	case connections.SQLConnection:
+		err := con.WithContext(ctx).ExecuteSQL(fmt.Sprintf("UPDATE %s SET lockedAt = ? WHERE id = ? AND owner = ?", s.lockEntity), lockedAt, lockID, owner)
+		if err != nil {
+			return err
+		}
+	case connections.MongoConnection:
+		err := con.WithContext(ctx).RunCommand(primitive.D{
+			{Key: "update", Value: s.lockEntity},
+			{Key: "updates", Value: primitive.A{primitive.D{
+				{Key: "q", Value: primitive.D{{Key: "_id", Value: lockID}, {Key: "owner", Value: owner}}},
+				{Key: "u", Value: primitive.D{{Key: "$set", Value: primitive.D{{Key: "lockedAt", Value: lockedAt}}}}},
+			}}},
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The lock is lost when the record of the owner is gone.
+	locks := make([]migrationLock, 0)
+	if err := s.where(ctx, s.lockEntity, ftypes.QMap{s.lockKey(): lockID, "owner": owner}).FindMany(&locks); err != nil {
+		return err
+	}
+	if len(locks) == 0 {
+		return fmt.Errorf("%w: the lock of %s was taken over", dbfusionErrors.ErrMigrationLocked, owner)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package dbfusion
 
 import (
 	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/encryption"
 )
 
 // Options is a structure that holds configuration options for connecting to a database.
@@ -19,4 +20,12 @@ type Options struct {
 	// Cache is an instance of a cache that can be associated with the database connection.
 	// It allows for caching data to improve query performance.
 	Cache caches.Cache
+
+	// AuditEntity is a pointer to a string representing the table or collection the audit entries of the entities
+	// implementing hooks.Auditable are written to. It can be nil to use audit.DefaultEntity.
+	AuditEntity *string
+
+	// KeyProvider supplies the keys encrypting the fields with the encrypt option in their dbfusion tag.
+	// It can be nil if no field is encrypted.
+	KeyProvider encryption.KeyProvider
 }
@@ -0,0 +1,18 @@
+package queryoptions
+
+import "io"
+
+// AutoMigrateOptions provides options for altering the tables of models to match their dbfusion tags.
+type AutoMigrateOptions struct {
+	// DryRun plans the statements without running them. The planned statements are printed to Output.
+	DryRun bool
+
+	// Output receives the planned statements of a dry run, one per line. Defaults to os.Stdout when it is not set.
+	Output io.Writer
+
+	// DropColumns drops the columns of the tables which no field of the model declares.
+	DropColumns bool
+
+	// DropIndexes drops the unique indexes on a single column which the model no longer declares UNIQUE.
+	DropIndexes bool
+}
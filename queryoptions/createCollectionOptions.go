@@ -0,0 +1,23 @@
+package queryoptions
+
+// Validation levels of a MongoDB collection, choosing the documents its validator checks.
+const (
+	ValidationLevelStrict   = "strict"   // Validates every insert and update.
+	ValidationLevelModerate = "moderate" // Skips the updates of existing documents which are already invalid.
+	ValidationLevelOff      = "off"      // Disables the validation.
+)
+
+// Validation actions of a MongoDB collection, choosing what happens to the invalid documents.
+const (
+	ValidationActionError = "error" // Rejects the invalid documents.
+	ValidationActionWarn  = "warn"  // Writes the invalid documents and logs a warning.
+)
+
+// CreateCollectionOptions provides options for creating a MongoDB collection validated by the schema of its model.
+type CreateCollectionOptions struct {
+	// ValidationLevel is one of the ValidationLevel constants. Defaults to the level of the server, strict.
+	ValidationLevel string
+
+	// ValidationAction is one of the ValidationAction constants. Defaults to the action of the server, error.
+	ValidationAction string
+}
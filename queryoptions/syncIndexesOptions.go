@@ -0,0 +1,11 @@
+package queryoptions
+
+// SyncIndexesOptions provides options for synchronising the indexes of an entity with the index hooks of its model.
+type SyncIndexesOptions struct {
+	// DryRun compares the indexes without creating or dropping any, e.g. to check for drift in CI.
+	DryRun bool
+
+	// DropUndeclared drops the indexes which the model no longer declares. The _id index of a MongoDB collection and
+	// the primary key of a SQL table are never dropped.
+	DropUndeclared bool
+}
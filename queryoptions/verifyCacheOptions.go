@@ -0,0 +1,21 @@
+package queryoptions
+
+// VerifyCacheOptions provides options for comparing the cache of an entity with the database.
+type VerifyCacheOptions struct {
+	// SampleSize is the number of randomly chosen records to verify. Every record is verified when it is not set.
+	SampleSize int
+
+	// BatchSize is the number of records verified with a single round trip to the cache.
+	// Defaults to 500 when it is not set.
+	BatchSize int
+
+	// Indexes overrides the cache indexes of the entity. It is required for models that don't implement
+	// hooks.CacheHook, e.g. a map[string]interface{} used together with Table.
+	Indexes []string
+
+	// CheckOrphans scans the cache for payloads of the entity that no index key points to.
+	CheckOrphans bool
+
+	// Repair caches the missing and stale records again and removes the orphaned payloads.
+	Repair bool
+}
@@ -0,0 +1,22 @@
+package queryoptions
+
+// WarmCacheOptions provides options for rebuilding the cache of an entity from the database.
+type WarmCacheOptions struct {
+	// BatchSize is the number of records read from the database and written to the cache at once.
+	// Defaults to 500 when it is not set.
+	BatchSize int
+
+	// RatePerSecond limits the number of records cached per second to protect the database and the cache.
+	// No limit is applied when it is not set.
+	RatePerSecond int
+
+	// Indexes overrides the cache indexes of the entity. It is required for models that don't implement
+	// hooks.CacheHook, e.g. a map[string]interface{} used together with Table.
+	Indexes []string
+
+	// Invalidate removes every cached key of the entity before warming, so that no stale entries survive.
+	Invalidate bool
+
+	// Progress is called after every batch with the number of records read and cached so far.
+	Progress func(processed int64, cached int64)
+}
@@ -0,0 +1,269 @@
+package schema
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options of the dbfusion tag describing the columns, e.g. `dbfusion:"reference,size:32,unique"`.
+const (
+	PrimaryKeyOption    = "pk"       // The column is the primary key, the parameter names the strategy generating it.
+	AutoIncrementOption = "autoincr" // The database generates the values of the column.
+	SizeOption          = "size"     // The size of a string or binary column.
+	DefaultOption       = "default"  // The default value of the column.
+	UniqueOption        = "unique"   // The column has a unique index.
+	ForeignKeyOption    = "fk"       // The column references the column of another table, e.g. fk:users.id.
+)
+
+// TypeTag is the tag setting the type of a column, e.g. `dbtype:"DECIMAL(10,2)"`.
+const TypeTag = "dbtype"
+
+// TagOptions lists the options of the dbfusion tag. The parts of a tag which are not options are the column
+// definition of the tags written before the options describing the columns.
+var TagOptions = []string{
+	"omitempty", "autoCreateTime", "autoUpdateTime", "softdelete", "version", "encrypt",
+	PrimaryKeyOption, AutoIncrementOption, SizeOption, DefaultOption, UniqueOption, ForeignKeyOption,
+}
+
+// timeType is the type of the fields stored as dates and times.
+var timeType = reflect.TypeOf(time.Time{})
+
+// nullTypes maps the sql.Null types to the type of the value they hold.
+var nullTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+	reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(uint8(0)),
+	reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+	reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+	reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+	reflect.TypeOf(sql.NullTime{}):    timeType,
+}
+
+// Reference is the column of another table referenced by a foreign key.
+type Reference struct {
+	Table  string // The referenced table.
+	Column string // The referenced column.
+}
+
+// ForeignKey writes the FOREIGN KEY constraint of a column referencing this column.
+//
+// Parameters:
+// - column: The name of the referencing column.
+//
+// Returns:
+// - string: The constraint, e.g. "FOREIGN KEY (userId) REFERENCES users(id)".
+func (r Reference) ForeignKey(column string) string {
+	return "FOREIGN KEY (" + column + ") REFERENCES " + r.Table + "(" + r.Column + ")"
+}
+
+// Column describes the column of a field.
+type Column struct {
+	Name          string              // The name of the column, the first part of the dbfusion tag.
+	Field         reflect.StructField // The field of the column.
+	Type          string              // The type of the column, from the dbtype tag, the dbfusion tag or the Go type.
+	Nullable      bool                // true if the column accepts NULL.
+	Default       *string             // The default value of the column, nil without one.
+	PrimaryKey    bool                // true if the column is the primary key.
+	AutoIncrement bool                // true if the database generates the values of the column.
+	Unique        bool                // true if the column has a unique index.
+	References    *Reference          // The column referenced by a foreign key, nil without one.
+}
+
+// IsOption reports whether a part of the dbfusion tag is an option rather than a part of a column definition.
+//
+// Parameters:
+// - tag: A part of the dbfusion tag, e.g. "size:32".
+//
+// Returns:
+// - bool: true if the part is an option.
+func IsOption(tag string) bool {
+	name, _, _ := strings.Cut(strings.TrimSpace(tag), ":")
+	for _, option := range TagOptions {
+		if name == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Columns returns the columns of the fields of a model, in the order of the fields. Fields without a name in their
+// dbfusion tag, or named "-", and fields whose type can't be stored are left out.
+//
+// Parameters:
+// - modelType: The type of the model, pointers and slices are dereferenced.
+// - dialect: The dialect inferring the column types.
+//
+// Returns:
+// - []Column: The columns, empty if the model is not a struct.
+//
+// Example:
+//   columns := schema.Columns(reflect.TypeOf(Order{}), schema.MySQL)
+func Columns(modelType reflect.Type, dialect Dialect) []Column {
+	columns := make([]Column, 0)
+	if modelType == nil {
+		return columns
+	}
+	for modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return columns
+	}
+
+	for i := 0; i < modelType.NumField(); i++ {
+		if column, ok := ParseColumn(modelType.Field(i), dialect); ok {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// ParseColumn reads the column of a field from its dbfusion and dbtype tags.
+//
+// Parameters:
+// - field: The field.
+// - dialect: The dialect inferring the type of the column when no tag sets it.
+//
+// Returns:
+// - Column: The column.
+// - bool: false if the field has no column.
+func ParseColumn(field reflect.StructField, dialect Dialect) (Column, bool) {
+	tags := strings.Split(field.Tag.Get("dbfusion"), ",")
+	if tags[0] == "" || tags[0] == "-" {
+		return Column{}, false
+	}
+
+	// Fields with a pointer or a sql.Null type are nullable.
+	column := Column{Name: tags[0], Field: field, Nullable: isNullable(field.Type)}
+	size := 0
+	definition := make([]string, 0)
+	for _, tag := range tags[1:] {
+		name, param, _ := strings.Cut(strings.TrimSpace(tag), ":")
+		switch {
+		case !IsOption(tag):
+			definition = append(definition, strings.TrimSpace(tag))
+		case name == PrimaryKeyOption:
+			column.PrimaryKey = true
+			column.AutoIncrement = column.AutoIncrement || param == AutoIncrementOption
+		case name == AutoIncrementOption:
+			column.AutoIncrement = true
+		case name == SizeOption:
+			size, _ = strconv.Atoi(param)
+		case name == DefaultOption:
+			column.Default = &param
+		case name == UniqueOption:
+			column.Unique = true
+		case name == ForeignKeyOption:
+			column.References = parseReference(param)
+		}
+	}
+
+	// Tags written before the options keep the column definition they carry.
+	if len(definition) > 0 {
+		column.Nullable = true
+		parseDefinition(&column, definition)
+	}
+	if column.PrimaryKey || column.AutoIncrement {
+		column.Nullable = false
+	}
+
+	// The dbtype tag sets the type, which is inferred from the Go type otherwise.
+	if columnType := strings.TrimSpace(field.Tag.Get(TypeTag)); columnType != "" {
+		column.Type = columnType
+	}
+	if column.Type == "" && dialect != nil {
+		column.Type = dialect.ColumnType(field.Type, size)
+	}
+	return column, column.Type != ""
+}
+
+// CreateTableQuery writes the CREATE TABLE query of a table, the foreign keys following the columns.
+//
+// Parameters:
+// - dialect: The dialect writing the column definitions.
+// - table: The name of the table.
+// - columns: The columns of the table.
+// - ifNotExist: Adds IF NOT EXISTS to the query.
+//
+// Returns:
+// - string: The query, without a trailing semicolon.
+//
+// Example:
+//   query := schema.CreateTableQuery(schema.Postgres, "orders", schema.Columns(reflect.TypeOf(Order{}), schema.Postgres), true)
+func CreateTableQuery(dialect Dialect, table string, columns []Column, ifNotExist bool) string {
+	definitions := make([]string, 0, len(columns))
+	for _, column := range columns {
+		definitions = append(definitions, dialect.ColumnDefinition(column))
+	}
+	for _, column := range columns {
+		if column.References != nil {
+			definitions = append(definitions, column.References.ForeignKey(column.Name))
+		}
+	}
+
+	query := "CREATE TABLE "
+	if ifNotExist {
+		query += "IF NOT EXISTS "
+	}
+	return query + table + " (" + strings.Join(definitions, ",") + ")"
+}
+
+// parseDefinition reads a column definition written in the dbfusion tag, e.g. "VARCHAR(255),NOT NULL,UNIQUE". The
+// first part and the parts which are not constraints make the type of the column.
+func parseDefinition(column *Column, definition []string) {
+	for position, token := range definition {
+		upper := strings.ToUpper(token)
+		switch {
+		case upper == "NOT NULL":
+			column.Nullable = false
+		case upper == "NULL":
+			column.Nullable = true
+		case upper == "AUTO_INCREMENT":
+			column.AutoIncrement = true
+		case upper == "PRIMARY KEY":
+			column.PrimaryKey = true
+		case upper == "UNIQUE" || upper == "UNIQUE KEY":
+			column.Unique = true
+		case strings.HasPrefix(upper, "DEFAULT "):
+			value := strings.TrimSpace(token[len("DEFAULT "):])
+			column.Default = &value
+		case position == 0 || column.Type != "":
+			column.Type = strings.TrimSpace(column.Type + " " + token)
+		}
+	}
+}
+
+// parseReference reads the parameter of the fk option, "table.column" or "table" for its id column.
+func parseReference(param string) *Reference {
+	if param == "" {
+		return nil
+	}
+	if index := strings.LastIndex(param, "."); index >= 0 {
+		return &Reference{Table: param[:index], Column: param[index+1:]}
+	}
+	return &Reference{Table: param, Column: "id"}
+}
+
+// isNullable reports whether a Go type holds NULL, a pointer or a sql.Null type.
+func isNullable(goType reflect.Type) bool {
+	if goType.Kind() == reflect.Ptr {
+		return true
+	}
+	_, ok := nullTypes[goType]
+	return ok
+}
+
+// valueType returns the type of the value held by a Go type, dereferencing pointers and the sql.Null types.
+func valueType(goType reflect.Type) reflect.Type {
+	for goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	if held, ok := nullTypes[goType]; ok {
+		return held
+	}
+	return goType
+}
@@ -0,0 +1,375 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Dialect infers column types and writes column definitions for a database.
+type Dialect interface {
+	// Name returns the name of the database, e.g. "mysql".
+	Name() string
+
+	// ColumnType infers the type of the column of a Go type, size being the size option of the field or 0.
+	// It returns an empty string when the Go type can't be stored, e.g. a function or a channel.
+	ColumnType(goType reflect.Type, size int) string
+
+	// ColumnDefinition writes the definition of a column in a CREATE TABLE or an ALTER TABLE query.
+	ColumnDefinition(column Column) string
+
+	// GoType returns the Go type of the values of a column type reported by the database, and the size inferring
+	// the column type back, 0 when the type takes no size. The types the dialect doesn't know are read as strings.
+	GoType(columnType string) (reflect.Type, int)
+}
+
+// Go types of the column values.
+var (
+	boolType    = reflect.TypeOf(false)
+	stringType  = reflect.TypeOf("")
+	bytesType   = reflect.TypeOf([]byte{})
+	float32Type = reflect.TypeOf(float32(0))
+	float64Type = reflect.TypeOf(float64(0))
+	jsonType    = reflect.TypeOf(json.RawMessage{})
+)
+
+// Dialects of the supported databases.
+var (
+	MySQL    Dialect = mysqlDialect{}
+	Postgres Dialect = postgresDialect{}
+	SQLite   Dialect = sqliteDialect{}
+)
+
+// mysqlDialect is the dialect of MySQL.
+type mysqlDialect struct{}
+
+// Name returns "mysql".
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+// ColumnType infers the MySQL type of a Go type. Strings are VARCHAR(255) unless their size asks for a TEXT type,
+// times keep their microseconds and the types which are not scalars are stored as JSON.
+func (mysqlDialect) ColumnType(goType reflect.Type, size int) string {
+	switch kind := goKind(goType); kind {
+	case "bool":
+		return "BOOLEAN"
+	case "int8":
+		return "TINYINT"
+	case "int16":
+		return "SMALLINT"
+	case "int32":
+		return "INT"
+	case "int64":
+		return "BIGINT"
+	case "uint8", "uint16", "uint32", "uint64":
+		return map[string]string{"uint8": "TINYINT", "uint16": "SMALLINT", "uint32": "INT", "uint64": "BIGINT"}[kind] + " UNSIGNED"
+	case "float32":
+		return "FLOAT"
+	case "float64":
+		return "DOUBLE"
+	case "string":
+		switch {
+		case size <= 0:
+			return "VARCHAR(255)"
+		case size <= 16383:
+			return fmt.Sprintf("VARCHAR(%d)", size)
+		case size <= 65535:
+			return "TEXT"
+		case size <= 16777215:
+			return "MEDIUMTEXT"
+		}
+		return "LONGTEXT"
+	case "time":
+		return "DATETIME(6)"
+	case "bytes":
+		if size > 0 {
+			return fmt.Sprintf("VARBINARY(%d)", size)
+		}
+		return "BLOB"
+	case "json":
+		return "JSON"
+	}
+	return ""
+}
+
+// ColumnDefinition writes a MySQL column definition, e.g. "id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY".
+func (mysqlDialect) ColumnDefinition(column Column) string {
+	definition := column.Name + " " + column.Type + nullability(column) + defaultClause(column)
+	if column.AutoIncrement {
+		definition += " AUTO_INCREMENT"
+	}
+	return definition + keyClauses(column)
+}
+
+// GoType returns the Go type of a MySQL column type, e.g. int64 for "bigint" and string with the size 64 for
+// "varchar(64)". TINYINT(1) columns are booleans.
+func (mysqlDialect) GoType(columnType string) (reflect.Type, int) {
+	base, size, unsigned := splitColumnType(columnType)
+	switch base {
+	case "tinyint":
+		if size == 1 {
+			return boolType, 0
+		}
+		return integerType(8, unsigned), 0
+	case "smallint", "year":
+		return integerType(16, unsigned), 0
+	case "mediumint", "int", "integer":
+		return integerType(32, unsigned), 0
+	case "bigint", "bit":
+		return integerType(64, unsigned || base == "bit"), 0
+	case "float":
+		return float32Type, 0
+	case "double", "real", "decimal", "numeric":
+		return float64Type, 0
+	case "varchar":
+		return stringType, size
+	case "tinytext":
+		return stringType, 255
+	case "text":
+		return stringType, 65535
+	case "mediumtext":
+		return stringType, 16777215
+	case "longtext":
+		return stringType, 4294967295
+	case "date", "datetime", "timestamp":
+		return timeType, 0
+	case "binary", "varbinary":
+		return bytesType, size
+	case "tinyblob", "blob", "mediumblob", "longblob":
+		return bytesType, 0
+	case "json":
+		return jsonType, 0
+	}
+	return stringType, 0
+}
+
+// postgresDialect is the dialect of PostgreSQL.
+type postgresDialect struct{}
+
+// Name returns "postgres".
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+// ColumnType infers the PostgreSQL type of a Go type. Unsigned integers get the next larger type, as PostgreSQL has
+// no unsigned types, and the types which are not scalars are stored as JSONB.
+func (postgresDialect) ColumnType(goType reflect.Type, size int) string {
+	switch goKind(goType) {
+	case "bool":
+		return "BOOLEAN"
+	case "int8", "int16", "uint8":
+		return "SMALLINT"
+	case "int32", "uint16":
+		return "INTEGER"
+	case "int64", "uint32":
+		return "BIGINT"
+	case "uint64":
+		return "NUMERIC(20)"
+	case "float32":
+		return "REAL"
+	case "float64":
+		return "DOUBLE PRECISION"
+	case "string":
+		if size > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", size)
+		}
+		return "TEXT"
+	case "time":
+		return "TIMESTAMP"
+	case "bytes":
+		return "BYTEA"
+	case "json":
+		return "JSONB"
+	}
+	return ""
+}
+
+// ColumnDefinition writes a PostgreSQL column definition, the values generated by the database being an identity,
+// e.g. "id BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL PRIMARY KEY".
+func (postgresDialect) ColumnDefinition(column Column) string {
+	definition := column.Name + " " + column.Type
+	if column.AutoIncrement {
+		definition += " GENERATED BY DEFAULT AS IDENTITY"
+	}
+	return definition + nullability(column) + defaultClause(column) + keyClauses(column)
+}
+
+// GoType returns the Go type of a PostgreSQL column type, e.g. int32 for "integer" and string with the size 64 for
+// "character varying(64)".
+func (postgresDialect) GoType(columnType string) (reflect.Type, int) {
+	base, size, _ := splitColumnType(columnType)
+	switch {
+	case base == "boolean" || base == "bool":
+		return boolType, 0
+	case base == "smallint" || base == "int2" || base == "smallserial":
+		return integerType(16, false), 0
+	case base == "integer" || base == "int" || base == "int4" || base == "serial":
+		return integerType(32, false), 0
+	case base == "bigint" || base == "int8" || base == "bigserial":
+		return integerType(64, false), 0
+	case base == "real" || base == "float4":
+		return float32Type, 0
+	case base == "double precision" || base == "float8" || base == "numeric" || base == "decimal":
+		return float64Type, 0
+	case base == "varchar" || base == "character varying":
+		return stringType, size
+	case base == "date" || strings.HasPrefix(base, "timestamp"):
+		return timeType, 0
+	case base == "bytea":
+		return bytesType, 0
+	case base == "json" || base == "jsonb":
+		return jsonType, 0
+	}
+	return stringType, 0
+}
+
+// sqliteDialect is the dialect of SQLite.
+type sqliteDialect struct{}
+
+// Name returns "sqlite".
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+// ColumnType infers the SQLite type of a Go type, one of the storage classes INTEGER, REAL, TEXT and BLOB, or
+// DATETIME for the times.
+func (sqliteDialect) ColumnType(goType reflect.Type, size int) string {
+	switch goKind(goType) {
+	case "bool", "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
+		return "INTEGER"
+	case "float32", "float64":
+		return "REAL"
+	case "string", "json":
+		return "TEXT"
+	case "time":
+		return "DATETIME"
+	case "bytes":
+		return "BLOB"
+	}
+	return ""
+}
+
+// ColumnDefinition writes a SQLite column definition. SQLite only generates the values of an INTEGER PRIMARY KEY,
+// e.g. "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT".
+func (sqliteDialect) ColumnDefinition(column Column) string {
+	definition := column.Name + " " + column.Type + nullability(column) + defaultClause(column)
+	if column.AutoIncrement && column.PrimaryKey {
+		return definition + " PRIMARY KEY AUTOINCREMENT"
+	}
+	return definition + keyClauses(column)
+}
+
+// GoType returns the Go type of a SQLite column type from the affinity rules of SQLite, e.g. int64 for the types
+// containing "INT" and float64 for the ones containing "REAL", "FLOA" or "DOUB".
+func (sqliteDialect) GoType(columnType string) (reflect.Type, int) {
+	base, _, _ := splitColumnType(columnType)
+	switch {
+	case strings.Contains(base, "bool"):
+		return boolType, 0
+	case strings.Contains(base, "int"):
+		return integerType(64, false), 0
+	case strings.Contains(base, "char") || strings.Contains(base, "clob") || strings.Contains(base, "text"):
+		return stringType, 0
+	case strings.Contains(base, "blob"):
+		return bytesType, 0
+	case strings.Contains(base, "real") || strings.Contains(base, "floa") || strings.Contains(base, "doub"):
+		return float64Type, 0
+	case strings.Contains(base, "date") || strings.Contains(base, "time"):
+		return timeType, 0
+	}
+	return stringType, 0
+}
+
+// splitColumnType splits a column type into its lowercase name, its first parameter and its UNSIGNED attribute,
+// e.g. "varchar", 64 and false for "VARCHAR(64)".
+func splitColumnType(columnType string) (string, int, bool) {
+	columnType = strings.Join(strings.Fields(strings.ToLower(columnType)), " ")
+	unsigned := strings.Contains(columnType, " unsigned")
+	base, params, _ := strings.Cut(columnType, "(")
+	if !strings.Contains(columnType, "(") {
+		base = strings.TrimSuffix(strings.TrimSuffix(columnType, " zerofill"), " unsigned")
+	}
+	param, _, _ := strings.Cut(params, ",")
+	size, _ := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(param, ")")))
+	return strings.TrimSpace(base), size, unsigned
+}
+
+// integerType returns the signed or unsigned integer type of a number of bits.
+func integerType(bits int, unsigned bool) reflect.Type {
+	if unsigned {
+		return map[int]reflect.Type{8: reflect.TypeOf(uint8(0)), 16: reflect.TypeOf(uint16(0)), 32: reflect.TypeOf(uint32(0)), 64: reflect.TypeOf(uint64(0))}[bits]
+	}
+	return map[int]reflect.Type{8: reflect.TypeOf(int8(0)), 16: reflect.TypeOf(int16(0)), 32: reflect.TypeOf(int32(0)), 64: reflect.TypeOf(int64(0))}[bits]
+}
+
+// goKind returns the kind of value stored for a Go type: a scalar kind, "time", "bytes", "json" or an empty string
+// for the types which can't be stored. Pointers and the sql.Null types are reduced to the type they hold.
+func goKind(goType reflect.Type) string {
+	goType = valueType(goType)
+	switch {
+	case goType == timeType:
+		return "time"
+	case goType.Kind() == reflect.Slice && goType.Elem().Kind() == reflect.Uint8:
+		return "bytes"
+	}
+
+	switch goType.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int8:
+		return "int8"
+	case reflect.Int16:
+		return "int16"
+	case reflect.Int32:
+		return "int32"
+	case reflect.Int, reflect.Int64:
+		return "int64"
+	case reflect.Uint8:
+		return "uint8"
+	case reflect.Uint16:
+		return "uint16"
+	case reflect.Uint32:
+		return "uint32"
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return "uint64"
+	case reflect.Float32:
+		return "float32"
+	case reflect.Float64:
+		return "float64"
+	case reflect.String:
+		return "string"
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Interface:
+		return "json"
+	}
+	return ""
+}
+
+// nullability writes the NULL or NOT NULL constraint of a column.
+func nullability(column Column) string {
+	if column.Nullable && !column.PrimaryKey {
+		return " NULL"
+	}
+	return " NOT NULL"
+}
+
+// defaultClause writes the DEFAULT clause of a column, empty if it has no default.
+func defaultClause(column Column) string {
+	if column.Default == nil {
+		return ""
+	}
+	return " DEFAULT " + *column.Default
+}
+
+// keyClauses writes the PRIMARY KEY and UNIQUE constraints of a column.
+func keyClauses(column Column) string {
+	if column.PrimaryKey {
+		return " PRIMARY KEY"
+	}
+	if column.Unique {
+		return " UNIQUE"
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+// Package schema describes the SQL columns of the models. The columns are read from the dbfusion tag of the fields,
+// which names the column and carries the options of dbFusion, and from the dbtype tag, which sets the column type.
+// Without a dbtype tag the type is inferred from the Go type of the field by the Dialect of the database.
+//
+// Example:
+//   type Order struct {
+//       ID        int64      `dbfusion:"id,pk:autoincr"`
+//       UserID    int64      `dbfusion:"userId,fk:users.id"`
+//       Reference string     `dbfusion:"reference,size:32,unique"`
+//       Status    string     `dbfusion:"status,size:16,default:'new'"`
+//       Total     float64    `dbfusion:"total" dbtype:"DECIMAL(10,2)"`
+//       ShippedAt *time.Time `dbfusion:"shippedAt"`
+//   }
+//
+// The options of the grammar are:
+//   - pk, or pk:autoincr: The column is the primary key, generated by the database with autoincr.
+//   - autoincr: The database generates the values of the column.
+//   - size:N: The size of a string or binary column, e.g. VARCHAR(N).
+//   - default:V: The default value of the column, a SQL literal or expression without commas.
+//   - unique: The column has a unique index.
+//   - fk:table.column: The column references the column of another table.
+//
+// Fields with a pointer type or a sql.Null type are nullable, the other fields are NOT NULL. Tags written before
+// this grammar, with the column definition in the dbfusion tag, e.g. `dbfusion:"id,INT,AUTO_INCREMENT,PRIMARY KEY"`,
+// keep their definition.
+//
+// The other way around, Generate writes the models of existing tables and collections described by a Table, e.g.
+// from the DescribeTable method of the SQL connections, with the tags and index hooks matching their schema.
+package schema
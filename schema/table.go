@@ -0,0 +1,356 @@
+package schema
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kinds of the indexes of a Table, each declared by its index hook in the generated model.
+const (
+	NormalIndex     = "normal"   // Declared by hooks.NormalIndexes.
+	UniqueIndex     = "unique"   // Declared by hooks.UniqueIndexes.
+	TextIndex       = "text"     // Declared by hooks.TextIndexes, which declares a single text index.
+	TwoDIndex       = "2d"       // Declared by hooks.TwoDimensionalIndexes.
+	TwoDSphereIndex = "2dsphere" // Declared by hooks.TwoDimensionalSpatialIndexes.
+	HashedIndex     = "hashed"   // Declared by hooks.HashedIndexes.
+	SparseIndex     = "sparse"   // Declared by hooks.SparseIndexes.
+	SpecIndex       = "spec"     // Declared by hooks.IndexSpecs, for the indexes with options the other hooks lack.
+)
+
+// indexHooks are the methods declaring each kind of index, in the order they are generated.
+var indexHooks = []struct {
+	kind        string
+	method      string
+	description string
+}{
+	{NormalIndex, "GetNormalIndexes", "normal indexes"},
+	{UniqueIndex, "GetUniqueIndexes", "unique indexes"},
+	{TextIndex, "GetTextIndex", "text index"},
+	{TwoDIndex, "Get2DIndexes", "2d indexes"},
+	{TwoDSphereIndex, "Get2DSpatialIndexes", "2dsphere indexes"},
+	{HashedIndex, "GetHashedIndexes", "hashed indexes"},
+	{SparseIndex, "GetSparseIndexes", "sparse indexes"},
+	{SpecIndex, "GetIndexSpecs", "indexes with options"},
+}
+
+// initialisms are the words written in capitals in the names of the generated fields and types.
+var initialisms = map[string]bool{
+	"API": true, "CSS": true, "DB": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// Table describes an existing table or collection, as read by the DescribeTable and DescribeCollection methods of
+// the connections, to generate its model.
+type Table struct {
+	Name       string        // The name of the table or the collection.
+	Model      string        // The name of the generated struct, derived from the name of the table when empty.
+	Collection bool          // true for a MongoDB collection.
+	Columns    []TableColumn // The columns or the fields, in the order of the table.
+	Indexes    []TableIndex  // The indexes which are not declared by the tags of the columns.
+}
+
+// TableColumn describes a column of an existing table, or a field of the documents of a collection.
+type TableColumn struct {
+	Name          string       // The name of the column.
+	GoType        reflect.Type // The type of the field, a pointer for a nullable column.
+	Type          string       // The type written in the dbtype tag, empty when the dialect infers it from GoType and Size.
+	Size          int          // The size option of the column, 0 without one.
+	Default       *string      // The default value of the column, a SQL literal or expression, nil without one.
+	PrimaryKey    bool         // true if the column is the primary key.
+	AutoIncrement bool         // true if the database generates the values of the column.
+	Unique        bool         // true if the column has a unique index of its own.
+	References    *Reference   // The column referenced by a foreign key, nil without one.
+	OmitEmpty     bool         // true for the fields missing from some documents of a collection.
+}
+
+// TableIndex describes an index of an existing table or collection.
+type TableIndex struct {
+	Name               string   // The name of the index.
+	Kind               string   // The kind of the index, e.g. NormalIndex, naming the hook declaring it.
+	Keys               []string // The keys of the index written like the hooks, e.g. "email:1" or "bio" for a text index.
+	Unique             bool     // true for a unique index declared by hooks.IndexSpecs.
+	Sparse             bool     // true for a sparse index declared by hooks.IndexSpecs.
+	Hidden             bool     // true for a hidden index declared by hooks.IndexSpecs.
+	ExpireAfterSeconds *int32   // The expiry of a TTL index declared by hooks.IndexSpecs, nil without one.
+}
+
+// Generate writes the Go source of the models of tables, a struct per table with the dbfusion and dbtype tags of
+// its columns, a GetEntityName method and the index hooks declaring the indexes of the table.
+//
+// Parameters:
+// - packageName: The package of the generated file.
+// - tables: The tables, read by the DescribeTable or DescribeCollection method of a connection.
+//
+// Returns:
+// - []byte: The formatted source.
+// - error: An error if the package name is not an identifier or the source can't be formatted.
+//
+// Defaults containing a comma, which the dbfusion tag can't express, are left out, and only the first text index
+// of a table is declared, hooks.TextIndexes declaring one.
+//
+// Example:
+//   table, err := con.DescribeTable("users")
+//   source, err := schema.Generate("models", table)
+func Generate(packageName string, tables ...Table) ([]byte, error) {
+	if !token.IsIdentifier(packageName) {
+		return nil, fmt.Errorf("invalid package name %q", packageName)
+	}
+
+	// Write the models first to collect the packages their types need.
+	imports := make(map[string]bool)
+	body := strings.Builder{}
+	for _, table := range tables {
+		writeModel(&body, table, imports)
+	}
+
+	source := strings.Builder{}
+	source.WriteString("// Code generated by dbfusion gen from the schema of the database, edit it as needed.\n\n")
+	source.WriteString("package " + packageName + "\n\n")
+	if len(imports) > 0 {
+		// Group the standard packages before the other ones.
+		standard, others := make([]string, 0), make([]string, 0)
+		for path := range imports {
+			if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+				others = append(others, strconv.Quote(path))
+			} else {
+				standard = append(standard, strconv.Quote(path))
+			}
+		}
+		groups := make([]string, 0, 2)
+		for _, group := range [][]string{standard, others} {
+			if len(group) > 0 {
+				sort.Strings(group)
+				groups = append(groups, strings.Join(group, "\n"))
+			}
+		}
+		source.WriteString("import (\n" + strings.Join(groups, "\n\n") + "\n)\n\n")
+	}
+	source.WriteString(body.String())
+	return format.Source([]byte(source.String()))
+}
+
+// writeModel writes the struct of a table, its GetEntityName method and its index hooks.
+func writeModel(body *strings.Builder, table Table, imports map[string]bool) {
+	model := table.Model
+	if model == "" {
+		model = ModelName(table.Name)
+	}
+	receiver := strings.ToLower(model[:1])
+	entity := "the " + table.Name + " table"
+	if table.Collection {
+		entity = "the " + table.Name + " collection"
+	}
+
+	// Write a field per column, the names made unique.
+	fmt.Fprintf(body, "// %s is the model of %s.\ntype %s struct {\n", model, entity, model)
+	used := make(map[string]bool)
+	for _, column := range table.Columns {
+		name := FieldName(column.Name)
+		for suffix := 2; used[name]; suffix++ {
+			name = FieldName(column.Name) + strconv.Itoa(suffix)
+		}
+		used[name] = true
+		fmt.Fprintf(body, "%s %s `%s`\n", name, goTypeName(column.GoType, imports), columnTag(column))
+	}
+	body.WriteString("}\n\n")
+
+	fmt.Fprintf(body, "// GetEntityName returns the name of %s.\nfunc (%s %s) GetEntityName() string {\nreturn %s\n}\n\n",
+		entity, receiver, model, strconv.Quote(table.Name))
+
+	// Write the hooks of the kinds of indexes the table has.
+	for _, hook := range indexHooks {
+		indexes := make([]TableIndex, 0)
+		for _, index := range table.Indexes {
+			if index.Kind == hook.kind && len(index.Keys) > 0 {
+				indexes = append(indexes, index)
+			}
+		}
+		if len(indexes) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(body, "// %s returns the %s of %s.\n", hook.method, hook.description, entity)
+		switch hook.kind {
+		case TextIndex:
+			fmt.Fprintf(body, "func (%s %s) %s() string {\nreturn %s\n}\n\n", receiver, model, hook.method, strconv.Quote(indexString(indexes[0])))
+		case SpecIndex:
+			imports["github.com/glodb/dbfusion/hooks"] = true
+			fmt.Fprintf(body, "func (%s %s) %s() []hooks.IndexSpec {\n", receiver, model, hook.method)
+			specs := make([]string, 0, len(indexes))
+			for i, index := range indexes {
+				spec := fmt.Sprintf("Name: %s, Keys: %s", strconv.Quote(index.Name), strconv.Quote(strings.Join(index.Keys, ",")))
+				if index.Unique {
+					spec += ", Unique: true"
+				}
+				if index.Sparse {
+					spec += ", Sparse: true"
+				}
+				if index.Hidden {
+					spec += ", Hidden: true"
+				}
+				if index.ExpireAfterSeconds != nil {
+					fmt.Fprintf(body, "expiry%d := int32(%d)\n", i, *index.ExpireAfterSeconds)
+					spec += fmt.Sprintf(", ExpireAfterSeconds: &expiry%d", i)
+				}
+				specs = append(specs, "{"+spec+"},")
+			}
+			fmt.Fprintf(body, "return []hooks.IndexSpec{\n%s\n}\n}\n\n", strings.Join(specs, "\n"))
+		default:
+			values := make([]string, 0, len(indexes))
+			for _, index := range indexes {
+				values = append(values, strconv.Quote(indexString(index)))
+			}
+			fmt.Fprintf(body, "func (%s %s) %s() []string {\nreturn []string{%s}\n}\n\n", receiver, model, hook.method, strings.Join(values, ", "))
+		}
+	}
+}
+
+// columnTag writes the tags of the field of a column, e.g. `dbfusion:"status,size:16,default:'new'"`.
+func columnTag(column TableColumn) string {
+	options := []string{column.Name}
+	if column.OmitEmpty {
+		options = append(options, "omitempty")
+	}
+	switch {
+	case column.PrimaryKey && column.AutoIncrement:
+		options = append(options, PrimaryKeyOption+":"+AutoIncrementOption)
+	case column.PrimaryKey:
+		options = append(options, PrimaryKeyOption)
+	case column.AutoIncrement:
+		options = append(options, AutoIncrementOption)
+	}
+	if column.Size > 0 && column.Type == "" {
+		options = append(options, SizeOption+":"+strconv.Itoa(column.Size))
+	}
+	if column.Default != nil && !strings.ContainsAny(*column.Default, ",`") {
+		options = append(options, DefaultOption+":"+*column.Default)
+	}
+	if column.Unique && !column.PrimaryKey {
+		options = append(options, UniqueOption)
+	}
+	if column.References != nil {
+		options = append(options, ForeignKeyOption+":"+column.References.Table+"."+column.References.Column)
+	}
+
+	tag := "dbfusion:" + strconv.Quote(strings.Join(options, ","))
+	if column.Type != "" {
+		tag += " " + TypeTag + ":" + strconv.Quote(column.Type)
+	}
+	return tag
+}
+
+// indexString writes an index like the hooks declare it, its name before an equal sign, e.g. "byEmail=email:1".
+func indexString(index TableIndex) string {
+	keys := strings.Join(index.Keys, ",")
+	if index.Name == "" {
+		return keys
+	}
+	return index.Name + "=" + keys
+}
+
+// goTypeName writes a Go type, adding the packages of its named types to imports.
+func goTypeName(goType reflect.Type, imports map[string]bool) string {
+	if goType == nil {
+		return "interface{}"
+	}
+	if goType.PkgPath() != "" {
+		imports[goType.PkgPath()] = true
+		return goType.String()
+	}
+	switch goType.Kind() {
+	case reflect.Ptr:
+		return "*" + goTypeName(goType.Elem(), imports)
+	case reflect.Slice:
+		if goType.Elem().Kind() == reflect.Uint8 {
+			return "[]byte"
+		}
+		return "[]" + goTypeName(goType.Elem(), imports)
+	case reflect.Map:
+		return "map[" + goTypeName(goType.Key(), imports) + "]" + goTypeName(goType.Elem(), imports)
+	case reflect.Interface:
+		return "interface{}"
+	}
+	return goType.String()
+}
+
+// FieldName returns the exported Go name of a column, e.g. "UserID" for "user_id" or "userId", and "ID" for "_id".
+//
+// Parameters:
+// - column: The name of the column.
+//
+// Returns:
+// - string: The name of the field.
+func FieldName(column string) string {
+	name := strings.Builder{}
+	for _, word := range splitWords(column) {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			name.WriteString(upper)
+		} else {
+			name.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	if name.Len() == 0 {
+		return "Field"
+	}
+	if first := []rune(name.String())[0]; !unicode.IsLetter(first) {
+		return "X" + name.String()
+	}
+	return name.String()
+}
+
+// ModelName returns the name of the model of a table, the singular of its last word, e.g. "OrderItem" for
+// "order_items" and "Category" for "categories".
+//
+// Parameters:
+// - table: The name of the table.
+//
+// Returns:
+// - string: The name of the model.
+func ModelName(table string) string {
+	words := splitWords(table)
+	if len(words) == 0 {
+		return "Model"
+	}
+	last := words[len(words)-1]
+	lower := strings.ToLower(last)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(last) > 3:
+		last = last[:len(last)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		last = last[:len(last)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss") && !strings.HasSuffix(lower, "us") && !strings.HasSuffix(lower, "is") && len(last) > 1:
+		last = last[:len(last)-1]
+	}
+	words[len(words)-1] = last
+	return FieldName(strings.Join(words, "_"))
+}
+
+// splitWords splits a name into its words, at the separators and at the lowercase letters followed by capitals,
+// e.g. "user", "Id" for "userId".
+func splitWords(name string) []string {
+	words := make([]string, 0)
+	word := make([]rune, 0)
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words, word = append(words, string(word)), word[:0]
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			words, word = append(words, string(word)), word[:0]
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
@@ -0,0 +1,66 @@
+package audit_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/glodb/dbfusion/audit"
+)
+
+// TestDiff tests the changes recorded for insertions, updates and deletions.
+func TestDiff(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		Before   map[string]interface{}
+		After    map[string]interface{}
+		Expected map[string]audit.Change
+		Name     string
+	}{
+		{
+			After:    map[string]interface{}{"email": "alice@example.com"},
+			Expected: map[string]audit.Change{"email": {After: "alice@example.com"}},
+			Name:     "Insertion records every value",
+		},
+		{
+			Before:   map[string]interface{}{"email": "alice@example.com", "age": int32(30)},
+			After:    map[string]interface{}{"email": "alice@example.com", "age": int64(31)},
+			Expected: map[string]audit.Change{"age": {Before: int32(30), After: int64(31)}},
+			Name:     "Update records the changed values",
+		},
+		{
+			Before:   map[string]interface{}{"age": int32(30), "createdAt": now.UTC()},
+			After:    map[string]interface{}{"age": int64(30), "createdAt": now},
+			Expected: map[string]audit.Change{},
+			Name:     "Values read with other types are unchanged",
+		},
+		{
+			Before:   map[string]interface{}{"email": "alice@example.com"},
+			Expected: map[string]audit.Change{"email": {Before: "alice@example.com"}},
+			Name:     "Deletion records every value",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			changes := audit.GetInstance().Diff(tc.Before, tc.After)
+			if !reflect.DeepEqual(changes, tc.Expected) {
+				t.Errorf("Expected %v, got %v", tc.Expected, changes)
+			}
+		})
+	}
+}
+
+// TestNewEntry tests that entries carry the actor of the context.
+func TestNewEntry(t *testing.T) {
+	ctx := audit.WithActor(context.Background(), "alice@example.com")
+	entry := audit.GetInstance().NewEntry(ctx, "users", 42, audit.Update, map[string]interface{}{"age": 30}, map[string]interface{}{"age": 31})
+	if entry.Actor != "alice@example.com" || entry.EntityID != 42 || entry.Operation != audit.Update || len(entry.Changes) != 1 {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	if actor := audit.ActorFromContext(context.Background()); actor != "" {
+		t.Errorf("Expected no actor, got %s", actor)
+	}
+}
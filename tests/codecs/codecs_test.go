@@ -2,9 +2,11 @@ package codecs_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/glodb/dbfusion/codec"
 	"github.com/glodb/dbfusion/tests/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var data map[string]interface{}
@@ -45,3 +47,40 @@ func TestJsonDecode(t *testing.T) {
 		t.Errorf("Error in decoding JSON: %v", err)
 	}
 }
+
+// TestBsonRoundTrip tests that the BSON codec keeps the types of untyped values, as write-behind records need.
+func TestBsonRoundTrip(t *testing.T) {
+	type queuedRecord struct {
+		Data map[string]interface{} `bson:"data"`
+	}
+
+	createdAt := time.Date(2023, 9, 1, 10, 30, 0, 0, time.UTC)
+	id := primitive.NewObjectID()
+	encoded, err := codec.GetBSONInstance().Encode(queuedRecord{Data: map[string]interface{}{
+		"_id":       id,
+		"createdAt": createdAt,
+		"age":       int64(30),
+		"avatar":    []byte("png"),
+	}})
+	if err != nil {
+		t.Fatalf("Error in encoding BSON: %v", err)
+	}
+
+	decoded := queuedRecord{}
+	if err := codec.GetBSONInstance().Decode(encoded, &decoded); err != nil {
+		t.Fatalf("Error in decoding BSON: %v", err)
+	}
+
+	if value, ok := decoded.Data["_id"].(primitive.ObjectID); !ok || value != id {
+		t.Errorf("Expected ObjectID %v, got %T %v", id, decoded.Data["_id"], decoded.Data["_id"])
+	}
+	if value, ok := decoded.Data["createdAt"].(time.Time); !ok || !value.Equal(createdAt) {
+		t.Errorf("Expected time %v, got %T %v", createdAt, decoded.Data["createdAt"], decoded.Data["createdAt"])
+	}
+	if value, ok := decoded.Data["age"].(int64); !ok || value != 30 {
+		t.Errorf("Expected int64 30, got %T %v", decoded.Data["age"], decoded.Data["age"])
+	}
+	if value, ok := decoded.Data["avatar"].([]byte); !ok || string(value) != "png" {
+		t.Errorf("Expected bytes png, got %T %v", decoded.Data["avatar"], decoded.Data["avatar"])
+	}
+}
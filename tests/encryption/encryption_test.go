@@ -0,0 +1,189 @@
+package encryption_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/encryption"
+)
+
+var (
+	oldKey = []byte("0123456789abcdef0123456789abcdef")
+	newKey = []byte("fedcba9876543210fedcba9876543210")
+)
+
+// TestEncryptDecrypt tests that values encrypted randomly and deterministically decrypt to their plaintext.
+func TestEncryptDecrypt(t *testing.T) {
+	provider := encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": oldKey})
+	testCases := []struct {
+		Plaintext     string
+		Deterministic bool
+		Name          string
+	}{
+		{
+			Plaintext: "+1 555 0100",
+			Name:      "Random encryption",
+		},
+		{
+			Plaintext:     "AB123456",
+			Deterministic: true,
+			Name:          "Deterministic encryption",
+		},
+		{
+			Plaintext: "",
+			Name:      "Empty value",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ciphertext, err := encryption.GetInstance().Encrypt(provider, tc.Plaintext, tc.Deterministic)
+			if err != nil {
+				t.Fatalf("Encryption failed with %v", err)
+			}
+			if !strings.HasPrefix(ciphertext, encryption.Prefix+"v1:") || (tc.Plaintext != "" && strings.Contains(ciphertext, tc.Plaintext)) {
+				t.Errorf("Unexpected ciphertext %s", ciphertext)
+			}
+			plaintext, err := encryption.GetInstance().Decrypt(provider, ciphertext)
+			if err != nil || plaintext != tc.Plaintext {
+				t.Errorf("Expected %q, got %q %v", tc.Plaintext, plaintext, err)
+			}
+		})
+	}
+}
+
+// TestDeterministic tests that only deterministic encryption gives equal values equal ciphertexts.
+func TestDeterministic(t *testing.T) {
+	provider := encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": oldKey})
+	testCases := []struct {
+		Deterministic bool
+		Equal         bool
+		Name          string
+	}{
+		{
+			Deterministic: true,
+			Equal:         true,
+			Name:          "Deterministic ciphertexts are equal",
+		},
+		{
+			Deterministic: false,
+			Equal:         false,
+			Name:          "Random ciphertexts differ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			first, _ := encryption.GetInstance().Encrypt(provider, "AB123456", tc.Deterministic)
+			second, _ := encryption.GetInstance().Encrypt(provider, "AB123456", tc.Deterministic)
+			if (first == second) != tc.Equal {
+				t.Errorf("Expected equal %v, got %s and %s", tc.Equal, first, second)
+			}
+		})
+	}
+}
+
+// TestDeterministicNonceKey tests that the nonce of deterministic encryption is not computed with the AES key itself.
+func TestDeterministicNonceKey(t *testing.T) {
+	provider := encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": oldKey})
+	ciphertext, err := encryption.GetInstance().Encrypt(provider, "AB123456", true)
+	if err != nil {
+		t.Fatalf("Encryption failed with %v", err)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(ciphertext, encryption.Prefix+"v1:"))
+	if err != nil {
+		t.Fatalf("Unexpected ciphertext %s", ciphertext)
+	}
+
+	mac := hmac.New(sha256.New, oldKey)
+	mac.Write([]byte("AB123456"))
+	if bytes.Equal(sealed[:12], mac.Sum(nil)[:12]) {
+		t.Errorf("Expected the nonce to be derived with a key of its own")
+	}
+}
+
+// TestRotation tests that values encrypted with a previous key are still decrypted after the rotation.
+func TestRotation(t *testing.T) {
+	before := encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": oldKey})
+	after := encryption.NewStaticKeyProvider("v2", map[string][]byte{"v1": oldKey, "v2": newKey})
+
+	ciphertext, err := encryption.GetInstance().Encrypt(before, "AB123456", true)
+	if err != nil {
+		t.Fatalf("Encryption failed with %v", err)
+	}
+	plaintext, err := encryption.GetInstance().Decrypt(after, ciphertext)
+	if err != nil || plaintext != "AB123456" {
+		t.Errorf("Expected the value encrypted with v1, got %q %v", plaintext, err)
+	}
+
+	rotated, _ := encryption.GetInstance().Encrypt(after, "AB123456", true)
+	if !strings.HasPrefix(rotated, encryption.Prefix+"v2:") {
+		t.Errorf("Expected the current key v2, got %s", rotated)
+	}
+}
+
+// TestDecryptErrors tests the values which can't be decrypted.
+func TestDecryptErrors(t *testing.T) {
+	provider := encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": oldKey})
+	ciphertext, _ := encryption.GetInstance().Encrypt(provider, "AB123456", false)
+	tampered := ciphertext[:len(ciphertext)-2] + "AA"
+	if tampered == ciphertext {
+		tampered = ciphertext[:len(ciphertext)-2] + "BB"
+	}
+
+	testCases := []struct {
+		Provider  encryption.KeyProvider
+		Value     string
+		Plaintext string
+		Err       error
+		Name      string
+	}{
+		{
+			Provider:  provider,
+			Value:     "AB123456",
+			Plaintext: "AB123456",
+			Name:      "Plaintext is read as it is",
+		},
+		{
+			Provider: provider,
+			Value:    tampered,
+			Err:      dbfusionErrors.ErrInvalidCiphertext,
+			Name:     "Tampered ciphertext",
+		},
+		{
+			Provider: provider,
+			Value:    encryption.Prefix + "v1",
+			Err:      dbfusionErrors.ErrInvalidCiphertext,
+			Name:     "Malformed ciphertext",
+		},
+		{
+			Provider: encryption.NewStaticKeyProvider("v2", map[string][]byte{"v2": newKey}),
+			Value:    ciphertext,
+			Err:      dbfusionErrors.ErrEncryptionKeyNotFound,
+			Name:     "Unknown key",
+		},
+		{
+			Value: ciphertext,
+			Err:   dbfusionErrors.ErrKeyProviderRequired,
+			Name:  "No key provider",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			plaintext, err := encryption.GetInstance().Decrypt(tc.Provider, tc.Value)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("Expected error %v, got %v", tc.Err, err)
+			}
+			if plaintext != tc.Plaintext {
+				t.Errorf("Expected %q, got %q", tc.Plaintext, plaintext)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package mapper_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glodb/dbfusion/hooks"
+	"github.com/glodb/dbfusion/mapper"
+	"github.com/glodb/dbfusion/tests/models"
+	"github.com/glodb/dbfusion/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestParse tests the fields and set checks read from the source of a package.
+func TestParse(t *testing.T) {
+	source := `package shop
+
+import (
+	"time"
+
+	oid "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Status string
+
+type Order struct {
+	ID        oid.ObjectID ` + "`dbfusion:\"_id,pk\"`" + `
+	Total     float64      ` + "`dbfusion:\"total\"`" + `
+	Paid      bool         ` + "`dbfusion:\"paid\"`" + `
+	Status    Status       ` + "`dbfusion:\"status\"`" + `
+	Items     []string     ` + "`dbfusion:\"items,omitempty\"`" + `
+	Codes     [2]int       ` + "`dbfusion:\"codes\"`" + `
+	ShippedAt *time.Time   ` + "`dbfusion:\"shippedAt\"`" + `
+	CreatedAt time.Time    ` + "`dbfusion:\"createdAt\"`" + `
+	Note      string       ` + "`json:\"note\"`" + `
+	Skipped   string       ` + "`dbfusion:\"-\"`" + `
+}
+
+type Untagged struct {
+	Name string
+}
+
+type Page[T any] struct {
+	Items []T ` + "`dbfusion:\"items\"`" + `
+}
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "shop.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	packageName, models, imports, err := mapper.Parse(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packageName != "shop" || len(models) != 1 || models[0].Name != "Order" {
+		t.Fatalf("expected the Order model of package shop, got %s %+v", packageName, models)
+	}
+
+	expected := []mapper.Field{
+		{Name: "ID", Column: "_id", IsSet: "%s != oid.NilObjectID"},
+		{Name: "Total", Column: "total", IsSet: "%s != 0"},
+		{Name: "Paid", Column: "paid", IsSet: "%s"},
+		{Name: "Status", Column: "status", IsSet: "utils.GetInstance().IsSet(%s)"},
+		{Name: "Items", Column: "items", IsSet: "%s != nil"},
+		{Name: "Codes", Column: "codes", IsSet: "utils.GetInstance().IsSet(%s)"},
+		{Name: "ShippedAt", Column: "shippedAt", IsSet: "%s != nil"},
+		{Name: "CreatedAt", Column: "createdAt", IsSet: "%s != (time.Time{})"},
+		{Name: "Skipped", Column: "-", IsSet: `%s != ""`},
+	}
+	if !reflect.DeepEqual(models[0].Fields, expected) {
+		t.Errorf("expected the fields %+v, got %+v", expected, models[0].Fields)
+	}
+
+	expectedImports := map[string]string{
+		"time": "time",
+		"go.mongodb.org/mongo-driver/bson/primitive": "oid",
+		"github.com/glodb/dbfusion/utils":            "utils",
+	}
+	if !reflect.DeepEqual(imports, expectedImports) {
+		t.Errorf("expected the imports %v, got %v", expectedImports, imports)
+	}
+
+	generated, err := mapper.Generate(packageName, models, imports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{
+		"oid \"go.mongodb.org/mongo-driver/bson/primitive\"",
+		"func (o *Order) DBFusionPointers() []interface{} {",
+		"o.ID != oid.NilObjectID, o.Total != 0, o.Paid, utils.GetInstance().IsSet(o.Status)",
+	} {
+		if !strings.Contains(string(generated), part) {
+			t.Errorf("expected the source to contain %q, got:\n%s", part, generated)
+		}
+	}
+
+	if _, _, _, err := mapper.Parse(dir, "Missing"); err == nil {
+		t.Errorf("expected an error for a missing struct")
+	}
+}
+
+// TestGeneratedUpToDate tests that the mapper of the test model matches its struct.
+func TestGeneratedUpToDate(t *testing.T) {
+	packageName, parsed, imports, err := mapper.Parse("../models", "UserMapped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := mapper.Generate(packageName, parsed, imports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := os.ReadFile("../models/mapped_dbfusion.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(source) != string(committed) {
+		t.Errorf("the mapper of UserMapped is stale, run go generate in tests/models")
+	}
+}
+
+// TestMapper tests that the generated mapper reads the same values as the reflection over the tagged fields.
+func TestMapper(t *testing.T) {
+	testCases := []struct {
+		User models.UserMapped
+		Name string
+	}{
+		{
+			User: models.UserMapped{},
+			Name: "Zero values are not set",
+		},
+		{
+			User: models.UserMapped{
+				ID:        primitive.NewObjectID(),
+				FirstName: "Alice",
+				Email:     "alice@example.com",
+				Age:       30,
+				Verified:  true,
+				Tags:      []string{},
+				Address:   models.Address{City: "Lahore"},
+				Settings:  map[string]interface{}{"theme": "dark"},
+				CreatedAt: time.Now(),
+				Session:   "untagged",
+			},
+			Name: "Filled values are set",
+		},
+	}
+
+	for _, tc := range testCases {
+		var fieldMapper hooks.FieldMapper = tc.User
+		values, set := fieldMapper.DBFusionValues(), fieldMapper.DBFusionSet()
+
+		// Read the tagged fields with reflection.
+		dataValue := reflect.ValueOf(tc.User)
+		expectedColumns, expectedValues, expectedSet := make([]string, 0), make([]interface{}, 0), make([]bool, 0)
+		for i := 0; i < dataValue.NumField(); i++ {
+			column := strings.Split(dataValue.Type().Field(i).Tag.Get("dbfusion"), ",")[0]
+			if column == "" {
+				continue
+			}
+			expectedColumns = append(expectedColumns, column)
+			expectedValues = append(expectedValues, dataValue.Field(i).Interface())
+			expectedSet = append(expectedSet, !dataValue.Field(i).IsZero())
+		}
+
+		if !reflect.DeepEqual(fieldMapper.DBFusionColumns(), expectedColumns) {
+			t.Errorf("%s: expected the columns %v, got %v", tc.Name, expectedColumns, fieldMapper.DBFusionColumns())
+		}
+		if !reflect.DeepEqual(values, expectedValues) {
+			t.Errorf("%s: expected the values %v, got %v", tc.Name, expectedValues, values)
+		}
+		if !reflect.DeepEqual(set, expectedSet) {
+			t.Errorf("%s: expected the set fields %v, got %v", tc.Name, expectedSet, set)
+		}
+	}
+}
+
+// TestAssignTo tests that the values read from SQL rows are assigned like AssignData assigns them.
+func TestAssignTo(t *testing.T) {
+	testCases := []struct {
+		Raw  interface{}
+		Name string
+	}{
+		{Raw: []byte("Alice"), Name: "Text"},
+		{Raw: []byte("42"), Name: "Integer"},
+		{Raw: []byte("1"), Name: "Boolean"},
+		{Raw: nil, Name: "NULL"},
+	}
+
+	for _, tc := range testCases {
+		var fast, reflected struct {
+			Text    string
+			Integer int
+			Int64   int64
+			Float   float64
+			Boolean bool
+			Pointer *string
+		}
+		fastPointers := []interface{}{&fast.Text, &fast.Integer, &fast.Int64, &fast.Float, &fast.Boolean, &fast.Pointer}
+		reflectedValue := reflect.ValueOf(&reflected).Elem()
+		for i, pointer := range fastPointers {
+			raw := tc.Raw
+			utils.GetInstance().AssignTo(&raw, pointer)
+			reflectedRaw := tc.Raw
+			utils.GetInstance().AssignData(&reflectedRaw, reflectedValue.Field(i))
+		}
+		if !reflect.DeepEqual(fast, reflected) {
+			t.Errorf("%s: expected %+v, got %+v", tc.Name, reflected, fast)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package metadata_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/glodb/dbfusion/metadata"
+	"github.com/glodb/dbfusion/tests/models"
+	"github.com/glodb/dbfusion/utils"
+)
+
+// benchmarkUser is the model read by the benchmarks.
+var benchmarkUser = models.UseWithAddress{
+	FirstName: "Alice",
+	Email:     "alice@example.com",
+	Username:  "alice",
+	Password:  "secret",
+	Address:   models.Address{City: "Lahore"},
+	CreatedAt: 1700000000,
+}
+
+// BenchmarkTagValueMapParsed reads the tagged fields of a model parsing their tags on every read, as dbFusion did
+// before the registry.
+func BenchmarkTagValueMapParsed(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		dataValue := reflect.ValueOf(benchmarkUser)
+		dataType := dataValue.Type()
+		values := make(map[string]interface{})
+		for i := 0; i < dataType.NumField(); i++ {
+			tagName := strings.Split(dataType.Field(i).Tag.Get("dbfusion"), ",")[0]
+			if tagName != "" {
+				values[tagName] = dataValue.Field(i).Interface()
+			}
+		}
+	}
+}
+
+// BenchmarkTagValueMapRegistry reads the tagged fields of a model with the metadata of the registry.
+func BenchmarkTagValueMapRegistry(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		dataValue := reflect.ValueOf(benchmarkUser)
+		model, _ := metadata.GetInstance().Model(dataValue.Type())
+		values := make(map[string]interface{}, len(model.Fields))
+		for _, field := range model.Fields {
+			values[field.Name] = dataValue.Field(field.Index).Interface()
+		}
+	}
+}
+
+// BenchmarkScanParsed assigns the columns of a row to a model, looking the fields up by the parsed tags.
+func BenchmarkScanParsed(b *testing.B) {
+	columns, row := benchmarkRow()
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		element := reflect.New(reflect.TypeOf(models.UserTest{})).Elem()
+		fieldNames := make(map[string]string)
+		for i := 0; i < element.NumField(); i++ {
+			field := element.Type().Field(i)
+			if tagName := strings.Split(field.Tag.Get("dbfusion"), ",")[0]; tagName != "" {
+				fieldNames[tagName] = field.Name
+			}
+		}
+		for idx, name := range columns {
+			if fieldName, ok := fieldNames[name]; ok {
+				utils.GetInstance().AssignData(row[idx], element.FieldByName(fieldName))
+			}
+		}
+	}
+}
+
+// BenchmarkScanRegistry assigns the columns of a row to a model with the metadata of the registry.
+func BenchmarkScanRegistry(b *testing.B) {
+	columns, row := benchmarkRow()
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		element := reflect.New(reflect.TypeOf(models.UserTest{})).Elem()
+		model, _ := metadata.GetInstance().Model(element.Type())
+		for idx, name := range columns {
+			if field, ok := model.Field(name); ok {
+				utils.GetInstance().AssignField(row[idx], element, field)
+			}
+		}
+	}
+}
+
+// benchmarkRow returns the columns of a users row and their values as the MySQL driver scans them.
+func benchmarkRow() ([]string, []interface{}) {
+	columns := []string{"firstname", "email", "username", "password", "createdAt", "updatedAt"}
+	row := make([]interface{}, 0, len(columns))
+	for _, value := range []string{"Alice", "alice@example.com", "alice", "secret", "1700000000", "1700000000"} {
+		var scanned interface{} = []byte(value)
+		row = append(row, &scanned)
+	}
+	return columns, row
+}
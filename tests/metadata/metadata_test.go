@@ -0,0 +1,148 @@
+package metadata_test
+
+import (
+	"database/sql"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/glodb/dbfusion/metadata"
+	"github.com/glodb/dbfusion/schema"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+type address struct {
+	City string `dbfusion:"city"`
+}
+
+type order struct {
+	Reference string         `dbfusion:"reference,size:32,unique"`
+	ID        int64          `dbfusion:"id,pk:autoincr"`
+	Note      sql.NullString `dbfusion:"note,omitempty"`
+	Address   address        `dbfusion:"address"`
+	Secret    string         `dbfusion:"secret, encrypt:deterministic"`
+	Internal  string
+	Skipped   string `dbfusion:"-"`
+}
+
+type legacy struct {
+	Code  string `dbfusion:"code,VARCHAR(10),PRIMARY KEY"`
+	Key   string `dbfusion:"_id"`
+	Other string `dbfusion:"id"`
+}
+
+// TestModel tests the metadata read from the tags of the models.
+func TestModel(t *testing.T) {
+	testCases := []struct {
+		Model      interface{}
+		Columns    []string
+		PrimaryKey string
+		Name       string
+	}{
+		{
+			Model:      order{},
+			Columns:    []string{"reference", "id", "note", "address", "secret"},
+			PrimaryKey: "id",
+			Name:       "The pk option marks the primary key, fields tagged - are left out",
+		},
+		{
+			Model:      &[]legacy{},
+			Columns:    []string{"code", "_id", "id"},
+			PrimaryKey: "code",
+			Name:       "PRIMARY KEY is ranked before the default names",
+		},
+		{
+			Model:   models.UserTest{},
+			Columns: []string{"firstname", "email", "username", "password", "createdAt", "updatedAt"},
+			Name:    "Models without a primary key",
+		},
+	}
+
+	for _, tc := range testCases {
+		model, ok := metadata.GetInstance().Model(reflect.TypeOf(tc.Model))
+		if !ok {
+			t.Fatalf("%s: expected the metadata of the model", tc.Name)
+		}
+		if !reflect.DeepEqual(model.ColumnNames, tc.Columns) {
+			t.Errorf("%s: expected the columns %v, got %v", tc.Name, tc.Columns, model.ColumnNames)
+		}
+		primaryKey := ""
+		if model.PrimaryKey != nil {
+			primaryKey = model.PrimaryKey.Name
+		}
+		if primaryKey != tc.PrimaryKey {
+			t.Errorf("%s: expected the primary key %q, got %q", tc.Name, tc.PrimaryKey, primaryKey)
+		}
+	}
+
+	if _, ok := metadata.GetInstance().Model(reflect.TypeOf(map[string]interface{}{})); ok {
+		t.Errorf("expected no metadata for a map")
+	}
+}
+
+// TestField tests the options, types and nested models of the fields.
+func TestField(t *testing.T) {
+	model, _ := metadata.GetInstance().Model(reflect.TypeOf(order{}))
+
+	field, ok := model.Field("secret")
+	if !ok || field.Index != 4 || field.TypeName != "string" {
+		t.Fatalf("expected the secret field at index 4, got %+v", field)
+	}
+	if param, ok := field.Option("encrypt"); !ok || param != "deterministic" {
+		t.Errorf("expected the deterministic encrypt option, got %q %v", param, ok)
+	}
+	if field, ok := model.FieldWithOption("omitempty"); !ok || field.Name != "note" || !field.Scanner {
+		t.Errorf("expected the note field to be omitempty and to scan its values, got %+v", field)
+	}
+	if field, _ := model.Field("address"); field.Nested == nil || field.Nested.ColumnNames[0] != "city" {
+		t.Errorf("expected the nested model of the address field, got %+v", field.Nested)
+	}
+	if _, ok := model.Field("Internal"); ok {
+		t.Errorf("expected the untagged field to be left out")
+	}
+
+	columns := model.Columns(schema.MySQL)
+	if len(columns) != 5 || !columns[0].Unique || !columns[1].PrimaryKey {
+		t.Errorf("expected the SQL columns of the model, got %+v", columns)
+	}
+}
+
+// TestConcurrentModel tests that concurrent readers share the metadata of a type.
+func TestConcurrentModel(t *testing.T) {
+	type concurrent struct {
+		Name string `dbfusion:"name"`
+	}
+
+	models := make([]*metadata.Model, 16)
+	wg := sync.WaitGroup{}
+	for i := range models {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			models[i], _ = metadata.GetInstance().Model(reflect.TypeOf(concurrent{}))
+		}(i)
+	}
+	wg.Wait()
+
+	for _, model := range models {
+		if model != models[0] {
+			t.Fatalf("expected every reader to get the same metadata")
+		}
+	}
+}
+
+// TestEntity tests the models registered for the entities of the databases.
+func TestEntity(t *testing.T) {
+	metadata.GetInstance().RegisterEntity("entityDB", "orders", reflect.TypeOf(&order{}))
+	metadata.GetInstance().RegisterEntity("entityDB", "addresses", reflect.TypeOf(map[string]interface{}{}))
+
+	if model, ok := metadata.GetInstance().Entity("entityDB", "orders"); !ok || model.Type != reflect.TypeOf(order{}) {
+		t.Errorf("expected the model of the orders, got %+v", model)
+	}
+	if _, ok := metadata.GetInstance().Entity("otherDB", "orders"); ok {
+		t.Errorf("expected the entities of another database to be left out")
+	}
+	if _, ok := metadata.GetInstance().Entity("entityDB", "addresses"); ok {
+		t.Errorf("expected no model for an entity registered with a map")
+	}
+}
@@ -0,0 +1,214 @@
+package migrations_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/migrations"
+)
+
+// up is a migration step doing nothing.
+func up(ctx context.Context, con connections.Connection) error {
+	return nil
+}
+
+// writeFiles creates a directory holding files with the given names.
+func writeFiles(t *testing.T, names ...string) string {
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestReadDir tests that migration files are listed by version and that invalid names are rejected.
+func TestReadDir(t *testing.T) {
+	testCases := []struct {
+		Files    []string
+		Expected []migrations.File
+		Err      error
+		Name     string
+	}{
+		{
+			Files: []string{"2_add_index.up.sql", "1_create_users.down.sql", "1_create_users.up.sql", "README.md"},
+			Expected: []migrations.File{
+				{Version: 1, Name: "create_users", Direction: migrations.DirectionDown, Extension: migrations.ExtensionSQL, Path: "1_create_users.down.sql"},
+				{Version: 1, Name: "create_users", Direction: migrations.DirectionUp, Extension: migrations.ExtensionSQL, Path: "1_create_users.up.sql"},
+				{Version: 2, Name: "add_index", Direction: migrations.DirectionUp, Extension: migrations.ExtensionSQL, Path: "2_add_index.up.sql"},
+			},
+			Name: "Files ordered by version",
+		},
+		{
+			Files: []string{"20240101120000_validator.up.json", "20240101120100_backfill.up.js"},
+			Expected: []migrations.File{
+				{Version: 20240101120000, Name: "validator", Direction: migrations.DirectionUp, Extension: migrations.ExtensionJSON, Path: "20240101120000_validator.up.json"},
+				{Version: 20240101120100, Name: "backfill", Direction: migrations.DirectionUp, Extension: migrations.ExtensionJavaScript, Path: "20240101120100_backfill.up.js"},
+			},
+			Name: "MongoDB files",
+		},
+		{
+			Files: []string{"first_create_users.up.sql"},
+			Err:   dbfusionErrors.ErrMigrationFileNotSupported,
+			Name:  "Invalid version",
+		},
+		{
+			Files: []string{"1_create_users.up.yaml"},
+			Err:   dbfusionErrors.ErrMigrationFileNotSupported,
+			Name:  "Unknown extension",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			dir := writeFiles(t, tc.Files...)
+			files, err := migrations.ReadDir(dir)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("Expected error %v, got %v", tc.Err, err)
+			}
+			for i := range tc.Expected {
+				tc.Expected[i].Path = filepath.Join(dir, tc.Expected[i].Path)
+			}
+			if tc.Err == nil && !reflect.DeepEqual(files, tc.Expected) {
+				t.Errorf("Expected %v, got %v", tc.Expected, files)
+			}
+		})
+	}
+}
+
+// TestSplitSQL tests that scripts are split on the semicolons ending a line.
+func TestSplitSQL(t *testing.T) {
+	testCases := []struct {
+		Script   string
+		Expected []string
+		Name     string
+	}{
+		{
+			Script:   "ALTER TABLE users ADD COLUMN nickname VARCHAR(255);\nCREATE INDEX nickname ON users (nickname);\n",
+			Expected: []string{"ALTER TABLE users ADD COLUMN nickname VARCHAR(255)", "CREATE INDEX nickname ON users (nickname)"},
+			Name:     "One statement per line",
+		},
+		{
+			Script:   "-- users\nCREATE TABLE users (\n  id INT,\n  note VARCHAR(10) DEFAULT 'a;b'\n);\n",
+			Expected: []string{"CREATE TABLE users (\n  id INT,\n  note VARCHAR(10) DEFAULT 'a;b'\n)"},
+			Name:     "Statement over several lines with a comment",
+		},
+		{
+			Script:   "DROP TABLE users",
+			Expected: []string{"DROP TABLE users"},
+			Name:     "Missing semicolon",
+		},
+		{
+			Script:   "-- nothing to do\n",
+			Expected: []string{},
+			Name:     "Only comments",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			statements := migrations.SplitSQL(tc.Script)
+			if !reflect.DeepEqual(statements, tc.Expected) {
+				t.Errorf("Expected %q, got %q", tc.Expected, statements)
+			}
+		})
+	}
+}
+
+// TestParseCommands tests that command documents keep the order of their keys.
+func TestParseCommands(t *testing.T) {
+	testCases := []struct {
+		Script   string
+		Expected []string
+		Err      bool
+		Name     string
+	}{
+		{
+			Script:   `{"collMod": "users", "validationLevel": "moderate"}`,
+			Expected: []string{"collMod"},
+			Name:     "Single command",
+		},
+		{
+			Script:   `[{"create": "orders"}, {"createIndexes": "orders", "indexes": [{"key": {"userId": 1}, "name": "userId"}]}]`,
+			Expected: []string{"create", "createIndexes"},
+			Name:     "Array of commands",
+		},
+		{
+			Script: `{"collMod": `,
+			Err:    true,
+			Name:   "Invalid JSON",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			commands, err := migrations.ParseCommands([]byte(tc.Script))
+			if (err != nil) != tc.Err {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			names := make([]string, 0)
+			for _, command := range commands {
+				names = append(names, command[0].Key)
+			}
+			if !tc.Err && !reflect.DeepEqual(names, tc.Expected) {
+				t.Errorf("Expected %v, got %v", tc.Expected, names)
+			}
+		})
+	}
+}
+
+// TestAdd tests that migrations need a version and an up step and that versions are unique.
+func TestAdd(t *testing.T) {
+	migrator := migrations.NewMigrator(nil, migrations.Options{})
+	if err := migrator.Add(migrations.Migration{Version: 1, Name: "create_users", Up: up}); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Migration migrations.Migration
+		Err       error
+		Name      string
+	}{
+		{
+			Migration: migrations.Migration{Version: 2, Name: "add_index", Up: up},
+			Name:      "New version",
+		},
+		{
+			Migration: migrations.Migration{Version: 1, Name: "create_orders", Up: up},
+			Err:       dbfusionErrors.ErrMigrationVersionConflict,
+			Name:      "Duplicated version",
+		},
+		{
+			Migration: migrations.Migration{Version: 3, Name: "no_up"},
+			Err:       dbfusionErrors.ErrMigrationInvalid,
+			Name:      "Missing up step",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := migrator.Add(tc.Migration); !errors.Is(err, tc.Err) {
+				t.Errorf("Expected error %v, got %v", tc.Err, err)
+			}
+		})
+	}
+	if len(migrator.Migrations()) != 2 {
+		t.Errorf("Expected 2 migrations, got %d", len(migrator.Migrations()))
+	}
+}
+
+// TestLoadDirUnsupported tests that files the connection can't run are rejected when they are loaded.
+func TestLoadDirUnsupported(t *testing.T) {
+	migrator := migrations.NewMigrator(nil, migrations.Options{})
+	err := migrator.LoadDir(writeFiles(t, "1_create_users.up.sql"))
+	if !errors.Is(err, dbfusionErrors.ErrMigrationFileNotSupported) {
+		t.Errorf("Expected %v, got %v", dbfusionErrors.ErrMigrationFileNotSupported, err)
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:generate go run ../../cmd/dbfusion mapper -type UserMapped -out mapped_dbfusion.go
+
+// UserMapped is a user whose fields are read and written by its generated mapper instead of reflection.
+type UserMapped struct {
+	ID        primitive.ObjectID     `dbfusion:"_id,pk"`
+	FirstName string                 `dbfusion:"firstname"`
+	Email     string                 `dbfusion:"email"`
+	Age       int                    `dbfusion:"age"`
+	Verified  bool                   `dbfusion:"verified"`
+	Tags      []string               `dbfusion:"tags,omitempty"`
+	Address   Address                `dbfusion:"address,omitempty"`
+	Settings  map[string]interface{} `dbfusion:"settings,omitempty"`
+	CreatedAt time.Time              `dbfusion:"createdAt,autoCreateTime"`
+	Session   string
+}
+
+func (u UserMapped) GetEntityName() string {
+	return "usersMapped"
+}
+
+func (u UserMapped) GetCacheIndexes() []string {
+	return []string{"email"}
+}
@@ -1,12 +1,16 @@
 package models
 
 import (
+	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"time"
 
 	"github.com/glodb/dbfusion/hooks"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserTest struct {
@@ -114,3 +118,307 @@ func (ne UserCreateTable) GetHashedIndexes() []string {
 // func (ne UserCreateTable) GetSparseIndexes() []string {
 // 	return []string{"email"}
 // }
+
+type UserCachePolicy struct {
+	FirstName string `dbfusion:"firstname"`
+	Email     string `dbfusion:"email"`
+	Username  string `dbfusion:"username"`
+	Password  string `dbfusion:"password"`
+	CreatedAt int64  `dbfusion:"createdAt"`
+	UpdatedAt int64  `dbfusion:"updatedAt"`
+}
+
+func (u UserCachePolicy) GetEntityName() string {
+	return "users"
+}
+
+func (u UserCachePolicy) GetCacheIndexes() []string {
+	return []string{"email", "email,username"}
+}
+
+func (u UserCachePolicy) GetCachePolicy() hooks.CachePolicy {
+	return hooks.ReadThrough | hooks.WriteBehind
+}
+
+var ErrHookRejected = errors.New("rejected by hook")
+
+type UserHooks struct {
+	FirstName string `dbfusion:"firstname"`
+	Email     string `dbfusion:"email"`
+	Username  string `dbfusion:"username"`
+	Password  string `dbfusion:"password"`
+	CreatedAt int64  `dbfusion:"createdAt"`
+	UpdatedAt int64  `dbfusion:"updatedAt"`
+}
+
+func (u UserHooks) GetEntityName() string {
+	return "users"
+}
+
+func (u UserHooks) BeforeInsert(ctx context.Context) error {
+	if u.Email == "" {
+		return ErrHookRejected
+	}
+	return ctx.Err()
+}
+
+func (u UserHooks) AfterInsert(ctx context.Context) error {
+	if u.Username == "reject-after" {
+		return ErrHookRejected
+	}
+	return nil
+}
+
+func (u UserHooks) UseTransaction() bool {
+	return true
+}
+
+type UserValidated struct {
+	FirstName string           `dbfusion:"firstname" validate:"required,min=2,max=50"`
+	Email     string           `dbfusion:"email" validate:"required,email"`
+	Username  string           `dbfusion:"username" validate:"regex=^[a-z0-9_]{3\\,20}$"`
+	Role      string           `dbfusion:"role" validate:"oneof=admin member"`
+	Phone     string           `dbfusion:"phone" validate:"len=11"`
+	Age       int              `dbfusion:"age" validate:"min=18,max=130"`
+	Tags      []string         `dbfusion:"tags" validate:"max=3"`
+	Address   ValidatedAddress `dbfusion:"address"`
+}
+
+type ValidatedAddress struct {
+	City       string `dbfusion:"city" validate:"required"`
+	PostalCode string `dbfusion:"postCode" validate:"len=5"`
+}
+
+func (u UserValidated) GetEntityName() string {
+	return "users"
+}
+
+type UserTimestamps struct {
+	FirstName string    `dbfusion:"firstname"`
+	Email     string    `dbfusion:"email"`
+	CreatedAt int64     `dbfusion:"createdAt,autoCreateTime"`
+	UpdatedAt int64     `dbfusion:"updatedAt,autoUpdateTime:milli"`
+	SeenAt    time.Time `dbfusion:"seenAt,autoUpdateTime"`
+}
+
+func (u UserTimestamps) GetEntityName() string {
+	return "usersTimestamps"
+}
+
+type UserSoftDelete struct {
+	FirstName string `dbfusion:"firstname"`
+	Email     string `dbfusion:"email"`
+	DeletedAt int64  `dbfusion:"deletedAt,softdelete:milli"`
+}
+
+func (u UserSoftDelete) GetEntityName() string {
+	return "usersSoftDelete"
+}
+
+type UserVersioned struct {
+	FirstName string `dbfusion:"firstname"`
+	Email     string `dbfusion:"email"`
+	Version   int64  `dbfusion:"version,version"`
+}
+
+func (u UserVersioned) GetEntityName() string {
+	return "usersVersioned"
+}
+
+type UserWithID struct {
+	ID        primitive.ObjectID `dbfusion:"_id,pk"`
+	FirstName string             `dbfusion:"firstname"`
+	Email     string             `dbfusion:"email"`
+}
+
+func (u UserWithID) GetEntityName() string {
+	return "usersWithID"
+}
+
+type UserKeyed struct {
+	ID        int64  `dbfusion:"id,pk:autoincr"`
+	FirstName string `dbfusion:"firstname,size:255"`
+	Email     string `dbfusion:"email,size:255"`
+	Age       int64  `dbfusion:"age"`
+}
+
+func (u UserKeyed) GetEntityName() string {
+	return "usersKeyed"
+}
+
+func (u UserKeyed) GetCacheIndexes() []string {
+	return []string{"email"}
+}
+
+type UserAudited struct {
+	ID        primitive.ObjectID `dbfusion:"_id,pk"`
+	FirstName string             `dbfusion:"firstname"`
+	Email     string             `dbfusion:"email"`
+}
+
+func (u UserAudited) GetEntityName() string {
+	return "usersAudited"
+}
+
+func (u UserAudited) UseAudit() bool {
+	return true
+}
+
+type UserAuditedKeyed struct {
+	ID        int64  `dbfusion:"id,pk:autoincr"`
+	FirstName string `dbfusion:"firstname,size:255"`
+	Email     string `dbfusion:"email,size:255"`
+}
+
+func (u UserAuditedKeyed) GetEntityName() string {
+	return "usersAuditedKeyed"
+}
+
+func (u UserAuditedKeyed) UseAudit() bool {
+	return true
+}
+
+type UserHistorical struct {
+	ID        primitive.ObjectID `dbfusion:"_id,pk"`
+	FirstName string             `dbfusion:"firstname"`
+	Email     string             `dbfusion:"email"`
+	CreatedAt time.Time          `dbfusion:"createdAt,autoCreateTime"`
+}
+
+func (u UserHistorical) GetEntityName() string {
+	return "usersHistorical"
+}
+
+func (u UserHistorical) UseHistory() bool {
+	return true
+}
+
+type UserHistoricalKeyed struct {
+	ID        int64  `dbfusion:"id,pk:autoincr"`
+	FirstName string `dbfusion:"firstname,size:255"`
+	Email     string `dbfusion:"email,size:255"`
+	CreatedAt int64  `dbfusion:"createdAt,autoCreateTime:milli"`
+}
+
+func (u UserHistoricalKeyed) GetEntityName() string {
+	return "usersHistoricalKeyed"
+}
+
+func (u UserHistoricalKeyed) UseHistory() bool {
+	return true
+}
+
+type UserEncrypted struct {
+	ID         primitive.ObjectID `dbfusion:"_id,pk"`
+	Email      string             `dbfusion:"email"`
+	NationalID string             `dbfusion:"nationalId,encrypt:deterministic"`
+	Phone      string             `dbfusion:"phone,encrypt"`
+}
+
+func (u UserEncrypted) GetEntityName() string {
+	return "usersEncrypted"
+}
+
+type UserEncryptedKeyed struct {
+	ID         int64  `dbfusion:"id,pk:autoincr"`
+	Email      string `dbfusion:"email,size:255"`
+	NationalID string `dbfusion:"nationalId,size:255,encrypt:deterministic"`
+	Phone      string `dbfusion:"phone,size:255,encrypt"`
+}
+
+func (u UserEncryptedKeyed) GetEntityName() string {
+	return "usersEncryptedKeyed"
+}
+
+type UserEncryptedCached struct {
+	ID         primitive.ObjectID `dbfusion:"_id,pk"`
+	Email      string             `dbfusion:"email"`
+	NationalID string             `dbfusion:"nationalId,encrypt:deterministic"`
+	Phone      string             `dbfusion:"phone,encrypt"`
+}
+
+func (u UserEncryptedCached) GetEntityName() string {
+	return "usersEncryptedCached"
+}
+
+func (u UserEncryptedCached) GetCacheIndexes() []string {
+	return []string{"email"}
+}
+
+type UserAutoMigrate struct {
+	Id        int    `dbfusion:"id,INT,AUTO_INCREMENT,PRIMARY KEY"`
+	Email     string `dbfusion:"email,VARCHAR(255),NOT NULL,UNIQUE"`
+	Phone     string `dbfusion:"phone,VARCHAR(32)"`
+	Nickname  string `dbfusion:"nickname,VARCHAR(50),NOT NULL,DEFAULT ''"`
+	CreatedAt int64  `dbfusion:"createdAt,BIGINT,autoCreateTime"`
+}
+
+func (u UserAutoMigrate) GetEntityName() string {
+	return "usersAutoMigrate"
+}
+
+type UserIndexes struct {
+	Id        int    `dbfusion:"id,pk:autoincr"`
+	Email     string `dbfusion:"email,size:255,unique"`
+	Phone     string `dbfusion:"phone,size:32"`
+	Bio       string `dbfusion:"bio,size:1000"`
+	CreatedAt int64  `dbfusion:"createdAt"`
+}
+
+func (u UserIndexes) GetEntityName() string {
+	return "usersIndexes"
+}
+
+func (u UserIndexes) GetNormalIndexes() []string {
+	return []string{"byPhone=phone:1,createdAt:-1", "createdAt:-1"}
+}
+
+func (u UserIndexes) GetUniqueIndexes() []string {
+	return []string{"email:1"}
+}
+
+func (u UserIndexes) GetTextIndex() string {
+	return "bio"
+}
+
+func (u UserIndexes) GetHashedIndexes() []string {
+	return []string{"phone"}
+}
+
+type UserRawIndexes struct {
+	Id       int    `dbfusion:"id,INT AUTO_INCREMENT PRIMARY KEY"`
+	Username string `dbfusion:"username,VARCHAR(64) NOT NULL UNIQUE"`
+}
+
+func (u UserRawIndexes) GetEntityName() string {
+	return "usersRawIndexes"
+}
+
+type UserSession struct {
+	Token     string                 `dbfusion:"token" bson:"token"`
+	Email     string                 `dbfusion:"email" bson:"email"`
+	Active    bool                   `dbfusion:"active" bson:"active"`
+	Device    map[string]interface{} `dbfusion:"device" bson:"device"`
+	CreatedAt time.Time              `dbfusion:"createdAt" bson:"createdAt"`
+}
+
+func (u UserSession) GetEntityName() string {
+	return "userSessions"
+}
+
+func (u UserSession) GetIndexSpecs() []hooks.IndexSpec {
+	expiry := int32(3600)
+	return []hooks.IndexSpec{
+		{Keys: "createdAt:1", ExpireAfterSeconds: &expiry},
+		{Name: "activeEmail", Keys: "email:1", Unique: true, PartialFilterExpression: primitive.M{"active": true}},
+		{Keys: "token:1", Collation: &hooks.Collation{Locale: "en", Strength: 2}},
+		{Keys: "device.$**"},
+		{Name: "hiddenToken", Keys: "token:-1", Hidden: true},
+	}
+}
+
+type UserSchema UserValidated
+
+func (u UserSchema) GetEntityName() string {
+	return "usersValidated"
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/glodb/dbfusion"
 	"github.com/glodb/dbfusion/caches"
 	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/ftypes"
 	"github.com/glodb/dbfusion/tests/models"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -146,3 +148,36 @@ func TestMongoAggregatePagination(t *testing.T) {
 		})
 	}
 }
+
+func TestMongoAggregatePaginationSoftDelete(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options := dbfusion.Options{
+		DbName: &validDBName,
+		Uri:    &validUri,
+	}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with error: %v", err)
+	}
+
+	where := ftypes.QMap{"firstname": "SoftPaginate"}
+	con.Where(where).HardDelete().DeleteMany(&models.UserSoftDelete{})
+	for _, email := range []string{"paginate-one@dbfusion.test", "paginate-two@dbfusion.test", "paginate-three@dbfusion.test"} {
+		if err := con.InsertOne(&models.UserSoftDelete{FirstName: "SoftPaginate", Email: email}); err != nil {
+			t.Fatalf("Insertion failed with %v", err)
+		}
+	}
+	if err := con.DeleteOne(&models.UserSoftDelete{Email: "paginate-one@dbfusion.test"}); err != nil {
+		t.Fatalf("Deletion failed with %v", err)
+	}
+
+	// The page and its count leave out the deleted document for results decoded into maps.
+	results := []bson.M{}
+	pagination, err := con.Table("usersSoftDelete").Match(primitive.M{"firstname": "SoftPaginate"}).AggregatePaginate(&results, 1)
+	if err != nil || pagination.TotalDocuments != 2 || len(results) != 2 {
+		t.Errorf("Expected 2 documents, found %d of %d %v", len(results), pagination.TotalDocuments, err)
+	}
+
+	con.Where(where).HardDelete().DeleteMany(&models.UserSoftDelete{})
+}
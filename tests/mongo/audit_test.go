@@ -0,0 +1,88 @@
+package mongotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/audit"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestMongoAudit(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	auditEntity := "usersAuditTrail"
+	options :=
+		dbfusion.Options{
+			DbName:      &validDBName,
+			Uri:         &validUri,
+			AuditEntity: &auditEntity,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+	// The connection may have been created by another test without the audit entity.
+	con.SetAuditEntity(auditEntity)
+
+	where := ftypes.QMap{"email": "audit@dbfusion.test"}
+	con.Where(where).DeleteMany(&models.UserAudited{})
+	con.Table(auditEntity).Where(ftypes.QMap{"entity": "usersAudited"}).DeleteMany()
+
+	ctx := audit.WithActor(context.Background(), "auditor@dbfusion.test")
+	inserted := models.UserAudited{FirstName: "Audit", Email: "audit@dbfusion.test"}
+	if err := con.WithContext(ctx).InsertOne(&inserted); err != nil {
+		t.Fatalf("Insertion failed with %v", err)
+	}
+	updated := models.UserAudited{}
+	if err := con.WithContext(ctx).UpdateByID(inserted.ID, ftypes.QMap{"firstname": "Audited"}, &updated); err != nil {
+		t.Fatalf("Update failed with %v", err)
+	}
+	if err := con.WithContext(ctx).DeleteByID(inserted.ID, &models.UserAudited{}); err != nil {
+		t.Fatalf("Deletion failed with %v", err)
+	}
+
+	testCases := []struct {
+		Operation audit.Operation
+		Field     string
+		Change    audit.Change
+		Name      string
+	}{
+		{
+			Operation: audit.Insert,
+			Field:     "firstname",
+			Change:    audit.Change{After: "Audit"},
+			Name:      "Insertion is recorded",
+		},
+		{
+			Operation: audit.Update,
+			Field:     "firstname",
+			Change:    audit.Change{Before: "Audit", After: "Audited"},
+			Name:      "Update is recorded with the values before and after",
+		},
+		{
+			Operation: audit.Delete,
+			Field:     "firstname",
+			Change:    audit.Change{Before: "Audited"},
+			Name:      "Deletion is recorded",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			entries := []audit.Entry{}
+			err := con.Table(auditEntity).Where(ftypes.QMap{"entityId": inserted.ID, "operation": tc.Operation}).FindMany(&entries)
+			if err != nil || len(entries) != 1 {
+				t.Fatalf("Expected one entry, found %d %v", len(entries), err)
+			}
+			entry := entries[0]
+			if entry.Actor != "auditor@dbfusion.test" || entry.Changes[tc.Field] != tc.Change {
+				t.Errorf("Expected %v by the auditor, found %+v", tc.Change, entry)
+			}
+		})
+	}
+
+	con.Table(auditEntity).Where(ftypes.QMap{"entity": "usersAudited"}).DeleteMany()
+}
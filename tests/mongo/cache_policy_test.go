@@ -0,0 +1,89 @@
+package mongotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestMongoCachePolicy(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	cache := caches.RedisCache{}
+	err := cache.ConnectCache("localhost:6379")
+	if err != nil {
+		t.Errorf("Error in redis connection, occurred %v", err)
+	}
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+			Cache:  &cache,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Errorf("DBConnection failed with %v", err)
+	}
+
+	testCases := []struct {
+		Con  connections.MongoConnection
+		Data *models.UserCachePolicy
+		Name string
+	}{
+		{
+			Con: con,
+			Data: &models.UserCachePolicy{
+				FirstName: "WriteBehind",
+				Email:     "writebehind@dbfusion.test",
+				Username:  "writebehind",
+				Password:  "change-me",
+			},
+			Name: "Write behind insertion is cached, written and read through",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Con.InsertOne(tc.Data)
+			if err != nil {
+				t.Fatalf("Error in write behind insertion %v", err)
+			}
+
+			// The record is served from the cache before it reaches the database.
+			cached := models.UserCachePolicy{}
+			err = tc.Con.Where(ftypes.QMap{"email": tc.Data.Email}).FindOne(&cached)
+			if err != nil || cached.Email != tc.Data.Email {
+				t.Errorf("Expected the record in the cache, got %v %v", cached, err)
+			}
+
+			// Wait for the worker to write the record and read it from the database.
+			stored := models.UserCachePolicy{}
+			for attempt := 0; attempt < 20; attempt++ {
+				err = tc.Con.Where(ftypes.QMap{"email": tc.Data.Email}).FindOne(&stored, queryoptions.FindOptions{ForceDB: true})
+				if err == nil {
+					break
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+			if err != nil || stored.Email != tc.Data.Email {
+				t.Errorf("Expected the record in the database, got %v %v", stored, err)
+			}
+
+			// Deleting through the write-behind model could overtake queued insertions.
+			err = tc.Con.Where(ftypes.QMap{"email": tc.Data.Email}).DeleteOne(&models.UserCachePolicy{})
+			if !errors.Is(err, dbfusionErrors.ErrWriteBehindNotSupported) {
+				t.Errorf("Expected ErrWriteBehindNotSupported, got %v", err)
+			}
+
+			tc.Con.Where(ftypes.QMap{"email": tc.Data.Email}).DeleteOne(&models.UserTest{})
+		})
+	}
+}
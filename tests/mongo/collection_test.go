@@ -0,0 +1,65 @@
+package mongotest
+
+import (
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/tests/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoCreateCollection(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+	con.RunCommand(primitive.D{{Key: "drop", Value: "usersValidated"}}, nil)
+
+	testCases := []struct {
+		Options   queryoptions.CreateCollectionOptions
+		Document  map[string]interface{}
+		ExpectErr bool
+		Name      string
+	}{
+		{
+			Document:  map[string]interface{}{"firstname": "Aafaq", "email": "aafaqzahid9@gmail.com", "role": "admin"},
+			ExpectErr: false,
+			Name:      "Create the collection and insert a valid document",
+		},
+		{
+			Document:  map[string]interface{}{"firstname": "Aafaq", "email": "aafaqzahid9@gmail.com", "role": "owner"},
+			ExpectErr: true,
+			Name:      "Document outside of the enum rejected",
+		},
+		{
+			Document:  map[string]interface{}{"firstname": "Aafaq"},
+			ExpectErr: true,
+			Name:      "Document without a required field rejected",
+		},
+		{
+			Options:   queryoptions.CreateCollectionOptions{ValidationAction: queryoptions.ValidationActionWarn},
+			Document:  map[string]interface{}{"firstname": "Aafaq"},
+			ExpectErr: false,
+			Name:      "Existing collection only warning",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := con.CreateCollection(models.UserSchema{}, tc.Options); err != nil {
+				t.Fatalf("CreateCollection failed with %v", err)
+			}
+			err := con.Table("usersValidated").InsertOne(tc.Document)
+			if (err != nil) != tc.ExpectErr {
+				t.Errorf("Expected an error %v, got %v", tc.ExpectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package mongotest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/tests/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoDescribeCollection(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	// Documents with a field missing from one and a number stored as an integer and as a double.
+	con.RunCommand(primitive.D{{Key: "drop", Value: "userSessions"}}, nil)
+	if err := con.CreateIndexes(models.UserSession{}); err != nil {
+		t.Fatalf("Index creation failed with %v", err)
+	}
+	con.Table("userSessions").InsertOne(map[string]interface{}{"token": "a", "email": "aafaqzahid9@gmail.com", "score": int32(1)})
+	con.Table("userSessions").InsertOne(map[string]interface{}{"token": "b", "score": 1.5})
+
+	table, err := con.DescribeCollection("userSessions", 0)
+	if err != nil {
+		t.Fatalf("DescribeCollection failed with %v", err)
+	}
+
+	testCases := []struct {
+		Field             string
+		ExpectedType      reflect.Type
+		ExpectedOmitEmpty bool
+		Name              string
+	}{
+		{Field: "_id", ExpectedType: reflect.TypeOf(primitive.ObjectID{}), ExpectedOmitEmpty: false, Name: "Object ID"},
+		{Field: "token", ExpectedType: reflect.TypeOf(""), ExpectedOmitEmpty: false, Name: "Field of every document"},
+		{Field: "email", ExpectedType: reflect.TypeOf(""), ExpectedOmitEmpty: true, Name: "Field missing from a document"},
+		{Field: "score", ExpectedType: reflect.TypeOf(float64(0)), ExpectedOmitEmpty: false, Name: "Integer and double widened"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			for _, column := range table.Columns {
+				if column.Name != tc.Field {
+					continue
+				}
+				if column.GoType != tc.ExpectedType || column.OmitEmpty != tc.ExpectedOmitEmpty {
+					t.Errorf("Expected %v with omitempty %v, got %v with omitempty %v", tc.ExpectedType, tc.ExpectedOmitEmpty, column.GoType, column.OmitEmpty)
+				}
+				return
+			}
+			t.Errorf("Field %s not described", tc.Field)
+		})
+	}
+	if len(table.Indexes) != len(models.UserSession{}.GetIndexSpecs()) {
+		t.Errorf("Expected %d indexes, got %+v", len(models.UserSession{}.GetIndexSpecs()), table.Indexes)
+	}
+}
@@ -0,0 +1,158 @@
+package mongotest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/encryption"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoEncryption(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	provider := encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": []byte("0123456789abcdef0123456789abcdef")})
+	options :=
+		dbfusion.Options{
+			DbName:      &validDBName,
+			Uri:         &validUri,
+			KeyProvider: provider,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+	// The connection may have been created by another test without the key provider.
+	con.SetKeyProvider(provider)
+
+	where := ftypes.QMap{"email": "encrypted@dbfusion.test"}
+	con.Where(where).DeleteMany(&models.UserEncrypted{})
+
+	inserted := models.UserEncrypted{Email: "encrypted@dbfusion.test", NationalID: "AB123456", Phone: "+1 555 0100"}
+	if err := con.InsertOne(&inserted); err != nil {
+		t.Fatalf("Insertion failed with %v", err)
+	}
+	if inserted.NationalID != "AB123456" {
+		t.Errorf("Expected the data to keep its plaintext, got %s", inserted.NationalID)
+	}
+
+	nationalID, err := con.EncryptLookup("AB123456")
+	if err != nil {
+		t.Fatalf("Lookup encryption failed with %v", err)
+	}
+
+	testCases := []struct {
+		Where ftypes.QMap
+		Name  string
+	}{
+		{
+			Where: where,
+			Name:  "Find by a plain field",
+		},
+		{
+			Where: ftypes.QMap{"nationalId": nationalID},
+			Name:  "Find by a deterministic field",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			found := models.UserEncrypted{}
+			if err := con.Where(tc.Where).FindOne(&found); err != nil {
+				t.Fatalf("Find failed with %v", err)
+			}
+			if found.NationalID != "AB123456" || found.Phone != "+1 555 0100" {
+				t.Errorf("Expected the decrypted values, found %+v", found)
+			}
+		})
+	}
+
+	stored := []primitive.M{}
+	if err := con.Table("usersEncrypted").Where(where).FindMany(&stored); err != nil || len(stored) != 1 {
+		t.Fatalf("Expected one document, found %d %v", len(stored), err)
+	}
+	for _, field := range []string{"nationalId", "phone"} {
+		if value, _ := stored[0][field].(string); !strings.HasPrefix(value, encryption.Prefix) {
+			t.Errorf("Expected %s to be stored encrypted, got %v", field, stored[0][field])
+		}
+	}
+
+	updated := models.UserEncrypted{}
+	err = con.UpdateByID(inserted.ID, ftypes.QMap{"phone": "+1 555 0199"}, &updated)
+	if err != nil || updated.Phone != "+1 555 0199" {
+		t.Errorf("Update failed with %v, found %+v", err, updated)
+	}
+
+	// A value which only looks like a ciphertext is encrypted and reads back as it was written.
+	lookalike := encryption.Prefix + "v1:not-a-ciphertext"
+	err = con.UpdateByID(inserted.ID, ftypes.QMap{"phone": lookalike}, &updated)
+	if err != nil || updated.Phone != lookalike {
+		t.Errorf("Update failed with %v, found %+v", err, updated)
+	}
+	found := models.UserEncrypted{}
+	if err := con.Where(where).FindOne(&found); err != nil || found.Phone != lookalike {
+		t.Errorf("Expected %s, found %+v %v", lookalike, found, err)
+	}
+}
+
+func TestMongoEncryptionCache(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	cache := caches.RedisCache{}
+	if err := cache.ConnectCache("localhost:6379"); err != nil {
+		t.Fatalf("Error in redis connection, occurred %v", err)
+	}
+	provider := encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": []byte("0123456789abcdef0123456789abcdef")})
+	options :=
+		dbfusion.Options{
+			DbName:      &validDBName,
+			Uri:         &validUri,
+			Cache:       &cache,
+			KeyProvider: provider,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+	con.SetKeyProvider(provider)
+
+	email := "encrypted-cached@dbfusion.test"
+	con.Where(ftypes.QMap{"email": email}).DeleteMany(&models.UserEncryptedCached{})
+
+	inserted := models.UserEncryptedCached{Email: email, NationalID: "AB123456", Phone: "+1 555 0100"}
+	if err := con.InsertOne(&inserted); err != nil {
+		t.Fatalf("Insertion failed with %v", err)
+	}
+	updated := models.UserEncryptedCached{}
+	if err := con.UpdateByID(inserted.ID, ftypes.QMap{"phone": "+1 555 0199"}, &updated); err != nil {
+		t.Fatalf("Update failed with %v", err)
+	}
+
+	// The payload cached by the update holds the ciphertexts.
+	payloadKey, err := cache.GetKey(caches.GetInstance().IndexKey(validDBName, "usersEncryptedCached", "email="+email))
+	if err != nil || payloadKey == nil {
+		t.Fatalf("Expected the record to be cached, found %v %v", payloadKey, err)
+	}
+	payload, err := cache.GetKey(string(payloadKey.([]byte)))
+	if err != nil || payload == nil {
+		t.Fatalf("Expected the payload to be cached, found %v %v", payload, err)
+	}
+	for _, plaintext := range []string{"AB123456", "+1 555 0199"} {
+		if strings.Contains(string(payload.([]byte)), plaintext) {
+			t.Errorf("Expected the cache to hold the ciphertext of %s, found %s", plaintext, payload)
+		}
+	}
+
+	// The record read from the cache is decrypted.
+	found := models.UserEncryptedCached{}
+	if err := con.Where(ftypes.QMap{"email": email}).FindOne(&found); err != nil {
+		t.Fatalf("Find failed with %v", err)
+	}
+	if found.NationalID != "AB123456" || found.Phone != "+1 555 0199" {
+		t.Errorf("Expected the decrypted values, found %+v", found)
+	}
+}
@@ -0,0 +1,99 @@
+package mongotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestMongoHistory(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	where := ftypes.QMap{"email": "history@dbfusion.test"}
+	con.Where(where).DeleteMany(&models.UserHistorical{})
+	con.Table("usersHistorical_history").Where(where).DeleteMany()
+
+	beforeInsert := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	inserted := models.UserHistorical{FirstName: "First", Email: "history@dbfusion.test"}
+	if err := con.InsertOne(&inserted); err != nil {
+		t.Fatalf("Insertion failed with %v", err)
+	}
+	afterInsert := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	updated := models.UserHistorical{}
+	if err := con.UpdateByID(inserted.ID, ftypes.QMap{"firstname": "Second"}, &updated); err != nil {
+		t.Fatalf("Update failed with %v", err)
+	}
+	afterUpdate := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := con.DeleteByID(inserted.ID, &models.UserHistorical{}); err != nil {
+		t.Fatalf("Deletion failed with %v", err)
+	}
+	afterDelete := time.Now()
+
+	testCases := []struct {
+		AsOf      time.Time
+		FirstName string
+		Found     bool
+		Name      string
+	}{
+		{
+			AsOf:  beforeInsert,
+			Found: false,
+			Name:  "Document not inserted yet, its first version has no start",
+		},
+		{
+			AsOf:      afterInsert,
+			FirstName: "First",
+			Found:     true,
+			Name:      "Version before the update",
+		},
+		{
+			AsOf:      afterUpdate,
+			FirstName: "Second",
+			Found:     true,
+			Name:      "Version before the deletion",
+		},
+		{
+			AsOf:  afterDelete,
+			Found: false,
+			Name:  "Deleted document",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			found := models.UserHistorical{}
+			err := con.AsOf(tc.AsOf).Where(ftypes.QMap{"_id": inserted.ID}).FindOne(&found)
+			if (err == nil) != tc.Found {
+				t.Fatalf("Expected found %v, got %+v %v", tc.Found, found, err)
+			}
+			if tc.Found && found.FirstName != tc.FirstName {
+				t.Errorf("Expected %s, found %+v", tc.FirstName, found)
+			}
+
+			versions := []models.UserHistorical{}
+			err = con.AsOf(tc.AsOf).Where(where).FindMany(&versions)
+			if err != nil || (len(versions) == 1) != tc.Found {
+				t.Errorf("Expected found %v, got %+v %v", tc.Found, versions, err)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package mongotest
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/glodb/dbfusion"
 	"github.com/glodb/dbfusion/caches"
 	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/queryoptions"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/glodb/dbfusion/tests/models"
 )
@@ -63,3 +66,135 @@ func TestMongoCreateIndexes(t *testing.T) {
 		})
 	}
 }
+
+func TestMongoSyncIndexes(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	// A collection with the declared indexes but the first one, and an index the model doesn't declare.
+	con.RunCommand(primitive.D{{Key: "drop", Value: "usersIndexes"}}, nil)
+	if err := con.CreateIndexes(models.UserIndexes{}); err != nil {
+		t.Fatalf("Index creation failed with %v", err)
+	}
+	con.RunCommand(primitive.D{{Key: "dropIndexes", Value: "usersIndexes"}, {Key: "index", Value: "byPhone"}}, nil)
+	con.RunCommand(primitive.D{
+		{Key: "createIndexes", Value: "usersIndexes"},
+		{Key: "indexes", Value: primitive.A{primitive.D{{Key: "key", Value: primitive.D{{Key: "bio", Value: 1}}}, {Key: "name", Value: "stale"}}}},
+	}, nil)
+
+	testCases := []struct {
+		Options  queryoptions.SyncIndexesOptions
+		Expected connections.IndexSyncReport
+		Name     string
+	}{
+		{
+			Options: queryoptions.SyncIndexesOptions{DryRun: true, DropUndeclared: true},
+			Expected: connections.IndexSyncReport{
+				Missing: []string{"byPhone"}, Changed: []string{}, Undeclared: []string{"stale"}, Created: []string{}, Dropped: []string{},
+			},
+			Name: "Dry run reports the drift",
+		},
+		{
+			Options: queryoptions.SyncIndexesOptions{DropUndeclared: true},
+			Expected: connections.IndexSyncReport{
+				Missing: []string{"byPhone"}, Changed: []string{}, Undeclared: []string{"stale"}, Created: []string{"byPhone"}, Dropped: []string{"stale"},
+			},
+			Name: "Sync creates and drops the indexes",
+		},
+		{
+			Options: queryoptions.SyncIndexesOptions{DryRun: true},
+			Expected: connections.IndexSyncReport{
+				Missing: []string{}, Changed: []string{}, Undeclared: []string{}, Created: []string{}, Dropped: []string{},
+			},
+			Name: "Indexes in sync",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			report, err := con.SyncIndexes(models.UserIndexes{}, tc.Options)
+			if err != nil {
+				t.Fatalf("SyncIndexes failed with %v", err)
+			}
+			if !reflect.DeepEqual(report, tc.Expected) {
+				t.Errorf("Expected %+v, got %+v", tc.Expected, report)
+			}
+		})
+	}
+}
+
+func TestMongoIndexSpecs(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	con.RunCommand(primitive.D{{Key: "drop", Value: "userSessions"}}, nil)
+	if err := con.CreateIndexes(models.UserSession{}); err != nil {
+		t.Fatalf("Index creation failed with %v", err)
+	}
+
+	testCases := []struct {
+		Prepare  func()
+		Options  queryoptions.SyncIndexesOptions
+		Expected connections.IndexSyncReport
+		Name     string
+	}{
+		{
+			Prepare: func() {},
+			Options: queryoptions.SyncIndexesOptions{DryRun: true},
+			Expected: connections.IndexSyncReport{
+				Missing: []string{}, Changed: []string{}, Undeclared: []string{}, Created: []string{}, Dropped: []string{},
+			},
+			Name: "Indexes created with their options",
+		},
+		{
+			Prepare: func() {
+				con.RunCommand(primitive.D{
+					{Key: "collMod", Value: "userSessions"},
+					{Key: "index", Value: primitive.D{{Key: "name", Value: "createdAt_1"}, {Key: "expireAfterSeconds", Value: 60}}},
+				}, nil)
+			},
+			Options: queryoptions.SyncIndexesOptions{},
+			Expected: connections.IndexSyncReport{
+				Missing: []string{}, Changed: []string{"createdAt_1"}, Undeclared: []string{}, Created: []string{}, Dropped: []string{},
+			},
+			Name: "Sync restores the TTL expiry",
+		},
+		{
+			Prepare: func() {},
+			Options: queryoptions.SyncIndexesOptions{DryRun: true},
+			Expected: connections.IndexSyncReport{
+				Missing: []string{}, Changed: []string{}, Undeclared: []string{}, Created: []string{}, Dropped: []string{},
+			},
+			Name: "Indexes in sync",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			tc.Prepare()
+			report, err := con.SyncIndexes(models.UserSession{}, tc.Options)
+			if err != nil {
+				t.Fatalf("SyncIndexes failed with %v", err)
+			}
+			if !reflect.DeepEqual(report, tc.Expected) {
+				t.Errorf("Expected %+v, got %+v", tc.Expected, report)
+			}
+		})
+	}
+}
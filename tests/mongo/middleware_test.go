@@ -0,0 +1,104 @@
+package mongotest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestMongoMiddleware(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	// Record every operation and reject the ones on the blocked entity.
+	errBlocked := errors.New("blocked entity")
+	operations := []connections.Operation{}
+	con.Use(
+		func(next connections.Handler) connections.Handler {
+			return func(op *connections.Operation) error {
+				err := next(op)
+				operations = append(operations, *op)
+				return err
+			}
+		},
+		func(next connections.Handler) connections.Handler {
+			return func(op *connections.Operation) error {
+				if op.Entity == "blocked" {
+					return errBlocked
+				}
+				return next(op)
+			}
+		},
+	)
+
+	user := models.UserTest{FirstName: "Middleware", Email: "middleware@dbfusion.test", Password: "change-me"}
+
+	testCases := []struct {
+		Run            func() error
+		ExpectedKind   connections.OperationKind
+		ExpectedEntity string
+		ExpectedResult error
+		Name           string
+	}{
+		{
+			Run:            func() error { return con.InsertOne(&user) },
+			ExpectedKind:   connections.OpInsertOne,
+			ExpectedEntity: "users",
+			Name:           "Insertion goes through the chain",
+		},
+		{
+			Run:            func() error { return con.Where(ftypes.QMap{"email": user.Email}).FindOne(&models.UserTest{}) },
+			ExpectedKind:   connections.OpFindOne,
+			ExpectedEntity: "users",
+			Name:           "Find goes through the chain",
+		},
+		{
+			Run:            func() error { return con.Table("blocked").Where(ftypes.QMap{"email": user.Email}).DeleteOne() },
+			ExpectedKind:   connections.OpDeleteOne,
+			ExpectedEntity: "blocked",
+			ExpectedResult: errBlocked,
+			Name:           "Middleware aborts the deletion",
+		},
+		{
+			Run: func() error {
+				return con.Where(ftypes.QMap{"email": user.Email}).DeleteOne(&models.UserTest{Email: user.Email})
+			},
+			ExpectedKind:   connections.OpDeleteOne,
+			ExpectedEntity: "users",
+			Name:           "Deletion goes through the chain",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			operations = operations[:0]
+			err := tc.Run()
+			if !errors.Is(err, tc.ExpectedResult) {
+				t.Errorf("Expected %v, got %v", tc.ExpectedResult, err)
+			}
+			if len(operations) != 1 {
+				t.Fatalf("Expected one operation, got %d", len(operations))
+			}
+			op := operations[0]
+			if op.Kind != tc.ExpectedKind || op.Entity != tc.ExpectedEntity || op.Database != validDBName {
+				t.Errorf("Unexpected operation %+v", op)
+			}
+			if tc.ExpectedResult == nil && op.Query == nil {
+				t.Errorf("Expected the compiled query, got %+v", op)
+			}
+		})
+	}
+}
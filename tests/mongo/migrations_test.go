@@ -0,0 +1,168 @@
+package mongotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/migrations"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoMigrations(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	entity := "schema_migrations_test"
+	con.Table(entity).DeleteMany()
+	con.Table(entity + "_lock").DeleteMany()
+	con.RunCommand(primitive.D{{Key: "drop", Value: "migratedOrders"}}, nil)
+
+	migrator := migrations.NewMigrator(con, migrations.Options{Entity: entity})
+	err = migrator.Add(
+		migrations.Migration{
+			Version: 1,
+			Name:    "create_orders",
+			Up: func(ctx context.Context, con connections.Connection) error {
+				return con.(connections.MongoConnection).RunCommand(primitive.D{{Key: "create", Value: "migratedOrders"}}, nil)
+			},
+			Down: func(ctx context.Context, con connections.Connection) error {
+				return con.(connections.MongoConnection).RunCommand(primitive.D{{Key: "drop", Value: "migratedOrders"}}, nil)
+			},
+		},
+		migrations.Migration{
+			Version: 2,
+			Name:    "seed_orders",
+			Up: func(ctx context.Context, con connections.Connection) error {
+				return con.(connections.MongoConnection).Table("migratedOrders").InsertOne(map[string]interface{}{"total": 10})
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Run      func() (int, error)
+		Expected int
+		Err      error
+		Applied  int
+		Name     string
+	}{
+		{
+			Run: func() (int, error) {
+				applied, err := migrator.Migrate(context.Background())
+				return len(applied), err
+			},
+			Expected: 2,
+			Applied:  2,
+			Name:     "Migrate applies the pending migrations",
+		},
+		{
+			Run: func() (int, error) {
+				applied, err := migrator.Migrate(context.Background())
+				return len(applied), err
+			},
+			Expected: 0,
+			Applied:  2,
+			Name:     "Migrate again applies nothing",
+		},
+		{
+			Run: func() (int, error) {
+				reverted, err := migrator.Rollback(context.Background(), 1)
+				return len(reverted), err
+			},
+			Expected: 0,
+			Err:      dbfusionErrors.ErrMigrationIrreversible,
+			Applied:  2,
+			Name:     "Rollback of a migration without down step",
+		},
+		{
+			Run: func() (int, error) {
+				err := con.Table(entity + "_lock").InsertOne(map[string]interface{}{"_id": "lock", "owner": "other", "lockedAt": time.Now()})
+				if err != nil {
+					return 0, err
+				}
+				defer con.Table(entity + "_lock").Where(ftypes.QMap{"owner": "other"}).DeleteMany()
+				applied, err := migrator.Migrate(context.Background())
+				return len(applied), err
+			},
+			Expected: 0,
+			Err:      dbfusionErrors.ErrMigrationLocked,
+			Applied:  2,
+			Name:     "Migrate while another instance holds the lock",
+		},
+		{
+			Run: func() (int, error) {
+				// The first migration runs longer than the timeout and another instance takes the lock over.
+				takeover := migrations.NewMigrator(con, migrations.Options{Entity: entity, LockTimeout: 300 * time.Millisecond})
+				err := takeover.Add(
+					migrations.Migration{
+						Version: 3,
+						Name:    "slow_orders",
+						Up: func(ctx context.Context, con connections.Connection) error {
+							time.Sleep(400 * time.Millisecond)
+							con.(connections.MongoConnection).Table(entity + "_lock").DeleteMany()
+							return con.(connections.MongoConnection).Table(entity + "_lock").InsertOne(map[string]interface{}{"_id": "lock", "owner": "other", "lockedAt": time.Now()})
+						},
+						Down: func(ctx context.Context, con connections.Connection) error { return nil },
+					},
+					migrations.Migration{
+						Version: 4,
+						Name:    "never_applied",
+						Up:      func(ctx context.Context, con connections.Connection) error { return nil },
+					},
+				)
+				if err != nil {
+					return 0, err
+				}
+				defer con.Table(entity + "_lock").Where(ftypes.QMap{"owner": "other"}).DeleteMany()
+				applied, err := takeover.Migrate(context.Background())
+				return len(applied), err
+			},
+			Expected: 1,
+			Err:      dbfusionErrors.ErrMigrationLocked,
+			Applied:  3,
+			Name:     "Migrate stops once its lock was taken over",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			count, err := tc.Run()
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("Expected error %v, got %v", tc.Err, err)
+			}
+			if count != tc.Expected {
+				t.Errorf("Expected %d migrations, got %d", tc.Expected, count)
+			}
+			statuses, err := migrator.Status(context.Background())
+			if err != nil {
+				t.Fatalf("Status failed with %v", err)
+			}
+			applied := 0
+			for _, status := range statuses {
+				if status.Applied {
+					applied++
+				}
+			}
+			if applied != tc.Applied {
+				t.Errorf("Expected %d applied migrations, got %d", tc.Applied, applied)
+			}
+		})
+	}
+}
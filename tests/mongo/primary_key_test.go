@@ -0,0 +1,85 @@
+package mongotest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoPrimaryKey(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	where := ftypes.QMap{"email": "pk@dbfusion.test"}
+	con.Where(where).DeleteMany(&models.UserWithID{})
+
+	inserted := models.UserWithID{FirstName: "Key", Email: "pk@dbfusion.test"}
+	if err := con.InsertOne(&inserted); err != nil || inserted.ID.IsZero() {
+		t.Fatalf("Insertion failed with %v, id %v", err, inserted.ID)
+	}
+
+	testCases := []struct {
+		ID   interface{}
+		Err  error
+		Name string
+	}{
+		{
+			ID:   inserted.ID,
+			Name: "Find by ObjectID",
+		},
+		{
+			ID:   inserted.ID.Hex(),
+			Name: "Find by hex string",
+		},
+		{
+			ID:   "not an object id",
+			Err:  primitive.ErrInvalidHex,
+			Name: "Find by invalid hex string",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			found := models.UserWithID{}
+			err := con.FindByID(tc.ID, &found)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("Expected error %v, got %v", tc.Err, err)
+			}
+			if tc.Err == nil && found.ID != inserted.ID {
+				t.Errorf("Expected %v, found %+v %v", inserted.ID, found, err)
+			}
+		})
+	}
+
+	updated := models.UserWithID{}
+	err = con.UpdateByID(inserted.ID, ftypes.QMap{"firstname": "Updated"}, &updated)
+	if err != nil || updated.FirstName != "Updated" {
+		t.Errorf("UpdateByID failed with %v, found %+v", err, updated)
+	}
+
+	if err := con.DeleteByID(inserted.ID, &models.UserWithID{}); err != nil {
+		t.Errorf("DeleteByID failed with %v", err)
+	}
+	found := models.UserWithID{}
+	if err := con.FindByID(inserted.ID, &found); err == nil {
+		t.Errorf("Expected the document to be deleted, found %+v", found)
+	}
+
+	if err := con.FindByID(1, &models.UserTest{}); !errors.Is(err, dbfusionErrors.ErrPrimaryKeyNotFound) {
+		t.Errorf("Expected error %v, got %v", dbfusionErrors.ErrPrimaryKeyNotFound, err)
+	}
+}
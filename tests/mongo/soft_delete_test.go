@@ -0,0 +1,132 @@
+package mongotest
+
+import (
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoSoftDelete(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	where := ftypes.QMap{"firstname": "SoftDelete"}
+	con.Where(where).HardDelete().DeleteMany(&models.UserSoftDelete{})
+	for _, email := range []string{"soft-one@dbfusion.test", "soft-two@dbfusion.test", "soft-three@dbfusion.test"} {
+		if err := con.InsertOne(&models.UserSoftDelete{FirstName: "SoftDelete", Email: email}); err != nil {
+			t.Fatalf("Insertion failed with %v", err)
+		}
+	}
+
+	testCases := []struct {
+		Run     func() error
+		Default int
+		With    int
+		Only    int
+		Name    string
+	}{
+		{
+			Run:     func() error { return con.DeleteOne(&models.UserSoftDelete{Email: "soft-one@dbfusion.test"}) },
+			Default: 2,
+			With:    3,
+			Only:    1,
+			Name:    "Delete one sets the deletion time",
+		},
+		{
+			Run:     func() error { return con.Where(where).DeleteMany(&models.UserSoftDelete{}) },
+			Default: 0,
+			With:    3,
+			Only:    3,
+			Name:    "Delete many sets the deletion time",
+		},
+		{
+			Run: func() error {
+				return con.Where(ftypes.QMap{"email": "soft-two@dbfusion.test"}).Restore(&models.UserSoftDelete{})
+			},
+			Default: 1,
+			With:    3,
+			Only:    2,
+			Name:    "Restore clears the deletion time",
+		},
+		{
+			Run: func() error {
+				return con.Where(ftypes.QMap{"email": "soft-three@dbfusion.test"}).HardDelete().DeleteMany(&models.UserSoftDelete{})
+			},
+			Default: 1,
+			With:    2,
+			Only:    1,
+			Name:    "Hard delete removes the document",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := tc.Run(); err != nil {
+				t.Fatalf("Operation failed with %v", err)
+			}
+
+			users := []models.UserSoftDelete{}
+			if err := con.Where(where).FindMany(&users); err != nil || len(users) != tc.Default {
+				t.Errorf("Expected %d users, found %d %v", tc.Default, len(users), err)
+			}
+			if err := con.Where(where).WithDeleted().FindMany(&users); err != nil || len(users) != tc.With {
+				t.Errorf("Expected %d users with deleted ones, found %d %v", tc.With, len(users), err)
+			}
+			if err := con.Where(where).OnlyDeleted().FindMany(&users); err != nil || len(users) != tc.Only {
+				t.Errorf("Expected %d deleted users, found %d %v", tc.Only, len(users), err)
+			}
+		})
+	}
+
+	// Aggregates decoded into maps leave out the deleted documents too.
+	counts := []bson.M{}
+	err = con.Table("usersSoftDelete").Match(primitive.M{"firstname": "SoftDelete"}).
+		Group(primitive.M{"_id": nil, "count": primitive.M{"$sum": 1}}).Aggregate(&counts)
+	if err != nil || len(counts) != 1 || counts[0]["count"] != int32(1) {
+		t.Errorf("Expected a count of 1, found %v %v", counts, err)
+	}
+	counts = []bson.M{}
+	err = con.Table("usersSoftDelete").WithDeleted().Match(primitive.M{"firstname": "SoftDelete"}).
+		Group(primitive.M{"_id": nil, "count": primitive.M{"$sum": 1}}).Aggregate(&counts)
+	if err != nil || len(counts) != 1 || counts[0]["count"] != int32(2) {
+		t.Errorf("Expected a count of 2 with deleted documents, found %v %v", counts, err)
+	}
+
+	user := models.UserSoftDelete{}
+	if err := con.Where(ftypes.QMap{"email": "soft-one@dbfusion.test"}).FindOne(&user); err == nil {
+		t.Errorf("Soft-deleted user found %+v", user)
+	}
+	if err := con.Where(ftypes.QMap{"email": "soft-one@dbfusion.test"}).OnlyDeleted().FindOne(&user); err != nil || user.DeletedAt == 0 {
+		t.Errorf("Deleted user not found %+v %v", user, err)
+	}
+
+	// Updates leave out the deleted documents.
+	updated := models.UserSoftDelete{}
+	err = con.Where(ftypes.QMap{"email": "soft-one@dbfusion.test"}).UpdateAndFindOne(ftypes.QMap{"firstname": "Updated"}, &updated, false)
+	if err == nil {
+		t.Errorf("Soft-deleted user updated %+v", updated)
+	}
+
+	// Deletes given a table and conditions only soft delete the documents of the entity.
+	if err := con.Table("usersSoftDelete").Where(ftypes.QMap{"email": "soft-two@dbfusion.test"}).DeleteOne(); err != nil {
+		t.Fatalf("Deletion failed with %v", err)
+	}
+	if err := con.Where(ftypes.QMap{"email": "soft-two@dbfusion.test"}).OnlyDeleted().FindOne(&user); err != nil {
+		t.Errorf("Deleted user not found %+v %v", user, err)
+	}
+
+	con.Where(where).HardDelete().DeleteMany(&models.UserSoftDelete{})
+}
@@ -0,0 +1,82 @@
+package mongotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestMongoTimestamps(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	start := time.Now()
+	inserted := models.UserTimestamps{FirstName: "Timestamps", Email: "timestamps@dbfusion.test"}
+	upserted := models.UserTimestamps{}
+
+	testCases := []struct {
+		Run   func() error
+		Check func(user models.UserTimestamps) bool
+		Email string
+		Name  string
+	}{
+		{
+			Run: func() error { return con.InsertOne(&inserted) },
+			Check: func(user models.UserTimestamps) bool {
+				return user.CreatedAt >= start.Unix() && user.UpdatedAt >= start.UnixMilli() && !user.SeenAt.IsZero()
+			},
+			Email: inserted.Email,
+			Name:  "Insertion fills the timestamps",
+		},
+		{
+			Run: func() error {
+				time.Sleep(10 * time.Millisecond)
+				return con.Where(ftypes.QMap{"email": inserted.Email}).UpdateMany(ftypes.QMap{"firstname": "Updated"}, &models.UserTimestamps{}, false)
+			},
+			Check: func(user models.UserTimestamps) bool {
+				return user.FirstName == "Updated" && user.CreatedAt == inserted.CreatedAt && user.UpdatedAt > inserted.UpdatedAt
+			},
+			Email: inserted.Email,
+			Name:  "Update many refreshes the update timestamps only",
+		},
+		{
+			Run: func() error {
+				return con.Where(ftypes.QMap{"email": "upsert-timestamps@dbfusion.test"}).UpdateAndFindOne(ftypes.QMap{"firstname": "Upserted"}, &upserted, true)
+			},
+			Check: func(user models.UserTimestamps) bool {
+				return user.CreatedAt >= start.Unix() && user.UpdatedAt >= start.UnixMilli()
+			},
+			Email: "upsert-timestamps@dbfusion.test",
+			Name:  "Upsert fills the creation timestamps",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := tc.Run(); err != nil {
+				t.Fatalf("Operation failed with %v", err)
+			}
+
+			stored := models.UserTimestamps{}
+			err := con.Where(ftypes.QMap{"email": tc.Email}).FindOne(&stored)
+			if err != nil || !tc.Check(stored) {
+				t.Errorf("Unexpected timestamps %+v %v", stored, err)
+			}
+		})
+	}
+
+	con.DeleteOne(&models.UserTimestamps{Email: inserted.Email})
+	con.DeleteOne(&models.UserTimestamps{Email: "upsert-timestamps@dbfusion.test"})
+}
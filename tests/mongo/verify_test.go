@@ -0,0 +1,103 @@
+package mongotest
+
+import (
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestMongoVerifyCache(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	cache := caches.RedisCache{}
+	err := cache.ConnectCache("localhost:6379")
+	if err != nil {
+		t.Errorf("Error in redis connection, occurred %v", err)
+	}
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+			Cache:  &cache,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Errorf("DBConnection failed with %v", err)
+	}
+
+	// Start from an empty cache for the entity so that every record is reported as missing.
+	caches.GetInstance().InvalidateEntity(&cache, validDBName, "users")
+
+	testCases := []struct {
+		Con             connections.MongoConnection
+		Data            interface{}
+		Options         queryoptions.VerifyCacheOptions
+		ExpectMissing   bool
+		ExpectedRepairs bool
+		Name            string
+	}{
+		{
+			Con:           con,
+			Data:          &models.UserTest{},
+			Options:       queryoptions.VerifyCacheOptions{SampleSize: 10},
+			ExpectMissing: true,
+			Name:          "Verify a sample of an empty cache",
+		},
+		{
+			Con:             con,
+			Data:            &models.UserTest{},
+			Options:         queryoptions.VerifyCacheOptions{CheckOrphans: true, Repair: true},
+			ExpectMissing:   true,
+			ExpectedRepairs: true,
+			Name:            "Verify and repair the cache",
+		},
+		{
+			Con:           con,
+			Data:          &models.UserTest{},
+			Options:       queryoptions.VerifyCacheOptions{CheckOrphans: true},
+			ExpectMissing: false,
+			Name:          "Verify the repaired cache",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			report, err := tc.Con.VerifyCache(tc.Data, tc.Options)
+			if err != nil {
+				t.Fatalf("Error in cache verification %v", err)
+			}
+			if report.Checked == 0 {
+				t.Fatalf("Expected records to be checked")
+			}
+			if (len(report.MissingKeys) > 0) != tc.ExpectMissing {
+				t.Errorf("Expected missing keys %v, got %v", tc.ExpectMissing, report.MissingKeys)
+			}
+			if (report.Repaired > 0) != tc.ExpectedRepairs {
+				t.Errorf("Expected repairs %v, got %v", tc.ExpectedRepairs, report.Repaired)
+			}
+		})
+	}
+
+	// Point an index key of a record to a stale payload of its own, the repair removes the payload it replaces.
+	indexKeys, err := cache.ScanKeys(caches.GetInstance().IndexKey(validDBName, "users", "*"))
+	if err != nil || len(indexKeys) == 0 {
+		t.Fatalf("Expected cached index keys, got %v %v", indexKeys, err)
+	}
+	replaced := caches.GetInstance().PayloadKey(validDBName, "users", "replaced")
+	err = cache.MSet(map[string]interface{}{indexKeys[0]: replaced, replaced: `{"email":"stale@dbfusion.test"}`})
+	if err != nil {
+		t.Fatalf("Error in MSet %v", err)
+	}
+	report, err := con.VerifyCache(&models.UserTest{}, queryoptions.VerifyCacheOptions{Repair: true})
+	if err != nil || report.Repaired == 0 {
+		t.Fatalf("Expected the stale record to be repaired, got %+v %v", report, err)
+	}
+	orphans, err := caches.GetInstance().FindOrphanedPayloads(&cache, validDBName, "users")
+	if err != nil || len(orphans) != 0 {
+		t.Errorf("Expected the repair to leave no orphaned payloads, got %v %v", orphans, err)
+	}
+}
@@ -0,0 +1,80 @@
+package mongotest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestMongoVersion(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "mongodb://localhost:27017"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMongoConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	where := ftypes.QMap{"email": "version@dbfusion.test"}
+	con.Where(where).DeleteMany(&models.UserVersioned{})
+
+	inserted := models.UserVersioned{FirstName: "Version", Email: "version@dbfusion.test"}
+	if err := con.InsertOne(&inserted); err != nil || inserted.Version != 1 {
+		t.Fatalf("Insertion failed with %v, version %d", err, inserted.Version)
+	}
+
+	testCases := []struct {
+		Data    interface{}
+		Err     error
+		Version int64
+		Name    string
+	}{
+		{
+			Data:    ftypes.QMap{"firstname": "First", "version": 1},
+			Version: 2,
+			Name:    "Update with the current version increments it",
+		},
+		{
+			Data:    ftypes.QMap{"firstname": "Stale", "version": 1},
+			Err:     dbfusionErrors.ErrStaleVersion,
+			Version: 2,
+			Name:    "Update with a stale version fails",
+		},
+		{
+			Data:    &models.UserVersioned{FirstName: "Second", Version: 2},
+			Version: 3,
+			Name:    "Update with a struct carrying the version",
+		},
+		{
+			Data:    ftypes.QMap{"firstname": "Third"},
+			Version: 4,
+			Name:    "Update without a version expects the version read",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			result := models.UserVersioned{}
+			err := con.Where(where).UpdateAndFindOne(tc.Data, &result, false)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("Expected error %v, got %v", tc.Err, err)
+			}
+
+			stored := models.UserVersioned{}
+			err = con.Where(where).FindOne(&stored)
+			if err != nil || stored.Version != tc.Version {
+				t.Errorf("Expected version %d, found %+v %v", tc.Version, stored, err)
+			}
+		})
+	}
+
+	con.Where(where).DeleteMany(&models.UserVersioned{})
+}
@@ -1,6 +1,11 @@
 package redistest
 
-import "testing"
+import (
+	"testing"
+	"time"
+
+	"github.com/glodb/dbfusion/caches"
+)
 
 func TestRedisSet(t *testing.T) {
 }
@@ -10,3 +15,290 @@ func TestRedisGet(t *testing.T) {
 
 func TestRedisDelete(t *testing.T) {
 }
+
+func TestRedisBatch(t *testing.T) {
+	var cache caches.Cache
+	cache = &caches.RedisCache{}
+	err := cache.ConnectCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Error in redis connection, occurred %v", err)
+	}
+	defer cache.DisconnectCache()
+
+	testCases := []struct {
+		Values map[string]interface{}
+		Name   string
+	}{
+		{
+			Values: map[string]interface{}{"dbfusion_batch_1": "one", "dbfusion_batch_2": "two"},
+			Name:   "Batch set, get and delete",
+		},
+		{
+			Values: map[string]interface{}{},
+			Name:   "Empty batch",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			keys := make([]string, 0)
+			for key := range tc.Values {
+				keys = append(keys, key)
+			}
+
+			if err := cache.MSet(tc.Values); err != nil {
+				t.Errorf("Error in MSet %v", err)
+			}
+
+			values, err := cache.MGet(keys)
+			if err != nil {
+				t.Errorf("Error in MGet %v", err)
+			}
+			for idx, key := range keys {
+				if string(values[idx].([]byte)) != tc.Values[key] {
+					t.Errorf("Expected %v, got %v", tc.Values[key], values[idx])
+				}
+			}
+
+			if err := cache.MDel(keys); err != nil {
+				t.Errorf("Error in MDel %v", err)
+			}
+
+			values, err = cache.MGet(keys)
+			if err != nil {
+				t.Errorf("Error in MGet %v", err)
+			}
+			for _, value := range values {
+				if value != nil {
+					t.Errorf("Expected deleted key, got %v", value)
+				}
+			}
+		})
+	}
+}
+
+func TestRedisPayload(t *testing.T) {
+	var cache caches.Cache
+	cache = &caches.RedisCache{}
+	err := cache.ConnectCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Error in redis connection, occurred %v", err)
+	}
+	defer cache.DisconnectCache()
+
+	oldIndex, keptIndex, newIndex := "dbfusion_payload_old", "dbfusion_payload_kept", "dbfusion_payload_new"
+	defer cache.MDel([]string{oldIndex, keptIndex, newIndex, "dbfusion_payload", "dbfusion_payload_unused"})
+
+	// A record without cached keys gets the given payload key.
+	payloadKey, err := cache.UpdatePayload([]string{oldIndex}, []string{oldIndex, keptIndex}, "dbfusion_payload", []byte("first"))
+	if err != nil || payloadKey != "dbfusion_payload" {
+		t.Fatalf("Expected dbfusion_payload, got %v %v", payloadKey, err)
+	}
+
+	// An update reuses the payload key, writes the new keys and removes the stale ones.
+	payloadKey, err = cache.UpdatePayload([]string{oldIndex, keptIndex}, []string{keptIndex, newIndex}, "dbfusion_payload_unused", []byte("second"))
+	if err != nil || payloadKey != "dbfusion_payload" {
+		t.Fatalf("Expected dbfusion_payload, got %v %v", payloadKey, err)
+	}
+	values, err := cache.MGet([]string{oldIndex, keptIndex, newIndex, "dbfusion_payload", "dbfusion_payload_unused"})
+	if err != nil {
+		t.Fatalf("Error in MGet %v", err)
+	}
+	if values[0] != nil || string(values[1].([]byte)) != payloadKey || string(values[2].([]byte)) != payloadKey ||
+		string(values[3].([]byte)) != "second" || values[4] != nil {
+		t.Errorf("Unexpected keys after the update %q", values)
+	}
+
+	// A deletion removes the keys along with the payload.
+	if err := cache.DeletePayload([]string{keptIndex, newIndex}); err != nil {
+		t.Fatalf("Error in DeletePayload %v", err)
+	}
+	values, err = cache.MGet([]string{keptIndex, newIndex, "dbfusion_payload"})
+	if err != nil {
+		t.Fatalf("Error in MGet %v", err)
+	}
+	for _, value := range values {
+		if value != nil {
+			t.Errorf("Expected deleted key, got %v", value)
+		}
+	}
+}
+
+func TestRedisInvalidate(t *testing.T) {
+	var cache caches.Cache
+	cache = &caches.RedisCache{}
+	err := cache.ConnectCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Error in redis connection, occurred %v", err)
+	}
+	defer cache.DisconnectCache()
+
+	processor := caches.GetInstance()
+	usersIndex := processor.IndexKey("invalidateDB", "users", "1")
+	usersQuery := processor.QueryKey("invalidateDB", "users", "query")
+	ordersIndex := processor.IndexKey("invalidateDB", "orders", "1")
+	otherIndex := processor.IndexKey("otherDB", "users", "1")
+	// Names containing the separator must not fall into the namespace of the shorter name.
+	nestedDBIndex := processor.IndexKey("invalidateDB:users", "orders", "1")
+	nestedEntityIndex := processor.IndexKey("invalidateDB", "users:archive", "1")
+
+	testCases := []struct {
+		Invalidate func() error
+		Deleted    []string
+		Kept       []string
+		Name       string
+	}{
+		{
+			Invalidate: func() error { return processor.InvalidateEntity(cache, "invalidateDB", "users") },
+			Deleted:    []string{usersIndex, usersQuery},
+			Kept:       []string{ordersIndex, otherIndex, nestedDBIndex, nestedEntityIndex},
+			Name:       "Invalidate entity",
+		},
+		{
+			Invalidate: func() error { return processor.InvalidateDatabase(cache, "invalidateDB") },
+			Deleted:    []string{usersIndex, usersQuery, ordersIndex, nestedEntityIndex},
+			Kept:       []string{otherIndex, nestedDBIndex},
+			Name:       "Invalidate database",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := cache.MSet(map[string]interface{}{usersIndex: "a", usersQuery: "b", ordersIndex: "c", otherIndex: "d",
+				nestedDBIndex: "e", nestedEntityIndex: "f"})
+			if err != nil {
+				t.Fatalf("Error in MSet %v", err)
+			}
+			defer cache.MDel([]string{usersIndex, usersQuery, ordersIndex, otherIndex, nestedDBIndex, nestedEntityIndex})
+
+			if err := tc.Invalidate(); err != nil {
+				t.Errorf("Error in invalidation %v", err)
+			}
+
+			values, err := cache.MGet(append(tc.Deleted, tc.Kept...))
+			if err != nil {
+				t.Fatalf("Error in MGet %v", err)
+			}
+			for idx, value := range values {
+				if idx < len(tc.Deleted) && value != nil {
+					t.Errorf("Expected %v to be deleted", tc.Deleted[idx])
+				}
+				if idx >= len(tc.Deleted) && value == nil {
+					t.Errorf("Expected %v to be kept", tc.Kept[idx-len(tc.Deleted)])
+				}
+			}
+		})
+	}
+}
+
+func TestRedisQueue(t *testing.T) {
+	cache := &caches.RedisCache{}
+	err := cache.ConnectCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Error in redis connection, occurred %v", err)
+	}
+	defer cache.DisconnectCache()
+
+	var queue caches.Queue = cache
+	queueKey := caches.GetInstance().QueueKey("localhost:3306", "queueDB", "test")
+	processingKey := caches.GetInstance().QueueKey("localhost:3306", "queueDB", "test:processing")
+	defer cache.MDel([]string{queueKey, processingKey})
+
+	testCases := []struct {
+		Values  []string
+		Requeue bool
+		Name    string
+	}{
+		{
+			Values: []string{"first", "second", "third"},
+			Name:   "Values are dequeued in order",
+		},
+		{
+			Values:  []string{"first", "second"},
+			Requeue: true,
+			Name:    "Unacknowledged values are requeued in order",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			for _, value := range tc.Values {
+				if err := queue.Enqueue(queueKey, []byte(value)); err != nil {
+					t.Fatalf("Error in Enqueue %v", err)
+				}
+			}
+
+			// Take every value without acknowledging them and move them back.
+			if tc.Requeue {
+				for range tc.Values {
+					if _, err := queue.Dequeue(queueKey, processingKey, time.Second); err != nil {
+						t.Fatalf("Error in Dequeue %v", err)
+					}
+				}
+				if err := queue.Requeue(processingKey, queueKey); err != nil {
+					t.Fatalf("Error in Requeue %v", err)
+				}
+			}
+
+			for _, expected := range tc.Values {
+				value, err := queue.Dequeue(queueKey, processingKey, time.Second)
+				if err != nil {
+					t.Fatalf("Error in Dequeue %v", err)
+				}
+				if string(value) != expected {
+					t.Errorf("Expected %v, got %v", expected, string(value))
+				}
+				if err := queue.Ack(processingKey, value); err != nil {
+					t.Errorf("Error in Ack %v", err)
+				}
+			}
+
+			value, err := queue.Dequeue(queueKey, processingKey, time.Second)
+			if err != nil || value != nil {
+				t.Errorf("Expected an empty queue, got %v %v", value, err)
+			}
+		})
+	}
+}
+
+func TestRedisInsertCacheBatch(t *testing.T) {
+	var cache caches.Cache
+	cache = &caches.RedisCache{}
+	err := cache.ConnectCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Error in redis connection, occurred %v", err)
+	}
+	defer cache.DisconnectCache()
+
+	processor := caches.GetInstance()
+	emailIndex := processor.IndexKey("batchDB", "users", "email=batch@dbfusion.test")
+	usernameIndex := processor.IndexKey("batchDB", "users", "email=batch@dbfusion.test_username=batch")
+	payload := processor.PayloadKey("batchDB", "users", "payload")
+	replacedPayload := processor.PayloadKey("batchDB", "users", "replaced")
+	defer processor.InvalidateDatabase(cache, "batchDB")
+
+	// The indexes of the record point to two payloads, e.g. after an index was rewritten.
+	err = cache.MSet(map[string]interface{}{emailIndex: payload, usernameIndex: replacedPayload, payload: "{}", replacedPayload: "{}"})
+	if err != nil {
+		t.Fatalf("Error in MSet %v", err)
+	}
+
+	batch := []map[string]interface{}{{"email": "batch@dbfusion.test", "username": "batch"}}
+	cached, err := processor.ProcessInsertCacheBatch(cache, []string{"email", "email,username"}, batch, "batchDB", "users")
+	if err != nil || cached != 1 {
+		t.Fatalf("Expected one cached record, got %v %v", cached, err)
+	}
+
+	// The record keeps its first payload and the replaced one is removed.
+	values, err := cache.MGet([]string{emailIndex, usernameIndex, replacedPayload})
+	if err != nil {
+		t.Fatalf("Error in MGet %v", err)
+	}
+	if string(values[0].([]byte)) != payload || string(values[1].([]byte)) != payload || values[2] != nil {
+		t.Errorf("Expected the indexes to point to %s only, got %q", payload, values)
+	}
+	keys, err := cache.ScanKeys(processor.PayloadKey("batchDB", "users", "*"))
+	if err != nil || len(keys) != 1 {
+		t.Errorf("Expected a single payload, got %v %v", keys, err)
+	}
+}
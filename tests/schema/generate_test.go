@@ -0,0 +1,152 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glodb/dbfusion/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestGoTypes tests the Go types read from the column types of each dialect.
+func TestGoTypes(t *testing.T) {
+	testCases := []struct {
+		Dialect    schema.Dialect
+		ColumnType string
+		Expected   reflect.Type
+		Size       int
+	}{
+		{schema.MySQL, "tinyint(1)", reflect.TypeOf(false), 0},
+		{schema.MySQL, "tinyint(4)", reflect.TypeOf(int8(0)), 0},
+		{schema.MySQL, "int unsigned", reflect.TypeOf(uint32(0)), 0},
+		{schema.MySQL, "bigint", reflect.TypeOf(int64(0)), 0},
+		{schema.MySQL, "decimal(10,2)", reflect.TypeOf(float64(0)), 0},
+		{schema.MySQL, "varchar(64)", reflect.TypeOf(""), 64},
+		{schema.MySQL, "text", reflect.TypeOf(""), 65535},
+		{schema.MySQL, "datetime(6)", reflect.TypeOf(time.Time{}), 0},
+		{schema.MySQL, "varbinary(16)", reflect.TypeOf([]byte{}), 16},
+		{schema.MySQL, "json", reflect.TypeOf(json.RawMessage{}), 0},
+		{schema.MySQL, "enum('new','paid')", reflect.TypeOf(""), 0},
+		{schema.Postgres, "character varying(32)", reflect.TypeOf(""), 32},
+		{schema.Postgres, "timestamp with time zone", reflect.TypeOf(time.Time{}), 0},
+		{schema.Postgres, "integer", reflect.TypeOf(int32(0)), 0},
+		{schema.SQLite, "INTEGER", reflect.TypeOf(int64(0)), 0},
+		{schema.SQLite, "VARCHAR(10)", reflect.TypeOf(""), 0},
+	}
+
+	for _, tc := range testCases {
+		goType, size := tc.Dialect.GoType(tc.ColumnType)
+		if goType != tc.Expected || size != tc.Size {
+			t.Errorf("%s %q: expected %v with size %d, got %v with size %d", tc.Dialect.Name(), tc.ColumnType, tc.Expected, tc.Size, goType, size)
+		}
+	}
+}
+
+// TestNames tests the names of the generated models and fields.
+func TestNames(t *testing.T) {
+	testCases := []struct {
+		Field    string
+		Model    string
+		Expected string
+	}{
+		{Field: "user_id", Expected: "UserID"},
+		{Field: "userId", Expected: "UserID"},
+		{Field: "_id", Expected: "ID"},
+		{Field: "createdAt", Expected: "CreatedAt"},
+		{Field: "avatar_url", Expected: "AvatarURL"},
+		{Field: "2fa", Expected: "X2fa"},
+		{Model: "users", Expected: "User"},
+		{Model: "order_items", Expected: "OrderItem"},
+		{Model: "categories", Expected: "Category"},
+		{Model: "addresses", Expected: "Address"},
+		{Model: "status", Expected: "Status"},
+	}
+
+	for _, tc := range testCases {
+		if tc.Field != "" {
+			if name := schema.FieldName(tc.Field); name != tc.Expected {
+				t.Errorf("field %q: expected %s, got %s", tc.Field, tc.Expected, name)
+			}
+			continue
+		}
+		if name := schema.ModelName(tc.Model); name != tc.Expected {
+			t.Errorf("model %q: expected %s, got %s", tc.Model, tc.Expected, name)
+		}
+	}
+}
+
+// TestGenerate tests the models generated for a table and a collection.
+func TestGenerate(t *testing.T) {
+	status, expiry := "'new'", int32(3600)
+	tables := []schema.Table{
+		{
+			Name: "order_items",
+			Columns: []schema.TableColumn{
+				{Name: "id", GoType: reflect.TypeOf(int64(0)), PrimaryKey: true, AutoIncrement: true},
+				{Name: "user_id", GoType: reflect.TypeOf(int64(0)), References: &schema.Reference{Table: "users", Column: "id"}},
+				{Name: "reference", GoType: reflect.TypeOf(""), Size: 32, Unique: true},
+				{Name: "status", GoType: reflect.TypeOf(""), Size: 16, Default: &status},
+				{Name: "total", GoType: reflect.TypeOf(float64(0)), Type: "decimal(10,2)"},
+				{Name: "shippedAt", GoType: reflect.PtrTo(reflect.TypeOf(time.Time{}))},
+			},
+			Indexes: []schema.TableIndex{
+				{Name: "idx_status_total", Kind: schema.NormalIndex, Keys: []string{"status:1", "total:-1"}},
+				{Name: "ft_reference", Kind: schema.TextIndex, Keys: []string{"reference"}},
+			},
+		},
+		{
+			Name:       "sessions",
+			Collection: true,
+			Columns: []schema.TableColumn{
+				{Name: "_id", GoType: reflect.TypeOf(primitive.ObjectID{}), PrimaryKey: true},
+				{Name: "attributes", GoType: reflect.TypeOf(map[string]interface{}{}), OmitEmpty: true},
+			},
+			Indexes: []schema.TableIndex{
+				{Name: "createdAt_1", Kind: schema.SpecIndex, Keys: []string{"createdAt:1"}, ExpireAfterSeconds: &expiry},
+			},
+		},
+	}
+
+	source, err := schema.Generate("models", tables...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "models.go", source, 0); err != nil {
+		t.Fatalf("the generated source doesn't parse: %v", err)
+	}
+
+	expected := []string{
+		"package models",
+		`"time"`,
+		`"github.com/glodb/dbfusion/hooks"`,
+		"type OrderItem struct {",
+		"ID        int64      `dbfusion:\"id,pk:autoincr\"`",
+		"UserID    int64      `dbfusion:\"user_id,fk:users.id\"`",
+		"Reference string     `dbfusion:\"reference,size:32,unique\"`",
+		"Status    string     `dbfusion:\"status,size:16,default:'new'\"`",
+		"Total     float64    `dbfusion:\"total\" dbtype:\"decimal(10,2)\"`",
+		"ShippedAt *time.Time `dbfusion:\"shippedAt\"`",
+		"func (o OrderItem) GetEntityName() string {\n\treturn \"order_items\"\n}",
+		"func (o OrderItem) GetNormalIndexes() []string {\n\treturn []string{\"idx_status_total=status:1,total:-1\"}\n}",
+		"func (o OrderItem) GetTextIndex() string {\n\treturn \"ft_reference=reference\"\n}",
+		"type Session struct {",
+		"ID         primitive.ObjectID     `dbfusion:\"_id,pk\"`",
+		"Attributes map[string]interface{} `dbfusion:\"attributes,omitempty\"`",
+		"expiry0 := int32(3600)",
+		"{Name: \"createdAt_1\", Keys: \"createdAt:1\", ExpireAfterSeconds: &expiry0},",
+	}
+	for _, part := range expected {
+		if !strings.Contains(string(source), part) {
+			t.Errorf("expected the source to contain %q, got:\n%s", part, source)
+		}
+	}
+
+	if _, err := schema.Generate("my-models", tables...); err == nil {
+		t.Errorf("expected an error for an invalid package name")
+	}
+}
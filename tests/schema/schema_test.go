@@ -0,0 +1,171 @@
+package schema_test
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/glodb/dbfusion/schema"
+)
+
+// order is a model written with the column grammar.
+type order struct {
+	ID        int64          `dbfusion:"id,pk:autoincr"`
+	UserID    int64          `dbfusion:"userId,fk:users.id"`
+	Reference string         `dbfusion:"reference,size:32,unique"`
+	Status    string         `dbfusion:"status,size:16,default:'new'"`
+	Total     float64        `dbfusion:"total" dbtype:"DECIMAL(10,2)"`
+	Note      sql.NullString `dbfusion:"note,size:20000"`
+	ShippedAt *time.Time     `dbfusion:"shippedAt"`
+	Paid      bool           `dbfusion:"paid"`
+	Internal  string         `dbfusion:"-"`
+	Ignored   string
+}
+
+// legacyOrder is a model whose tags carry the column definitions.
+type legacyOrder struct {
+	ID        int    `dbfusion:"id,INT,AUTO_INCREMENT,PRIMARY KEY"`
+	Email     string `dbfusion:"email,VARCHAR(255),NOT NULL,UNIQUE"`
+	Phone     string `dbfusion:"phone,VARCHAR(32)"`
+	Nickname  string `dbfusion:"nickname,omitempty,VARCHAR(50),NOT NULL,DEFAULT ''"`
+	CreatedAt int64  `dbfusion:"createdAt,autoCreateTime"`
+}
+
+// TestColumnDefinitions tests the column definitions written by each dialect.
+func TestColumnDefinitions(t *testing.T) {
+	testCases := []struct {
+		Model    interface{}
+		Dialect  schema.Dialect
+		Expected []string
+		Name     string
+	}{
+		{
+			Model:   order{},
+			Dialect: schema.MySQL,
+			Expected: []string{
+				"id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY",
+				"userId BIGINT NOT NULL",
+				"reference VARCHAR(32) NOT NULL UNIQUE",
+				"status VARCHAR(16) NOT NULL DEFAULT 'new'",
+				"total DECIMAL(10,2) NOT NULL",
+				"note TEXT NULL",
+				"shippedAt DATETIME(6) NULL",
+				"paid BOOLEAN NOT NULL",
+			},
+			Name: "MySQL",
+		},
+		{
+			Model:   order{},
+			Dialect: schema.Postgres,
+			Expected: []string{
+				"id BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL PRIMARY KEY",
+				"userId BIGINT NOT NULL",
+				"reference VARCHAR(32) NOT NULL UNIQUE",
+				"status VARCHAR(16) NOT NULL DEFAULT 'new'",
+				"total DECIMAL(10,2) NOT NULL",
+				"note VARCHAR(20000) NULL",
+				"shippedAt TIMESTAMP NULL",
+				"paid BOOLEAN NOT NULL",
+			},
+			Name: "PostgreSQL",
+		},
+		{
+			Model:   order{},
+			Dialect: schema.SQLite,
+			Expected: []string{
+				"id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT",
+				"userId INTEGER NOT NULL",
+				"reference TEXT NOT NULL UNIQUE",
+				"status TEXT NOT NULL DEFAULT 'new'",
+				"total DECIMAL(10,2) NOT NULL",
+				"note TEXT NULL",
+				"shippedAt DATETIME NULL",
+				"paid INTEGER NOT NULL",
+			},
+			Name: "SQLite",
+		},
+		{
+			Model:   &legacyOrder{},
+			Dialect: schema.MySQL,
+			Expected: []string{
+				"id INT NOT NULL AUTO_INCREMENT PRIMARY KEY",
+				"email VARCHAR(255) NOT NULL UNIQUE",
+				"phone VARCHAR(32) NULL",
+				"nickname VARCHAR(50) NOT NULL DEFAULT ''",
+				"createdAt BIGINT NOT NULL",
+			},
+			Name: "Legacy tags",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			columns := schema.Columns(reflect.TypeOf(tc.Model), tc.Dialect)
+			definitions := make([]string, 0, len(columns))
+			for _, column := range columns {
+				definitions = append(definitions, tc.Dialect.ColumnDefinition(column))
+			}
+			if !reflect.DeepEqual(definitions, tc.Expected) {
+				t.Errorf("Expected %q, got %q", tc.Expected, definitions)
+			}
+		})
+	}
+}
+
+// TestCreateTableQuery tests that foreign keys follow the columns of the CREATE TABLE query.
+func TestCreateTableQuery(t *testing.T) {
+	type item struct {
+		ID      int32 `dbfusion:"id,pk"`
+		OrderID int64 `dbfusion:"orderId,fk:orders"`
+	}
+
+	testCases := []struct {
+		IfNotExist bool
+		Expected   string
+		Name       string
+	}{
+		{
+			IfNotExist: true,
+			Expected:   "CREATE TABLE IF NOT EXISTS items (id INT NOT NULL PRIMARY KEY,orderId BIGINT NOT NULL,FOREIGN KEY (orderId) REFERENCES orders(id))",
+			Name:       "If not exists",
+		},
+		{
+			Expected: "CREATE TABLE items (id INT NOT NULL PRIMARY KEY,orderId BIGINT NOT NULL,FOREIGN KEY (orderId) REFERENCES orders(id))",
+			Name:     "Without if not exists",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			columns := schema.Columns(reflect.TypeOf(item{}), schema.MySQL)
+			if query := schema.CreateTableQuery(schema.MySQL, "items", columns, tc.IfNotExist); query != tc.Expected {
+				t.Errorf("Expected %q, got %q", tc.Expected, query)
+			}
+		})
+	}
+}
+
+// TestIsOption tests that the options are told apart from the parts of a column definition.
+func TestIsOption(t *testing.T) {
+	testCases := []struct {
+		Tag      string
+		Expected bool
+	}{
+		{Tag: "size:32", Expected: true},
+		{Tag: "pk:autoincr", Expected: true},
+		{Tag: "fk:users.id", Expected: true},
+		{Tag: "omitempty", Expected: true},
+		{Tag: "VARCHAR(32)", Expected: false},
+		{Tag: "NOT NULL", Expected: false},
+		{Tag: "DEFAULT 0", Expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Tag, func(t *testing.T) {
+			if schema.IsOption(tc.Tag) != tc.Expected {
+				t.Errorf("Expected %v for %q", tc.Expected, tc.Tag)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package sqltest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/audit"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+// auditRow reads the columns of the audit table, MySQL stores the changes as JSON.
+type auditRow struct {
+	EntityID  string `dbfusion:"entityId"`
+	Operation string `dbfusion:"operation"`
+	Changes   string `dbfusion:"changes"`
+	Actor     string `dbfusion:"actor"`
+}
+
+func TestSQLAudit(t *testing.T) {
+	validDBName := "dbfusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	auditEntity := "usersAuditTrail"
+	options :=
+		dbfusion.Options{
+			DbName:      &validDBName,
+			Uri:         &validUri,
+			AuditEntity: &auditEntity,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+	// The connection may have been created by another test without the audit entity.
+	con.SetAuditEntity(auditEntity)
+
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersAuditedKeyed")
+	con.ExecuteSQL("DROP TABLE IF EXISTS " + auditEntity)
+	if err := con.CreateTable(models.UserAuditedKeyed{}, true); err != nil {
+		t.Fatalf("Table creation failed with %v", err)
+	}
+	if err := con.CreateAuditTable(true); err != nil {
+		t.Fatalf("Audit table creation failed with %v", err)
+	}
+
+	ctx := audit.WithActor(context.Background(), "auditor@dbfusion.test")
+	inserted := models.UserAuditedKeyed{FirstName: "Audit", Email: "audit@dbfusion.test"}
+	if err := con.WithContext(ctx).InsertOne(&inserted); err != nil {
+		t.Fatalf("Insertion failed with %v", err)
+	}
+	updated := models.UserAuditedKeyed{}
+	if err := con.WithContext(ctx).UpdateByID(inserted.ID, ftypes.QMap{"firstname": "Audited"}, &updated); err != nil {
+		t.Fatalf("Update failed with %v", err)
+	}
+	if err := con.WithContext(ctx).DeleteByID(inserted.ID, &models.UserAuditedKeyed{}); err != nil {
+		t.Fatalf("Deletion failed with %v", err)
+	}
+
+	testCases := []struct {
+		Operation audit.Operation
+		Field     string
+		Change    audit.Change
+		Name      string
+	}{
+		{
+			Operation: audit.Insert,
+			Field:     "firstname",
+			Change:    audit.Change{After: "Audit"},
+			Name:      "Insertion is recorded",
+		},
+		{
+			Operation: audit.Update,
+			Field:     "firstname",
+			Change:    audit.Change{Before: "Audit", After: "Audited"},
+			Name:      "Update is recorded with the values before and after",
+		},
+		{
+			Operation: audit.Delete,
+			Field:     "firstname",
+			Change:    audit.Change{Before: "Audited"},
+			Name:      "Deletion is recorded",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			rows := []auditRow{}
+			where := ftypes.DMap{
+				{Key: "entity = ", Value: "usersAuditedKeyed"},
+				{Key: " AND entityId = ", Value: inserted.ID},
+				{Key: " AND operation = ", Value: string(tc.Operation)}}
+			err := con.Table(auditEntity).Where(where).FindMany(&rows)
+			if err != nil || len(rows) != 1 {
+				t.Fatalf("Expected one entry, found %d %v", len(rows), err)
+			}
+			changes := map[string]audit.Change{}
+			if err := json.Unmarshal([]byte(rows[0].Changes), &changes); err != nil {
+				t.Fatalf("Changes decoding failed with %v", err)
+			}
+			if rows[0].Actor != "auditor@dbfusion.test" || changes[tc.Field] != tc.Change {
+				t.Errorf("Expected %v by the auditor, found %+v", tc.Change, rows[0])
+			}
+		})
+	}
+
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersAuditedKeyed")
+	con.ExecuteSQL("DROP TABLE IF EXISTS " + auditEntity)
+}
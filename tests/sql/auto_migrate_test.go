@@ -0,0 +1,80 @@
+package sqltest
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestSQLAutoMigrate(t *testing.T) {
+	validDBName := "dbfusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	// The table of an older version of the model, without nickname and with a legacy column.
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersAutoMigrate")
+	err = con.ExecuteSQL("CREATE TABLE usersAutoMigrate (id INT AUTO_INCREMENT PRIMARY KEY, email VARCHAR(255) NOT NULL, phone VARCHAR(16) NOT NULL, createdAt BIGINT NOT NULL, legacy INT)")
+	if err != nil {
+		t.Fatalf("Table creation failed with %v", err)
+	}
+
+	testCases := []struct {
+		Options  queryoptions.AutoMigrateOptions
+		Expected []string
+		Name     string
+	}{
+		{
+			Options: queryoptions.AutoMigrateOptions{DryRun: true, DropColumns: true},
+			Expected: []string{
+				"ALTER TABLE usersAutoMigrate MODIFY COLUMN phone VARCHAR(32) NULL",
+				"ALTER TABLE usersAutoMigrate ADD COLUMN nickname VARCHAR(50) NOT NULL DEFAULT '' AFTER phone",
+				"ALTER TABLE usersAutoMigrate ADD UNIQUE INDEX email (email)",
+				"ALTER TABLE usersAutoMigrate DROP COLUMN legacy",
+			},
+			Name: "Dry run plans the changes",
+		},
+		{
+			Options: queryoptions.AutoMigrateOptions{},
+			Expected: []string{
+				"ALTER TABLE usersAutoMigrate MODIFY COLUMN phone VARCHAR(32) NULL",
+				"ALTER TABLE usersAutoMigrate ADD COLUMN nickname VARCHAR(50) NOT NULL DEFAULT '' AFTER phone",
+				"ALTER TABLE usersAutoMigrate ADD UNIQUE INDEX email (email)",
+			},
+			Name: "Migration keeps the legacy column",
+		},
+		{
+			Options:  queryoptions.AutoMigrateOptions{DryRun: true},
+			Expected: []string{},
+			Name:     "Migrated table has nothing to change",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			output := bytes.Buffer{}
+			tc.Options.Output = &output
+			statements, err := con.AutoMigrate(tc.Options, &models.UserAutoMigrate{})
+			if err != nil {
+				t.Fatalf("AutoMigrate failed with %v", err)
+			}
+			if !reflect.DeepEqual(statements, tc.Expected) {
+				t.Errorf("Expected %q, got %q", tc.Expected, statements)
+			}
+			if !tc.Options.DryRun && output.Len() != 0 {
+				t.Errorf("Expected no output outside of a dry run, got %q", output.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package sqltest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/schema"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestSQLDescribeTable(t *testing.T) {
+	validDBName := "dbfusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersIndexes")
+	if err := con.CreateTable(models.UserIndexes{}, true); err != nil {
+		t.Fatalf("Table creation failed with %v", err)
+	}
+	if err := con.CreateIndexes(models.UserIndexes{}); err != nil {
+		t.Fatalf("Index creation failed with %v", err)
+	}
+
+	testCases := []struct {
+		Table           string
+		ExpectedColumns []string
+		ExpectedIndexes []schema.TableIndex
+		ExpectedResult  error
+		Name            string
+	}{
+		{
+			Table:           "usersIndexes",
+			ExpectedColumns: []string{"id", "email", "phone", "bio", "createdAt"},
+			ExpectedIndexes: []schema.TableIndex{
+				{Name: "byPhone", Kind: schema.NormalIndex, Keys: []string{"phone:1", "createdAt:-1"}},
+				{Name: "ft_bio", Kind: schema.TextIndex, Keys: []string{"bio"}},
+				{Name: "idx_createdAt", Kind: schema.NormalIndex, Keys: []string{"createdAt:-1"}},
+			},
+			ExpectedResult: nil,
+			Name:           "Describe a table with indexes",
+		},
+		{
+			Table:          "missingTable",
+			ExpectedResult: dbfusionErrors.ErrEntityNotFound,
+			Name:           "Describe a missing table",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			table, err := con.DescribeTable(tc.Table)
+			if err != tc.ExpectedResult {
+				t.Fatalf("Expected %v, got %v", tc.ExpectedResult, err)
+			}
+			if err != nil {
+				return
+			}
+			columns := make([]string, 0)
+			for _, column := range table.Columns {
+				columns = append(columns, column.Name)
+			}
+			if !reflect.DeepEqual(columns, tc.ExpectedColumns) {
+				t.Errorf("Expected columns %v, got %v", tc.ExpectedColumns, columns)
+			}
+			if !table.Columns[0].PrimaryKey || !table.Columns[0].AutoIncrement || !table.Columns[1].Unique {
+				t.Errorf("Expected the primary key on id and a unique email, got %+v", table.Columns[:2])
+			}
+			if !reflect.DeepEqual(table.Indexes, tc.ExpectedIndexes) {
+				t.Errorf("Expected indexes %+v, got %+v", tc.ExpectedIndexes, table.Indexes)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package sqltest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/encryption"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+// encryptedRow reads the encrypted columns as they are stored.
+type encryptedRow struct {
+	Email      string `dbfusion:"email"`
+	NationalID string `dbfusion:"nationalId"`
+	Phone      string `dbfusion:"phone"`
+}
+
+func TestSQLEncryption(t *testing.T) {
+	validDBName := "dbfusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	provider := encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": []byte("0123456789abcdef0123456789abcdef")})
+	options :=
+		dbfusion.Options{
+			DbName:      &validDBName,
+			Uri:         &validUri,
+			KeyProvider: provider,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+	// The connection may have been created by another test without the key provider.
+	con.SetKeyProvider(provider)
+
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersEncryptedKeyed")
+	if err := con.CreateTable(models.UserEncryptedKeyed{}, true); err != nil {
+		t.Fatalf("Table creation failed with %v", err)
+	}
+
+	inserted := models.UserEncryptedKeyed{Email: "encrypted@dbfusion.test", NationalID: "AB123456", Phone: "+1 555 0100"}
+	if err := con.InsertOne(&inserted); err != nil {
+		t.Fatalf("Insertion failed with %v", err)
+	}
+	if inserted.NationalID != "AB123456" {
+		t.Errorf("Expected the data to keep its plaintext, got %s", inserted.NationalID)
+	}
+
+	// The upsert inserts the record missing from the table with its fields encrypted.
+	upserted := models.UserEncryptedKeyed{}
+	data := ftypes.QMap{"email": "upserted@dbfusion.test", "nationalId": "CD789012", "phone": "+1 555 0111"}
+	err = con.Where(ftypes.QMap{"email = ": "upserted@dbfusion.test"}).UpdateAndFindOne(data, &upserted, true)
+	if err != nil {
+		t.Fatalf("Upsert failed with %v", err)
+	}
+
+	nationalID, err := con.EncryptLookup("CD789012")
+	if err != nil {
+		t.Fatalf("Lookup encryption failed with %v", err)
+	}
+
+	testCases := []struct {
+		Where      ftypes.QMap
+		Email      string
+		NationalID string
+		Phone      string
+		Name       string
+	}{
+		{
+			Where:      ftypes.QMap{"email = ": "encrypted@dbfusion.test"},
+			Email:      "encrypted@dbfusion.test",
+			NationalID: "AB123456",
+			Phone:      "+1 555 0100",
+			Name:       "Find an inserted record by a plain field",
+		},
+		{
+			Where:      ftypes.QMap{"email = ": "upserted@dbfusion.test"},
+			Email:      "upserted@dbfusion.test",
+			NationalID: "CD789012",
+			Phone:      "+1 555 0111",
+			Name:       "Find an upserted record by a plain field",
+		},
+		{
+			Where:      ftypes.QMap{"nationalId = ": nationalID},
+			Email:      "upserted@dbfusion.test",
+			NationalID: "CD789012",
+			Phone:      "+1 555 0111",
+			Name:       "Find an upserted record by a deterministic field",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			found := models.UserEncryptedKeyed{}
+			if err := con.Where(tc.Where).FindOne(&found); err != nil {
+				t.Fatalf("Find failed with %v", err)
+			}
+			if found.Email != tc.Email || found.NationalID != tc.NationalID || found.Phone != tc.Phone {
+				t.Errorf("Expected the decrypted values, found %+v", found)
+			}
+		})
+	}
+
+	stored := []encryptedRow{}
+	if err := con.Table("usersEncryptedKeyed").FindMany(&stored); err != nil || len(stored) != 2 {
+		t.Fatalf("Expected two records, found %d %v", len(stored), err)
+	}
+	for _, row := range stored {
+		if !strings.HasPrefix(row.NationalID, encryption.Prefix) || !strings.HasPrefix(row.Phone, encryption.Prefix) {
+			t.Errorf("Expected %s to be stored encrypted, got %+v", row.Email, row)
+		}
+	}
+
+	updated := models.UserEncryptedKeyed{}
+	err = con.UpdateByID(inserted.ID, ftypes.QMap{"phone": "+1 555 0199"}, &updated)
+	if err != nil || updated.Phone != "+1 555 0199" {
+		t.Errorf("Update failed with %v, found %+v", err, updated)
+	}
+
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersEncryptedKeyed")
+}
@@ -0,0 +1,107 @@
+package sqltest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestSQLHistory(t *testing.T) {
+	validDBName := "dbfusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersHistoricalKeyed")
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersHistoricalKeyed_history")
+	if err := con.CreateTable(models.UserHistoricalKeyed{}, true); err != nil {
+		t.Fatalf("Table creation failed with %v", err)
+	}
+	if err := con.CreateHistoryTable(models.UserHistoricalKeyed{}, true); err != nil {
+		t.Fatalf("History table creation failed with %v", err)
+	}
+
+	beforeInsert := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	inserted := models.UserHistoricalKeyed{FirstName: "First", Email: "history@dbfusion.test"}
+	if err := con.InsertOne(&inserted); err != nil {
+		t.Fatalf("Insertion failed with %v", err)
+	}
+	afterInsert := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	updated := models.UserHistoricalKeyed{}
+	if err := con.UpdateByID(inserted.ID, ftypes.QMap{"firstname": "Second"}, &updated); err != nil {
+		t.Fatalf("Update failed with %v", err)
+	}
+	afterUpdate := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := con.DeleteByID(inserted.ID, &models.UserHistoricalKeyed{}); err != nil {
+		t.Fatalf("Deletion failed with %v", err)
+	}
+	afterDelete := time.Now()
+
+	testCases := []struct {
+		AsOf      time.Time
+		FirstName string
+		Found     bool
+		Name      string
+	}{
+		{
+			AsOf:  beforeInsert,
+			Found: false,
+			Name:  "Record not inserted yet, its first version has no start",
+		},
+		{
+			AsOf:      afterInsert,
+			FirstName: "First",
+			Found:     true,
+			Name:      "Version before the update",
+		},
+		{
+			AsOf:      afterUpdate,
+			FirstName: "Second",
+			Found:     true,
+			Name:      "Version before the deletion",
+		},
+		{
+			AsOf:  afterDelete,
+			Found: false,
+			Name:  "Deleted record",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			found := models.UserHistoricalKeyed{}
+			err := con.AsOf(tc.AsOf).Where(ftypes.QMap{"id = ": inserted.ID}).FindOne(&found)
+			if (err == nil) != tc.Found {
+				t.Fatalf("Expected found %v, got %+v %v", tc.Found, found, err)
+			}
+			if tc.Found && found.FirstName != tc.FirstName {
+				t.Errorf("Expected %s, found %+v", tc.FirstName, found)
+			}
+
+			versions := []models.UserHistoricalKeyed{}
+			err = con.AsOf(tc.AsOf).Where(ftypes.QMap{"email = ": "history@dbfusion.test"}).FindMany(&versions)
+			if err != nil || (len(versions) == 1) != tc.Found {
+				t.Errorf("Expected found %v, got %+v %v", tc.Found, versions, err)
+			}
+		})
+	}
+
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersHistoricalKeyed")
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersHistoricalKeyed_history")
+}
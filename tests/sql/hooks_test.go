@@ -0,0 +1,79 @@
+package sqltest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestSQLHooks(t *testing.T) {
+	validDBName := "dbfusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		Ctx            context.Context
+		Data           *models.UserHooks
+		ExpectedResult error
+		Stored         bool
+		Name           string
+	}{
+		{
+			Ctx:            context.Background(),
+			Data:           &models.UserHooks{FirstName: "Hooks", Username: "hooks"},
+			ExpectedResult: models.ErrHookRejected,
+			Name:           "BeforeInsert aborts the insertion",
+		},
+		{
+			Ctx:            cancelled,
+			Data:           &models.UserHooks{FirstName: "Hooks", Email: "cancelled@dbfusion.test", Username: "hooks"},
+			ExpectedResult: context.Canceled,
+			Name:           "BeforeInsert receives the context",
+		},
+		{
+			Ctx:            context.Background(),
+			Data:           &models.UserHooks{FirstName: "Hooks", Email: "rollback@dbfusion.test", Username: "reject-after"},
+			ExpectedResult: models.ErrHookRejected,
+			Name:           "AfterInsert rolls the transaction back",
+		},
+		{
+			Ctx:    context.Background(),
+			Data:   &models.UserHooks{FirstName: "Hooks", Email: "committed@dbfusion.test", Username: "hooks"},
+			Stored: true,
+			Name:   "Insertion is committed when the hooks succeed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := con.WithContext(tc.Ctx).InsertOne(tc.Data)
+			if !errors.Is(err, tc.ExpectedResult) {
+				t.Errorf("Expected %v, got %v", tc.ExpectedResult, err)
+			}
+
+			stored := models.UserHooks{}
+			err = con.Where(ftypes.QMap{"email": tc.Data.Email}).FindOne(&stored)
+			if tc.Stored != (err == nil) {
+				t.Errorf("Expected stored to be %v, got %v", tc.Stored, err)
+			}
+			if tc.Stored {
+				con.Where(ftypes.QMap{"email": tc.Data.Email}).DeleteOne()
+			}
+		})
+	}
+}
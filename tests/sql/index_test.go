@@ -0,0 +1,154 @@
+package sqltest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestSQLCreateIndexes(t *testing.T) {
+	validDBName := "dbfusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersIndexes")
+	if err := con.CreateTable(models.UserIndexes{}, true); err != nil {
+		t.Fatalf("Table creation failed with %v", err)
+	}
+
+	testCases := []struct {
+		Data           interface{}
+		ExpectedResult error
+		Name           string
+	}{
+		{
+			Data:           models.UserIndexes{},
+			ExpectedResult: nil,
+			Name:           "Create the indexes",
+		},
+		{
+			Data:           models.UserIndexes{},
+			ExpectedResult: nil,
+			Name:           "Create the existing indexes again",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := con.CreateIndexes(tc.Data)
+			if err != tc.ExpectedResult {
+				t.Errorf("Expected %v, got %v", tc.ExpectedResult, err)
+			}
+		})
+	}
+}
+
+func TestSQLSyncIndexes(t *testing.T) {
+	validDBName := "dbfusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	// A table with the declared indexes but the first one, and an index the model doesn't declare.
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersIndexes")
+	if err := con.CreateTable(models.UserIndexes{}, true); err != nil {
+		t.Fatalf("Table creation failed with %v", err)
+	}
+	if err := con.CreateIndexes(models.UserIndexes{}); err != nil {
+		t.Fatalf("Index creation failed with %v", err)
+	}
+	con.ExecuteSQL("DROP INDEX byPhone ON usersIndexes")
+	con.ExecuteSQL("CREATE INDEX stale ON usersIndexes (bio)")
+
+	testCases := []struct {
+		Options  queryoptions.SyncIndexesOptions
+		Expected connections.IndexSyncReport
+		Name     string
+	}{
+		{
+			Options: queryoptions.SyncIndexesOptions{DryRun: true, DropUndeclared: true},
+			Expected: connections.IndexSyncReport{
+				Missing: []string{"byPhone"}, Changed: []string{}, Undeclared: []string{"stale"}, Created: []string{}, Dropped: []string{},
+			},
+			Name: "Dry run reports the drift",
+		},
+		{
+			Options: queryoptions.SyncIndexesOptions{},
+			Expected: connections.IndexSyncReport{
+				Missing: []string{"byPhone"}, Changed: []string{}, Undeclared: []string{"stale"}, Created: []string{"byPhone"}, Dropped: []string{},
+			},
+			Name: "Sync creates the missing indexes",
+		},
+		{
+			Options: queryoptions.SyncIndexesOptions{DropUndeclared: true},
+			Expected: connections.IndexSyncReport{
+				Missing: []string{}, Changed: []string{}, Undeclared: []string{"stale"}, Created: []string{}, Dropped: []string{"stale"},
+			},
+			Name: "Sync drops the undeclared indexes",
+		},
+		{
+			Options: queryoptions.SyncIndexesOptions{DryRun: true},
+			Expected: connections.IndexSyncReport{
+				Missing: []string{}, Changed: []string{}, Undeclared: []string{}, Created: []string{}, Dropped: []string{},
+			},
+			Name: "Indexes in sync",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			report, err := con.SyncIndexes(models.UserIndexes{}, tc.Options)
+			if err != nil {
+				t.Fatalf("SyncIndexes failed with %v", err)
+			}
+			if !reflect.DeepEqual(report, tc.Expected) {
+				t.Errorf("Expected %+v, got %+v", tc.Expected, report)
+			}
+		})
+	}
+}
+
+func TestSQLSyncRawIndexes(t *testing.T) {
+	validDBName := "dbfusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	// The unique index is declared by the column definition written in the tag.
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersRawIndexes")
+	if err := con.CreateTable(models.UserRawIndexes{}, true); err != nil {
+		t.Fatalf("Table creation failed with %v", err)
+	}
+
+	report, err := con.SyncIndexes(models.UserRawIndexes{}, queryoptions.SyncIndexesOptions{DryRun: true, DropUndeclared: true})
+	if err != nil {
+		t.Fatalf("SyncIndexes failed with %v", err)
+	}
+	if len(report.Undeclared) != 0 {
+		t.Errorf("Expected no undeclared indexes, got %v", report.Undeclared)
+	}
+}
@@ -0,0 +1,100 @@
+package sqltest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestSQLPrimaryKey(t *testing.T) {
+	validDBName := "dbfusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	cache := caches.RedisCache{}
+	err := cache.ConnectCache("localhost:6379")
+	if err != nil {
+		t.Errorf("Error in redis connection, occurred %v", err)
+	}
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+			Cache:  &cache,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Fatalf("DBConnection failed with %v", err)
+	}
+
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersKeyed")
+	if err := con.CreateTable(models.UserKeyed{}, true); err != nil {
+		t.Fatalf("Table creation failed with %v", err)
+	}
+	caches.GetInstance().InvalidateEntity(&cache, validDBName, "usersKeyed")
+
+	// The auto-increment key is read back into the inserted structs.
+	first := models.UserKeyed{FirstName: "First", Email: "first@dbfusion.test", Age: 100}
+	if err := con.InsertOne(&first); err != nil || first.ID == 0 {
+		t.Fatalf("Insertion failed with %v, id %d", err, first.ID)
+	}
+	second := models.UserKeyed{FirstName: "Second", Email: "second@dbfusion.test", Age: first.ID}
+	if err := con.InsertOne(&second); err != nil || second.ID != first.ID+1 {
+		t.Fatalf("Insertion failed with %v, id %d", err, second.ID)
+	}
+
+	testCases := []struct {
+		Find     func(found *models.UserKeyed) error
+		Expected string
+		Name     string
+	}{
+		{
+			Find:     func(found *models.UserKeyed) error { return con.FindByID(first.ID, found) },
+			Expected: first.Email,
+			Name:     "Find by the auto-increment key",
+		},
+		{
+			Find:     func(found *models.UserKeyed) error { return con.FindByID(second.ID, found) },
+			Expected: second.Email,
+			Name:     "Find the second record by its key",
+		},
+		{
+			Find: func(found *models.UserKeyed) error {
+				return con.Where(ftypes.QMap{"age = ": first.ID}).FindOne(found)
+			},
+			Expected: second.Email,
+			Name:     "Condition on another field holding the value of a cached key",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			found := models.UserKeyed{}
+			if err := tc.Find(&found); err != nil || found.Email != tc.Expected {
+				t.Errorf("Expected %s, found %+v %v", tc.Expected, found, err)
+			}
+		})
+	}
+
+	updated := models.UserKeyed{}
+	err = con.UpdateByID(first.ID, ftypes.QMap{"firstname": "Updated"}, &updated)
+	if err != nil || updated.ID != first.ID || updated.FirstName != "Updated" {
+		t.Errorf("UpdateByID failed with %v, found %+v", err, updated)
+	}
+
+	if err := con.DeleteByID(first.ID, &models.UserKeyed{}); err != nil {
+		t.Errorf("DeleteByID failed with %v", err)
+	}
+	found := models.UserKeyed{}
+	if err := con.FindByID(first.ID, &found); err == nil {
+		t.Errorf("Expected the record to be deleted, found %+v", found)
+	}
+
+	if err := con.FindByID(1, &models.UserTest{}); !errors.Is(err, dbfusionErrors.ErrPrimaryKeyNotFound) {
+		t.Errorf("Expected error %v, got %v", dbfusionErrors.ErrPrimaryKeyNotFound, err)
+	}
+	con.ExecuteSQL("DROP TABLE IF EXISTS usersKeyed")
+}
@@ -0,0 +1,101 @@
+package sqltest
+
+import (
+	"testing"
+
+	"github.com/glodb/dbfusion"
+	"github.com/glodb/dbfusion/caches"
+	"github.com/glodb/dbfusion/connections"
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/queryoptions"
+	"github.com/glodb/dbfusion/tests/models"
+)
+
+func TestSqlWarmCache(t *testing.T) {
+	validDBName := "testDBFusion"
+	validUri := "root:change-me@tcp(localhost:3306)/dbfusion"
+	cache := caches.RedisCache{}
+	err := cache.ConnectCache("localhost:6379")
+	if err != nil {
+		t.Errorf("Error in redis connection, occurred %v", err)
+	}
+	options :=
+		dbfusion.Options{
+			DbName: &validDBName,
+			Uri:    &validUri,
+			Cache:  &cache,
+		}
+	con, err := dbfusion.GetInstance().GetMySqlConnection(options)
+	if err != nil {
+		t.Errorf("DBConnection failed with %v", err)
+	}
+
+	testCases := []struct {
+		Con         connections.SQLConnection
+		Table       string
+		Data        interface{}
+		Options     queryoptions.WarmCacheOptions
+		ExpectedErr error
+		Name        string
+	}{
+		{
+			Con:         con,
+			Data:        &models.UserTest{},
+			Options:     queryoptions.WarmCacheOptions{BatchSize: 2, Invalidate: true},
+			ExpectedErr: nil,
+			Name:        "Warm cache of a struct with cache hooks",
+		},
+		{
+			Con:         con,
+			Table:       "users",
+			Data:        map[string]interface{}{},
+			Options:     queryoptions.WarmCacheOptions{Indexes: []string{"email"}, RatePerSecond: 100},
+			ExpectedErr: nil,
+			Name:        "Warm cache of a table with map rows",
+		},
+		{
+			Con:         con,
+			Table:       "users",
+			Data:        map[string]interface{}{},
+			Options:     queryoptions.WarmCacheOptions{},
+			ExpectedErr: dbfusionErrors.ErrCacheIndexesRequired,
+			Name:        "Warm cache without indexes",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			progressCalled := false
+			tc.Options.Progress = func(processed int64, cached int64) {
+				progressCalled = true
+			}
+
+			if tc.Table != "" {
+				tc.Con.Table(tc.Table)
+			}
+			results, err := tc.Con.WarmCache(tc.Data, tc.Options)
+			if err != tc.ExpectedErr {
+				t.Errorf("Expected error %v, got %v", tc.ExpectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if results.Processed != results.Cached+results.Skipped {
+				t.Errorf("Processed %v doesn't match cached %v and skipped %v", results.Processed, results.Cached, results.Skipped)
+			}
+			if results.Processed > 0 && !progressCalled {
+				t.Errorf("Expected progress to be reported")
+			}
+
+			// The records must now be found through the cache.
+			if results.Cached > 0 {
+				user := models.UserTest{}
+				err = tc.Con.Where(map[string]interface{}{"email": "aafaqzahid9+2@gmail.com"}).FindOne(&user)
+				if err != nil {
+					t.Errorf("Expected the warmed record to be found, got %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package validation_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/tests/models"
+	"github.com/glodb/dbfusion/validation"
+)
+
+// property returns the schema of a property, following the properties of nested objects.
+func property(schema map[string]interface{}, path ...string) interface{} {
+	var current interface{} = schema
+	for _, name := range path {
+		properties, _ := current.(map[string]interface{})["properties"].(map[string]interface{})
+		current = properties[name]
+	}
+	return current
+}
+
+// TestJSONSchema tests the $jsonSchema derived from the types and the validate tags of a struct.
+func TestJSONSchema(t *testing.T) {
+	schema, err := validation.GetInstance().JSONSchema(&models.UserValidated{})
+	if err != nil {
+		t.Fatalf("JSONSchema failed with %v", err)
+	}
+
+	testCases := []struct {
+		Path     []string
+		Expected interface{}
+		Name     string
+	}{
+		{
+			Expected: []string{"firstname", "email"},
+			Name:     "Required fields",
+		},
+		{
+			Path: []string{"firstname"},
+			Expected: map[string]interface{}{
+				"bsonType": "string", "minLength": int64(2), "maxLength": int64(50),
+				"not": map[string]interface{}{"enum": []interface{}{""}},
+			},
+			Name: "Required string with its length",
+		},
+		{
+			Path: []string{"role"},
+			Expected: map[string]interface{}{
+				"bsonType": "string",
+				"anyOf": []interface{}{
+					map[string]interface{}{"enum": []interface{}{""}},
+					map[string]interface{}{"enum": []interface{}{"admin", "member"}},
+				},
+			},
+			Name: "Optional enum",
+		},
+		{
+			Path: []string{"age"},
+			Expected: map[string]interface{}{
+				"bsonType": []interface{}{"int", "long"},
+				"anyOf": []interface{}{
+					map[string]interface{}{"enum": []interface{}{0}},
+					map[string]interface{}{"minimum": float64(18), "maximum": float64(130)},
+				},
+			},
+			Name: "Optional number with its range",
+		},
+		{
+			Path: []string{"tags"},
+			Expected: map[string]interface{}{
+				"bsonType": []interface{}{"array", "null"},
+				"items":    map[string]interface{}{"bsonType": "string"},
+				"anyOf": []interface{}{
+					map[string]interface{}{"enum": []interface{}{nil}},
+					map[string]interface{}{"maxItems": int64(3)},
+				},
+			},
+			Name: "Array with its items",
+		},
+		{
+			Path: []string{"address", "postalcode"},
+			Expected: map[string]interface{}{
+				"bsonType": "string",
+				"anyOf": []interface{}{
+					map[string]interface{}{"enum": []interface{}{""}},
+					map[string]interface{}{"minLength": int64(5), "maxLength": int64(5)},
+				},
+			},
+			Name: "Nested struct named like the driver encodes it",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var got interface{}
+			if tc.Path == nil {
+				got = schema["required"]
+			} else {
+				got = property(schema, tc.Path...)
+			}
+			if !reflect.DeepEqual(got, tc.Expected) {
+				t.Errorf("Expected %#v, got %#v", tc.Expected, got)
+			}
+		})
+	}
+
+	// The nested struct lists its own required fields.
+	if required := property(schema, "address").(map[string]interface{})["required"]; !reflect.DeepEqual(required, []string{"city"}) {
+		t.Errorf("Expected the required fields of the address, got %v", required)
+	}
+}
+
+// TestJSONSchemaErrors tests the errors of the structs whose schema can't be derived.
+func TestJSONSchemaErrors(t *testing.T) {
+	testCases := []struct {
+		Data     interface{}
+		Expected error
+		Name     string
+	}{
+		{
+			Data: struct {
+				Age int `dbfusion:"age" validate:"email"`
+			}{},
+			Expected: dbfusionErrors.ErrValidationRuleNotSupported,
+			Name:     "Rule not applying to the type",
+		},
+		{
+			Data:     map[string]interface{}{"age": 1},
+			Expected: dbfusionErrors.ErrInvalidType,
+			Name:     "Not a struct",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if _, err := validation.GetInstance().JSONSchema(tc.Data); !errors.Is(err, tc.Expected) {
+				t.Errorf("Expected %v, got %v", tc.Expected, err)
+			}
+		})
+	}
+}
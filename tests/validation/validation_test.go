@@ -0,0 +1,138 @@
+package validation_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"github.com/glodb/dbfusion/tests/models"
+	"github.com/glodb/dbfusion/validation"
+)
+
+// validUser returns a user satisfying every rule, the test cases break one rule at a time.
+func validUser() models.UserValidated {
+	return models.UserValidated{
+		FirstName: "Aafaq",
+		Email:     "aafaqzahid9@gmail.com",
+		Username:  "aafaq_zahid",
+		Role:      "admin",
+		Phone:     "03001234567",
+		Age:       30,
+		Tags:      []string{"a", "b"},
+		Address:   models.ValidatedAddress{City: "Lahore", PostalCode: "54000"},
+	}
+}
+
+// TestValidate tests the rules of the validate tags.
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		Update         func(user *models.UserValidated)
+		ExpectedFields []string
+		Name           string
+	}{
+		{
+			Update: func(user *models.UserValidated) {},
+			Name:   "Valid struct",
+		},
+		{
+			Update: func(user *models.UserValidated) {
+				user.Username = ""
+				user.Role = ""
+				user.Phone = ""
+				user.Age = 0
+				user.Tags = nil
+				user.Address.PostalCode = ""
+			},
+			Name: "Optional fields left empty",
+		},
+		{
+			Update:         func(user *models.UserValidated) { user.FirstName = "" },
+			ExpectedFields: []string{"firstname:required"},
+			Name:           "Required field missing",
+		},
+		{
+			Update:         func(user *models.UserValidated) { user.FirstName = "A" },
+			ExpectedFields: []string{"firstname:min"},
+			Name:           "String shorter than min",
+		},
+		{
+			Update:         func(user *models.UserValidated) { user.Email = "not an email" },
+			ExpectedFields: []string{"email:email"},
+			Name:           "Invalid email",
+		},
+		{
+			Update:         func(user *models.UserValidated) { user.Username = "Aafaq Zahid" },
+			ExpectedFields: []string{"username:regex"},
+			Name:           "Regex with an escaped comma",
+		},
+		{
+			Update:         func(user *models.UserValidated) { user.Role = "owner" },
+			ExpectedFields: []string{"role:oneof"},
+			Name:           "Value not in oneof",
+		},
+		{
+			Update:         func(user *models.UserValidated) { user.Phone = "0300" },
+			ExpectedFields: []string{"phone:len"},
+			Name:           "String of the wrong length",
+		},
+		{
+			Update:         func(user *models.UserValidated) { user.Age = 150 },
+			ExpectedFields: []string{"age:max"},
+			Name:           "Number above max",
+		},
+		{
+			Update:         func(user *models.UserValidated) { user.Tags = []string{"a", "b", "c", "d"} },
+			ExpectedFields: []string{"tags:max"},
+			Name:           "Slice longer than max",
+		},
+		{
+			Update: func(user *models.UserValidated) {
+				user.Email = ""
+				user.Age = 10
+				user.Address.City = ""
+			},
+			ExpectedFields: []string{"email:required", "age:min", "address.city:required"},
+			Name:           "Every failing field is listed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			user := validUser()
+			tc.Update(&user)
+
+			err := validation.GetInstance().Validate(&user)
+			if len(tc.ExpectedFields) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			validationErr := &validation.ValidationError{}
+			if !errors.As(err, &validationErr) || !errors.Is(err, dbfusionErrors.ErrValidationFailed) {
+				t.Fatalf("Expected a validation error, got %v", err)
+			}
+			if len(validationErr.Fields) != len(tc.ExpectedFields) {
+				t.Fatalf("Expected %v, got %v", tc.ExpectedFields, validationErr.Fields)
+			}
+			for i, fieldErr := range validationErr.Fields {
+				if fieldErr.Field+":"+fieldErr.Rule != tc.ExpectedFields[i] {
+					t.Errorf("Expected %s, got %s:%s", tc.ExpectedFields[i], fieldErr.Field, fieldErr.Rule)
+				}
+			}
+		})
+	}
+}
+
+// TestValidateUnsupportedRule tests that unknown rules are reported instead of being ignored.
+func TestValidateUnsupportedRule(t *testing.T) {
+	data := struct {
+		Name string `dbfusion:"name" validate:"unknown"`
+	}{Name: "Aafaq"}
+
+	err := validation.GetInstance().Validate(data)
+	if !errors.Is(err, dbfusionErrors.ErrValidationRuleNotSupported) {
+		t.Errorf("Expected %v, got %v", dbfusionErrors.ErrValidationRuleNotSupported, err)
+	}
+}
@@ -1,15 +1,19 @@
 package utils
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/glodb/dbfusion/caches"
 	"github.com/glodb/dbfusion/conditions"
 	"github.com/glodb/dbfusion/dbfusionErrors"
 	"github.com/glodb/dbfusion/ftypes"
+	"github.com/glodb/dbfusion/metadata"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -31,9 +35,56 @@ func GetInstance() *utils {
 
 // AssignData assigns a value to a variable based on its type using reflection.
 // It supports various data types such as string, int, float, bool, etc.,
-// and sets the value to the provided reflectValue.
+// and sets the value to the provided reflectValue. Pointer fields are left nil for NULL values and fields
+// implementing sql.Scanner, e.g. sql.NullString, scan the value themselves.
 func (u *utils) AssignData(val interface{}, reflectValue reflect.Value) {
-	switch reflectValue.Type().String() {
+	scanner := false
+	if reflectValue.CanAddr() {
+		_, scanner = reflectValue.Addr().Interface().(sql.Scanner)
+	}
+	u.assign(val, reflectValue, reflectValue.Type().String(), scanner)
+}
+
+// AssignField assigns a value to a tagged field of a struct like AssignData does, the type of the field being read
+// from the metadata of the model rather than from the field.
+//
+// Parameters:
+// - val: A pointer to the interface{} the value was scanned into.
+// - structValue: The struct, addressable for the fields implementing sql.Scanner.
+// - field: The metadata of the field.
+func (u *utils) AssignField(val interface{}, structValue reflect.Value, field *metadata.Field) {
+	u.assign(val, structValue.Field(field.Index), field.TypeName, field.Scanner)
+}
+
+// assign assigns a value to a variable of a type.
+//
+// Parameters:
+// - val: A pointer to the interface{} the value was scanned into.
+// - reflectValue: The variable.
+// - typeName: The name of the type of the variable, e.g. "int64" or "time.Time".
+// - scanner: true if a pointer to the variable implements sql.Scanner.
+func (u *utils) assign(val interface{}, reflectValue reflect.Value, typeName string, scanner bool) {
+	// Let the fields implementing sql.Scanner read the value.
+	if scanner && reflectValue.CanAddr() {
+		reflectValue.Addr().Interface().(sql.Scanner).Scan(*val.(*interface{}))
+		return
+	}
+
+	// Point the pointer fields to a new value, or leave them nil for NULL.
+	if reflectValue.Kind() == reflect.Ptr {
+		if reflectValue.CanSet() {
+			if *val.(*interface{}) == nil {
+				reflectValue.Set(reflect.Zero(reflectValue.Type()))
+				return
+			}
+			element := reflect.New(reflectValue.Type().Elem())
+			u.AssignData(val, element.Elem())
+			reflectValue.Set(element)
+		}
+		return
+	}
+
+	switch typeName {
 	case "string":
 		if reflectValue.CanSet() {
 			reflectValue.SetString(fmt.Sprintf("%s", *val.(*interface{})))
@@ -68,12 +119,71 @@ func (u *utils) AssignData(val interface{}, reflectValue reflect.Value) {
 			intVal, _ := (*val.(*interface{})).([]byte)
 			reflectValue.SetBytes(intVal)
 		}
+	case "time.Time":
+		// DATETIME columns are read as time.Time with parseTime and as text without it.
+		if reflectValue.CanSet() {
+			switch timeVal := (*val.(*interface{})).(type) {
+			case time.Time:
+				reflectValue.Set(reflect.ValueOf(timeVal))
+			case []byte, string:
+				if parsed, err := time.Parse("2006-01-02 15:04:05.999999999", fmt.Sprintf("%s", timeVal)); err == nil {
+					reflectValue.Set(reflect.ValueOf(parsed))
+				}
+			}
+		}
+	}
+}
+
+// AssignTo assigns a value read from a SQL row to the variable a pointer points to, like AssignData does. The text
+// values of the common types are parsed without reflection, the other values are assigned by AssignData.
+//
+// Parameters:
+// - val: A pointer to the interface{} the value was scanned into.
+// - target: A pointer to the variable, usually returned by the DBFusionPointers method of a generated mapper.
+func (u *utils) AssignTo(val interface{}, target interface{}) {
+	// Parse the text the drivers return for the common types.
+	if raw, ok := (*val.(*interface{})).([]byte); ok {
+		switch pointer := target.(type) {
+		case *string:
+			*pointer = string(raw)
+			return
+		case *int:
+			parsed, _ := strconv.ParseInt(string(raw), 10, 64)
+			*pointer = int(parsed)
+			return
+		case *int32:
+			parsed, _ := strconv.ParseInt(string(raw), 10, 64)
+			*pointer = int32(parsed)
+			return
+		case *int64:
+			*pointer, _ = strconv.ParseInt(string(raw), 10, 64)
+			return
+		case *uint64:
+			*pointer, _ = strconv.ParseUint(string(raw), 10, 64)
+			return
+		case *float64:
+			*pointer, _ = strconv.ParseFloat(string(raw), 64)
+			return
+		case *bool:
+			*pointer, _ = strconv.ParseBool(string(raw))
+			return
+		}
+	}
+	u.AssignData(val, reflect.ValueOf(target).Elem())
+}
+
+// IsSet reports whether a value is other than the zero value of its type, the check the generated mappers use for
+// the fields whose type they can't compare.
+func (u *utils) IsSet(value interface{}) bool {
+	if value == nil {
+		return false
 	}
+	return !reflect.DeepEqual(value, reflect.Zero(reflect.TypeOf(value)).Interface())
 }
 
 // buildSqlData constructs SQL data for a key-value pair and appends it to the provided query and values.
 // It handles cases where the key contains "IN" to build SQL IN clauses.
-func (u *utils) buildSqlData(key string, val interface{}, cacheKey *string, values *string, query *string, valuesInterface *[]interface{}) {
+func (u *utils) buildSqlData(key string, val interface{}, cacheKey *string, values map[string]interface{}, query *string, valuesInterface *[]interface{}) {
 	tempKey := key
 
 	if strings.Contains(strings.ToLower(key), " in ") || strings.Contains(strings.ToLower(key), " in") {
@@ -98,20 +208,12 @@ func (u *utils) buildSqlData(key string, val interface{}, cacheKey *string, valu
 			*cacheKey += fmt.Sprintf("_%s_%v", key, val)
 		}
 		inquery += ")"
-		if *values == "" {
-			*values += fmt.Sprintf("%v", val)
-		} else {
-			*values += fmt.Sprintf("_%v", val)
-		}
+		values[strings.TrimSpace(key)] = val
 		*query += fmt.Sprintf("%s %s ", key, inquery)
 	default:
 		if val != nil {
 			*valuesInterface = append(*valuesInterface, val)
-			if *values == "" {
-				*values += fmt.Sprintf("%v", val)
-			} else {
-				*values += fmt.Sprintf("_%v", val)
-			}
+			values[u.sqlConditionField(key)] = val
 			*query += fmt.Sprintf("%s ? ", key)
 		} else {
 			*query += fmt.Sprintf("%s", key)
@@ -124,13 +226,31 @@ func (u *utils) buildSqlData(key string, val interface{}, cacheKey *string, valu
 	}
 }
 
+// sqlConditionField returns the field an SQL condition key compares for equality, e.g. "email" for "email = " or
+// " AND email = ", so that the condition builds the key of the cache index of the field. Other conditions keep their
+// key, which never matches an index.
+func (u *utils) sqlConditionField(key string) string {
+	condition := strings.TrimSpace(key)
+	if !strings.HasSuffix(condition, "=") || strings.ContainsAny(condition[:len(condition)-1], "<>!=()") {
+		return condition
+	}
+	words := strings.Fields(condition[:len(condition)-1])
+	switch {
+	case len(words) == 1:
+		return words[0]
+	case len(words) == 2 && strings.EqualFold(words[0], "AND"):
+		return words[1]
+	}
+	return condition
+}
+
 // GetSqlFusionData constructs a SQL DBFusionData object based on the provided data.
 // It converts data of various types, such as QMap, DMap, or a map[string]interface{},
 // into SQL-compatible DBFusionData.
 func (u *utils) GetSqlFusionData(data interface{}) (conditions.DBFusionData, error) {
 	dbFusionData := &conditions.SqlData{}
 	valuesInterface := make([]interface{}, 0)
-	values := ""
+	values := make(map[string]interface{})
 	cacheKey := ""
 	query := ""
 	if value, ok := data.(conditions.DBFusionData); ok {
@@ -138,21 +258,21 @@ func (u *utils) GetSqlFusionData(data interface{}) (conditions.DBFusionData, err
 		return dbFusionData, nil
 	} else if value, ok := data.(ftypes.QMap); ok {
 		for key, val := range value {
-			u.buildSqlData(key, val, &cacheKey, &values, &query, &valuesInterface)
+			u.buildSqlData(key, val, &cacheKey, values, &query, &valuesInterface)
 		}
 	} else if value, ok := data.(ftypes.DMap); ok {
 		for _, val := range value {
-			u.buildSqlData(val.Key, val.Value, &cacheKey, &values, &query, &valuesInterface)
+			u.buildSqlData(val.Key, val.Value, &cacheKey, values, &query, &valuesInterface)
 		}
 	} else if value, ok := data.(map[string]interface{}); ok {
 		for key, val := range value {
-			u.buildSqlData(key, val, &cacheKey, &values, &query, &valuesInterface)
+			u.buildSqlData(key, val, &cacheKey, values, &query, &valuesInterface)
 		}
 	} else {
 		return dbFusionData, dbfusionErrors.ErrInvalidType
 	}
 	dbFusionData.SetCacheKey(cacheKey)
-	dbFusionData.SetCacheValues(values)
+	dbFusionData.SetCacheValues(caches.GetInstance().IndexValues(values))
 	dbFusionData.SetValues(valuesInterface)
 	dbFusionData.SetQuery(query)
 	return dbFusionData, nil
@@ -160,17 +280,13 @@ func (u *utils) GetSqlFusionData(data interface{}) (conditions.DBFusionData, err
 
 // buildMongoData constructs MongoDB data for a key-value pair and appends it to the provided query and values.
 // It also updates cacheKey and values for MongoDB data.
-func (u *utils) buildMongoData(key string, val interface{}, cacheKey *string, values *string) primitive.E {
+func (u *utils) buildMongoData(key string, val interface{}, cacheKey *string, values map[string]interface{}) primitive.E {
 	if *cacheKey == "" {
 		*cacheKey += fmt.Sprintf("%s_%v", key, val)
 	} else {
 		*cacheKey += fmt.Sprintf("_%s_%v", key, val)
 	}
-	if *values == "" {
-		*values += fmt.Sprintf("%v", val)
-	} else {
-		*values += fmt.Sprintf("_%v", val)
-	}
+	values[key] = val
 
 	return primitive.E{Key: key, Value: val}
 }
@@ -180,7 +296,7 @@ func (u *utils) buildMongoData(key string, val interface{}, cacheKey *string, va
 // into MongoDB-compatible DBFusionData.
 func (u *utils) GetMongoFusionData(data interface{}) (conditions.DBFusionData, error) {
 	dbFusionData := &conditions.MongoData{}
-	values := ""
+	values := make(map[string]interface{})
 	cacheKey := ""
 	query := primitive.D{}
 	if value, ok := data.(conditions.DBFusionData); ok {
@@ -188,24 +304,24 @@ func (u *utils) GetMongoFusionData(data interface{}) (conditions.DBFusionData, e
 		return dbFusionData, nil
 	} else if value, ok := data.(ftypes.QMap); ok {
 		for key, val := range value {
-			singleData := u.buildMongoData(key, val, &cacheKey, &values)
+			singleData := u.buildMongoData(key, val, &cacheKey, values)
 			query = append(query, singleData)
 		}
 	} else if value, ok := data.(ftypes.DMap); ok {
 		for _, val := range value {
-			singleData := u.buildMongoData(val.Key, val.Value, &cacheKey, &values)
+			singleData := u.buildMongoData(val.Key, val.Value, &cacheKey, values)
 			query = append(query, singleData)
 		}
 	} else if value, ok := data.(map[string]interface{}); ok {
 		for key, val := range value {
-			singleData := u.buildMongoData(key, val, &cacheKey, &values)
+			singleData := u.buildMongoData(key, val, &cacheKey, values)
 			query = append(query, singleData)
 		}
 	} else {
 		return dbFusionData, dbfusionErrors.ErrInvalidType
 	}
 	dbFusionData.SetCacheKey(cacheKey)
-	dbFusionData.SetCacheValues(values)
+	dbFusionData.SetCacheValues(caches.GetInstance().IndexValues(values))
 	dbFusionData.SetQuery(query)
 	return dbFusionData, nil
 }
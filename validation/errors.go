@@ -0,0 +1,42 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/glodb/dbfusion/dbfusionErrors"
+)
+
+// FieldError describes a rule a field failed.
+type FieldError struct {
+	Field string      // The path of the field, using the names of its dbfusion tags.
+	Rule  string      // The rule that failed, e.g. "min".
+	Param string      // The parameter of the rule, e.g. "3" for "min=3", empty for rules without one.
+	Value interface{} // The value of the field.
+}
+
+// Error describes the failure of the field.
+func (fe FieldError) Error() string {
+	if fe.Param == "" {
+		return fe.Field + " failed " + fe.Rule
+	}
+	return fe.Field + " failed " + fe.Rule + "=" + fe.Param
+}
+
+// ValidationError lists every field of a struct that failed its validation rules.
+type ValidationError struct {
+	Fields []FieldError // The failures, in the order of the fields in the struct.
+}
+
+// Error lists the failures of all the fields.
+func (ve *ValidationError) Error() string {
+	failures := make([]string, len(ve.Fields))
+	for i, fieldErr := range ve.Fields {
+		failures[i] = fieldErr.Error()
+	}
+	return dbfusionErrors.ErrValidationFailed.Error() + ": " + strings.Join(failures, ", ")
+}
+
+// Unwrap returns dbfusionErrors.ErrValidationFailed, so that errors.Is identifies validation errors.
+func (ve *ValidationError) Unwrap() error {
+	return dbfusionErrors.ErrValidationFailed
+}
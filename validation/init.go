@@ -0,0 +1,30 @@
+// Package validation checks the values of the structs written to the database against the rules declared in their
+// `validate` struct tags. The connections validate the data in preInsert and preUpdate, so an invalid struct is
+// rejected before anything is written to the database or the cache.
+//
+// Supported Rules:
+//   - required: The field must not be the zero value of its type.
+//   - min=N: Numbers must be at least N, strings, slices and maps must hold at least N elements.
+//   - max=N: Numbers must be at most N, strings, slices and maps must hold at most N elements.
+//   - len=N: Strings, slices and maps must hold exactly N elements.
+//   - email: The string must be an email address.
+//   - regex=pattern: The string must match the regular expression, commas in the pattern are escaped as "\,".
+//   - oneof=a b c: The value must be one of the space separated values.
+//
+// Rules other than required are skipped for zero values, which keeps optional fields optional. Nested structs are
+// validated as well and their fields are reported with their path, e.g. "address.city".
+//
+// Example:
+//   type User struct {
+//       Email string `dbfusion:"email" validate:"required,email"`
+//       Age   int    `dbfusion:"age" validate:"min=18,max=130"`
+//       Role  string `dbfusion:"role" validate:"oneof=admin member"`
+//   }
+//
+//   err := validation.GetInstance().Validate(user)
+//   if validationErr, ok := err.(*validation.ValidationError); ok {
+//       for _, fieldErr := range validationErr.Fields {
+//           log.Println(fieldErr.Field, fieldErr.Rule)
+//       }
+//   }
+package validation
@@ -0,0 +1,377 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glodb/dbfusion/dbfusionErrors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// emailPattern is the pattern of the email rule in a JSON schema, which can't use the address parser of Validate.
+const emailPattern = `^[^@\s]+@[^@\s]+\.[^@\s]+$`
+
+// Types with a BSON type of their own.
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	objectIDType   = reflect.TypeOf(primitive.ObjectID{})
+	dateTimeType   = reflect.TypeOf(primitive.DateTime(0))
+	decimal128Type = reflect.TypeOf(primitive.Decimal128{})
+)
+
+// JSONSchema derives the MongoDB $jsonSchema validator of a struct from the types of its fields and the rules of
+// their validate tags. The fields are named by their dbfusion tags, like the documents written by the connections,
+// and the fields of nested structs by their bson tags or their lowercased names, like the driver encodes them.
+//
+// Field types map to BSON types: integers accept int and long, pointers, slices and maps accept null, slices and
+// arrays describe their items and nested structs their properties. The rules map to the keywords of the schema:
+//   - required: The field is listed in required and can't hold the zero value of its type.
+//   - min=N, max=N, len=N: minimum and maximum for numbers, minLength and maxLength for strings, minItems and
+//     maxItems for slices and arrays, and minProperties and maxProperties for maps.
+//   - email, regex=pattern: A pattern the string must match.
+//   - oneof=a b c: An enum of the values, converted to the type of the field.
+//
+// Like Validate, the rules other than required accept the zero value of fields which are not required. Only the
+// required rule applies to the fields with the encrypt option, which store a ciphertext.
+//
+// Parameters:
+//   - data: The struct, or a pointer to the struct.
+//
+// Returns:
+//   - map[string]interface{}: The schema, the value of the $jsonSchema operator.
+//   - error: dbfusionErrors.ErrInvalidType if data is not a struct, or dbfusionErrors.ErrValidationRuleNotSupported
+//     wrapped with the field and the rule if a tag is invalid.
+//
+// Example:
+//   schema, err := validation.GetInstance().JSONSchema(User{})
+//   validator := bson.M{"$jsonSchema": schema}
+func (v *validator) JSONSchema(data interface{}) (map[string]interface{}, error) {
+	dataType := reflect.TypeOf(data)
+	for dataType != nil && dataType.Kind() == reflect.Ptr {
+		dataType = dataType.Elem()
+	}
+	if dataType == nil || dataType.Kind() != reflect.Struct {
+		return nil, dbfusionErrors.ErrInvalidType
+	}
+	return v.objectSchema(dataType, "", map[reflect.Type]bool{})
+}
+
+// objectSchema derives the schema of a struct.
+//
+// Parameters:
+//   - structType: The type of the struct.
+//   - path: The path of the struct, empty for the top level struct whose fields are named by their dbfusion tags.
+//   - parents: The structs being described, so that a recursive struct is described once.
+//
+// Returns:
+//   - map[string]interface{}: The schema of the struct, an object with its properties.
+//   - error: An error if a validate tag is invalid.
+func (v *validator) objectSchema(structType reflect.Type, path string, parents map[reflect.Type]bool) (map[string]interface{}, error) {
+	schema := map[string]interface{}{"bsonType": "object"}
+	if parents[structType] {
+		return schema, nil
+	}
+	parents[structType] = true
+	defer delete(parents, structType)
+
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		// Unexported fields are never written to the database.
+		if field.PkgPath != "" {
+			continue
+		}
+		name, omitEmpty, stored := v.documentField(field, path == "")
+		if !stored {
+			continue
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+
+		// Describe the type of the field.
+		property, err := v.typeSchema(field.Type, fieldPath, parents)
+		if err != nil {
+			return nil, err
+		}
+
+		// Translate the rules of the field. Encrypted fields store a ciphertext the other rules can't check.
+		isRequired := false
+		constraints := map[string]interface{}{}
+		for _, fieldRule := range v.parseRules(field.Tag.Get("validate")) {
+			if fieldRule.name == "required" {
+				isRequired = true
+				continue
+			}
+			if path == "" && isEncrypted(field) {
+				continue
+			}
+			if err := v.ruleSchema(fieldRule, field.Type, constraints); err != nil {
+				return nil, fmt.Errorf("%w: %s on %s", err, fieldRule.name, fieldPath)
+			}
+		}
+
+		// Required fields can't hold their zero value, the others skip the rules when they hold it.
+		zero := zeroValue(field.Type)
+		if isRequired {
+			required = append(required, name)
+			if derefType(field.Type).Kind() != reflect.Struct || zero == nil {
+				property["not"] = map[string]interface{}{"enum": []interface{}{zero}}
+			}
+		}
+		if len(constraints) > 0 {
+			if isRequired || omitEmpty {
+				for keyword, value := range constraints {
+					property[keyword] = value
+				}
+			} else {
+				property["anyOf"] = []interface{}{map[string]interface{}{"enum": []interface{}{zero}}, constraints}
+			}
+		}
+		properties[name] = property
+	}
+
+	schema["properties"] = properties
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// documentField returns the name of a field in the documents, from its dbfusion tag at the top level and from its
+// bson tag or its lowercased name in nested structs.
+//
+// Parameters:
+//   - field: The field.
+//   - topLevel: true for a field of the top level struct.
+//
+// Returns:
+//   - string: The name of the field.
+//   - bool: true if the field is left out of the documents when it is empty.
+//   - bool: false if the field is not stored.
+func (v *validator) documentField(field reflect.StructField, topLevel bool) (string, bool, bool) {
+	tag := field.Tag.Get("bson")
+	if topLevel {
+		tag = field.Tag.Get("dbfusion")
+	}
+	parts := strings.Split(tag, ",")
+	name := strings.TrimSpace(parts[0])
+	if name == "-" || (topLevel && name == "") {
+		return "", false, false
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	omitEmpty := false
+	for _, part := range parts[1:] {
+		omitEmpty = omitEmpty || strings.TrimSpace(part) == "omitempty"
+	}
+	return name, omitEmpty, true
+}
+
+// typeSchema derives the schema of a Go type, its BSON types and the schema of its items or properties.
+//
+// Parameters:
+//   - valueType: The Go type.
+//   - path: The path of the field holding the type.
+//   - parents: The structs being described.
+//
+// Returns:
+//   - map[string]interface{}: The schema, empty for interfaces which hold any type.
+//   - error: An error if a validate tag of a nested struct is invalid.
+func (v *validator) typeSchema(valueType reflect.Type, path string, parents map[reflect.Type]bool) (map[string]interface{}, error) {
+	nullable := false
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+		nullable = true
+	}
+
+	schema := map[string]interface{}{}
+	bsonTypes := []interface{}{}
+	switch {
+	case valueType == timeType || valueType == dateTimeType:
+		bsonTypes = append(bsonTypes, "date")
+	case valueType == objectIDType:
+		bsonTypes = append(bsonTypes, "objectId")
+	case valueType == decimal128Type:
+		bsonTypes = append(bsonTypes, "decimal")
+	case valueType.Kind() == reflect.Slice && valueType.Elem().Kind() == reflect.Uint8:
+		bsonTypes, nullable = append(bsonTypes, "binData"), true
+	default:
+		switch valueType.Kind() {
+		case reflect.Bool:
+			bsonTypes = append(bsonTypes, "bool")
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			bsonTypes = append(bsonTypes, "int", "long")
+		case reflect.Float32, reflect.Float64:
+			bsonTypes = append(bsonTypes, "double")
+		case reflect.String:
+			bsonTypes = append(bsonTypes, "string")
+		case reflect.Slice, reflect.Array:
+			items, err := v.typeSchema(valueType.Elem(), path, parents)
+			if err != nil {
+				return nil, err
+			}
+			bsonTypes, nullable = append(bsonTypes, "array"), nullable || valueType.Kind() == reflect.Slice
+			if len(items) > 0 {
+				schema["items"] = items
+			}
+		case reflect.Map:
+			bsonTypes, nullable = append(bsonTypes, "object"), true
+		case reflect.Struct:
+			object, err := v.objectSchema(valueType, path, parents)
+			if err != nil {
+				return nil, err
+			}
+			for keyword, value := range object {
+				schema[keyword] = value
+			}
+			bsonTypes = append(bsonTypes, "object")
+		default:
+			// Interfaces hold values of any type.
+			return schema, nil
+		}
+	}
+
+	if nullable {
+		bsonTypes = append(bsonTypes, "null")
+	}
+	if len(bsonTypes) == 1 {
+		schema["bsonType"] = bsonTypes[0]
+	} else {
+		schema["bsonType"] = bsonTypes
+	}
+	return schema, nil
+}
+
+// ruleSchema adds the keywords of a rule to the constraints of a field.
+//
+// Parameters:
+//   - fieldRule: The rule.
+//   - fieldType: The Go type of the field.
+//   - constraints: The keywords of the field, the keywords of the rule are added to it.
+//
+// Returns:
+//   - error: dbfusionErrors.ErrValidationRuleNotSupported if the rule is unknown, its parameter is invalid or it
+//     doesn't apply to the type of the field.
+func (v *validator) ruleSchema(fieldRule rule, fieldType reflect.Type, constraints map[string]interface{}) error {
+	fieldType = derefType(fieldType)
+	kind := fieldType.Kind()
+	isNumber := v.isNumber(reflect.Zero(fieldType))
+
+	switch fieldRule.name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(fieldRule.param, 64)
+		if err != nil {
+			return dbfusionErrors.ErrValidationRuleNotSupported
+		}
+		if isNumber {
+			constraints[map[string]string{"min": "minimum", "max": "maximum"}[fieldRule.name]] = limit
+			return nil
+		}
+		return v.sizeSchema(fieldRule.name, int64(limit), kind, constraints)
+	case "len":
+		length, err := strconv.ParseInt(fieldRule.param, 10, 64)
+		if err != nil || isNumber {
+			return dbfusionErrors.ErrValidationRuleNotSupported
+		}
+		if err := v.sizeSchema("min", length, kind, constraints); err != nil {
+			return err
+		}
+		return v.sizeSchema("max", length, kind, constraints)
+	case "email", "regex":
+		if kind != reflect.String {
+			return dbfusionErrors.ErrValidationRuleNotSupported
+		}
+		pattern := fieldRule.param
+		if fieldRule.name == "email" {
+			pattern = emailPattern
+		} else if _, err := v.expression(pattern); err != nil {
+			return dbfusionErrors.ErrValidationRuleNotSupported
+		}
+		constraints["pattern"] = pattern
+		return nil
+	case "oneof":
+		values := []interface{}{}
+		for _, option := range strings.Fields(fieldRule.param) {
+			value, err := enumValue(option, fieldType)
+			if err != nil {
+				return dbfusionErrors.ErrValidationRuleNotSupported
+			}
+			values = append(values, value)
+		}
+		constraints["enum"] = values
+		return nil
+	}
+	return dbfusionErrors.ErrValidationRuleNotSupported
+}
+
+// sizeSchema adds the keyword limiting the size of a string, an array or a map.
+//
+// Parameters:
+//   - bound: "min" or "max".
+//   - size: The limit.
+//   - kind: The kind of the field.
+//   - constraints: The keywords of the field.
+//
+// Returns:
+//   - error: dbfusionErrors.ErrValidationRuleNotSupported if the kind has no size.
+func (v *validator) sizeSchema(bound string, size int64, kind reflect.Kind, constraints map[string]interface{}) error {
+	keywords := map[reflect.Kind]string{reflect.String: "Length", reflect.Slice: "Items", reflect.Array: "Items", reflect.Map: "Properties"}
+	keyword, ok := keywords[kind]
+	if !ok {
+		return dbfusionErrors.ErrValidationRuleNotSupported
+	}
+	constraints[bound+keyword] = size
+	return nil
+}
+
+// enumValue converts a value of the oneof rule to the type of the field.
+func enumValue(option string, fieldType reflect.Type) (interface{}, error) {
+	switch fieldType.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(option)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseInt(option, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(option, 64)
+	case reflect.String:
+		return option, nil
+	}
+	return nil, dbfusionErrors.ErrValidationRuleNotSupported
+}
+
+// isEncrypted reports whether the dbfusion tag of a field carries the encrypt option.
+func isEncrypted(field reflect.StructField) bool {
+	for _, part := range strings.Split(field.Tag.Get("dbfusion"), ",")[1:] {
+		if name, _, _ := strings.Cut(strings.TrimSpace(part), ":"); name == "encrypt" {
+			return true
+		}
+	}
+	return false
+}
+
+// zeroValue returns the zero value of a type as stored in the documents, nil for the types stored as null.
+func zeroValue(valueType reflect.Type) interface{} {
+	switch valueType.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return nil
+	}
+	return reflect.Zero(valueType).Interface()
+}
+
+// derefType returns the type a pointer type points to.
+func derefType(valueType reflect.Type) reflect.Type {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	return valueType
+}
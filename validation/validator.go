@@ -0,0 +1,312 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/glodb/dbfusion/dbfusionErrors"
+)
+
+// validator is a singleton checking structs against the rules of their validate tags. It keeps the compiled
+// regular expressions of the regex rules.
+type validator struct {
+	expressions sync.Map // Compiled regular expressions keyed by their pattern.
+}
+
+var (
+	instance *validator // Singleton instance of the validator.
+	once     sync.Once  // Once ensures the singleton instance is created only once.
+)
+
+// GetInstance returns the singleton instance of the validator.
+//
+// Returns:
+//   - *validator: A pointer to the singleton instance of the validator.
+func GetInstance() *validator {
+	// Use sync.Once to ensure that the instance is created only once.
+	once.Do(func() {
+		instance = &validator{}
+	})
+
+	// Return the singleton instance.
+	return instance
+}
+
+// rule is a parsed rule of a validate tag.
+type rule struct {
+	name  string // The name of the rule, e.g. "min".
+	param string // The parameter following "=", empty for rules without one.
+}
+
+// Validate checks every field of a struct, or a pointer to a struct, against the rules of its validate tag.
+// Values that are not structs, such as maps, have no rules and are always valid.
+//
+// Parameters:
+//   - data: The struct to be validated.
+//
+// Returns:
+//   - error: A *ValidationError listing every failing field, dbfusionErrors.ErrValidationRuleNotSupported wrapped
+//     with the field and the rule if a tag is invalid, or nil if the struct is valid.
+func (v *validator) Validate(data interface{}) error {
+	// Dereference pointers to reach the struct.
+	value := reflect.ValueOf(data)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	// Collect the failures of all the fields.
+	validationErr := &ValidationError{}
+	if err := v.validateStruct(value, "", validationErr); err != nil {
+		return err
+	}
+	if len(validationErr.Fields) != 0 {
+		return validationErr
+	}
+	return nil
+}
+
+// validateStruct checks the fields of a struct value and appends their failures to validationErr. Nested structs
+// are checked recursively.
+//
+// Parameters:
+//   - value: The struct value.
+//   - path: The path of the struct, empty for the top level struct.
+//   - validationErr: The error collecting the failures.
+//
+// Returns:
+//   - error: An error if a validate tag is invalid.
+func (v *validator) validateStruct(value reflect.Value, path string, validationErr *ValidationError) error {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+
+		// Unexported fields are never written to the database.
+		if field.PkgPath != "" {
+			continue
+		}
+
+		// Build the path of the field from the name of its dbfusion tag.
+		name := strings.Split(field.Tag.Get("dbfusion"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if path != "" {
+			name = path + "." + name
+		}
+
+		fieldValue := value.Field(i)
+
+		// Check the rules of the field.
+		for _, fieldRule := range v.parseRules(field.Tag.Get("validate")) {
+			valid, err := v.check(fieldRule, fieldValue)
+			if err != nil {
+				return fmt.Errorf("%w: %s on %s", err, fieldRule.name, name)
+			}
+			if !valid {
+				validationErr.Fields = append(validationErr.Fields, FieldError{
+					Field: name,
+					Rule:  fieldRule.name,
+					Param: fieldRule.param,
+					Value: fieldValue.Interface(),
+				})
+			}
+		}
+
+		// Check the fields of nested structs.
+		nested := fieldValue
+		for nested.Kind() == reflect.Ptr && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct {
+			if err := v.validateStruct(nested, name, validationErr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseRules splits a validate tag into its rules. Rules are separated by commas, a comma escaped as "\," belongs
+// to the parameter of the rule, which allows commas in regular expressions.
+//
+// Parameters:
+//   - tag: The value of the validate tag.
+//
+// Returns:
+//   - []rule: The parsed rules.
+func (v *validator) parseRules(tag string) []rule {
+	rules := []rule{}
+	if tag == "" {
+		return rules
+	}
+
+	// Split on the commas that are not escaped.
+	parts := []string{}
+	current := strings.Builder{}
+	for i := 0; i < len(tag); i++ {
+		if tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',' {
+			current.WriteByte(',')
+			i++
+			continue
+		}
+		if tag[i] == ',' {
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(tag[i])
+	}
+	parts = append(parts, current.String())
+
+	// Separate the names of the rules from their parameters.
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, rule{name: strings.TrimSpace(name), param: param})
+	}
+	return rules
+}
+
+// check reports whether a value satisfies a rule.
+//
+// Parameters:
+//   - fieldRule: The rule to check.
+//   - value: The value of the field.
+//
+// Returns:
+//   - bool: true if the value satisfies the rule.
+//   - error: dbfusionErrors.ErrValidationRuleNotSupported if the rule is unknown, its parameter is invalid or it
+//     doesn't apply to the type of the value.
+func (v *validator) check(fieldRule rule, value reflect.Value) (bool, error) {
+	if fieldRule.name == "required" {
+		return !value.IsZero(), nil
+	}
+
+	// The other rules only apply to values that are set.
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return true, nil
+		}
+		value = value.Elem()
+	}
+	if value.IsZero() {
+		return true, nil
+	}
+
+	switch fieldRule.name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(fieldRule.param, 64)
+		if err != nil {
+			return false, dbfusionErrors.ErrValidationRuleNotSupported
+		}
+		size, err := v.size(value)
+		if err != nil {
+			return false, err
+		}
+		if fieldRule.name == "min" {
+			return size >= limit, nil
+		}
+		return size <= limit, nil
+	case "len":
+		length, err := strconv.Atoi(fieldRule.param)
+		if err != nil || value.Kind() == reflect.Struct || v.isNumber(value) {
+			return false, dbfusionErrors.ErrValidationRuleNotSupported
+		}
+		size, err := v.size(value)
+		return size == float64(length), err
+	case "email":
+		if value.Kind() != reflect.String {
+			return false, dbfusionErrors.ErrValidationRuleNotSupported
+		}
+		address, err := mail.ParseAddress(value.String())
+		return err == nil && address.Address == value.String(), nil
+	case "regex":
+		if value.Kind() != reflect.String {
+			return false, dbfusionErrors.ErrValidationRuleNotSupported
+		}
+		expression, err := v.expression(fieldRule.param)
+		if err != nil {
+			return false, dbfusionErrors.ErrValidationRuleNotSupported
+		}
+		return expression.MatchString(value.String()), nil
+	case "oneof":
+		current := fmt.Sprintf("%v", value.Interface())
+		for _, option := range strings.Fields(fieldRule.param) {
+			if option == current {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, dbfusionErrors.ErrValidationRuleNotSupported
+}
+
+// size returns the number compared by the min, max and len rules: the value of numbers, the number of characters
+// of strings and the number of elements of slices, arrays and maps.
+//
+// Parameters:
+//   - value: The value of the field.
+//
+// Returns:
+//   - float64: The size of the value.
+//   - error: dbfusionErrors.ErrValidationRuleNotSupported if the value has no size.
+func (v *validator) size(value reflect.Value) (float64, error) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), nil
+	}
+	return 0, dbfusionErrors.ErrValidationRuleNotSupported
+}
+
+// isNumber reports whether the value is an integer or a floating point number.
+func (v *validator) isNumber(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// expression returns the compiled regular expression of a pattern, compiling it on its first use.
+//
+// Parameters:
+//   - pattern: The regular expression.
+//
+// Returns:
+//   - *regexp.Regexp: The compiled regular expression.
+//   - error: An error if the pattern is invalid.
+func (v *validator) expression(pattern string) (*regexp.Regexp, error) {
+	if expression, ok := v.expressions.Load(pattern); ok {
+		return expression.(*regexp.Regexp), nil
+	}
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	v.expressions.Store(pattern, expression)
+	return expression, nil
+}